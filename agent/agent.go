@@ -3,64 +3,222 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/tool"
 )
 
 // PlanningAgent orchestrates task planning and subagent execution.
 type PlanningAgent struct {
-	client             *openai.Client
+	verboseLog
+	client             LLMClient
 	config             AgentConfig
 	messages           []openai.ChatCompletionMessage
-	subagents          map[TaskType]Subagent
+	orchestrator       *Orchestrator
 	interactionHandler InteractionHandler
+	toolOptions        tool.Options
 }
 
 // AgentConfig holds the configuration for the planning agent.
 type AgentConfig struct {
+	// Provider selects the LLM backend: ProviderOpenAI (default),
+	// ProviderAnthropic or ProviderGemini.
+	Provider   string
 	APIKey     string
 	APIBase    string
 	Model      string
 	Verbose    bool
 	RenderHTML bool
+	RenderPDF  bool
 	OutputDir  string
+	// SummaryTargetLength is the maximum length (in runes) of summaries
+	// produced by the SUMMARIZE subagent. Zero uses the default.
+	SummaryTargetLength int
+	// AutoApproveTools lets the CODE subagent run generated code without confirmation.
+	AutoApproveTools bool
+	// AllowedShellCommands, when not empty, limits the shell scripts of the
+	// CODE subagent to scripts that only run these commands, e.g.
+	// []string{"ls", "cat", "grep"}.
+	AllowedShellCommands []string
+	// CheckPythonSyntax makes the CODE subagent compile Python code before
+	// running it, so a syntax error is returned to the model without running
+	// any of the code.
+	CheckPythonSyntax bool
+	// SubagentModels overrides Model for specific task types (e.g. a cheaper
+	// model for ANALYZE and a stronger one for REPORT).
+	SubagentModels map[TaskType]string
+	// SubagentTemperatures overrides the default temperature for specific task types.
+	SubagentTemperatures map[TaskType]float32
+	// RetryPolicy controls retries of transient LLM errors in the ANALYZE and
+	// REPORT subagents. Nil uses DefaultRetryPolicy.
+	RetryPolicy *RetryPolicy
+	// ContextBudget limits the estimated tokens of earlier task outputs in
+	// the prompts of the ANALYZE and REPORT subagents; older outputs are
+	// trimmed first. Zero uses DefaultContextBudget and a negative value
+	// disables the limit.
+	ContextBudget int
+	// Wikipedia controls when the SEARCH subagent adds a Wikipedia lookup
+	// to its web results. The zero value does so only for sparse results.
+	Wikipedia WikipediaPolicy
+	// RenderFormat overrides the render output format, e.g. FormatPlain for
+	// logs and CI or FormatJSON for other programs. RenderPDF takes
+	// precedence.
+	RenderFormat RenderFormat
+	// ImageAssetDir, when set, makes HTML and PDF output download referenced
+	// images into this directory so the report works offline.
+	ImageAssetDir string
+	// MaxImageSize limits downloaded images, in bytes. Zero uses DefaultMaxImageSize.
+	MaxImageSize int64
+	// RenderWidth is the terminal wrap width. Zero detects the terminal width.
+	RenderWidth int
+	// RenderTheme selects the HTML/PDF color theme (ThemeLight or ThemeDark).
+	RenderTheme string
+	// WrapPolicy controls how terminal output wraps long lines, e.g. whether
+	// URLs may be split.
+	WrapPolicy WrapPolicy
+	// NoSyntaxHighlight disables ANSI highlighting of code blocks in terminal output.
+	NoSyntaxHighlight bool
+	// StreamReport streams the REPORT output through InteractionHandler.StreamChunk.
+	StreamReport bool
+	// Client, when set, sends the LLM requests instead of a client created
+	// from Provider, APIKey and APIBase, e.g. an agenttest.FakeClient in
+	// tests. APIKey is then optional.
+	Client LLMClient
+	// Search, when set, replaces the web search providers of the SEARCH
+	// subagent, e.g. with canned results in tests. Image search is then
+	// skipped.
+	Search func(ctx context.Context, query string) (string, error)
+	// HTTPClient, when set, sends the LLM requests and the requests of the
+	// search and fetch tools, e.g. through a proxy.
+	HTTPClient *http.Client
+	// LogWriter receives the verbose progress output of the agent and its
+	// subagents. Nil uses os.Stderr.
+	LogWriter io.Writer
+	// Metrics, when set, observes the tool calls of the subagents, such as
+	// searches and code runs.
+	Metrics tool.Metrics
+	// Seed, when set, is sent with the requests of the planner and every
+	// subagent, for reproducible runs on backends that support it. See
+	// WithSeed.
+	Seed *int
+	// PromptTemplates overrides the text/template system prompts of the
+	// subagents, keyed by PromptAnalysis, PromptReport or PromptReportJSON.
+	// Templates are rendered with PromptData; see DefaultAnalysisPrompt and
+	// friends for the defaults.
+	PromptTemplates map[string]string
+}
+
+// subagentOptions returns the configured overrides for the given task type.
+func (c AgentConfig) subagentOptions(taskType TaskType) []SubagentOption {
+	var opts []SubagentOption
+	if c.RetryPolicy != nil {
+		opts = append(opts, WithRetryPolicy(*c.RetryPolicy))
+	}
+	if model, ok := c.SubagentModels[taskType]; ok {
+		opts = append(opts, WithModel(model))
+	}
+	if temperature, ok := c.SubagentTemperatures[taskType]; ok {
+		opts = append(opts, WithTemperature(temperature))
+	}
+	if c.ContextBudget != 0 {
+		opts = append(opts, WithContextBudget(c.ContextBudget))
+	}
+	if taskType == TaskTypeSearch && c.Wikipedia != (WikipediaPolicy{}) {
+		opts = append(opts, WithWikipedia(c.Wikipedia))
+	}
+	if taskType == TaskTypeReport && c.StreamReport {
+		opts = append(opts, WithStreaming(true))
+	}
+	return opts
 }
 
 // NewPlanningAgent creates and initializes a new PlanningAgent.
 func NewPlanningAgent(config AgentConfig, interactionHandler InteractionHandler) (*PlanningAgent, error) {
-	if config.APIKey == "" {
+	if config.APIKey == "" && config.Client == nil {
 		return nil, fmt.Errorf("API key is required")
 	}
 	if config.Model == "" {
-		config.Model = "gpt-4o" // Default model
+		config.Model = DefaultModel(config.Provider)
 	}
 	if config.OutputDir == "" {
 		config.OutputDir = "generated" // Default output directory
 	}
 
-	openaiConfig := openai.DefaultConfig(config.APIKey)
-	if config.APIBase != "" {
-		openaiConfig.BaseURL = config.APIBase
+	client := config.Client
+	if client == nil {
+		var err error
+		client, err = NewLLMClientWithHTTPClient(config.Provider, config.APIKey, config.APIBase, config.HTTPClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.Seed != nil {
+		client = WithSeed(client, *config.Seed)
+	}
+	prompts, err := ParsePromptTemplates(config.PromptTemplates)
+	if err != nil {
+		return nil, err
+	}
+	var promptOpts []SubagentOption
+	for name, tmpl := range prompts {
+		promptOpts = append(promptOpts, WithPromptTemplate(name, tmpl))
 	}
-	client := openai.NewClientWithConfig(openaiConfig)
 
 	agent := &PlanningAgent{
 		client:             client,
 		config:             config,
 		messages:           []openai.ChatCompletionMessage{},
-		subagents:          make(map[TaskType]Subagent),
+		orchestrator:       NewOrchestrator(config.Verbose, interactionHandler),
 		interactionHandler: interactionHandler,
+		toolOptions:        tool.Options{HTTPClient: config.HTTPClient, Metrics: config.Metrics},
 	}
 
 	// Initialize subagents
-	agent.subagents[TaskTypeSearch] = NewSearchSubagent(client, config.Model, config.Verbose, interactionHandler)
-	agent.subagents[TaskTypeAnalyze] = NewAnalysisSubagent(client, config.Model, config.Verbose, interactionHandler)
-	agent.subagents[TaskTypeReport] = NewReportSubagent(client, config.Model, config.Verbose, interactionHandler)
-	agent.subagents[TaskTypeRender] = NewRenderSubagent(config.Verbose, config.RenderHTML, interactionHandler)
-	agent.subagents[TaskTypePodcast] = NewPodcastSubagent(client, config.Model, config.Verbose, interactionHandler)
-	agent.subagents[TaskTypePPT] = NewPPTSubagent(client, config.Model, config.Verbose, interactionHandler, config.OutputDir)
+	search := NewSearchSubagent(client, config.Model, config.Verbose, interactionHandler, config.subagentOptions(TaskTypeSearch)...)
+	search.searchFunc = config.Search
+	agent.orchestrator.Register(search)
+	agent.orchestrator.Register(NewAnalysisSubagent(client, config.Model, config.Verbose, interactionHandler, append(config.subagentOptions(TaskTypeAnalyze), promptOpts...)...))
+	agent.orchestrator.Register(NewReportSubagent(client, config.Model, config.Verbose, interactionHandler, append(config.subagentOptions(TaskTypeReport), promptOpts...)...))
+	renderFormat := config.RenderFormat
+	if renderFormat == "" && config.RenderHTML {
+		renderFormat = FormatHTML
+	}
+	var renderOpts []RenderOption
+	if config.RenderPDF {
+		renderOpts = append(renderOpts, WithPDF(config.OutputDir))
+	}
+	if config.RenderWidth > 0 {
+		renderOpts = append(renderOpts, WithWidth(config.RenderWidth, 6))
+	}
+	if config.ImageAssetDir != "" {
+		renderOpts = append(renderOpts, WithImageAssets(config.ImageAssetDir, config.MaxImageSize))
+	}
+	if config.RenderTheme != "" {
+		renderOpts = append(renderOpts, WithTheme(config.RenderTheme))
+	}
+	if config.WrapPolicy != (WrapPolicy{}) {
+		renderOpts = append(renderOpts, WithWrapPolicy(config.WrapPolicy))
+	}
+	if config.NoSyntaxHighlight {
+		renderOpts = append(renderOpts, WithSyntaxHighlighting(false))
+	}
+	agent.orchestrator.Register(NewRenderSubagentWithFormat(config.Verbose, renderFormat, interactionHandler, renderOpts...))
+	agent.orchestrator.Register(NewPodcastSubagent(client, config.Model, config.Verbose, interactionHandler))
+	agent.orchestrator.Register(NewPPTSubagent(client, config.Model, config.Verbose, interactionHandler, config.OutputDir))
+	agent.orchestrator.Register(NewSummarizeSubagent(client, config.Model, config.Verbose, interactionHandler, config.SummaryTargetLength))
+	agent.orchestrator.Register(NewTranslationSubagent(client, config.Model, config.Verbose, interactionHandler))
+	agent.orchestrator.Register(NewCodeSubagent(client, config.Model, config.Verbose, interactionHandler, config.AutoApproveTools, WithAllowedShellCommands(config.AllowedShellCommands), WithPythonSyntaxCheck(config.CheckPythonSyntax)))
+	agent.orchestrator.Register(NewCritiqueSubagent(client, config.Model, config.Verbose, interactionHandler))
+
+	if config.LogWriter != nil {
+		agent.SetLogWriter(config.LogWriter)
+		agent.orchestrator.SetLogWriter(config.LogWriter)
+	}
 
 	return agent, nil
 }
@@ -68,7 +226,7 @@ func NewPlanningAgent(config AgentConfig, interactionHandler InteractionHandler)
 // Plan decomposes a user request into subtasks.
 func (a *PlanningAgent) Plan(ctx context.Context, userRequest string) (*Plan, error) {
 	if a.config.Verbose {
-		fmt.Println("🧠 规划 Agent")
+		a.logln("🧠 规划 Agent")
 	}
 	if a.interactionHandler != nil {
 		a.interactionHandler.Log("🧠 正在规划...")
@@ -76,23 +234,34 @@ func (a *PlanningAgent) Plan(ctx context.Context, userRequest string) (*Plan, er
 
 	systemPrompt := `你是一个规划 Agent，负责将用户请求分解为子任务。
 你可以使用以下 Subagent：
-- SEARCH: 执行网络搜索以收集信息
+- SEARCH: 执行网络搜索以收集信息 (parameters: {"query": "搜索词", "mode": "answer" 表示直接获取简短事实问题的答案, "limit": 可选的结果数量, "includeDomains"/"excludeDomains": 可选的域名列表，如 [".gov", ".edu"], "images": 报告需要配图时设为 true})
 - ANALYZE: 分析和综合收集到的信息
+- SUMMARIZE: 将大量搜索结果压缩为简洁摘要 (TaskType: SUMMARIZE)
 - REPORT: 根据分析数据生成格式化报告
+- CODE: 编写并执行 Python 或 Shell 脚本以完成计算或数据处理 (TaskType: CODE, parameters: {"language": "python" 或 "shell"})
+- CRITIQUE: 审阅报告草稿，列出事实缺失、缺乏依据的论断和格式问题 (TaskType: CRITIQUE, parameters: {"strictness": "light"/"standard"/"rigorous", "revise": true 表示审阅后自动修订报告})
+- TRANSLATE: 将报告翻译为其他语言 (TaskType: TRANSLATE, parameters: {"targetLang": "目标语言"})
 - PODCAST: 根据报告生成播客脚本 (TaskType: PODCAST)
 - PPT: 根据报告生成幻灯片 (HTML) (TaskType: PPT)
 - RENDER: 将 Markdown 内容渲染为终端友好的格式
 
 对于给定的用户请求，创建一个包含任务序列的计划。
 每个任务应包含：
-- type: SEARCH, ANALYZE, REPORT, PODCAST, PPT, 或 RENDER 之一
+- type: SEARCH, ANALYZE, SUMMARIZE, CODE, REPORT, CRITIQUE, TRANSLATE, PODCAST, PPT, 或 RENDER 之一
 - description:  Subagent 应该做什么
 - parameters: 任务的可选参数 (例如: {"query": "搜索词"})
+- id: 可选，任务的唯一标识，供 depends_on 引用
+- depends_on: 可选，该任务所依赖任务的 id 列表
 
 重要提示：
 - 仅在用户明确请求播客时包含 PODCAST 任务。
 - 仅在用户明确请求幻灯片或演示文稿时包含 PPT 任务。
+- 仅在用户要求高质量或经过核查的报告时，在 REPORT 之后包含 CRITIQUE 任务。
+- 仅在用户要求特定语言的输出时包含 TRANSLATE 任务，放在 REPORT 之后、RENDER 之前。
+- 对于简单的事实性问题，使用 "mode": "answer" 的 SEARCH 任务，并省略 ANALYZE 任务。
+- 当预计搜索结果非常多时，可在 SEARCH 与 ANALYZE/REPORT 之间插入 SUMMARIZE 任务。
 - 在 REPORT 任务之后始终包含 RENDER 任务，以生成最终的文本报告。
+- 当需要多个相互独立的搜索时，可以为所有任务设置 id，并用 depends_on 声明依赖（例如 ANALYZE 依赖两个 SEARCH）。相互独立的任务会并行执行，每个任务只接收其所依赖任务的输出，因此除首批任务外，每个任务都必须设置 depends_on。
 
 仅返回具有此结构的有效 JSON 对象：
 {
@@ -169,11 +338,11 @@ func (a *PlanningAgent) Plan(ctx context.Context, userRequest string) (*Plan, er
 	}
 
 	if a.config.Verbose {
-		fmt.Printf("📋 计划: %s\n", plan.Description)
+		a.logf("📋 计划: %s\n", plan.Description)
 		for i, task := range plan.Tasks {
-			fmt.Printf("  %d. [%s] %s\n", i+1, task.Type, task.Description)
+			a.logf("  %d. [%s] %s\n", i+1, task.Type, task.Description)
 		}
-		fmt.Println()
+		a.logln()
 	}
 	if a.interactionHandler != nil {
 		a.interactionHandler.Log(fmt.Sprintf("📋 计划已生成: %s", plan.Description))
@@ -209,7 +378,7 @@ func (a *PlanningAgent) PlanWithReview(ctx context.Context, userRequest string)
 
 		// Re-plan with the user's modification
 		if a.config.Verbose {
-			fmt.Printf("🔄 根据用户反馈重新规划: %s\n\n", modification)
+			a.logf("🔄 根据用户反馈重新规划: %s\n\n", modification)
 		}
 		a.interactionHandler.Log(fmt.Sprintf("🔄 根据用户反馈重新规划: %s", modification))
 
@@ -223,101 +392,34 @@ func (a *PlanningAgent) PlanWithReview(ctx context.Context, userRequest string)
 }
 
 // Execute runs the plan by executing each task with the appropriate subagent.
+// Tasks inserted by the subagents are added to plan.Tasks.
 func (a *PlanningAgent) Execute(ctx context.Context, plan *Plan) ([]Result, error) {
 	if a.config.Verbose {
-		fmt.Println("🔍 正在执行计划...")
-		fmt.Println()
+		a.logln("🔍 正在执行计划...")
+		a.logln()
 	}
 
-	results := make([]Result, 0, len(plan.Tasks))
-
-	var contextData []string
-
-	// Use a loop index that can be modified to support dynamic task insertion
-	for i := 0; i < len(plan.Tasks); i++ {
-		task := plan.Tasks[i]
-
-		if a.config.Verbose {
-			fmt.Printf("📍 步骤 %d/%d: [%s] %s\n", i+1, len(plan.Tasks), task.Type, task.Description)
-		}
-		if a.interactionHandler != nil {
-			a.interactionHandler.Log(fmt.Sprintf("📍 步骤 %d/%d: [%s] %s", i+1, len(plan.Tasks), task.Type, task.Description))
-		}
-
-		// Inject global context from history
-		if task.Parameters == nil {
-			task.Parameters = make(map[string]interface{})
-		}
-		var globalContextBuilder strings.Builder
-		for _, msg := range a.messages {
-			if msg.Role == openai.ChatMessageRoleUser {
-				globalContextBuilder.WriteString(fmt.Sprintf("User: %s\n", msg.Content))
-			}
-		}
-		task.Parameters["global_context"] = globalContextBuilder.String()
-
-		// Inject context from previous tasks
-		if len(contextData) > 0 {
-			if task.Parameters == nil {
-				task.Parameters = make(map[string]interface{})
-			}
-			// If context already exists in parameters, append to it
-			if existingContext, ok := task.Parameters["context"].([]string); ok {
-				task.Parameters["context"] = append(existingContext, contextData...)
-			} else {
-				task.Parameters["context"] = contextData
-			}
-		}
-
-		subagent, ok := a.subagents[task.Type]
-		if !ok {
-			return nil, fmt.Errorf("unknown task type: %s", task.Type)
-		}
-
-		result, err := subagent.Execute(ctx, task)
-		if err != nil {
-			return nil, fmt.Errorf("task %d failed: %w", i+1, err)
-		}
-
-		results = append(results, result)
-
-		if result.Success {
-			// Check for dynamic tasks
-			if len(result.NewTasks) > 0 {
-				if a.config.Verbose {
-					fmt.Printf("  🔄 动态规划更新: 插入 %d 个新任务\n", len(result.NewTasks))
-				}
-				if a.interactionHandler != nil {
-					a.interactionHandler.Log(fmt.Sprintf("🔄 动态规划更新: 插入 %d 个新任务", len(result.NewTasks)))
-				}
-
-				// Insert new tasks at the current position + 1
-				// We need to create a new slice to avoid modifying the original plan array in place if it was smaller
-				// But here plan.Tasks is a slice, so we can use append tricks
-				rear := append([]Task{}, plan.Tasks[i+1:]...)
-				plan.Tasks = append(plan.Tasks[:i+1], append(result.NewTasks, rear...)...)
-			}
-
-			// Accumulate output for next tasks
-			contextData = append(contextData, fmt.Sprintf("Output from %s task:\n%s", task.Type, result.Output))
-
-			if a.config.Verbose {
-				fmt.Printf("  ✓ 完成\n\n")
-			}
-			if a.interactionHandler != nil {
-				a.interactionHandler.Log("  ✓ 完成")
-			}
-		} else {
-			if a.config.Verbose {
-				fmt.Printf("  ✗ 失败: %s\n\n", result.Error)
-			}
-			if a.interactionHandler != nil {
-				a.interactionHandler.Log(fmt.Sprintf("  ✗ 失败: %s", result.Error))
-			}
+	// Inject global context from history
+	var globalContextBuilder strings.Builder
+	for _, msg := range a.messages {
+		if msg.Role == openai.ChatMessageRoleUser {
+			globalContextBuilder.WriteString(fmt.Sprintf("User: %s\n", msg.Content))
 		}
 	}
+	a.orchestrator.GlobalContext = globalContextBuilder.String()
+
+	results, tasks, err := a.orchestrator.run(tool.WithOptions(ctx, a.toolOptions), plan.Tasks)
+	plan.Tasks = tasks
+	return results, err
+}
 
-	return results, nil
+// RegisterSubagent adds a subagent for the tasks of its Type, replacing the
+// built-in one for that type, e.g. to run a custom task type in plans. The
+// planner only proposes the built-in task types, so custom tasks come from
+// plans the caller builds or edits, or from another subagent's
+// Result.NewTasks.
+func (a *PlanningAgent) RegisterSubagent(subagent Subagent) {
+	a.orchestrator.Register(subagent)
 }
 
 // Run is the main entry point that plans and executes a user request.
@@ -328,9 +430,9 @@ func (a *PlanningAgent) Run(ctx context.Context, userRequest string) (string, er
 		return "", err
 	}
 
-	// Execute the plan
+	// Execute the plan; a canceled run still returns its partial results
 	results, err := a.Execute(ctx, plan)
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrCanceled) {
 		return "", err
 	}
 
@@ -352,7 +454,15 @@ func (a *PlanningAgent) Run(ctx context.Context, userRequest string) (string, er
 		}
 	}
 
-	return finalOutput, nil
+	return finalOutput, err
+}
+
+// SystemFingerprints returns the distinct system fingerprints of the LLM
+// responses so far when AgentConfig.Seed is set, in the order they were first
+// seen. More than one means the backend changed and outputs may not be
+// reproducible. Streamed reports do not report fingerprints.
+func (a *PlanningAgent) SystemFingerprints() []string {
+	return ClientFingerprints(a.client)
 }
 
 // AddUserMessage adds a user message to the conversation history.
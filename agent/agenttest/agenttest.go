@@ -0,0 +1,248 @@
+// Package agenttest provides fakes for testing code that embeds goskills
+// without network access: an LLM client that returns scripted responses and
+// an interaction handler that records what the agent reports and answers its
+// questions from a script.
+//
+// Pass a FakeClient as AgentConfig.Client or goskills.RunnerConfig.Client,
+// and a TestInteractionHandler wherever an agent.InteractionHandler is
+// expected.
+package agenttest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent"
+	"github.com/smallnest/goskills/tool"
+)
+
+// ErrNoResponse is returned by a FakeClient that has no response left for a
+// request.
+var ErrNoResponse = errors.New("agenttest: no scripted response left")
+
+// FakeClient is an agent.LLMClient returning scripted responses. It is safe
+// for concurrent use.
+type FakeClient struct {
+	mu        sync.Mutex
+	responses []openai.ChatCompletionResponse
+	requests  []openai.ChatCompletionRequest
+	// Respond, when set, answers the requests made after the scripted
+	// responses have run out.
+	Respond func(req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+}
+
+// NewFakeClient returns a FakeClient answering requests with responses, in
+// order.
+func NewFakeClient(responses ...openai.ChatCompletionResponse) *FakeClient {
+	return &FakeClient{responses: responses}
+}
+
+// CreateChatCompletion records req and returns the next scripted response.
+func (c *FakeClient) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	if len(c.responses) > 0 {
+		resp := c.responses[0]
+		c.responses = c.responses[1:]
+		c.mu.Unlock()
+		return resp, nil
+	}
+	respond := c.Respond
+	c.mu.Unlock()
+	if respond == nil {
+		return openai.ChatCompletionResponse{}, ErrNoResponse
+	}
+	return respond(req)
+}
+
+// Requests returns the requests received so far.
+func (c *FakeClient) Requests() []openai.ChatCompletionRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]openai.ChatCompletionRequest(nil), c.requests...)
+}
+
+// Reply returns a response in which the model answers with content.
+func Reply(content string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+			FinishReason: openai.FinishReasonStop,
+		}},
+	}
+}
+
+// CallTools returns a response in which the model calls tools.
+func CallTools(calls ...openai.ToolCall) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, ToolCalls: calls},
+			FinishReason: openai.FinishReasonToolCalls,
+		}},
+	}
+}
+
+// ToolCall returns a call of the tool name with id. args is encoded as JSON
+// unless it is a string, which is used as is.
+func ToolCall(id, name string, args any) openai.ToolCall {
+	arguments, ok := args.(string)
+	if !ok {
+		data, err := json.Marshal(args)
+		if err != nil {
+			panic(fmt.Sprintf("agenttest: encoding the arguments of %s: %v", name, err))
+		}
+		arguments = string(data)
+	}
+	return openai.ToolCall{
+		ID:       id,
+		Type:     openai.ToolTypeFunction,
+		Function: openai.FunctionCall{Name: name, Arguments: arguments},
+	}
+}
+
+// TestInteractionHandler is an agent.InteractionHandler that records what it
+// is told and answers from its fields. The zero value approves the plan and
+// declines podcasts, code runs, tool calls and clarifying questions. It is
+// safe for concurrent use.
+type TestInteractionHandler struct {
+	// PlanChange is returned by ReviewPlan; empty approves the plan.
+	PlanChange string
+	// ConfirmPodcast, ConfirmCode and ApproveTools answer the confirmations.
+	ConfirmPodcast bool
+	ConfirmCode    bool
+	ApproveTools   bool
+	// Answers answer the clarifying questions in order; once they run out
+	// the questions are skipped.
+	Answers []string
+	// Cancel, when set, is reported by ShouldCancel.
+	Cancel func() bool
+
+	mu        sync.Mutex
+	logs      []string
+	chunks    []string
+	questions []string
+	toolCalls []openai.ToolCall
+	tasks     []agent.Task
+	results   []agent.Result
+}
+
+var _ agent.InteractionHandler = (*TestInteractionHandler)(nil)
+
+// ReviewPlan returns PlanChange.
+func (h *TestInteractionHandler) ReviewPlan(plan *agent.Plan) (string, error) {
+	return h.PlanChange, nil
+}
+
+// ConfirmPodcastGeneration returns ConfirmPodcast.
+func (h *TestInteractionHandler) ConfirmPodcastGeneration(report string) (bool, error) {
+	return h.ConfirmPodcast, nil
+}
+
+// ConfirmCodeExecution returns ConfirmCode.
+func (h *TestInteractionHandler) ConfirmCodeExecution(language, code string) (bool, error) {
+	return h.ConfirmCode, nil
+}
+
+// ApproveTool records tc and returns ApproveTools.
+func (h *TestInteractionHandler) ApproveTool(tc openai.ToolCall, risk tool.RiskLevel) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.toolCalls = append(h.toolCalls, tc)
+	return h.ApproveTools, nil
+}
+
+// AskClarification records question and returns the next of Answers.
+func (h *TestInteractionHandler) AskClarification(question string) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.questions = append(h.questions, question)
+	if len(h.questions) > len(h.Answers) {
+		return "", nil
+	}
+	return h.Answers[len(h.questions)-1], nil
+}
+
+// Log records message.
+func (h *TestInteractionHandler) Log(message string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logs = append(h.logs, message)
+}
+
+// StreamChunk records chunk.
+func (h *TestInteractionHandler) StreamChunk(chunk string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.chunks = append(h.chunks, chunk)
+}
+
+// OnTaskStart records task.
+func (h *TestInteractionHandler) OnTaskStart(task agent.Task) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tasks = append(h.tasks, task)
+}
+
+// OnTaskComplete records result.
+func (h *TestInteractionHandler) OnTaskComplete(result agent.Result) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.results = append(h.results, result)
+}
+
+// OnStepProgress does nothing.
+func (h *TestInteractionHandler) OnStepProgress(pct float64, msg string) {}
+
+// ShouldCancel reports the result of Cancel, or false without one.
+func (h *TestInteractionHandler) ShouldCancel() bool {
+	return h.Cancel != nil && h.Cancel()
+}
+
+// Logs returns the messages logged so far.
+func (h *TestInteractionHandler) Logs() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.logs...)
+}
+
+// Chunks returns the streamed chunks so far.
+func (h *TestInteractionHandler) Chunks() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.chunks...)
+}
+
+// Questions returns the clarifying questions asked so far.
+func (h *TestInteractionHandler) Questions() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.questions...)
+}
+
+// ToolCalls returns the tool calls submitted for approval so far.
+func (h *TestInteractionHandler) ToolCalls() []openai.ToolCall {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]openai.ToolCall(nil), h.toolCalls...)
+}
+
+// Tasks returns the tasks started so far.
+func (h *TestInteractionHandler) Tasks() []agent.Task {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]agent.Task(nil), h.tasks...)
+}
+
+// Results returns the results of the tasks completed so far.
+func (h *TestInteractionHandler) Results() []agent.Result {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]agent.Result(nil), h.results...)
+}
@@ -0,0 +1,95 @@
+package agenttest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/smallnest/goskills/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanningAgentWithFakes(t *testing.T) {
+	plan := `{"description": "research", "tasks": [` +
+		`{"type": "SEARCH", "description": "search", "parameters": {"query": "go generics"}},` +
+		`{"type": "REPORT", "description": "write"},` +
+		`{"type": "RENDER", "description": "render"}]}`
+	client := agenttest.NewFakeClient(agenttest.Reply(plan))
+	client.Respond = func(req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		return agenttest.Reply("# Generics\n\nGo has generics since 1.18."), nil
+	}
+	var queries []string
+	handler := &agenttest.TestInteractionHandler{}
+
+	a, err := agent.NewPlanningAgent(agent.AgentConfig{
+		Client:       client,
+		RenderFormat: agent.FormatPlain,
+		Search: func(ctx context.Context, query string) (string, error) {
+			queries = append(queries, query)
+			return "Title: Go 1.18\nURL: https://go.dev/blog/go1.18\nContent: generics\n", nil
+		},
+	}, handler)
+	require.NoError(t, err)
+
+	output, err := a.Run(context.Background(), "tell me about go generics")
+	require.NoError(t, err)
+	assert.Contains(t, output, "GENERICS")
+	assert.Contains(t, queries, "go generics")
+
+	requests := client.Requests()
+	require.NotEmpty(t, requests)
+	assert.Contains(t, requests[0].Messages[1].Content, "tell me about go generics")
+
+	var types []agent.TaskType
+	for _, task := range handler.Tasks() {
+		types = append(types, task.Type)
+	}
+	assert.Equal(t, []agent.TaskType{agent.TaskTypeSearch, agent.TaskTypeReport, agent.TaskTypeRender}, types)
+	assert.Len(t, handler.Results(), 3)
+	assert.NotEmpty(t, handler.Logs())
+}
+
+func TestFakeClient(t *testing.T) {
+	call := agenttest.ToolCall("call_1", "read_file", map[string]string{"filePath": "a.txt"})
+	assert.Equal(t, `{"filePath":"a.txt"}`, call.Function.Arguments)
+	assert.Equal(t, "{}", agenttest.ToolCall("call_2", "noop", "{}").Function.Arguments)
+
+	client := agenttest.NewFakeClient(agenttest.CallTools(call))
+	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{Model: "m"})
+	require.NoError(t, err)
+	assert.Equal(t, openai.FinishReasonToolCalls, resp.Choices[0].FinishReason)
+	assert.Equal(t, []openai.ToolCall{call}, resp.Choices[0].Message.ToolCalls)
+
+	_, err = client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	assert.ErrorIs(t, err, agenttest.ErrNoResponse)
+	assert.Len(t, client.Requests(), 2)
+}
+
+func TestInteractionHandlerAnswers(t *testing.T) {
+	canceled := false
+	h := &agenttest.TestInteractionHandler{Answers: []string{"in French"}, ApproveTools: true, Cancel: func() bool { return canceled }}
+	answer, err := h.AskClarification("Which language?")
+	require.NoError(t, err)
+	assert.Equal(t, "in French", answer)
+	answer, err = h.AskClarification("Anything else?")
+	require.NoError(t, err)
+	assert.Empty(t, answer)
+	assert.Equal(t, []string{"Which language?", "Anything else?"}, h.Questions())
+
+	approved, err := h.ApproveTool(agenttest.ToolCall("1", "write_file", "{}"), tool.RiskDestructive)
+	require.NoError(t, err)
+	assert.True(t, approved)
+	assert.Len(t, h.ToolCalls(), 1)
+
+	assert.False(t, h.ShouldCancel())
+	canceled = true
+	assert.True(t, h.ShouldCancel())
+
+	h.StreamChunk("a")
+	h.StreamChunk("b")
+	assert.Equal(t, "ab", strings.Join(h.Chunks(), ""))
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Source is a web page found by a search, cited by number in reports.
+type Source struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// citationInstruction is added to the analysis and report prompts when there
+// are sources to cite, and referencesInstruction to the report prompt.
+const (
+	citationInstruction   = "\n\n引用来源时，请在相关句子后使用 [n] 形式的行内引用标记，其中 n 是来源列表中的编号。只引用列表中存在的来源。"
+	referencesInstruction = "在报告末尾添加“参考文献”部分，按编号列出被引用来源的标题和 URL。"
+)
+
+// citationMarker matches inline citation markers such as [3], [1, 2] or [4-6].
+var citationMarker = regexp.MustCompile(`\[(\d+(?:\s*[,，\-–]\s*\d+)*)\]`)
+
+// citationNumber matches the source numbers of a citation marker.
+var citationNumber = regexp.MustCompile(`\d+`)
+
+// parseSources extracts the sources of search results in the
+// "Title: ...\nURL: ...\nContent: ..." format, in order and without
+// duplicate URLs.
+func parseSources(results string) []Source {
+	var sources []Source
+	for _, entry := range strings.Split(results, "\n\n") {
+		var source Source
+		for _, line := range strings.Split(entry, "\n") {
+			if title, ok := strings.CutPrefix(line, "Title: "); ok {
+				source.Title = title
+			} else if url, ok := strings.CutPrefix(line, "URL: "); ok {
+				source.URL = url
+			}
+		}
+		if source.Title != "" && source.URL != "" {
+			sources = mergeSources(sources, []Source{source})
+		}
+	}
+	return sources
+}
+
+// mergeSources appends the sources of more whose URLs are not in sources yet.
+func mergeSources(sources, more []Source) []Source {
+	for _, source := range more {
+		seen := false
+		for _, s := range sources {
+			if s.URL == source.URL {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// formatSources numbers sources for a prompt, starting at 1.
+func formatSources(sources []Source) string {
+	var sb strings.Builder
+	sb.WriteString("来源列表:\n")
+	for i, source := range sources {
+		sb.WriteString(fmt.Sprintf("[%d] %s - %s\n", i+1, source.Title, source.URL))
+	}
+	return sb.String()
+}
+
+// CheckCitations returns the citation markers of report, such as "[7]", that
+// refer to no entry of sources, each once and in order of appearance.
+func CheckCitations(report string, sources []Source) []string {
+	var unmatched []string
+	seen := make(map[string]bool)
+	for _, match := range citationMarker.FindAllStringSubmatch(report, -1) {
+		marker := match[0]
+		if seen[marker] {
+			continue
+		}
+		for _, number := range citationNumber.FindAllString(match[1], -1) {
+			n, err := strconv.Atoi(number)
+			if err != nil || n < 1 || n > len(sources) {
+				seen[marker] = true
+				unmatched = append(unmatched, marker)
+				break
+			}
+		}
+	}
+	return unmatched
+}
@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSources(t *testing.T) {
+	results := "Title: Go\nURL: https://go.dev\nContent: Go.\n\n" +
+		"Title: No URL\nContent: skipped\n\n" +
+		"--- Additional Search Results ---\nTitle: Go again\nURL: https://go.dev\nContent: dup\n\n" +
+		"Title: Blog\nURL: https://go.dev/blog\nContent: Blog.\n\n"
+	sources := parseSources(results)
+	assert.Equal(t, []Source{{Title: "Go", URL: "https://go.dev"}, {Title: "Blog", URL: "https://go.dev/blog"}}, sources)
+
+	sources = mergeSources(sources, []Source{{Title: "Blog", URL: "https://go.dev/blog"}, {Title: "Spec", URL: "https://go.dev/ref/spec"}})
+	assert.Len(t, sources, 3)
+	assert.Equal(t, "来源列表:\n[1] Go - https://go.dev\n[2] Blog - https://go.dev/blog\n[3] Spec - https://go.dev/ref/spec\n", formatSources(sources))
+}
+
+func TestCheckCitations(t *testing.T) {
+	sources := []Source{{URL: "a"}, {URL: "b"}, {URL: "c"}}
+	report := "Go is simple [1]. It compiles fast [2, 3][4]. Generics arrived [1-5]. Again [4]. See [the spec](https://go.dev/ref/spec) and [0]."
+	assert.Equal(t, []string{"[4]", "[1-5]", "[0]"}, CheckCitations(report, sources))
+	assert.Empty(t, CheckCitations("No citations.", sources))
+}
+
+func TestReportCitations(t *testing.T) {
+	client := &replyClient{replies: []string{"Go is simple [1][3].\n\n## 参考文献\n[1] Go - https://go.dev"}}
+	report := NewReportSubagent(client, "gpt-4o", false, nil)
+
+	sources := []Source{{Title: "Go", URL: "https://go.dev"}}
+	result, err := report.Execute(context.Background(), Task{
+		Type:        TaskTypeReport,
+		Description: "write a report",
+		Parameters:  map[string]interface{}{"context": []string{"Output from SEARCH task:\n..."}, "sources": sources},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, client.got.Messages[0].Content, citationInstruction)
+	assert.Contains(t, client.got.Messages[1].Content, "[1] Go - https://go.dev")
+	assert.Equal(t, sources, result.Metadata["sources"])
+	assert.Equal(t, []string{"[3]"}, result.Metadata["unmatched_citations"])
+}
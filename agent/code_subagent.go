@@ -0,0 +1,281 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/smallnest/goskills/tool"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const defaultCodeMaxIterations = 3
+
+// CodeSubagent writes a script for a task, runs it and fixes it on failure.
+type CodeSubagent struct {
+	client      LLMClient
+	model       string
+	temperature float32
+	verbose     bool
+	verboseLog
+	interactionHandler InteractionHandler
+	autoApprove        bool
+	maxIterations      int
+	// allowedCommands, when not empty, restricts shell scripts to these
+	// commands, see WithAllowedShellCommands.
+	allowedCommands []string
+	// checkSyntax compiles Python code before running it, see
+	// WithPythonSyntaxCheck.
+	checkSyntax bool
+}
+
+// NewCodeSubagent creates a new CodeSubagent.
+// Unless autoApprove is set, every generated script must be confirmed through
+// the interaction handler before it is executed.
+func NewCodeSubagent(client LLMClient, model string, verbose bool, interactionHandler InteractionHandler, autoApprove bool, opts ...SubagentOption) *CodeSubagent {
+	o := applySubagentOptions(model, 0.2, opts)
+	return &CodeSubagent{
+		client:             client,
+		model:              o.model,
+		temperature:        o.temperature,
+		verbose:            verbose,
+		interactionHandler: interactionHandler,
+		autoApprove:        autoApprove,
+		maxIterations:      defaultCodeMaxIterations,
+		allowedCommands:    o.allowedCommands,
+		checkSyntax:        o.checkSyntax,
+	}
+}
+
+// WithAllowedShellCommands restricts the shell scripts of the CodeSubagent
+// to the given commands, e.g. []string{"ls", "cat", "grep"}. A script that
+// runs any other command is not executed and the model is asked to fix it.
+// See tool.CheckShellCommands.
+func WithAllowedShellCommands(commands []string) SubagentOption {
+	return func(o *subagentOptions) {
+		o.allowedCommands = commands
+	}
+}
+
+// Type returns the task type this subagent handles.
+func (c *CodeSubagent) Type() TaskType {
+	return TaskTypeCode
+}
+
+// Execute generates and runs code for the task, retrying with the error output on failure.
+func (c *CodeSubagent) Execute(ctx context.Context, task Task) (result Result, err error) {
+	if c.verbose {
+		c.logln("💻 代码 Subagent")
+	}
+	if c.interactionHandler != nil {
+		c.interactionHandler.Log(fmt.Sprintf("> 代码 Subagent: %s", task.Description))
+		c.interactionHandler.OnTaskStart(task)
+		defer func() { c.interactionHandler.OnTaskComplete(result) }()
+	}
+
+	language, _ := task.Parameters["language"].(string)
+	language = strings.ToLower(language)
+	if language != "shell" {
+		language = "python"
+	}
+
+	prompt := task.Description
+	if contextData, ok := task.Parameters["context"].([]string); ok && len(contextData) > 0 {
+		prompt = fmt.Sprintf("%s\n\n可参考以下信息:\n\n%s", task.Description, strings.Join(contextData, "\n\n"))
+	}
+
+	systemPrompt := fmt.Sprintf("你是一个编程助手。编写一个完整、可直接运行的 %s 脚本来完成用户的任务，并将结果打印到标准输出。\n"+
+		"仅输出一个代码块，不要添加任何解释。", language)
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: systemPrompt,
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: prompt,
+		},
+	}
+
+	var lastErr error
+	var code string
+	for i := 0; i < c.maxIterations; i++ {
+		if shouldCancel(c.interactionHandler) {
+			return Result{
+				TaskType: TaskTypeCode,
+				Success:  false,
+				Output:   code,
+				Error:    ErrCanceled.Error(),
+			}, nil
+		}
+		if c.interactionHandler != nil {
+			c.interactionHandler.OnStepProgress(float64(i)/float64(c.maxIterations), fmt.Sprintf("生成代码 (第 %d 次尝试)", i+1))
+		}
+		resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+			Model:       c.model,
+			Messages:    messages,
+			Temperature: c.temperature,
+		})
+		if err != nil {
+			return Result{
+				TaskType: TaskTypeCode,
+				Success:  false,
+				Error:    err.Error(),
+			}, err
+		}
+
+		reply := resp.Choices[0].Message.Content
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: reply,
+		})
+		code = extractCodeBlock(reply)
+
+		approved, err := c.approve(language, code)
+		if err != nil {
+			return Result{
+				TaskType: TaskTypeCode,
+				Success:  false,
+				Error:    err.Error(),
+			}, err
+		}
+		if !approved {
+			if c.interactionHandler != nil {
+				c.interactionHandler.Log("❌ 代码执行被拒绝")
+			}
+			return Result{
+				TaskType: TaskTypeCode,
+				Success:  false,
+				Output:   code,
+				Error:    "code execution was not approved",
+			}, nil
+		}
+
+		if c.verbose {
+			c.logf("  正在执行 %s 脚本 (第 %d 次尝试)\n", language, i+1)
+		}
+
+		output, err := c.runCode(ctx, language, code)
+		if err == nil {
+			if c.verbose {
+				c.logf("  ✓ 代码执行成功 (%d 字节输出)\n", len(output))
+			}
+			if c.interactionHandler != nil {
+				c.interactionHandler.Log(fmt.Sprintf("✓ 代码执行成功 (%d 字节输出)", len(output)))
+			}
+			return Result{
+				TaskType: TaskTypeCode,
+				Success:  true,
+				Output:   output,
+				Metadata: map[string]interface{}{
+					"language":   language,
+					"code":       code,
+					"iterations": i + 1,
+				},
+			}, nil
+		}
+
+		lastErr = err
+		if c.interactionHandler != nil {
+			c.interactionHandler.Log(fmt.Sprintf("  ⚠️ 代码执行失败 (第 %d 次尝试)，正在修正", i+1))
+		}
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("脚本执行失败:\n%v\n\n请修正脚本并输出完整的新版本。", err),
+		})
+	}
+
+	return Result{
+		TaskType: TaskTypeCode,
+		Success:  false,
+		Output:   code,
+		Error:    fmt.Sprintf("code failed after %d attempts: %v", c.maxIterations, lastErr),
+	}, nil
+}
+
+// approve decides whether the generated code may be executed.
+func (c *CodeSubagent) approve(language, code string) (bool, error) {
+	if c.autoApprove {
+		return true, nil
+	}
+	if c.interactionHandler == nil {
+		return false, nil
+	}
+	return c.interactionHandler.ConfirmCodeExecution(language, code)
+}
+
+// WithPythonSyntaxCheck makes the CodeSubagent compile Python code before
+// running it, so a syntax error is returned to the model without running
+// any of the code. See tool.CheckPythonSyntax.
+func WithPythonSyntaxCheck(check bool) SubagentOption {
+	return func(o *subagentOptions) {
+		o.checkSyntax = check
+	}
+}
+
+// runCode writes code to a temporary file and executes it. Shell code
+// that runs commands outside the allow-list is rejected without running.
+func (c *CodeSubagent) runCode(ctx context.Context, language, code string) (string, error) {
+	if language == "shell" && len(c.allowedCommands) > 0 {
+		if err := tool.CheckShellCommands(code, c.allowedCommands); err != nil {
+			return "", err
+		}
+	}
+
+	pattern := "code-*.py"
+	if language == "shell" {
+		pattern = "code-*.sh"
+	}
+
+	tmpfile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.WriteString(code); err != nil {
+		tmpfile.Close()
+		return "", fmt.Errorf("failed to write to temp file: %w", err)
+	}
+	if err := tmpfile.Close(); err != nil {
+		return "", fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	start := time.Now()
+	if language == "shell" {
+		output, err := tool.RunShellScript(ctx, tmpfile.Name(), nil)
+		tool.RecordToolCall(ctx, "run_shell_code", start, err)
+		return output, err
+	}
+	if c.checkSyntax {
+		if err := tool.CheckPythonSyntax(ctx, tmpfile.Name()); err != nil {
+			tool.RecordToolCall(ctx, "run_python_code", start, err)
+			return "", err
+		}
+	}
+	output, err := tool.RunPythonScript(ctx, tmpfile.Name(), nil)
+	tool.RecordToolCall(ctx, "run_python_code", start, err)
+	return output, err
+}
+
+// extractCodeBlock returns the content of the first fenced code block,
+// or the whole text if there is none.
+func extractCodeBlock(text string) string {
+	start := strings.Index(text, "```")
+	if start == -1 {
+		return strings.TrimSpace(text)
+	}
+	rest := text[start+3:]
+	// Skip the language tag
+	if idx := strings.Index(rest, "\n"); idx != -1 {
+		rest = rest[idx+1:]
+	}
+	if end := strings.Index(rest, "```"); end != -1 {
+		rest = rest[:end]
+	}
+	return strings.TrimSpace(rest)
+}
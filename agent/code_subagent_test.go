@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeAllowedShellCommands(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	client := &replyClient{replies: []string{
+		"```sh\ntouch " + marker + "\n```",
+		"```sh\necho ok\n```",
+	}}
+	code := NewCodeSubagent(client, "gpt-4o", false, nil, true, WithAllowedShellCommands([]string{"echo"}))
+
+	result, err := code.Execute(context.Background(), Task{Type: TaskTypeCode, Description: "say ok", Parameters: map[string]interface{}{"language": "shell"}})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "ok\n", result.Output)
+	assert.Equal(t, 2, result.Metadata["iterations"])
+
+	// The rejected script was not run and its error was sent back to the model
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err))
+	assert.Contains(t, client.got.Messages[len(client.got.Messages)-1].Content, "shell command not allowed")
+}
+
+func TestCodePythonSyntaxCheck(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	client := &replyClient{replies: []string{
+		"```python\nprint('ok'\n```",
+		"```python\nprint('ok')\n```",
+	}}
+	code := NewCodeSubagent(client, "gpt-4o", false, nil, true, WithPythonSyntaxCheck(true))
+
+	result, err := code.Execute(context.Background(), Task{Type: TaskTypeCode, Description: "say ok"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "ok\n", result.Output)
+	assert.Contains(t, client.got.Messages[len(client.got.Messages)-1].Content, "python syntax error, nothing was run")
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// DefaultContextBudget is the estimated number of tokens of earlier task
+// outputs the ANALYZE and REPORT subagents put in their prompt.
+const DefaultContextBudget = 32000
+
+// minTruncatedTokens is the smallest part of an output worth keeping when it
+// does not fit the budget whole.
+const minTruncatedTokens = 200
+
+// truncatedMarker ends an output cut to fit the context budget.
+const truncatedMarker = "\n...[已截断]"
+
+// WithContextBudget limits the estimated tokens of earlier task outputs a
+// subagent puts in its prompt. Zero uses DefaultContextBudget and a negative
+// budget disables the limit. Only the AnalysisSubagent and ReportSubagent use
+// a context budget.
+func WithContextBudget(tokens int) SubagentOption {
+	return func(o *subagentOptions) {
+		o.contextBudget = tokens
+	}
+}
+
+// estimateTextTokens roughly estimates the tokens of s: about four bytes per
+// token for ASCII text and one token per other character, e.g. for Chinese.
+func estimateTextTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// truncateToTokens returns the head of s estimated to fit in tokens.
+func truncateToTokens(s string, tokens int) string {
+	used := 0
+	for i, r := range s {
+		if r < utf8.RuneSelf {
+			used++
+		} else {
+			used += 4
+		}
+		if used > tokens*4 {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// fitContext keeps the most recent entries of contextData that fit in budget
+// estimated tokens, in their original order. The newest entry that does not
+// fit whole is cut short when enough of the budget is left for it; older
+// ones are dropped. It returns the kept entries and the number of entries
+// dropped or cut.
+func fitContext(contextData []string, budget int) ([]string, int) {
+	if budget == 0 {
+		budget = DefaultContextBudget
+	}
+	if budget < 0 {
+		return contextData, 0
+	}
+	total := 0
+	for _, entry := range contextData {
+		total += estimateTextTokens(entry)
+	}
+	if total <= budget {
+		return contextData, 0
+	}
+
+	remaining := budget
+	start := len(contextData)
+	var truncated string
+	for i := len(contextData) - 1; i >= 0; i-- {
+		tokens := estimateTextTokens(contextData[i])
+		if tokens <= remaining {
+			remaining -= tokens
+			start = i
+			continue
+		}
+		if remaining >= minTruncatedTokens {
+			truncated = truncateToTokens(contextData[i], remaining-estimateTextTokens(truncatedMarker)) + truncatedMarker
+			start = i
+		}
+		break
+	}
+
+	kept := make([]string, 0, len(contextData)-start)
+	kept = append(kept, contextData[start:]...)
+	if truncated != "" {
+		kept[0] = truncated
+	}
+	trimmed := start
+	if truncated != "" {
+		trimmed++
+	}
+	return kept, trimmed
+}
+
+// trimContext fits contextData into budget with fitContext and logs what was
+// trimmed.
+func trimContext(contextData []string, budget int, verbose bool, log *verboseLog, handler InteractionHandler) []string {
+	kept, trimmed := fitContext(contextData, budget)
+	if trimmed == 0 {
+		return contextData
+	}
+	before, after := 0, 0
+	for _, entry := range contextData {
+		before += estimateTextTokens(entry)
+	}
+	for _, entry := range kept {
+		after += estimateTextTokens(entry)
+	}
+	msg := fmt.Sprintf("  ✂️ 上下文过长 (约 %d tokens)，已裁剪 %d 段较早的输出，保留约 %d tokens", before, trimmed, after)
+	if verbose {
+		log.logln(msg)
+	}
+	if handler != nil {
+		handler.Log(msg)
+	}
+	return kept
+}
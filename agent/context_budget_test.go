@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFitContext(t *testing.T) {
+	assert.Equal(t, 3, estimateTextTokens("hello world"))
+	assert.Equal(t, 2, estimateTextTokens("你好"))
+
+	old := strings.Repeat("a", 4000)    // ~1000 tokens
+	middle := strings.Repeat("b", 4000) // ~1000 tokens
+	recent := strings.Repeat("c", 2000) // ~500 tokens
+	contextData := []string{old, middle, recent}
+
+	kept, trimmed := fitContext(contextData, 5000)
+	assert.Equal(t, contextData, kept)
+	assert.Zero(t, trimmed)
+
+	// The middle output is cut short and the oldest dropped
+	kept, trimmed = fitContext(contextData, 1000)
+	require.Len(t, kept, 2)
+	assert.Equal(t, 2, trimmed)
+	assert.True(t, strings.HasSuffix(kept[0], truncatedMarker))
+	assert.True(t, strings.HasPrefix(kept[0], "bbb"))
+	assert.Equal(t, recent, kept[1])
+	assert.LessOrEqual(t, estimateTextTokens(strings.Join(kept, "")), 1000)
+
+	// Too little budget left to keep part of the middle output
+	kept, trimmed = fitContext(contextData, 600)
+	assert.Equal(t, []string{recent}, kept)
+	assert.Equal(t, 2, trimmed)
+
+	kept, trimmed = fitContext(contextData, -1)
+	assert.Equal(t, contextData, kept)
+	assert.Zero(t, trimmed)
+}
+
+func TestAnalysisContextBudget(t *testing.T) {
+	client := &replyClient{replies: []string{"analysis"}}
+	analysis := NewAnalysisSubagent(client, "gpt-4o", true, nil, WithContextBudget(100))
+	var log bytes.Buffer
+	analysis.SetLogWriter(&log)
+
+	_, err := analysis.Execute(context.Background(), Task{
+		Type:        TaskTypeAnalyze,
+		Description: "compare",
+		Parameters: map[string]interface{}{"context": []string{
+			"Output from SEARCH task:\n" + strings.Repeat("old ", 1000),
+			"Output from SEARCH task:\nrecent",
+		}},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, client.got.Messages[1].Content, "old old")
+	assert.Contains(t, client.got.Messages[1].Content, "recent")
+	assert.Contains(t, log.String(), "已裁剪 1 段较早的输出")
+}
@@ -0,0 +1,221 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Critique strictness levels.
+const (
+	CritiqueLight    = "light"    // copy-editing only
+	CritiqueStandard = "standard" // structure, clarity and obvious gaps
+	CritiqueRigorous = "rigorous" // full fact-checking
+)
+
+// Critique is the structured review of a draft report.
+type Critique struct {
+	FactualGaps       []string `json:"factual_gaps"`
+	UnsupportedClaims []string `json:"unsupported_claims"`
+	FormattingIssues  []string `json:"formatting_issues"`
+	Summary           string   `json:"summary"`
+}
+
+// IssueCount returns the total number of issues found.
+func (c Critique) IssueCount() int {
+	return len(c.FactualGaps) + len(c.UnsupportedClaims) + len(c.FormattingIssues)
+}
+
+// CritiqueSubagent reviews draft reports and lists their problems.
+type CritiqueSubagent struct {
+	client  LLMClient
+	model   string
+	verbose bool
+	verboseLog
+	interactionHandler InteractionHandler
+}
+
+// NewCritiqueSubagent creates a new CritiqueSubagent.
+func NewCritiqueSubagent(client LLMClient, model string, verbose bool, interactionHandler InteractionHandler) *CritiqueSubagent {
+	return &CritiqueSubagent{
+		client:             client,
+		model:              model,
+		verbose:            verbose,
+		interactionHandler: interactionHandler,
+	}
+}
+
+// Type returns the task type this subagent handles.
+func (c *CritiqueSubagent) Type() TaskType {
+	return TaskTypeCritique
+}
+
+// Execute critiques a draft report against the original request.
+func (c *CritiqueSubagent) Execute(ctx context.Context, task Task) (result Result, err error) {
+	if c.verbose {
+		c.logln("🧐 审阅 Subagent")
+	}
+	if c.interactionHandler != nil {
+		c.interactionHandler.Log(fmt.Sprintf("> 审阅 Subagent: %s", task.Description))
+		c.interactionHandler.OnTaskStart(task)
+		defer func() { c.interactionHandler.OnTaskComplete(result) }()
+	}
+
+	// Get the draft from parameters or the last REPORT output
+	draft, _ := task.Parameters["draft"].(string)
+	if draft == "" {
+		if ctxContent, ok := task.Parameters["context"].([]string); ok {
+			for i := len(ctxContent) - 1; i >= 0; i-- {
+				if strings.HasPrefix(ctxContent[i], "Output from REPORT task:") {
+					draft = strings.TrimSpace(strings.TrimPrefix(ctxContent[i], "Output from REPORT task:"))
+					break
+				}
+			}
+		}
+	}
+	if draft == "" {
+		err := fmt.Errorf("no draft report to critique")
+		return Result{
+			TaskType: TaskTypeCritique,
+			Success:  false,
+			Error:    err.Error(),
+		}, err
+	}
+
+	request, _ := task.Parameters["request"].(string)
+	if request == "" {
+		request, _ = task.Parameters["global_context"].(string)
+	}
+
+	strictness, _ := task.Parameters["strictness"].(string)
+	var instructions string
+	switch strictness {
+	case CritiqueLight:
+		instructions = "只做轻度校对：关注错别字、语法、格式和可读性问题。除非明显错误，否则不要质疑事实。"
+	case CritiqueRigorous:
+		instructions = "进行严格的事实核查：逐条检查每个论断是否有来源支持，指出所有缺失的关键事实、未经证实的说法和格式问题。"
+	default:
+		strictness = CritiqueStandard
+		instructions = "检查结构、清晰度、明显的事实缺失、缺乏依据的论断以及格式问题。"
+	}
+
+	systemPrompt := "你是一位严谨的报告审阅者。" + instructions + `
+仅返回具有此结构的有效 JSON 对象：
+{
+  "factual_gaps": ["缺失的事实或未回答的问题"],
+  "unsupported_claims": ["缺乏依据的论断"],
+  "formatting_issues": ["格式问题"],
+  "summary": "总体评价"
+}
+没有问题的类别使用空数组。`
+
+	userPrompt := fmt.Sprintf("原始请求:\n%s\n\n报告草稿:\n%s", request, draft)
+
+	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return Result{
+			TaskType: TaskTypeCritique,
+			Success:  false,
+			Error:    err.Error(),
+		}, err
+	}
+
+	content := resp.Choices[0].Message.Content
+
+	// Clean up markdown code blocks if present
+	if idx := strings.Index(content, "```json"); idx != -1 {
+		content = content[idx+7:]
+	} else if idx := strings.Index(content, "```"); idx != -1 {
+		content = content[idx+3:]
+	}
+	if idx := strings.LastIndex(content, "```"); idx != -1 {
+		content = content[:idx]
+	}
+	content = strings.TrimSpace(content)
+
+	var critique Critique
+	if err := json.Unmarshal([]byte(content), &critique); err != nil {
+		return Result{
+			TaskType: TaskTypeCritique,
+			Success:  false,
+			Error:    fmt.Sprintf("解析审阅 JSON 失败: %v", err),
+		}, fmt.Errorf("解析审阅 JSON 失败: %w", err)
+	}
+
+	if c.verbose {
+		c.logf("  ✓ 审阅完成，发现 %d 个问题\n", critique.IssueCount())
+	}
+	if c.interactionHandler != nil {
+		c.interactionHandler.Log(fmt.Sprintf("✓ 审阅完成，发现 %d 个问题", critique.IssueCount()))
+	}
+
+	result = Result{
+		TaskType: TaskTypeCritique,
+		Success:  true,
+		Output:   formatCritique(critique),
+		Metadata: map[string]interface{}{
+			"critique":   critique,
+			"strictness": strictness,
+		},
+	}
+
+	// Optionally queue a revision pass of the report; the draft and this
+	// critique reach it through the accumulated context.
+	if revise, _ := task.Parameters["revise"].(bool); revise && critique.IssueCount() > 0 {
+		result.NewTasks = []Task{
+			{
+				Type:        TaskTypeReport,
+				Description: "根据审阅意见修订报告草稿，修正所有列出的问题，并输出完整的修订版报告",
+			},
+		}
+	}
+
+	return result, nil
+}
+
+// formatCritique renders a critique as Markdown.
+func formatCritique(c Critique) string {
+	var sb strings.Builder
+	sb.WriteString("## 审阅意见\n\n")
+	if c.Summary != "" {
+		sb.WriteString(c.Summary + "\n\n")
+	}
+
+	sections := []struct {
+		title string
+		items []string
+	}{
+		{"事实缺失", c.FactualGaps},
+		{"缺乏依据的论断", c.UnsupportedClaims},
+		{"格式问题", c.FormattingIssues},
+	}
+	for _, section := range sections {
+		sb.WriteString(fmt.Sprintf("### %s\n", section.title))
+		if len(section.items) == 0 {
+			sb.WriteString("- 无\n\n")
+			continue
+		}
+		for _, item := range section.items {
+			sb.WriteString(fmt.Sprintf("- %s\n", item))
+		}
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,276 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// graphNode is a task of a dependency graph and the state of its run.
+type graphNode struct {
+	task    Task
+	key     string // the ID, or a name for tasks without one
+	deps    []*graphNode
+	started bool
+	done    bool
+	result  Result
+	// sources are those found by the task and its dependencies
+	sources []Source
+}
+
+// graphDone is a finished task of a graph run.
+type graphDone struct {
+	node   *graphNode
+	result Result
+	err    error
+}
+
+// hasDependencies reports whether any of tasks depends on another.
+func hasDependencies(tasks []Task) bool {
+	for _, task := range tasks {
+		if len(task.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// buildGraph links tasks to the tasks they depend on. Tasks without an ID
+// get one of their position, which other tasks cannot depend on.
+func buildGraph(tasks []Task, existing map[string]*graphNode, offset int) ([]*graphNode, error) {
+	nodes := make([]*graphNode, len(tasks))
+	for i, task := range tasks {
+		node := &graphNode{task: task, key: task.ID}
+		if task.ID == "" {
+			node.key = fmt.Sprintf("#%d", offset+i+1)
+		} else if _, ok := existing[task.ID]; ok {
+			return nil, fmt.Errorf("duplicate task id: %s", task.ID)
+		}
+		existing[node.key] = node
+		nodes[i] = node
+	}
+	for _, node := range nodes {
+		for _, id := range node.task.DependsOn {
+			dep, ok := existing[id]
+			if !ok || strings.HasPrefix(id, "#") {
+				return nil, fmt.Errorf("task %s depends on unknown task %s", node.key, id)
+			}
+			node.deps = append(node.deps, dep)
+		}
+	}
+	if cycle := findCycle(nodes); cycle != nil {
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nodes, nil
+}
+
+// findCycle returns the keys of a dependency cycle among nodes, starting and
+// ending with the same task, or nil when there is none.
+func findCycle(nodes []*graphNode) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*graphNode]int)
+	var path []*graphNode
+	var visit func(node *graphNode) []string
+	visit = func(node *graphNode) []string {
+		switch state[node] {
+		case visited:
+			return nil
+		case visiting:
+			var cycle []string
+			for i := len(path) - 1; i >= 0; i-- {
+				if path[i] == node {
+					for _, n := range path[i:] {
+						cycle = append(cycle, n.key)
+					}
+					return append(cycle, node.key)
+				}
+			}
+		}
+		state[node] = visiting
+		path = append(path, node)
+		for _, dep := range node.deps {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = visited
+		return nil
+	}
+	for _, node := range nodes {
+		if cycle := visit(node); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// runGraph runs tasks as a dependency graph, see Run.
+func (o *Orchestrator) runGraph(ctx context.Context, tasks []Task) ([]Result, []Task, error) {
+	byKey := make(map[string]*graphNode)
+	nodes, err := buildGraph(tasks, byKey, 0)
+	if err != nil {
+		return nil, tasks, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	finished := make(chan graphDone)
+	running, completed := 0, 0
+
+	planTasks := func() []Task {
+		tasks := make([]Task, len(nodes))
+		for i, node := range nodes {
+			tasks[i] = node.task
+		}
+		return tasks
+	}
+	doneResults := func() []Result {
+		var results []Result
+		for _, node := range nodes {
+			if node.done {
+				results = append(results, node.result)
+			}
+		}
+		return results
+	}
+	// wait lets the running tasks finish after a failure or cancellation
+	wait := func() {
+		cancel()
+		for ; running > 0; running-- {
+			<-finished
+		}
+	}
+
+	for completed < len(nodes) {
+		if shouldCancel(o.interactionHandler) {
+			wait()
+			if o.verbose {
+				o.logln("⏹️ 执行已被用户取消")
+			}
+			o.interactionHandler.Log("⏹️ 执行已被用户取消")
+			return doneResults(), planTasks(), ErrCanceled
+		}
+
+		// Start the tasks whose dependencies have finished
+		for _, node := range nodes {
+			if o.MaxConcurrency > 0 && running >= o.MaxConcurrency {
+				break
+			}
+			if node.started || !depsDone(node) {
+				continue
+			}
+			subagent, ok := o.subagents[node.task.Type]
+			if !ok {
+				wait()
+				return nil, planTasks(), fmt.Errorf("unknown task type: %s", node.task.Type)
+			}
+
+			var contextData []string
+			var outputs []TaskOutput
+			var sources []Source
+			for _, dep := range node.deps {
+				contextData = append(contextData, taskOutputs(dep.task, dep.result)...)
+				if dep.result.Success || dep.result.Output != "" {
+					outputs = append(outputs, TaskOutput{ID: dep.task.ID, Type: dep.task.Type, Success: dep.result.Success, Output: dep.result.Output})
+				}
+				sources = mergeSources(sources, dep.sources)
+			}
+			node.sources = sources
+			task := o.prepareTask(node.task, contextData, outputs, sources)
+
+			node.started = true
+			running++
+			if o.verbose {
+				o.logf("📍 步骤 %s (%d/%d): [%s] %s\n", node.key, completed+running, len(nodes), task.Type, task.Description)
+			}
+			if o.interactionHandler != nil {
+				o.interactionHandler.Log(fmt.Sprintf("📍 步骤 %s (%d/%d): [%s] %s", node.key, completed+running, len(nodes), task.Type, task.Description))
+				o.interactionHandler.OnStepProgress(float64(completed)/float64(len(nodes)), fmt.Sprintf("[%s] %s", task.Type, task.Description))
+			}
+			go func(node *graphNode, task Task) {
+				result, err := subagent.Execute(ctx, task)
+				finished <- graphDone{node: node, result: result, err: err}
+			}(node, task)
+		}
+
+		done := <-finished
+		running--
+		node := done.node
+		if done.err != nil {
+			wait()
+			return nil, planTasks(), fmt.Errorf("task %s failed: %w", node.key, done.err)
+		}
+		node.done = true
+		node.result = done.result
+		completed++
+
+		if !done.result.Success {
+			if o.verbose {
+				o.logf("  ✗ [%s] 失败: %s\n\n", node.key, done.result.Error)
+			}
+			if o.interactionHandler != nil {
+				o.interactionHandler.Log(fmt.Sprintf("  ✗ [%s] 失败: %s", node.key, done.result.Error))
+			}
+			continue
+		}
+
+		if found, ok := done.result.Metadata["sources"].([]Source); ok {
+			node.sources = mergeSources(node.sources, found)
+		}
+		if len(done.result.NewTasks) > 0 {
+			if o.verbose {
+				o.logf("  🔄 动态规划更新: 插入 %d 个新任务\n", len(done.result.NewTasks))
+			}
+			if o.interactionHandler != nil {
+				o.interactionHandler.Log(fmt.Sprintf("🔄 动态规划更新: 插入 %d 个新任务", len(done.result.NewTasks)))
+			}
+
+			inserted, err := buildGraph(done.result.NewTasks, byKey, len(byKey))
+			if err != nil {
+				wait()
+				return nil, planTasks(), fmt.Errorf("task %s inserted invalid tasks: %w", node.key, err)
+			}
+			// Inserted tasks without dependencies follow the task that
+			// inserted them
+			for _, n := range inserted {
+				if len(n.deps) == 0 {
+					n.deps = []*graphNode{node}
+				}
+			}
+			for i, n := range nodes {
+				if n == node {
+					nodes = append(nodes[:i+1], append(inserted, nodes[i+1:]...)...)
+					break
+				}
+			}
+		}
+
+		if o.verbose {
+			o.logf("  ✓ [%s] 完成\n\n", node.key)
+		}
+		if o.interactionHandler != nil {
+			o.interactionHandler.Log(fmt.Sprintf("  ✓ [%s] 完成", node.key))
+		}
+	}
+
+	if o.interactionHandler != nil {
+		o.interactionHandler.OnStepProgress(1, "计划执行完成")
+	}
+
+	return doneResults(), planTasks(), nil
+}
+
+// depsDone reports whether the dependencies of node have finished.
+func depsDone(node *graphNode) bool {
+	for _, dep := range node.deps {
+		if !dep.done {
+			return false
+		}
+	}
+	return true
+}
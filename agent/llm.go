@@ -0,0 +1,239 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// LLMClient is the chat completion API used by the agents and subagents.
+// Requests and responses use the go-openai types, which other backends
+// translate to and from their own formats. *openai.Client implements it.
+type LLMClient interface {
+	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+}
+
+// WithSeed returns a client that sends seed with every request of client
+// that has none, so backends that support it, such as OpenAI and Gemini,
+// sample deterministically on a best-effort basis. Compare the
+// SystemFingerprint of the responses to detect backend changes that break
+// reproducibility; see ClientFingerprints. The returned client streams when
+// client does, and Unwrap returns client for its other optional APIs, such
+// as embeddings.
+func WithSeed(client LLMClient, seed int) LLMClient {
+	seeded := seededClient{client: client, seed: seed, fingerprints: &fingerprintSet{}}
+	if streamer, ok := client.(streamingClient); ok {
+		return seededStreamingClient{seededClient: seeded, streamer: streamer}
+	}
+	return seeded
+}
+
+// UnwrapClient returns the client wrapped by client, such as the client
+// passed to WithSeed, or nil when client wraps none.
+func UnwrapClient(client LLMClient) LLMClient {
+	if u, ok := client.(interface{ Unwrap() LLMClient }); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// ClientFingerprints returns the distinct system fingerprints of the
+// non-streamed responses of a client returned by WithSeed, in the order they
+// were first seen, or nil for other clients.
+func ClientFingerprints(client LLMClient) []string {
+	if c, ok := client.(interface{ systemFingerprints() []string }); ok {
+		return c.systemFingerprints()
+	}
+	return nil
+}
+
+// seededClient sets the seed of the requests of WithSeed.
+type seededClient struct {
+	client       LLMClient
+	seed         int
+	fingerprints *fingerprintSet
+}
+
+// Unwrap returns the client the seed is added for.
+func (c seededClient) Unwrap() LLMClient {
+	return c.client
+}
+
+func (c seededClient) systemFingerprints() []string {
+	return c.fingerprints.list()
+}
+
+func (c seededClient) withSeed(req openai.ChatCompletionRequest) openai.ChatCompletionRequest {
+	if req.Seed == nil {
+		seed := c.seed
+		req.Seed = &seed
+	}
+	return req
+}
+
+// CreateChatCompletion implements LLMClient.
+func (c seededClient) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	resp, err := c.client.CreateChatCompletion(ctx, c.withSeed(req))
+	if err == nil {
+		c.fingerprints.add(resp.SystemFingerprint)
+	}
+	return resp, err
+}
+
+// fingerprintSet collects distinct system fingerprints. It is safe for
+// concurrent use, as subagents may share a client.
+type fingerprintSet struct {
+	mu     sync.Mutex
+	values []string
+}
+
+func (s *fingerprintSet) add(fingerprint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fingerprint != "" && !slices.Contains(s.values, fingerprint) {
+		s.values = append(s.values, fingerprint)
+	}
+}
+
+func (s *fingerprintSet) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.values)
+}
+
+// seededStreamingClient is a seededClient of a client that streams.
+type seededStreamingClient struct {
+	seededClient
+	streamer streamingClient
+}
+
+func (c seededStreamingClient) CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	return c.streamer.CreateChatCompletionStream(ctx, c.withSeed(req))
+}
+
+// Supported LLM providers.
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderGemini    = "gemini"
+)
+
+// defaultModels is the model used for each provider when none is configured.
+var defaultModels = map[string]string{
+	ProviderOpenAI:    "gpt-4o",
+	ProviderAnthropic: "claude-sonnet-4-5",
+	ProviderGemini:    "gemini-2.5-flash",
+}
+
+// DefaultModel returns the model used for provider when none is configured.
+func DefaultModel(provider string) string {
+	if model, ok := defaultModels[strings.ToLower(provider)]; ok {
+		return model
+	}
+	return defaultModels[ProviderOpenAI]
+}
+
+// NewLLMClient creates a client for provider. An empty provider selects
+// ProviderOpenAI; apiBase overrides the provider's default endpoint.
+func NewLLMClient(provider, apiKey, apiBase string) (LLMClient, error) {
+	return NewLLMClientWithHTTPClient(provider, apiKey, apiBase, nil)
+}
+
+// NewLLMClientWithHTTPClient is like NewLLMClient, sending the requests
+// through httpClient, e.g. one with a proxy or custom TLS roots. Nil uses
+// the backend's default client.
+func NewLLMClientWithHTTPClient(provider, apiKey, apiBase string, httpClient *http.Client) (LLMClient, error) {
+	switch strings.ToLower(provider) {
+	case "", ProviderOpenAI:
+		openaiConfig := openai.DefaultConfig(apiKey)
+		if apiBase != "" {
+			openaiConfig.BaseURL = apiBase
+		}
+		if httpClient != nil {
+			openaiConfig.HTTPClient = httpClient
+		}
+		return openai.NewClientWithConfig(openaiConfig), nil
+	case ProviderAnthropic:
+		client := NewAnthropicClient(apiKey, apiBase)
+		client.httpClient = httpClient
+		return client, nil
+	case ProviderGemini:
+		client := NewGeminiClient(apiKey, apiBase)
+		client.httpClient = httpClient
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (want %s, %s or %s)", provider, ProviderOpenAI, ProviderAnthropic, ProviderGemini)
+	}
+}
+
+// llmHTTPClient is used by the non-OpenAI backends without a client of
+// their own.
+var llmHTTPClient = &http.Client{Timeout: 5 * time.Minute}
+
+// postJSON sends body to url through client, or llmHTTPClient when nil, and
+// decodes the JSON response into out. Non-2xx responses are returned as
+// *openai.RequestError so retries treat them like OpenAI errors.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	if client == nil {
+		client = llmHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &openai.RequestError{
+			HTTPStatus:     resp.Status,
+			HTTPStatusCode: resp.StatusCode,
+			Err:            fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(respBody))),
+			Body:           respBody,
+		}
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// jsonModeInstruction is added to the system prompt of backends without a
+// JSON response format.
+const jsonModeInstruction = "Respond with a single valid JSON object and nothing else."
+
+// wantsJSON reports whether req asks for a JSON response.
+func wantsJSON(req openai.ChatCompletionRequest) bool {
+	return req.ResponseFormat != nil && req.ResponseFormat.Type != openai.ChatCompletionResponseFormatTypeText
+}
+
+// toolArguments parses the JSON arguments of a tool call, treating empty
+// arguments as an empty object.
+func toolArguments(arguments string) json.RawMessage {
+	if strings.TrimSpace(arguments) == "" || !json.Valid([]byte(arguments)) {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
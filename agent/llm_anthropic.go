@@ -0,0 +1,217 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// defaultAnthropicBase is the Anthropic Messages API endpoint.
+const defaultAnthropicBase = "https://api.anthropic.com/v1"
+
+// anthropicVersion is sent in the anthropic-version header.
+const anthropicVersion = "2023-06-01"
+
+// defaultAnthropicMaxTokens is used when the request sets no limit, since the
+// Messages API requires one.
+const defaultAnthropicMaxTokens = 4096
+
+// AnthropicClient implements LLMClient with Anthropic's Messages API,
+// including tool use.
+type AnthropicClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client // nil uses llmHTTPClient
+}
+
+// NewAnthropicClient creates a client for the Anthropic Messages API.
+// An empty baseURL uses the public endpoint.
+func NewAnthropicClient(apiKey, baseURL string) *AnthropicClient {
+	if baseURL == "" {
+		baseURL = defaultAnthropicBase
+	}
+	return &AnthropicClient{apiKey: apiKey, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature *float32           `json:"temperature,omitempty"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	ID         string           `json:"id"`
+	Model      string           `json:"model"`
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// CreateChatCompletion implements LLMClient.
+func (c *AnthropicClient) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	var resp anthropicResponse
+	headers := map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+	if err := postJSON(ctx, c.httpClient, c.baseURL+"/messages", headers, toAnthropicRequest(req), &resp); err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	return fromAnthropicResponse(resp), nil
+}
+
+// toAnthropicRequest converts an OpenAI chat request. System messages become
+// the system prompt, and tool results are sent as user tool_result blocks.
+func toAnthropicRequest(req openai.ChatCompletionRequest) anthropicRequest {
+	out := anthropicRequest{
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+	}
+	if req.MaxCompletionTokens > 0 {
+		out.MaxTokens = req.MaxCompletionTokens
+	}
+	if out.MaxTokens == 0 {
+		out.MaxTokens = defaultAnthropicMaxTokens
+	}
+	if req.Temperature != 0 {
+		temperature := req.Temperature
+		out.Temperature = &temperature
+	}
+
+	var system []string
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case openai.ChatMessageRoleSystem, openai.ChatMessageRoleDeveloper:
+			system = append(system, msg.Content)
+		case openai.ChatMessageRoleAssistant:
+			var blocks []anthropicBlock
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				blocks = append(blocks, anthropicBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Function.Name,
+					Input: toolArguments(tc.Function.Arguments),
+				})
+			}
+			out.Messages = appendAnthropicMessage(out.Messages, "assistant", blocks)
+		case openai.ChatMessageRoleTool:
+			out.Messages = appendAnthropicMessage(out.Messages, "user", []anthropicBlock{{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   msg.Content,
+			}})
+		default:
+			out.Messages = appendAnthropicMessage(out.Messages, "user", []anthropicBlock{{Type: "text", Text: msg.Content}})
+		}
+	}
+	if wantsJSON(req) {
+		system = append(system, jsonModeInstruction)
+	}
+	out.System = strings.Join(system, "\n\n")
+
+	for _, t := range req.Tools {
+		if t.Function == nil {
+			continue
+		}
+		schema := t.Function.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		out.Tools = append(out.Tools, anthropicTool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	return out
+}
+
+// appendAnthropicMessage adds blocks to the conversation, merging them into
+// the previous message when it has the same role, as the API requires
+// alternating roles.
+func appendAnthropicMessage(messages []anthropicMessage, role string, blocks []anthropicBlock) []anthropicMessage {
+	if len(blocks) == 0 {
+		return messages
+	}
+	if n := len(messages); n > 0 && messages[n-1].Role == role {
+		messages[n-1].Content = append(messages[n-1].Content, blocks...)
+		return messages
+	}
+	return append(messages, anthropicMessage{Role: role, Content: blocks})
+}
+
+func fromAnthropicResponse(resp anthropicResponse) openai.ChatCompletionResponse {
+	msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	var text strings.Builder
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:   block.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      block.Name,
+					Arguments: string(toolArguments(string(block.Input))),
+				},
+			})
+		}
+	}
+	msg.Content = text.String()
+
+	finishReason := openai.FinishReasonStop
+	switch resp.StopReason {
+	case "tool_use":
+		finishReason = openai.FinishReasonToolCalls
+	case "max_tokens":
+		finishReason = openai.FinishReasonLength
+	}
+
+	return openai.ChatCompletionResponse{
+		ID:    resp.ID,
+		Model: resp.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      msg,
+			FinishReason: finishReason,
+		}},
+		Usage: openai.Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+}
@@ -0,0 +1,232 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// defaultGeminiBase is the Gemini generateContent API endpoint.
+const defaultGeminiBase = "https://generativelanguage.googleapis.com/v1beta"
+
+// GeminiClient implements LLMClient with Google's Gemini generateContent API,
+// including function calling.
+type GeminiClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client // nil uses llmHTTPClient
+}
+
+// NewGeminiClient creates a client for the Gemini API. An empty baseURL uses
+// the public endpoint.
+func NewGeminiClient(apiKey, baseURL string) *GeminiClient {
+	if baseURL == "" {
+		baseURL = defaultGeminiBase
+	}
+	return &GeminiClient{apiKey: apiKey, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	Tools             []geminiTool           `json:"tools,omitempty"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiFunctionCall struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature      *float32 `json:"temperature,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+	ResponseMimeType string   `json:"responseMimeType,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	ModelVersion string `json:"modelVersion"`
+}
+
+// CreateChatCompletion implements LLMClient.
+func (c *GeminiClient) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	endpoint := fmt.Sprintf("%s/models/%s:generateContent", c.baseURL, url.PathEscape(req.Model))
+	headers := map[string]string{"x-goog-api-key": c.apiKey}
+
+	var resp geminiResponse
+	if err := postJSON(ctx, c.httpClient, endpoint, headers, toGeminiRequest(req), &resp); err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	if len(resp.Candidates) == 0 {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("gemini returned no candidates")
+	}
+	return fromGeminiResponse(resp), nil
+}
+
+// toGeminiRequest converts an OpenAI chat request. Gemini matches function
+// responses to calls by name, so tool results are mapped back to the name of
+// the call with the same ID.
+func toGeminiRequest(req openai.ChatCompletionRequest) geminiRequest {
+	var out geminiRequest
+	if req.Temperature != 0 {
+		temperature := req.Temperature
+		out.GenerationConfig.Temperature = &temperature
+	}
+	out.GenerationConfig.Seed = req.Seed
+	out.GenerationConfig.MaxOutputTokens = req.MaxTokens
+	if req.MaxCompletionTokens > 0 {
+		out.GenerationConfig.MaxOutputTokens = req.MaxCompletionTokens
+	}
+	if wantsJSON(req) {
+		out.GenerationConfig.ResponseMimeType = "application/json"
+	}
+
+	callNames := make(map[string]string)
+	var system []geminiPart
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case openai.ChatMessageRoleSystem, openai.ChatMessageRoleDeveloper:
+			system = append(system, geminiPart{Text: msg.Content})
+		case openai.ChatMessageRoleAssistant:
+			var parts []geminiPart
+			if msg.Content != "" {
+				parts = append(parts, geminiPart{Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				callNames[tc.ID] = tc.Function.Name
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{
+					Name: tc.Function.Name,
+					Args: toolArguments(tc.Function.Arguments),
+				}})
+			}
+			out.Contents = appendGeminiContent(out.Contents, "model", parts)
+		case openai.ChatMessageRoleTool:
+			name := msg.Name
+			if name == "" {
+				name = callNames[msg.ToolCallID]
+			}
+			out.Contents = appendGeminiContent(out.Contents, "user", []geminiPart{{FunctionResponse: &geminiFunctionResponse{
+				Name:     name,
+				Response: map[string]any{"content": msg.Content},
+			}}})
+		default:
+			out.Contents = appendGeminiContent(out.Contents, "user", []geminiPart{{Text: msg.Content}})
+		}
+	}
+	if len(system) > 0 {
+		out.SystemInstruction = &geminiContent{Parts: system}
+	}
+
+	var decls []geminiFunctionDeclaration
+	for _, t := range req.Tools {
+		if t.Function == nil {
+			continue
+		}
+		decls = append(decls, geminiFunctionDeclaration{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  t.Function.Parameters,
+		})
+	}
+	if len(decls) > 0 {
+		out.Tools = []geminiTool{{FunctionDeclarations: decls}}
+	}
+	return out
+}
+
+// appendGeminiContent adds parts to the conversation, merging them into the
+// previous content when it has the same role.
+func appendGeminiContent(contents []geminiContent, role string, parts []geminiPart) []geminiContent {
+	if len(parts) == 0 {
+		return contents
+	}
+	if n := len(contents); n > 0 && contents[n-1].Role == role {
+		contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+		return contents
+	}
+	return append(contents, geminiContent{Role: role, Parts: parts})
+}
+
+func fromGeminiResponse(resp geminiResponse) openai.ChatCompletionResponse {
+	candidate := resp.Candidates[0]
+	msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		if part.FunctionCall != nil {
+			// Gemini has no call IDs; generate one so tool results can be matched
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:   fmt.Sprintf("call_%d", len(msg.ToolCalls)),
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      part.FunctionCall.Name,
+					Arguments: string(toolArguments(string(part.FunctionCall.Args))),
+				},
+			})
+			continue
+		}
+		text.WriteString(part.Text)
+	}
+	msg.Content = text.String()
+
+	finishReason := openai.FinishReasonStop
+	switch {
+	case len(msg.ToolCalls) > 0:
+		finishReason = openai.FinishReasonToolCalls
+	case candidate.FinishReason == "MAX_TOKENS":
+		finishReason = openai.FinishReasonLength
+	}
+
+	return openai.ChatCompletionResponse{
+		Model: resp.ModelVersion,
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      msg,
+			FinishReason: finishReason,
+		}},
+		Usage: openai.Usage{
+			PromptTokens:     resp.UsageMetadata.PromptTokenCount,
+			CompletionTokens: resp.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:      resp.UsageMetadata.TotalTokenCount,
+		},
+	}
+}
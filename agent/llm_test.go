@@ -0,0 +1,219 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolConversation is a request with a completed tool call, shared by the backend tests.
+func toolConversation() openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model: "test-model",
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "Be brief."},
+			{Role: openai.ChatMessageRoleUser, Content: "Weather in Paris?"},
+			{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{{
+				ID:       "call_1",
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+			}}},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: "call_1", Content: "sunny"},
+		},
+		Tools: []openai.Tool{{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "get_weather",
+				Description: "Gets the weather.",
+				Parameters:  map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+			},
+		}},
+	}
+}
+
+func TestAnthropicClient(t *testing.T) {
+	var got anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("x-api-key"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"id":"msg_1","content":[{"type":"text","text":"Checking."},{"type":"tool_use","id":"toolu_2","name":"get_weather","input":{"city":"Lyon"}}],"stop_reason":"tool_use","usage":{"input_tokens":20,"output_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(ProviderAnthropic, "secret", server.URL)
+	require.NoError(t, err)
+	resp, err := client.CreateChatCompletion(context.Background(), toolConversation())
+	require.NoError(t, err)
+
+	assert.Equal(t, "Be brief.", got.System)
+	assert.Equal(t, defaultAnthropicMaxTokens, got.MaxTokens)
+	require.Len(t, got.Messages, 3)
+	assert.Equal(t, "tool_use", got.Messages[1].Content[0].Type)
+	assert.JSONEq(t, `{"city":"Paris"}`, string(got.Messages[1].Content[0].Input))
+	assert.Equal(t, "user", got.Messages[2].Role)
+	assert.Equal(t, "call_1", got.Messages[2].Content[0].ToolUseID)
+	require.Len(t, got.Tools, 1)
+	assert.Equal(t, "get_weather", got.Tools[0].Name)
+
+	msg := resp.Choices[0].Message
+	assert.Equal(t, "Checking.", msg.Content)
+	require.Len(t, msg.ToolCalls, 1)
+	assert.Equal(t, "toolu_2", msg.ToolCalls[0].ID)
+	assert.JSONEq(t, `{"city":"Lyon"}`, msg.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, openai.FinishReasonToolCalls, resp.Choices[0].FinishReason)
+	assert.Equal(t, 25, resp.Usage.TotalTokens)
+}
+
+func TestGeminiClient(t *testing.T) {
+	var got geminiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models/test-model:generateContent", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("x-goog-api-key"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Sunny in Paris."}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":4,"totalTokenCount":16}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(ProviderGemini, "secret", server.URL)
+	require.NoError(t, err)
+	resp, err := client.CreateChatCompletion(context.Background(), toolConversation())
+	require.NoError(t, err)
+
+	require.NotNil(t, got.SystemInstruction)
+	assert.Equal(t, "Be brief.", got.SystemInstruction.Parts[0].Text)
+	require.Len(t, got.Contents, 3)
+	assert.Equal(t, "model", got.Contents[1].Role)
+	assert.Equal(t, "get_weather", got.Contents[1].Parts[0].FunctionCall.Name)
+	require.NotNil(t, got.Contents[2].Parts[0].FunctionResponse)
+	assert.Equal(t, "get_weather", got.Contents[2].Parts[0].FunctionResponse.Name)
+	assert.Equal(t, "sunny", got.Contents[2].Parts[0].FunctionResponse.Response["content"])
+	require.Len(t, got.Tools, 1)
+	assert.Equal(t, "get_weather", got.Tools[0].FunctionDeclarations[0].Name)
+
+	assert.Equal(t, "Sunny in Paris.", resp.Choices[0].Message.Content)
+	assert.Equal(t, openai.FinishReasonStop, resp.Choices[0].FinishReason)
+	assert.Equal(t, 16, resp.Usage.TotalTokens)
+}
+
+func TestWithSeed(t *testing.T) {
+	var seeds []*int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got geminiRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		seeds = append(seeds, got.GenerationConfig.Seed)
+		w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"Hi."}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	gemini, err := NewLLMClient(ProviderGemini, "secret", server.URL)
+	require.NoError(t, err)
+	client := WithSeed(gemini, 42)
+	_, isStreaming := client.(streamingClient)
+	assert.False(t, isStreaming)
+
+	_, err = client.CreateChatCompletion(context.Background(), toolConversation())
+	require.NoError(t, err)
+	req := toolConversation()
+	own := 7
+	req.Seed = &own
+	_, err = client.CreateChatCompletion(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Len(t, seeds, 2)
+	require.NotNil(t, seeds[0])
+	assert.Equal(t, 42, *seeds[0])
+	require.NotNil(t, seeds[1])
+	assert.Equal(t, 7, *seeds[1])
+
+	openaiClient, err := NewLLMClient(ProviderOpenAI, "secret", server.URL)
+	require.NoError(t, err)
+	_, isStreaming = WithSeed(openaiClient, 42).(streamingClient)
+	assert.True(t, isStreaming)
+	assert.Same(t, openaiClient, UnwrapClient(WithSeed(openaiClient, 42)))
+	assert.Nil(t, UnwrapClient(openaiClient))
+}
+
+// fingerprintClient answers with the next of its system fingerprints.
+type fingerprintClient struct {
+	fingerprints []string
+}
+
+func (c *fingerprintClient) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	fingerprint := c.fingerprints[0]
+	c.fingerprints = c.fingerprints[1:]
+	return openai.ChatCompletionResponse{SystemFingerprint: fingerprint}, nil
+}
+
+func TestClientFingerprints(t *testing.T) {
+	inner := &fingerprintClient{fingerprints: []string{"fp_a", "", "fp_a", "fp_b"}}
+	client := WithSeed(inner, 1)
+	for range 4 {
+		_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"fp_a", "fp_b"}, ClientFingerprints(client))
+	assert.Nil(t, ClientFingerprints(inner))
+
+	planner, err := NewPlanningAgent(AgentConfig{Client: &fingerprintClient{}, Seed: new(int)}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, planner.SystemFingerprints())
+}
+
+func TestNewLLMClientWithHTTPClient(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"Hi."}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	proxied := 0
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		proxied++
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	client, err := NewLLMClientWithHTTPClient(ProviderGemini, "secret", server.URL, httpClient)
+	require.NoError(t, err)
+	_, err = client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    "gemini-2.5-flash",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, 1, proxied)
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestNewLLMClient(t *testing.T) {
+	client, err := NewLLMClient("", "key", "")
+	require.NoError(t, err)
+	assert.IsType(t, &openai.Client{}, client)
+
+	_, err = NewLLMClient("mistral", "key", "")
+	assert.ErrorContains(t, err, "unknown LLM provider")
+
+	assert.Equal(t, "gpt-4o", DefaultModel(""))
+	assert.Equal(t, "gemini-2.5-flash", DefaultModel(ProviderGemini))
+}
+
+func TestLLMClientHTTPErrorIsRetryable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"overloaded"}`, http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := NewAnthropicClient("key", server.URL).CreateChatCompletion(context.Background(), toolConversation())
+	require.Error(t, err)
+	assert.True(t, isTransientError(err))
+}
@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// verboseLog writes the verbose progress output of the planning agent and
+// the subagents, to os.Stderr unless another writer is set, so that stdout
+// only carries the results.
+type verboseLog struct {
+	out io.Writer
+}
+
+// SetLogWriter directs the verbose output to w. Nil restores os.Stderr.
+func (l *verboseLog) SetLogWriter(w io.Writer) {
+	l.out = w
+}
+
+func (l *verboseLog) writer() io.Writer {
+	if l.out == nil {
+		return os.Stderr
+	}
+	return l.out
+}
+
+// logf writes formatted verbose output.
+func (l *verboseLog) logf(format string, args ...any) {
+	fmt.Fprintf(l.writer(), format, args...)
+}
+
+// logln writes a line of verbose output.
+func (l *verboseLog) logln(args ...any) {
+	fmt.Fprintln(l.writer(), args...)
+}
@@ -0,0 +1,237 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/smallnest/goskills/tool"
+)
+
+// Orchestrator runs a sequence of tasks, dispatching each one to the
+// subagent registered for its type and passing the outputs of the earlier
+// tasks on as its "context" parameter. Subagents may insert follow-up tasks
+// through Result.NewTasks. Tasks with Task.DependsOn run as a dependency
+// graph instead (see Run). PlanningAgent runs its plans through an
+// Orchestrator; use one directly to build a pipeline of your own tasks and
+// subagents, including ones for custom task types.
+type Orchestrator struct {
+	verboseLog
+	subagents          map[TaskType]Subagent
+	verbose            bool
+	interactionHandler InteractionHandler
+	// GlobalContext, when set, is passed to every task as its
+	// "global_context" parameter, e.g. earlier instructions of the user.
+	GlobalContext string
+	// MaxConcurrency limits the tasks of a dependency graph that run at
+	// once. Zero means no limit.
+	MaxConcurrency int
+}
+
+// NewOrchestrator creates an Orchestrator without subagents.
+func NewOrchestrator(verbose bool, interactionHandler InteractionHandler) *Orchestrator {
+	return &Orchestrator{
+		subagents:          make(map[TaskType]Subagent),
+		verbose:            verbose,
+		interactionHandler: interactionHandler,
+	}
+}
+
+// Register adds subagent for the tasks of its Type, replacing the subagent
+// previously registered for that type.
+func (o *Orchestrator) Register(subagent Subagent) {
+	if o.out != nil {
+		if s, ok := subagent.(interface{ SetLogWriter(io.Writer) }); ok {
+			s.SetLogWriter(o.out)
+		}
+	}
+	o.subagents[subagent.Type()] = subagent
+}
+
+// Subagent returns the subagent registered for taskType.
+func (o *Orchestrator) Subagent(taskType TaskType) (Subagent, bool) {
+	subagent, ok := o.subagents[taskType]
+	return subagent, ok
+}
+
+// Subagents returns the registered subagents by task type, e.g. for
+// ExecuteTasksParallel.
+func (o *Orchestrator) Subagents() map[TaskType]Subagent {
+	subagents := make(map[TaskType]Subagent, len(o.subagents))
+	for taskType, subagent := range o.subagents {
+		subagents[taskType] = subagent
+	}
+	return subagents
+}
+
+// SetLogWriter directs the verbose output of the orchestrator and its
+// subagents to w.
+func (o *Orchestrator) SetLogWriter(w io.Writer) {
+	o.verboseLog.SetLogWriter(w)
+	for _, subagent := range o.subagents {
+		if s, ok := subagent.(interface{ SetLogWriter(io.Writer) }); ok {
+			s.SetLogWriter(w)
+		}
+	}
+}
+
+// Run executes tasks in order and returns their results, including those of
+// the tasks the subagents inserted. It stops with an error at a task without
+// a registered subagent or whose subagent fails with an error, and with
+// ErrCanceled and the results so far when the InteractionHandler cancels.
+// A task that completes with an unsuccessful Result does not stop the run.
+//
+// When any task has DependsOn, the tasks run as a graph: a task starts once
+// the tasks it depends on have finished, independent tasks run in parallel
+// (see MaxConcurrency), and a task's context holds only the outputs of its
+// dependencies. Results are still returned in task order. Run fails before
+// running anything when a dependency is unknown or the dependencies form a
+// cycle.
+func (o *Orchestrator) Run(ctx context.Context, tasks []Task) ([]Result, error) {
+	results, _, err := o.run(ctx, tasks)
+	return results, err
+}
+
+// run is Run, also returning the tasks with the inserted ones.
+func (o *Orchestrator) run(ctx context.Context, tasks []Task) ([]Result, []Task, error) {
+	if hasDependencies(tasks) {
+		return o.runGraph(ctx, tasks)
+	}
+	tasks = append([]Task(nil), tasks...)
+	results := make([]Result, 0, len(tasks))
+
+	var contextData []string
+	var outputs []TaskOutput
+	// Sources found by searches, numbered in order for citations
+	var sources []Source
+
+	// Use a loop index that can be modified to support dynamic task insertion
+	for i := 0; i < len(tasks); i++ {
+		task := tasks[i]
+
+		if shouldCancel(o.interactionHandler) {
+			if o.verbose {
+				o.logln("⏹️ 执行已被用户取消")
+			}
+			o.interactionHandler.Log("⏹️ 执行已被用户取消")
+			return results, tasks, ErrCanceled
+		}
+
+		if o.verbose {
+			o.logf("📍 步骤 %d/%d: [%s] %s\n", i+1, len(tasks), task.Type, task.Description)
+		}
+		if o.interactionHandler != nil {
+			o.interactionHandler.Log(fmt.Sprintf("📍 步骤 %d/%d: [%s] %s", i+1, len(tasks), task.Type, task.Description))
+			o.interactionHandler.OnStepProgress(float64(i)/float64(len(tasks)), fmt.Sprintf("[%s] %s", task.Type, task.Description))
+		}
+
+		task = o.prepareTask(task, contextData, outputs, sources)
+
+		subagent, ok := o.subagents[task.Type]
+		if !ok {
+			return nil, tasks, fmt.Errorf("unknown task type: %s", task.Type)
+		}
+
+		result, err := subagent.Execute(ctx, task)
+		if err != nil {
+			return nil, tasks, fmt.Errorf("task %d failed: %w", i+1, err)
+		}
+
+		results = append(results, result)
+		if result.Success || result.Output != "" {
+			outputs = append(outputs, TaskOutput{ID: task.ID, Type: task.Type, Success: result.Success, Output: result.Output})
+		}
+
+		if result.Success {
+			// Check for dynamic tasks
+			if len(result.NewTasks) > 0 {
+				if o.verbose {
+					o.logf("  🔄 动态规划更新: 插入 %d 个新任务\n", len(result.NewTasks))
+				}
+				if o.interactionHandler != nil {
+					o.interactionHandler.Log(fmt.Sprintf("🔄 动态规划更新: 插入 %d 个新任务", len(result.NewTasks)))
+				}
+
+				// Insert new tasks at the current position + 1
+				rear := append([]Task{}, tasks[i+1:]...)
+				tasks = append(tasks[:i+1], append(result.NewTasks, rear...)...)
+			}
+
+			// Accumulate output for next tasks
+			contextData = append(contextData, taskOutputs(task, result)...)
+			if found, ok := result.Metadata["sources"].([]Source); ok {
+				sources = mergeSources(sources, found)
+			}
+
+			if o.verbose {
+				o.logf("  ✓ 完成\n\n")
+			}
+			if o.interactionHandler != nil {
+				o.interactionHandler.Log("  ✓ 完成")
+			}
+		} else {
+			// Keep partial output so downstream steps can still use it
+			contextData = append(contextData, taskOutputs(task, result)...)
+			if o.verbose {
+				o.logf("  ✗ 失败: %s\n\n", result.Error)
+			}
+			if o.interactionHandler != nil {
+				o.interactionHandler.Log(fmt.Sprintf("  ✗ 失败: %s", result.Error))
+			}
+		}
+	}
+
+	if o.interactionHandler != nil {
+		o.interactionHandler.OnStepProgress(1, "计划执行完成")
+	}
+
+	return results, tasks, nil
+}
+
+// prepareTask returns task with a copy of its parameters extended for this
+// run by the global context, the sources found so far and the outputs of
+// earlier tasks, as text in contextData and typed in outputs.
+func (o *Orchestrator) prepareTask(task Task, contextData []string, outputs []TaskOutput, sources []Source) Task {
+	params := make(map[string]interface{}, len(task.Parameters)+3)
+	for key, value := range task.Parameters {
+		params[key] = value
+	}
+	task.Parameters = params
+	if o.GlobalContext != "" {
+		task.Parameters["global_context"] = o.GlobalContext
+	}
+	if len(sources) > 0 {
+		task.Parameters["sources"] = sources
+	}
+
+	// Inject context from previous tasks
+	if len(contextData) > 0 {
+		// If context already exists in parameters, append to it
+		if existingContext, ok := task.Parameters["context"].([]string); ok {
+			task.Parameters["context"] = append(existingContext[:len(existingContext):len(existingContext)], contextData...)
+		} else {
+			task.Parameters["context"] = contextData
+		}
+	}
+	if len(outputs) > 0 {
+		task.Parameters["outputs"] = outputs
+	}
+	return task
+}
+
+// taskOutputs returns the context entries later tasks get from the result
+// of task: its output and the images it found. Failed tasks only pass on
+// their partial output.
+func taskOutputs(task Task, result Result) []string {
+	var outputs []string
+	if result.Success || result.Output != "" {
+		outputs = append(outputs, fmt.Sprintf("Output from %s task:\n%s", task.Type, result.Output))
+	}
+	if !result.Success {
+		return outputs
+	}
+	if images, ok := result.Metadata["images"].([]tool.SearchImage); ok && len(images) > 0 {
+		outputs = append(outputs, formatSearchImages(images))
+	}
+	return outputs
+}
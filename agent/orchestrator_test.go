@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// funcSubagent runs a function for the tasks of a custom type.
+type funcSubagent struct {
+	verboseLog
+	taskType TaskType
+	run      func(task Task) (Result, error)
+}
+
+func (s *funcSubagent) Type() TaskType { return s.taskType }
+func (s *funcSubagent) Execute(ctx context.Context, task Task) (Result, error) {
+	return s.run(task)
+}
+
+func TestOrchestrator(t *testing.T) {
+	o := NewOrchestrator(false, nil)
+	o.GlobalContext = "User: be brief\n"
+	o.Register(&funcSubagent{taskType: "ECHO", run: func(task Task) (Result, error) {
+		return Result{TaskType: "ECHO", Success: true, Output: task.Parameters["text"].(string)}, nil
+	}})
+	o.Register(&funcSubagent{taskType: "JOIN", run: func(task Task) (Result, error) {
+		context, _ := task.Parameters["context"].([]string)
+		result := Result{TaskType: "JOIN", Success: true, Output: fmt.Sprintf("%d outputs, %s", len(context), task.Parameters["global_context"])}
+		if task.Parameters["again"] == true {
+			result.NewTasks = []Task{{Type: "ECHO", Parameters: map[string]interface{}{"text": "inserted"}}}
+		}
+		return result, nil
+	}})
+
+	tasks := []Task{
+		{Type: "ECHO", Parameters: map[string]interface{}{"text": "a"}},
+		{Type: "JOIN", Parameters: map[string]interface{}{"again": true}},
+		{Type: "JOIN"},
+	}
+	results, err := o.Run(context.Background(), tasks)
+	require.NoError(t, err)
+	var outputs []string
+	for _, result := range results {
+		outputs = append(outputs, result.Output)
+	}
+	assert.Equal(t, []string{"a", "1 outputs, User: be brief\n", "inserted", "3 outputs, User: be brief\n"}, outputs)
+	assert.Len(t, tasks[0].Parameters, 1, "the caller's parameters are not modified")
+
+	_, err = o.Run(context.Background(), []Task{{Type: "MISSING"}})
+	assert.ErrorContains(t, err, "unknown task type: MISSING")
+
+	_, err = o.Run(context.Background(), []Task{{Type: "FAIL"}})
+	assert.Error(t, err)
+	o.Register(&funcSubagent{taskType: "FAIL", run: func(task Task) (Result, error) {
+		return Result{}, io.ErrUnexpectedEOF
+	}})
+	_, err = o.Run(context.Background(), []Task{{Type: "FAIL"}})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestOrchestratorLogWriter(t *testing.T) {
+	var log bytes.Buffer
+	o := NewOrchestrator(true, nil)
+	o.SetLogWriter(&log)
+	echo := &funcSubagent{taskType: "ECHO", run: func(task Task) (Result, error) {
+		return Result{Success: true}, nil
+	}}
+	o.Register(echo)
+	assert.Same(t, &log, echo.out)
+
+	_, err := o.Run(context.Background(), []Task{{Type: "ECHO", Description: "say hi"}})
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(log.String(), "[ECHO] say hi"))
+}
+
+func TestOrchestratorGraph(t *testing.T) {
+	o := NewOrchestrator(false, nil)
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	o.Register(&funcSubagent{taskType: "SEARCH", run: func(task Task) (Result, error) {
+		started <- task.Parameters["query"].(string)
+		<-release
+		return Result{TaskType: "SEARCH", Success: true, Output: "found " + task.Parameters["query"].(string)}, nil
+	}})
+	o.Register(&funcSubagent{taskType: "ANALYZE", run: func(task Task) (Result, error) {
+		context, _ := task.Parameters["context"].([]string)
+		return Result{TaskType: "ANALYZE", Success: true, Output: strings.Join(context, " | ")}, nil
+	}})
+
+	// Both searches must start before either finishes
+	go func() {
+		<-started
+		<-started
+		close(release)
+	}()
+	results, err := o.Run(context.Background(), []Task{
+		{ID: "a", Type: "SEARCH", Parameters: map[string]interface{}{"query": "A"}},
+		{ID: "b", Type: "SEARCH", Parameters: map[string]interface{}{"query": "B"}},
+		{ID: "both", Type: "ANALYZE", DependsOn: []string{"b", "a"}},
+		{ID: "only-a", Type: "ANALYZE", DependsOn: []string{"a"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, "found A", results[0].Output)
+	assert.Equal(t, "found B", results[1].Output)
+	assert.Equal(t, "Output from SEARCH task:\nfound B | Output from SEARCH task:\nfound A", results[2].Output)
+	assert.Equal(t, "Output from SEARCH task:\nfound A", results[3].Output)
+}
+
+func TestOrchestratorGraphInsertedTasks(t *testing.T) {
+	o := NewOrchestrator(false, nil)
+	o.Register(&funcSubagent{taskType: "ECHO", run: func(task Task) (Result, error) {
+		result := Result{Success: true, Output: task.Description}
+		if task.Description == "first" {
+			result.NewTasks = []Task{{Type: "ECHO", Description: "inserted"}}
+		}
+		return result, nil
+	}})
+	o.Register(&funcSubagent{taskType: "JOIN", run: func(task Task) (Result, error) {
+		context, _ := task.Parameters["context"].([]string)
+		return Result{Success: true, Output: strings.Join(context, " | ")}, nil
+	}})
+
+	results, tasks, err := o.run(context.Background(), []Task{
+		{ID: "first", Type: "ECHO", Description: "first"},
+		{ID: "last", Type: "JOIN", DependsOn: []string{"first"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, tasks, 3)
+	assert.Equal(t, "inserted", tasks[1].Description)
+	var outputs []string
+	for _, result := range results {
+		outputs = append(outputs, result.Output)
+	}
+	assert.Equal(t, []string{"first", "inserted", "Output from ECHO task:\nfirst"}, outputs)
+}
+
+func TestOrchestratorGraphErrors(t *testing.T) {
+	o := NewOrchestrator(false, nil)
+	ran := false
+	o.Register(&funcSubagent{taskType: "ECHO", run: func(task Task) (Result, error) {
+		ran = true
+		return Result{Success: true}, nil
+	}})
+
+	_, err := o.Run(context.Background(), []Task{
+		{ID: "a", Type: "ECHO", DependsOn: []string{"c"}},
+		{ID: "b", Type: "ECHO", DependsOn: []string{"a"}},
+		{ID: "c", Type: "ECHO", DependsOn: []string{"b"}},
+	})
+	assert.EqualError(t, err, "dependency cycle: a -> c -> b -> a")
+
+	_, err = o.Run(context.Background(), []Task{{ID: "a", Type: "ECHO", DependsOn: []string{"missing"}}})
+	assert.EqualError(t, err, "task a depends on unknown task missing")
+
+	_, err = o.Run(context.Background(), []Task{
+		{ID: "a", Type: "ECHO"},
+		{ID: "a", Type: "ECHO", DependsOn: []string{"a"}},
+	})
+	assert.EqualError(t, err, "duplicate task id: a")
+	assert.False(t, ran)
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ExecuteTasksParallel runs independent tasks concurrently with at most
+// maxConcurrency tasks in flight, and returns their results in the same order
+// as tasks. Tasks must not depend on each other's output; dependency-linked
+// tasks should go through PlanningAgent.Execute instead.
+// A task whose subagent is missing or returns an error yields a failed Result.
+func ExecuteTasksParallel(ctx context.Context, tasks []Task, subagents map[TaskType]Subagent, maxConcurrency int) []Result {
+	if maxConcurrency <= 0 {
+		maxConcurrency = 1
+	}
+
+	results := make([]Result, len(tasks))
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, task := range tasks {
+		subagent, ok := subagents[task.Type]
+		if !ok {
+			results[i] = Result{
+				TaskType: task.Type,
+				Success:  false,
+				Error:    fmt.Sprintf("unknown task type: %s", task.Type),
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, task Task, subagent Subagent) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = Result{
+					TaskType: task.Type,
+					Success:  false,
+					Error:    ctx.Err().Error(),
+				}
+				return
+			}
+
+			result, err := subagent.Execute(ctx, task)
+			if err != nil {
+				result.TaskType = task.Type
+				result.Success = false
+				if result.Error == "" {
+					result.Error = err.Error()
+				}
+			}
+			results[i] = result
+		}(i, task, subagent)
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSubagent struct {
+	taskType TaskType
+	running  int32
+	maxSeen  int32
+}
+
+func (f *fakeSubagent) Type() TaskType {
+	return f.taskType
+}
+
+func (f *fakeSubagent) Execute(ctx context.Context, task Task) (Result, error) {
+	n := atomic.AddInt32(&f.running, 1)
+	defer atomic.AddInt32(&f.running, -1)
+	for {
+		seen := atomic.LoadInt32(&f.maxSeen)
+		if n <= seen || atomic.CompareAndSwapInt32(&f.maxSeen, seen, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if task.Description == "fail" {
+		return Result{}, errors.New("boom")
+	}
+	return Result{TaskType: f.taskType, Success: true, Output: task.Description}, nil
+}
+
+func TestExecuteTasksParallel(t *testing.T) {
+	search := &fakeSubagent{taskType: TaskTypeSearch}
+	subagents := map[TaskType]Subagent{TaskTypeSearch: search}
+
+	tasks := []Task{
+		{Type: TaskTypeSearch, Description: "a"},
+		{Type: TaskTypeSearch, Description: "fail"},
+		{Type: TaskTypeSearch, Description: "c"},
+		{Type: TaskTypeReport, Description: "d"},
+		{Type: TaskTypeSearch, Description: "e"},
+	}
+
+	results := ExecuteTasksParallel(context.Background(), tasks, subagents, 2)
+	assert.Len(t, results, 5)
+	assert.Equal(t, "a", results[0].Output)
+	assert.False(t, results[1].Success)
+	assert.Equal(t, "boom", results[1].Error)
+	assert.Equal(t, "c", results[2].Output)
+	assert.False(t, results[3].Success)
+	assert.Equal(t, "e", results[4].Output)
+	assert.LessOrEqual(t, atomic.LoadInt32(&search.maxSeen), int32(2))
+}
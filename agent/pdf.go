@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pdfConverters lists the external HTML-to-PDF converters in order of preference.
+// Each entry returns the command arguments for converting htmlPath to pdfPath.
+var pdfConverters = []struct {
+	name string
+	args func(htmlPath, pdfPath string) []string
+}{
+	{"wkhtmltopdf", func(htmlPath, pdfPath string) []string {
+		return []string{"--quiet", "--enable-local-file-access", "--encoding", "utf-8", htmlPath, pdfPath}
+	}},
+	{"chromium", chromePDFArgs},
+	{"chromium-browser", chromePDFArgs},
+	{"google-chrome", chromePDFArgs},
+}
+
+func chromePDFArgs(htmlPath, pdfPath string) []string {
+	return []string{"--headless", "--disable-gpu", "--no-sandbox", "--allow-file-access-from-files", "--no-pdf-header-footer", "--print-to-pdf=" + pdfPath, "file://" + htmlPath}
+}
+
+// renderToPDF renders markdown content to HTML and converts it to a PDF file in the output directory.
+// Images referenced with ![desc](url) are embedded by the converter while loading the HTML page.
+func (r *RenderSubagent) renderToPDF(ctx context.Context, content string) (Result, error) {
+	if err := os.MkdirAll(r.outputDir, 0755); err != nil {
+		return Result{
+			TaskType: TaskTypeRender,
+			Success:  false,
+			Error:    fmt.Sprintf("创建输出目录失败: %v", err),
+		}, err
+	}
+
+	base := fmt.Sprintf("report_%d", time.Now().Unix())
+	htmlPath, err := filepath.Abs(filepath.Join(r.outputDir, base+".html"))
+	if err != nil {
+		return Result{
+			TaskType: TaskTypeRender,
+			Success:  false,
+			Error:    err.Error(),
+		}, err
+	}
+	pdfPath := strings.TrimSuffix(htmlPath, ".html") + ".pdf"
+
+	if err := os.WriteFile(htmlPath, []byte(renderMarkdownHTML(content, r.theme)), 0644); err != nil {
+		return Result{
+			TaskType: TaskTypeRender,
+			Success:  false,
+			Error:    fmt.Sprintf("写入 HTML 失败: %v", err),
+		}, err
+	}
+
+	if r.verbose {
+		r.logln("  正在转换为 PDF...")
+	}
+	if r.interactionHandler != nil {
+		r.interactionHandler.Log("正在转换为 PDF...")
+	}
+
+	if err := htmlToPDF(ctx, htmlPath, pdfPath); err != nil {
+		if r.verbose {
+			r.logf("❌ PDF 转换失败: %v\n", err)
+		}
+		if r.interactionHandler != nil {
+			r.interactionHandler.Log("❌ PDF 转换失败，已保存 HTML 版本。")
+		}
+
+		// Return success with the HTML file so the report is not lost
+		return Result{
+			TaskType: TaskTypeRender,
+			Success:  true,
+			Output:   fmt.Sprintf("PDF 转换失败，报告已保存为 HTML: %s", htmlPath),
+			Metadata: map[string]interface{}{
+				"html_path": htmlPath,
+				"error":     err.Error(),
+			},
+		}, nil
+	}
+
+	if r.interactionHandler != nil {
+		r.interactionHandler.Log(fmt.Sprintf("✓ PDF 已生成: %s", pdfPath))
+	}
+
+	return Result{
+		TaskType: TaskTypeRender,
+		Success:  true,
+		Output:   fmt.Sprintf("PDF 报告已生成: %s", pdfPath),
+		Metadata: map[string]interface{}{
+			"pdf_path":  pdfPath,
+			"html_path": htmlPath,
+		},
+	}, nil
+}
+
+// htmlToPDF converts an HTML file to PDF with the first available converter.
+func htmlToPDF(ctx context.Context, htmlPath, pdfPath string) error {
+	convertCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	var tried []string
+	var lastErr error
+	for _, converter := range pdfConverters {
+		exe, err := exec.LookPath(converter.name)
+		if err != nil {
+			continue
+		}
+		tried = append(tried, converter.name)
+
+		cmd := exec.CommandContext(convertCtx, exe, converter.args(htmlPath, pdfPath)...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			lastErr = fmt.Errorf("%s 转换失败: %v\n输出: %s", converter.name, err, string(output))
+			continue
+		}
+		if _, err := os.Stat(pdfPath); err != nil {
+			lastErr = fmt.Errorf("%s 未生成 PDF 文件: %w", converter.name, err)
+			continue
+		}
+		return nil
+	}
+
+	if len(tried) == 0 {
+		return fmt.Errorf("未找到 PDF 转换工具，请安装 wkhtmltopdf 或 Chrome/Chromium")
+	}
+	return fmt.Errorf("PDF 转换失败 (已尝试: %s): %w", strings.Join(tried, ", "), lastErr)
+}
@@ -11,14 +11,15 @@ import (
 
 // PodcastSubagent generates a podcast from a report.
 type PodcastSubagent struct {
-	client             *openai.Client
-	model              string
-	verbose            bool
+	client  LLMClient
+	model   string
+	verbose bool
+	verboseLog
 	interactionHandler InteractionHandler
 }
 
 // NewPodcastSubagent creates a new PodcastSubagent.
-func NewPodcastSubagent(client *openai.Client, model string, verbose bool, interactionHandler InteractionHandler) *PodcastSubagent {
+func NewPodcastSubagent(client LLMClient, model string, verbose bool, interactionHandler InteractionHandler) *PodcastSubagent {
 	return &PodcastSubagent{
 		client:             client,
 		model:              model,
@@ -39,12 +40,14 @@ type DialogueLine struct {
 }
 
 // Execute generates a podcast from the input content.
-func (p *PodcastSubagent) Execute(ctx context.Context, task Task) (Result, error) {
+func (p *PodcastSubagent) Execute(ctx context.Context, task Task) (result Result, err error) {
 	if p.verbose {
-		fmt.Println("🎙️ 播客 Subagent")
+		p.logln("🎙️ 播客 Subagent")
 	}
 	if p.interactionHandler != nil {
 		p.interactionHandler.Log(fmt.Sprintf("> 播客 Subagent: %s", task.Description))
+		p.interactionHandler.OnTaskStart(task)
+		defer func() { p.interactionHandler.OnTaskComplete(result) }()
 	}
 
 	// Get content from parameters or description
@@ -83,7 +86,7 @@ func (p *PodcastSubagent) Execute(ctx context.Context, task Task) (Result, error
 	}
 
 	if p.verbose {
-		fmt.Println("  正在生成对话脚本...")
+		p.logln("  正在生成对话脚本...")
 	}
 
 	// 1. Generate Dialogue Script
@@ -97,7 +100,7 @@ func (p *PodcastSubagent) Execute(ctx context.Context, task Task) (Result, error
 	}
 
 	if p.verbose {
-		fmt.Printf("  ✓ 脚本已生成 (%d 行)\n", len(script))
+		p.logf("  ✓ 脚本已生成 (%d 行)\n", len(script))
 	}
 	if p.interactionHandler != nil {
 		p.interactionHandler.Log(fmt.Sprintf("✓ 脚本已生成 (%d 行)", len(script)))
@@ -16,15 +16,16 @@ import (
 
 // PPTSubagent generates a modern HTML presentation from content.
 type PPTSubagent struct {
-	client             *openai.Client
-	model              string
-	verbose            bool
+	client  LLMClient
+	model   string
+	verbose bool
+	verboseLog
 	interactionHandler InteractionHandler
 	outputDir          string
 }
 
 // NewPPTSubagent creates a new PPTSubagent.
-func NewPPTSubagent(client *openai.Client, model string, verbose bool, interactionHandler InteractionHandler, outputDir string) *PPTSubagent {
+func NewPPTSubagent(client LLMClient, model string, verbose bool, interactionHandler InteractionHandler, outputDir string) *PPTSubagent {
 	return &PPTSubagent{
 		client:             client,
 		model:              model,
@@ -48,12 +49,14 @@ type Slide struct {
 }
 
 // Execute generates a PPT from the input content.
-func (p *PPTSubagent) Execute(ctx context.Context, task Task) (Result, error) {
+func (p *PPTSubagent) Execute(ctx context.Context, task Task) (result Result, err error) {
 	if p.verbose {
-		fmt.Println("📊 PPT  Subagent")
+		p.logln("📊 PPT  Subagent")
 	}
 	if p.interactionHandler != nil {
 		p.interactionHandler.Log(fmt.Sprintf("> PPT  Subagent: %s", task.Description))
+		p.interactionHandler.OnTaskStart(task)
+		defer func() { p.interactionHandler.OnTaskComplete(result) }()
 	}
 
 	// Ensure output directory exists
@@ -111,9 +114,9 @@ func (p *PPTSubagent) Execute(ctx context.Context, task Task) (Result, error) {
 	}
 
 	if p.verbose {
-		fmt.Println("  正在生成幻灯片结构...")
+		p.logln("  正在生成幻灯片结构...")
 		if len(images) > 0 {
-			fmt.Printf("  在内容中发现 %d 张图片\n", len(images))
+			p.logf("  在内容中发现 %d 张图片\n", len(images))
 		}
 	}
 
@@ -128,7 +131,7 @@ func (p *PPTSubagent) Execute(ctx context.Context, task Task) (Result, error) {
 	}
 
 	if p.verbose {
-		fmt.Printf("  ✓ 已生成 %d 张幻灯片\n", len(slides))
+		p.logf("  ✓ 已生成 %d 张幻灯片\n", len(slides))
 	}
 
 	// 2. Generate and Build
@@ -136,7 +139,7 @@ func (p *PPTSubagent) Execute(ctx context.Context, task Task) (Result, error) {
 	if err != nil {
 		// Log detailed error to terminal/logs
 		if p.verbose {
-			fmt.Printf("❌ PPT 构建失败: %v\n", err)
+			p.logf("❌ PPT 构建失败: %v\n", err)
 		}
 		if p.interactionHandler != nil {
 			p.interactionHandler.Log("❌ PPT 构建失败。已跳过构建步骤。")
@@ -181,7 +184,7 @@ func (p *PPTSubagent) GenerateAndBuild(ctx context.Context, slides []Slide) (str
 	}
 
 	if p.verbose {
-		fmt.Printf("  ✓ 已在 %s 生成 slides.md\n", projectDir)
+		p.logf("  ✓ 已在 %s 生成 slides.md\n", projectDir)
 	}
 
 	// Build with Slidev
@@ -208,7 +211,7 @@ func (p *PPTSubagent) GenerateAndBuild(ctx context.Context, slides []Slide) (str
 
 	// Run npm install
 	if p.verbose {
-		fmt.Println("  正在安装依赖 (npm install)...")
+		p.logln("  正在安装依赖 (npm install)...")
 	}
 	if p.interactionHandler != nil {
 		p.interactionHandler.Log("正在安装依赖...")
@@ -226,7 +229,7 @@ func (p *PPTSubagent) GenerateAndBuild(ctx context.Context, slides []Slide) (str
 
 	// Run npm run build
 	if p.verbose {
-		fmt.Println("  正在构建 Slidev 项目 (npm run build)...")
+		p.logln("  正在构建 Slidev 项目 (npm run build)...")
 	}
 	if p.interactionHandler != nil {
 		p.interactionHandler.Log("正在构建演示文稿...")
@@ -243,7 +246,7 @@ func (p *PPTSubagent) GenerateAndBuild(ctx context.Context, slides []Slide) (str
 	}
 
 	if p.verbose {
-		fmt.Println("  ✓ 构建完成")
+		p.logln("  ✓ 构建完成")
 	}
 	if p.interactionHandler != nil {
 		p.interactionHandler.Log("✓ 演示文稿构建成功")
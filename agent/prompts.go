@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Names of the overridable system prompt templates, the keys of
+// AgentConfig.PromptTemplates.
+const (
+	PromptAnalysis   = "analysis"
+	PromptReport     = "report"
+	PromptReportJSON = "report_json"
+)
+
+// PromptData is the data the system prompt templates are rendered with.
+type PromptData struct {
+	// Task is the description of the task being executed.
+	Task string
+	// GlobalContext holds the user's instructions that apply to the whole plan.
+	GlobalContext string
+	// Schema is the JSON Schema the report must follow, in JSON mode.
+	Schema string
+}
+
+// Default system prompt templates.
+const (
+	DefaultAnalysisPrompt = "你是一个分析助手，负责综合和分析信息。请提供清晰、结构化的分析。\n" +
+		"如果提供的信息不足以完成分析，你可以请求更多信息。\n" +
+		"如果需要更多信息，请仅回复 'MISSING_INFO: <具体的搜索查询>'。\n" +
+		"例如: 'MISSING_INFO: 2024年Q3特斯拉财报数据'" +
+		globalContextSection
+
+	DefaultReportPrompt = "你是一个报告写作助手，负责创建格式良好、清晰且全面的 Markdown 格式报告。使用适当的标题、列表和格式使报告易于阅读。如果提供的信息包含带有 URL 和描述的图片，请选择最相关的图片，并使用标准 Markdown 图片语法 `![描述](URL)` 将其嵌入报告中。将图片放置在相关文本部分附近。" +
+		globalContextSection
+
+	DefaultReportJSONPrompt = "你是一个报告写作助手，负责将信息整理为结构化数据。仅输出一个有效的 JSON 文档，不要使用代码块，不要添加任何解释。" +
+		"{{if .Schema}}\n输出必须符合以下 JSON Schema：\n{{.Schema}}{{end}}" +
+		globalContextSection
+)
+
+// globalContextSection appends the global context to a prompt, if any.
+const globalContextSection = "{{if .GlobalContext}}\n\n来自用户的重要上下文/指令：\n{{.GlobalContext}}{{end}}"
+
+var defaultPrompts = map[string]*template.Template{
+	PromptAnalysis:   template.Must(template.New(PromptAnalysis).Parse(DefaultAnalysisPrompt)),
+	PromptReport:     template.Must(template.New(PromptReport).Parse(DefaultReportPrompt)),
+	PromptReportJSON: template.Must(template.New(PromptReportJSON).Parse(DefaultReportJSONPrompt)),
+}
+
+// ParsePromptTemplates parses prompt template overrides keyed by prompt name.
+func ParsePromptTemplates(texts map[string]string) (map[string]*template.Template, error) {
+	prompts := make(map[string]*template.Template, len(texts))
+	for name, text := range texts {
+		if _, ok := defaultPrompts[name]; !ok {
+			return nil, fmt.Errorf("unknown prompt template %q", name)
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s prompt template: %w", name, err)
+		}
+		prompts[name] = tmpl
+	}
+	return prompts, nil
+}
+
+// WithPromptTemplate overrides the system prompt template called name.
+func WithPromptTemplate(name string, tmpl *template.Template) SubagentOption {
+	return func(o *subagentOptions) {
+		if o.prompts == nil {
+			o.prompts = make(map[string]*template.Template)
+		}
+		o.prompts[name] = tmpl
+	}
+}
+
+// renderPrompt renders the prompt called name from prompts, falling back to
+// the default template.
+func renderPrompt(prompts map[string]*template.Template, name string, data PromptData) (string, error) {
+	tmpl, ok := prompts[name]
+	if !ok {
+		tmpl = defaultPrompts[name]
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", name, err)
+	}
+	return sb.String(), nil
+}
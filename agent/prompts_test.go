@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderDefaultPrompts(t *testing.T) {
+	prompt, err := renderPrompt(nil, PromptAnalysis, PromptData{})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "MISSING_INFO")
+	assert.NotContains(t, prompt, "来自用户的重要上下文")
+
+	prompt, err = renderPrompt(nil, PromptReportJSON, PromptData{GlobalContext: "用英文", Schema: `{"type":"object"}`})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "JSON Schema：\n{\"type\":\"object\"}")
+	assert.Contains(t, prompt, "来自用户的重要上下文/指令：\n用英文")
+}
+
+func TestPromptTemplateOverride(t *testing.T) {
+	prompts, err := ParsePromptTemplates(map[string]string{
+		PromptReport: "You write reports in English. Task: {{.Task}}",
+	})
+	require.NoError(t, err)
+
+	report := NewReportSubagent(nil, "gpt-4o", false, nil, WithPromptTemplate(PromptReport, prompts[PromptReport]))
+	prompt, err := renderPrompt(report.prompts, PromptReport, PromptData{Task: "summarize"})
+	require.NoError(t, err)
+	assert.Equal(t, "You write reports in English. Task: summarize", prompt)
+
+	_, err = ParsePromptTemplates(map[string]string{"planner": "x"})
+	assert.Error(t, err)
+	_, err = ParsePromptTemplates(map[string]string{PromptAnalysis: "{{.Task"})
+	assert.Error(t, err)
+}
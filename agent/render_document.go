@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// DocumentNode is a block of a report rendered with FormatJSON or FormatXML.
+// Type is one of "document", "heading", "paragraph", "list", "item", "code",
+// "blockquote", "table", "row", "cell", "rule" or "html". Inline markup is
+// flattened into Text as in FormatPlain, so links read "text (url)".
+type DocumentNode struct {
+	Type string `json:"type"`
+	// Level is the level of a heading, 1 to 6.
+	Level int `json:"level,omitempty"`
+	// Ordered marks a numbered list, which starts at Start.
+	Ordered bool `json:"ordered,omitempty"`
+	Start   int  `json:"start,omitempty"`
+	// Header marks the header row of a table.
+	Header bool `json:"header,omitempty"`
+	// Language is the info string of a fenced code block.
+	Language string          `json:"language,omitempty"`
+	Text     string          `json:"text,omitempty"`
+	Children []*DocumentNode `json:"children,omitempty"`
+}
+
+// ParseDocument parses markdown content into a tree of DocumentNodes.
+func ParseDocument(content string) *DocumentNode {
+	p := parser.NewWithExtensions(parser.CommonExtensions)
+	doc := p.Parse([]byte(content))
+	return &DocumentNode{Type: "document", Children: documentBlocks(doc.GetChildren())}
+}
+
+func documentBlocks(nodes []ast.Node) []*DocumentNode {
+	var blocks []*DocumentNode
+	for _, node := range nodes {
+		if block := documentBlock(node); block != nil {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+func documentBlock(node ast.Node) *DocumentNode {
+	switch n := node.(type) {
+	case *ast.Heading:
+		return &DocumentNode{Type: "heading", Level: n.Level, Text: plainInline(n)}
+	case *ast.Paragraph:
+		return &DocumentNode{Type: "paragraph", Text: plainInline(n)}
+	case *ast.CodeBlock:
+		return &DocumentNode{Type: "code", Language: string(n.Info), Text: strings.TrimRight(string(n.Literal), "\n")}
+	case *ast.BlockQuote:
+		return &DocumentNode{Type: "blockquote", Children: documentBlocks(n.Children)}
+	case *ast.List:
+		list := &DocumentNode{Type: "list"}
+		if n.ListFlags&ast.ListTypeOrdered != 0 {
+			list.Ordered = true
+			list.Start = n.Start
+			if list.Start == 0 {
+				list.Start = 1
+			}
+		}
+		for _, child := range n.Children {
+			if item, ok := child.(*ast.ListItem); ok {
+				list.Children = append(list.Children, &DocumentNode{Type: "item", Children: documentBlocks(item.Children)})
+			}
+		}
+		return list
+	case *ast.HorizontalRule:
+		return &DocumentNode{Type: "rule"}
+	case *ast.Table:
+		table := &DocumentNode{Type: "table"}
+		ast.WalkFunc(n, func(node ast.Node, entering bool) ast.WalkStatus {
+			row, ok := node.(*ast.TableRow)
+			if !ok || !entering {
+				return ast.GoToNext
+			}
+			_, header := row.Parent.(*ast.TableHeader)
+			r := &DocumentNode{Type: "row", Header: header}
+			for _, cell := range row.Children {
+				r.Children = append(r.Children, &DocumentNode{Type: "cell", Text: plainInline(cell)})
+			}
+			table.Children = append(table.Children, r)
+			return ast.SkipChildren
+		})
+		return table
+	case *ast.HTMLBlock:
+		return &DocumentNode{Type: "html", Text: strings.TrimSpace(string(n.Literal))}
+	default:
+		if text := plainInline(node); text != "" {
+			return &DocumentNode{Type: "paragraph", Text: text}
+		}
+		return nil
+	}
+}
+
+// MarshalXML writes the node as an element named after its Type, with the
+// other fields as attributes and the text as character data.
+func (n *DocumentNode) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Local: n.Type}}
+	if n.Level > 0 {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "level"}, Value: strconv.Itoa(n.Level)})
+	}
+	if n.Ordered {
+		start.Attr = append(start.Attr,
+			xml.Attr{Name: xml.Name{Local: "ordered"}, Value: "true"},
+			xml.Attr{Name: xml.Name{Local: "start"}, Value: strconv.Itoa(n.Start)})
+	}
+	if n.Header {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "header"}, Value: "true"})
+	}
+	if n.Language != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "language"}, Value: n.Language})
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if n.Text != "" {
+		if err := e.EncodeToken(xml.CharData(n.Text)); err != nil {
+			return err
+		}
+	}
+	for _, child := range n.Children {
+		if err := e.Encode(child); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// renderDocumentJSON renders markdown content as an indented JSON document tree.
+func renderDocumentJSON(content string) (string, error) {
+	data, err := json.MarshalIndent(ParseDocument(content), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// renderDocumentXML renders markdown content as an indented XML document tree.
+func renderDocumentXML(content string) (string, error) {
+	data, err := xml.MarshalIndent(ParseDocument(content), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data) + "\n", nil
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultMaxImageSize is the largest image downloaded when no limit is configured.
+const DefaultMaxImageSize int64 = 10 << 20
+
+// imagePattern matches markdown images: ![desc](url "optional title").
+var imagePattern = regexp.MustCompile(`!\[([^\]]*)\]\((\S+?)(\s+"[^"]*")?\)`)
+
+// imageHTTPClient is used to download report images.
+var imageHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// localizeImages downloads the remote images referenced in content into
+// r.assetDir and rewrites their URLs to the local files. Images that fail to
+// download keep their original URL.
+func (r *RenderSubagent) localizeImages(ctx context.Context, content string) string {
+	if err := os.MkdirAll(r.assetDir, 0755); err != nil {
+		r.warn(fmt.Sprintf("⚠️ 创建图片目录失败: %v", err))
+		return content
+	}
+
+	localPaths := make(map[string]string)
+	return imagePattern.ReplaceAllStringFunc(content, func(match string) string {
+		sub := imagePattern.FindStringSubmatch(match)
+		desc, imageURL, title := sub[1], sub[2], sub[3]
+		if !strings.HasPrefix(imageURL, "http://") && !strings.HasPrefix(imageURL, "https://") {
+			return match
+		}
+
+		localPath, ok := localPaths[imageURL]
+		if !ok {
+			var err error
+			localPath, err = downloadImage(ctx, imageURL, r.assetDir, r.maxImageSize)
+			if err != nil {
+				r.warn(fmt.Sprintf("⚠️ 下载图片失败，保留原始链接 %s: %v", imageURL, err))
+				return match
+			}
+			localPaths[imageURL] = localPath
+		}
+		return fmt.Sprintf("![%s](%s%s)", desc, localPath, title)
+	})
+}
+
+// warn reports a non-fatal rendering problem.
+func (r *RenderSubagent) warn(msg string) {
+	if r.verbose {
+		r.logln("  " + msg)
+	}
+	if r.interactionHandler != nil {
+		r.interactionHandler.Log(msg)
+	}
+}
+
+// downloadImage saves the image at imageURL into dir and returns the absolute
+// path of the file. Images larger than maxSize bytes are rejected.
+func downloadImage(ctx context.Context, imageURL, dir string, maxSize int64) (string, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxImageSize
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := imageHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	if resp.ContentLength > maxSize {
+		return "", fmt.Errorf("image is %d bytes, exceeds limit of %d bytes", resp.ContentLength, maxSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > maxSize {
+		return "", fmt.Errorf("image exceeds limit of %d bytes", maxSize)
+	}
+
+	sum := sha1.Sum([]byte(imageURL))
+	name := hex.EncodeToString(sum[:8]) + imageExtension(imageURL, resp.Header.Get("Content-Type"))
+	filePath, err := filepath.Abs(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// imageExtension picks a file extension from the URL path, falling back to the content type.
+func imageExtension(imageURL, contentType string) string {
+	if u, err := url.Parse(imageURL); err == nil {
+		if ext := strings.ToLower(path.Ext(u.Path)); ext != "" && len(ext) <= 5 {
+			return ext
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return ".img"
+}
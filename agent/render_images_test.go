@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalizeImages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/small.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png-data"))
+		case "/large.png":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	r := NewRenderSubagent(false, true, nil, WithImageAssets(dir, 50))
+
+	content := "![small](" + server.URL + "/small.png) ![large](" + server.URL + "/large.png) ![missing](" + server.URL + "/missing.png)"
+	out := r.localizeImages(context.Background(), content)
+
+	assert.NotContains(t, out, server.URL+"/small.png")
+	assert.Contains(t, out, "![large]("+server.URL+"/large.png)")
+	assert.Contains(t, out, "![missing]("+server.URL+"/missing.png)")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, strings.HasSuffix(entries[0].Name(), ".png"))
+	assert.Contains(t, out, entries[0].Name())
+}
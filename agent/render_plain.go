@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// renderPlainText strips markdown to readable plain text for destinations that
+// cannot display ANSI escape codes, such as log files, email bodies and CI output.
+// Headings become UPPERCASE lines, lists keep "- " bullets, links render as
+// "text (url)" and code fences are removed.
+func renderPlainText(content string) string {
+	p := parser.NewWithExtensions(parser.CommonExtensions)
+	doc := p.Parse([]byte(content))
+
+	var sb strings.Builder
+	writePlainBlocks(&sb, doc.GetChildren(), "")
+	return strings.TrimSpace(sb.String()) + "\n"
+}
+
+// writePlainBlocks writes block-level nodes separated by blank lines, prefixing
+// every line with indent.
+func writePlainBlocks(sb *strings.Builder, nodes []ast.Node, indent string) {
+	for i, node := range nodes {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		writePlainBlock(sb, node, indent)
+	}
+}
+
+func writePlainBlock(sb *strings.Builder, node ast.Node, indent string) {
+	switch n := node.(type) {
+	case *ast.Heading:
+		writeIndented(sb, strings.ToUpper(plainInline(n)), indent)
+	case *ast.Paragraph:
+		writeIndented(sb, plainInline(n), indent)
+	case *ast.CodeBlock:
+		writeIndented(sb, strings.TrimRight(string(n.Literal), "\n"), indent)
+	case *ast.BlockQuote:
+		writePlainBlocks(sb, n.Children, indent+"> ")
+	case *ast.List:
+		writePlainList(sb, n, indent)
+	case *ast.HorizontalRule:
+		writeIndented(sb, "----", indent)
+	case *ast.Table:
+		writePlainTable(sb, n, indent)
+	case *ast.HTMLBlock:
+		writeIndented(sb, strings.TrimSpace(string(n.Literal)), indent)
+	default:
+		if text := plainInline(node); text != "" {
+			writeIndented(sb, text, indent)
+		}
+	}
+}
+
+func writePlainList(sb *strings.Builder, list *ast.List, indent string) {
+	number := list.Start
+	if number == 0 {
+		number = 1
+	}
+	for _, child := range list.Children {
+		item, ok := child.(*ast.ListItem)
+		if !ok {
+			continue
+		}
+
+		bullet := "- "
+		if list.ListFlags&ast.ListTypeOrdered != 0 {
+			bullet = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		var body strings.Builder
+		for i, block := range item.Children {
+			if i > 0 {
+				if _, nested := block.(*ast.List); !nested {
+					body.WriteString("\n")
+				}
+			}
+			if nested, ok := block.(*ast.List); ok {
+				writePlainList(&body, nested, "")
+				continue
+			}
+			writePlainBlock(&body, block, "")
+		}
+
+		lines := strings.Split(strings.TrimRight(body.String(), "\n"), "\n")
+		for i, line := range lines {
+			if i == 0 {
+				sb.WriteString(indent + bullet + line + "\n")
+			} else {
+				sb.WriteString(indent + strings.Repeat(" ", len(bullet)) + line + "\n")
+			}
+		}
+	}
+}
+
+func writePlainTable(sb *strings.Builder, table *ast.Table, indent string) {
+	ast.WalkFunc(table, func(node ast.Node, entering bool) ast.WalkStatus {
+		row, ok := node.(*ast.TableRow)
+		if !ok || !entering {
+			return ast.GoToNext
+		}
+		var cells []string
+		for _, cell := range row.Children {
+			cells = append(cells, plainInline(cell))
+		}
+		writeIndented(sb, strings.Join(cells, " | "), indent)
+		return ast.SkipChildren
+	})
+}
+
+// plainInline flattens the inline content of node to plain text.
+func plainInline(node ast.Node) string {
+	var sb strings.Builder
+	for _, child := range node.GetChildren() {
+		switch n := child.(type) {
+		case *ast.Text:
+			sb.Write(n.Literal)
+		case *ast.Code:
+			sb.Write(n.Literal)
+		case *ast.Softbreak:
+			sb.WriteString("\n")
+		case *ast.Hardbreak:
+			sb.WriteString("\n")
+		case *ast.Link:
+			text := plainInline(n)
+			dest := string(n.Destination)
+			if text == "" || text == dest {
+				sb.WriteString(dest)
+			} else {
+				sb.WriteString(fmt.Sprintf("%s (%s)", text, dest))
+			}
+		case *ast.Image:
+			text := plainInline(n)
+			if text == "" {
+				text = "image"
+			}
+			sb.WriteString(fmt.Sprintf("[%s] (%s)", text, n.Destination))
+		case *ast.HTMLSpan:
+			// Drop inline HTML tags
+		default:
+			if leaf := child.AsLeaf(); leaf != nil {
+				sb.Write(leaf.Literal)
+			} else {
+				sb.WriteString(plainInline(child))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// writeIndented writes text with indent in front of every line.
+func writeIndented(sb *strings.Builder, text, indent string) {
+	for _, line := range strings.Split(text, "\n") {
+		sb.WriteString(strings.TrimRight(indent+line, " ") + "\n")
+	}
+}
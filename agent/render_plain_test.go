@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPlainText(t *testing.T) {
+	content := "# Title\n\nSee [docs](https://example.com) and **bold** text.\n\n- one\n- two\n  - nested\n\n1. first\n2. second\n\n```go\nfmt.Println(\"hi\")\n```\n"
+
+	expected := "TITLE\n\nSee docs (https://example.com) and bold text.\n\n- one\n- two\n  - nested\n\n1. first\n2. second\n\nfmt.Println(\"hi\")\n"
+	assert.Equal(t, expected, renderPlainText(content))
+}
+
+func TestRenderDocument(t *testing.T) {
+	content := "# Title\n\nSee [docs](https://example.com).\n\n1. three\n2. four\n\n```go\nfmt.Println(\"hi\")\n```\n"
+
+	doc := ParseDocument(content)
+	assert.Equal(t, &DocumentNode{Type: "document", Children: []*DocumentNode{
+		{Type: "heading", Level: 1, Text: "Title"},
+		{Type: "paragraph", Text: "See docs (https://example.com)."},
+		{Type: "list", Ordered: true, Start: 1, Children: []*DocumentNode{
+			{Type: "item", Children: []*DocumentNode{{Type: "paragraph", Text: "three"}}},
+			{Type: "item", Children: []*DocumentNode{{Type: "paragraph", Text: "four"}}},
+		}},
+		{Type: "code", Language: "go", Text: "fmt.Println(\"hi\")"},
+	}}, doc)
+
+	r := NewRenderSubagentWithFormat(false, FormatJSON, nil)
+	result, err := r.Execute(context.Background(), Task{Type: TaskTypeRender, Parameters: map[string]interface{}{"content": content}})
+	require.NoError(t, err)
+	var decoded DocumentNode
+	require.NoError(t, json.Unmarshal([]byte(result.Output), &decoded))
+	assert.Equal(t, doc, &decoded)
+
+	result, err = r.Execute(context.Background(), Task{Type: TaskTypeRender, Parameters: map[string]interface{}{"content": "# A & B\n\n- x\n", "format": "XML"}})
+	require.NoError(t, err)
+	assert.Equal(t, xml.Header+"<document>\n  <heading level=\"1\">A &amp; B</heading>\n  <list>\n    <item>\n      <paragraph>x</paragraph>\n    </item>\n  </list>\n</document>\n", result.Output)
+
+	// Unknown formats asked for by a task are ignored
+	result, err = r.Execute(context.Background(), Task{Type: TaskTypeRender, Parameters: map[string]interface{}{"content": "text", "format": "markdown"}})
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, `"type": "document"`)
+}
@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	markdown "github.com/MichaelMure/go-term-markdown"
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/formatters"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/charmbracelet/x/term"
+	"github.com/mattn/go-isatty"
+)
+
+// fencePattern matches top-level fenced code blocks and captures the language tag and code.
+var fencePattern = regexp.MustCompile("(?ms)^```([\\w+#.-]*)[^\\n]*\\n(.*?)^```[ \\t]*$")
+
+// codePlaceholderPrefix marks where a code block was taken out of the markdown.
+// It only contains letters so the markdown renderer leaves it untouched.
+const codePlaceholderPrefix = "GOSKILLSCODEBLOCK"
+
+type fencedCode struct {
+	lang string
+	code string
+}
+
+// stdoutIsTerminal reports whether standard output is attached to a terminal.
+func stdoutIsTerminal() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// defaultRenderWidth is used when the terminal width cannot be detected.
+const defaultRenderWidth = 80
+
+// terminalWidth returns the width of the controlling terminal, checking
+// standard output first and then /dev/tty, or defaultRenderWidth if neither
+// is a terminal.
+func terminalWidth() int {
+	if width, _, err := term.GetSize(os.Stdout.Fd()); err == nil && width > 0 {
+		return width
+	}
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		defer tty.Close()
+		if width, _, err := term.GetSize(tty.Fd()); err == nil && width > 0 {
+			return width
+		}
+	}
+	return defaultRenderWidth
+}
+
+// renderTerminalMarkdown renders markdown for the terminal, wrapping lines
+// as policy says. Fenced code blocks are rendered separately so they can be
+// syntax highlighted with ANSI colors.
+func renderTerminalMarkdown(content string, width, leftPad int, highlight bool, policy WrapPolicy) string {
+	var blocks []fencedCode
+	content = fencePattern.ReplaceAllStringFunc(content, func(match string) string {
+		sub := fencePattern.FindStringSubmatch(match)
+		blocks = append(blocks, fencedCode{lang: sub[1], code: sub[2]})
+		return fmt.Sprintf("\n%s%d\n", codePlaceholderPrefix, len(blocks)-1)
+	})
+
+	// Leave room for the indentation of lists and quotes
+	var spans unbreakableSpans
+	protected := spans.protect(content, policy, max(width-leftPad-8, 10))
+	output, ok := spans.restore(string(markdown.Render(protected, width, leftPad)))
+	if !ok {
+		output = string(markdown.Render(content, width, leftPad))
+	}
+	if !policy.NoHangingPunctuation {
+		output = hangPunctuation(output)
+	}
+	if len(blocks) == 0 {
+		return output
+	}
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		idx := strings.Index(line, codePlaceholderPrefix)
+		if idx == -1 {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(line[idx+len(codePlaceholderPrefix):]))
+		if err != nil || n >= len(blocks) {
+			continue
+		}
+		lines[i] = formatCodeBlock(blocks[n], line[:idx], highlight)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// formatCodeBlock renders a code block with a gutter, highlighting it when requested.
+func formatCodeBlock(block fencedCode, pad string, highlight bool) string {
+	code := strings.TrimRight(block.code, "\n")
+	if highlight {
+		if highlighted, err := highlightCode(block.lang, code); err == nil {
+			code = strings.TrimRight(highlighted, "\n")
+		}
+	}
+
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		lines[i] = pad + "┃ " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// highlightCode highlights code with ANSI escape codes, keyed off the fence language.
+func highlightCode(lang, code string) (string, error) {
+	var lexer chroma.Lexer
+	if lang != "" {
+		lexer = lexers.Get(lang)
+	}
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, code)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := formatters.TTY256.Format(&buf, styles.Monokai, iterator); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
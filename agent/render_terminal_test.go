@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTerminalMarkdown(t *testing.T) {
+	content := "# Title\n\nSome text.\n\n```go\nfunc main() {}\n```\n\nMore text.\n"
+
+	plain := renderTerminalMarkdown(content, 80, 6, false, WrapPolicy{})
+	assert.Contains(t, plain, "┃ func main() {}")
+	assert.NotContains(t, plain, codePlaceholderPrefix)
+	assert.Contains(t, plain, "More text.")
+
+	highlighted := renderTerminalMarkdown(content, 80, 6, true, WrapPolicy{})
+	assert.NotContains(t, highlighted, codePlaceholderPrefix)
+	assert.True(t, strings.Contains(highlighted, "\x1b["), "expected ANSI escape codes")
+}
+
+func TestRenderSubagentWidthAndTheme(t *testing.T) {
+	r := NewRenderSubagent(false, false, nil)
+	assert.Equal(t, 0, r.width)
+	assert.Equal(t, 6, r.leftPad)
+	assert.Equal(t, ThemeLight, r.theme)
+
+	r = NewRenderSubagent(false, true, nil, WithWidth(120, 2), WithTheme(ThemeDark))
+	assert.Equal(t, 120, r.width)
+	assert.Equal(t, 2, r.leftPad)
+
+	html := renderMarkdownHTML("# Title", ThemeDark)
+	assert.Contains(t, html, "#0d1117")
+	assert.Contains(t, renderMarkdownHTML("# Title", "unknown"), "#ffffff")
+}
+
+func TestRenderTerminalWrapPolicy(t *testing.T) {
+	url := "https://example.com/docs/path/to/page"
+	content := "参考资料在这里可以找到 " + url + " 运行 `go test ./... -run X` 即可。\n"
+
+	output := renderTerminalMarkdown(content, 30, 2, false, WrapPolicy{})
+	assert.Contains(t, output, url)
+	assert.Contains(t, output, "go test ./... -run X")
+	assert.NotContains(t, output, unbreakablePrefix)
+
+	output = renderTerminalMarkdown(content, 30, 2, false, WrapPolicy{BreakURLs: true, BreakCodeSpans: true})
+	assert.NotContains(t, output, url)
+}
+
+func TestHangPunctuation(t *testing.T) {
+	assert.Equal(t, "  第一行文字。”\n  第二行", hangPunctuation("  第一行文字\n  。”第二行"))
+	assert.Equal(t, "  文字，\n\n  ，", hangPunctuation("  文字\n  ，\n\n  ，"))
+	assert.Equal(t, "  文字，\x1b[1m\x1b[0m", hangPunctuation("  文字\n  \x1b[1m，\x1b[0m"))
+}
@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// WrapPolicy controls how terminal output wraps long lines. Widths are
+// measured in terminal columns, so full-width CJK characters count twice;
+// set RUNEWIDTH_EASTASIAN=1 to also count ambiguous characters such as “ and
+// … as two columns, as most CJK terminals do. The zero value keeps URLs and
+// inline code on one line and hangs CJK closing punctuation.
+type WrapPolicy struct {
+	// BreakURLs lets URLs be split across lines. By default a URL stays
+	// whole and overflows the line when it is wider than the text.
+	BreakURLs bool
+	// BreakCodeSpans lets inline code be split across lines.
+	BreakCodeSpans bool
+	// NoHangingPunctuation lets a line start with closing punctuation such
+	// as "，" or "。". By default it is moved to the end of the line before.
+	NoHangingPunctuation bool
+}
+
+// WithWrapPolicy sets how terminal output wraps long lines.
+func WithWrapPolicy(policy WrapPolicy) RenderOption {
+	return func(r *RenderSubagent) {
+		r.wrapPolicy = policy
+	}
+}
+
+var (
+	// codeSpanPattern matches single-backtick inline code.
+	codeSpanPattern = regexp.MustCompile("`[^`\n]+`")
+	// urlPattern matches URLs in text and link destinations.
+	urlPattern = regexp.MustCompile(`https?://[^\s<>()\[\]` + "`" + `]+`)
+)
+
+// unbreakablePrefix starts the placeholders of unbreakable spans. The
+// placeholders only contain capital letters so the markdown renderer keeps
+// them as one word.
+const unbreakablePrefix = "GSKW"
+
+// unbreakableSpans replaces the URLs and inline code of content that must
+// not be split with placeholders of the same width, which the renderer
+// wraps as single words. Spans wider than maxWidth get a placeholder of
+// maxWidth, so they start a line of their own and overflow it once restored.
+type unbreakableSpans struct {
+	originals map[string]string
+}
+
+// protect returns content with the spans the policy keeps whole replaced.
+func (u *unbreakableSpans) protect(content string, policy WrapPolicy, maxWidth int) string {
+	u.originals = make(map[string]string)
+	placeholder := func(original string) string {
+		index := len(u.originals)
+		var sb strings.Builder
+		sb.WriteString(unbreakablePrefix)
+		// The index is written in the letters A to Y; Z pads
+		for {
+			sb.WriteByte(byte('A' + index%25))
+			index /= 25
+			if index == 0 {
+				break
+			}
+		}
+		for width := min(runewidth.StringWidth(original), maxWidth); sb.Len() < width; {
+			sb.WriteByte('Z')
+		}
+		u.originals[sb.String()] = original
+		return sb.String()
+	}
+
+	if !policy.BreakCodeSpans {
+		content = codeSpanPattern.ReplaceAllStringFunc(content, func(span string) string {
+			if !strings.ContainsAny(span, " \t") && isASCII(span) {
+				// Nothing in it to break at
+				return span
+			}
+			return "`" + placeholder(span[1:len(span)-1]) + "`"
+		})
+	}
+	if !policy.BreakURLs {
+		// Inline code is either kept or replaced by now; skip it
+		var sb strings.Builder
+		last := 0
+		for _, loc := range codeSpanPattern.FindAllStringIndex(content, -1) {
+			sb.WriteString(urlPattern.ReplaceAllStringFunc(content[last:loc[0]], placeholder))
+			sb.WriteString(content[loc[0]:loc[1]])
+			last = loc[1]
+		}
+		sb.WriteString(urlPattern.ReplaceAllStringFunc(content[last:], placeholder))
+		content = sb.String()
+	}
+	return content
+}
+
+// restore puts the original spans back into the rendered output. ok is false
+// when a placeholder was split or lost in rendering.
+func (u *unbreakableSpans) restore(output string) (string, bool) {
+	if len(u.originals) == 0 {
+		return output, true
+	}
+	// Longer placeholders first, as a shorter one may be a prefix
+	placeholders := make([]string, 0, len(u.originals))
+	for p := range u.originals {
+		placeholders = append(placeholders, p)
+	}
+	sort.Slice(placeholders, func(i, j int) bool { return len(placeholders[i]) > len(placeholders[j]) })
+	pairs := make([]string, 0, 2*len(placeholders))
+	for _, p := range placeholders {
+		pairs = append(pairs, p, u.originals[p])
+	}
+	output = strings.NewReplacer(pairs...).Replace(output)
+	return output, !strings.Contains(output, unbreakablePrefix)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// closingPunctuation must not start a line in CJK text.
+const closingPunctuation = "，。、；：！？）》」』】〉”’"
+
+// hangPunctuation moves closing punctuation at the start of a line to the
+// end of the line before, which may then overflow by a column or two.
+func hangPunctuation(output string) string {
+	lines := strings.Split(output, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if len(kept) == 0 || !hasVisibleText(kept[len(kept)-1]) {
+			kept = append(kept, line)
+			continue
+		}
+		moved := ""
+		for {
+			start := firstVisible(line)
+			if start == -1 {
+				break
+			}
+			r, size := utf8.DecodeRuneInString(line[start:])
+			if !strings.ContainsRune(closingPunctuation, r) {
+				break
+			}
+			moved += string(r)
+			line = line[:start] + line[start+size:]
+		}
+		if moved != "" {
+			kept[len(kept)-1] = strings.TrimRight(kept[len(kept)-1], " ") + moved
+			if !hasVisibleText(line) {
+				// Keep the escape sequences left on the line
+				kept[len(kept)-1] += strings.TrimSpace(line)
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// firstVisible returns the byte offset of the first character of line that
+// is neither a space nor part of an ANSI escape sequence, or -1.
+func firstVisible(line string) int {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+		case '\x1b':
+			// Skip to the final byte of the CSI sequence
+			for i++; i < len(line) && (line[i] < '@' || line[i] > '~' || line[i] == '['); i++ {
+			}
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+func hasVisibleText(line string) bool {
+	return firstVisible(line) != -1
+}
@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/tool"
+)
+
+// reportSchema parses and resolves the schema of a JSON report, given as a
+// JSON string or a decoded JSON object. It returns nils if no schema is given.
+func reportSchema(v interface{}) (json.RawMessage, *jsonschema.Resolved, error) {
+	if text, ok := v.(string); v == nil || ok && strings.TrimSpace(text) == "" {
+		return nil, nil, nil
+	}
+	schema, err := tool.SchemaArgument(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return schema, resolved, nil
+}
+
+// jsonResponseFormat returns the response_format for JSON mode, using a JSON
+// schema when one is provided.
+func jsonResponseFormat(schema json.RawMessage) *openai.ChatCompletionResponseFormat {
+	if schema == nil {
+		return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+	return &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   "report",
+			Schema: schema,
+		},
+	}
+}
+
+// extractJSON makes a best-effort attempt to pull a JSON document out of a
+// model response, removing code fences and surrounding prose.
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if json.Valid([]byte(content)) {
+		return content
+	}
+
+	// Remove markdown code blocks if present
+	if idx := strings.Index(content, "```json"); idx != -1 {
+		content = content[idx+7:]
+	} else if idx := strings.Index(content, "```"); idx != -1 {
+		content = content[idx+3:]
+	}
+	if idx := strings.LastIndex(content, "```"); idx != -1 {
+		content = content[:idx]
+	}
+	content = strings.TrimSpace(content)
+	if json.Valid([]byte(content)) {
+		return content
+	}
+
+	// Fall back to the outermost object or array
+	start := strings.IndexAny(content, "{[")
+	if start == -1 {
+		return content
+	}
+	closing := "}"
+	if content[start] == '[' {
+		closing = "]"
+	}
+	if end := strings.LastIndex(content, closing); end > start {
+		return content[start : end+1]
+	}
+	return content
+}
+
+// validateJSON checks that data is valid JSON and, when a schema is given,
+// that it matches the schema.
+func validateJSON(data string, schema *jsonschema.Resolved) error {
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if schema == nil {
+		return nil
+	}
+	return schema.Validate(value)
+}
+
+// finalizeJSON extracts and validates the JSON report, asking the model once
+// to repair it if it does not validate.
+func (r *ReportSubagent) finalizeJSON(ctx context.Context, req openai.ChatCompletionRequest, content string, schema *jsonschema.Resolved) (string, error) {
+	candidate := extractJSON(content)
+	verr := validateJSON(candidate, schema)
+	if verr == nil {
+		return candidate, nil
+	}
+
+	if r.interactionHandler != nil {
+		r.interactionHandler.Log(fmt.Sprintf("  ⚠️ JSON 输出无效 (%v)，正在修复", verr))
+	}
+
+	req.Messages = append(req.Messages,
+		openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: content,
+		},
+		openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("上面的输出不是有效的 JSON 或不符合要求的结构 (%v)。请仅输出修正后的 JSON。", verr),
+		},
+	)
+	resp, err := createChatCompletionWithRetry(ctx, r.client, req, r.retryPolicy)
+	if err != nil {
+		return "", err
+	}
+
+	candidate = extractJSON(resp.Choices[0].Message.Content)
+	if err := validateJSON(candidate, schema); err != nil {
+		return "", fmt.Errorf("model did not return valid JSON: %w", err)
+	}
+	return candidate, nil
+}
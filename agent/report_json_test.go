@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractJSON(t *testing.T) {
+	assert.Equal(t, `{"a":1}`, extractJSON(`{"a":1}`))
+	assert.Equal(t, `{"a":1}`, extractJSON("Here you go:\n```json\n{\"a\":1}\n```"))
+	assert.Equal(t, `{"a":{"b":2}}`, extractJSON(`The result is {"a":{"b":2}} as requested.`))
+	assert.Equal(t, `[1,2]`, extractJSON(`Result: [1,2]`))
+}
+
+func TestValidateJSON(t *testing.T) {
+	_, schema, err := reportSchema(`{"type":"object","required":["title","items"],"properties":{` +
+		`"title":{"type":"string"},` +
+		`"items":{"type":"array","items":{"type":"object","properties":{"status":{"enum":["done","open"]}}}}}}`)
+	require.NoError(t, err)
+
+	assert.NoError(t, validateJSON(`{"title":"t","items":[]}`, schema))
+	assert.NoError(t, validateJSON(`{"title":"t","items":[{"status":"done"}]}`, schema))
+	assert.Error(t, validateJSON(`{"title":"t"}`, schema))
+	assert.Error(t, validateJSON(`[1]`, schema))
+	// Nested types, property types and enums are checked too
+	assert.Error(t, validateJSON(`{"title":1,"items":[]}`, schema))
+	assert.Error(t, validateJSON(`{"title":"t","items":[1]}`, schema))
+	assert.Error(t, validateJSON(`{"title":"t","items":[{"status":"late"}]}`, schema))
+	assert.Error(t, validateJSON(`{"title":`, nil))
+	assert.NoError(t, validateJSON(`[1]`, nil))
+}
+
+func TestReportSchema(t *testing.T) {
+	schema, resolved, err := reportSchema(map[string]interface{}{"type": "object"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object"}`, string(schema))
+	assert.NotNil(t, resolved)
+
+	schema, resolved, err = reportSchema("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, schema)
+	assert.Nil(t, resolved)
+
+	_, _, err = reportSchema("{not json")
+	assert.Error(t, err)
+	_, _, err = reportSchema(`{"type":"object","required":"title"}`)
+	assert.Error(t, err)
+}
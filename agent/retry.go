@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// RetryPolicy controls how LLM calls are retried on transient errors.
+type RetryPolicy struct {
+	MaxRetries     int           // Number of retries after the first attempt
+	InitialBackoff time.Duration // Delay before the first retry
+	MaxBackoff     time.Duration // Upper bound for the exponential backoff
+}
+
+// DefaultRetryPolicy is used when no retry policy is configured.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     2,
+	InitialBackoff: time.Second,
+	MaxBackoff:     10 * time.Second,
+}
+
+// isTransientError reports whether err is worth retrying: rate limits,
+// server errors, timeouts and dropped connections.
+func isTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode == http.StatusTooManyRequests || apiErr.HTTPStatusCode >= 500
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode == http.StatusTooManyRequests || reqErr.HTTPStatusCode >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// createChatCompletionWithRetry calls CreateChatCompletion, retrying transient
+// errors with exponential backoff according to policy.
+func createChatCompletionWithRetry(ctx context.Context, client LLMClient, req openai.ChatCompletionRequest, policy RetryPolicy) (openai.ChatCompletionResponse, error) {
+	var resp openai.ChatCompletionResponse
+	err := withRetry(ctx, policy, func() error {
+		var err error
+		resp, err = client.CreateChatCompletion(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// withRetry calls fn, retrying transient errors with exponential backoff
+// according to policy.
+func withRetry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	backoff := policy.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= policy.MaxRetries || !isTransientError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateChatCompletionWithRetry(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"message":"overloaded"}}`))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = server.URL
+	client := openai.NewClientWithConfig(cfg)
+
+	policy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond}
+	resp, err := createChatCompletionWithRetry(context.Background(), client, openai.ChatCompletionRequest{Model: "test"}, policy)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Choices[0].Message.Content)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCreateChatCompletionWithRetry_NonTransient(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"bad request"}}`))
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = server.URL
+	client := openai.NewClientWithConfig(cfg)
+
+	_, err := createChatCompletionWithRetry(context.Background(), client, openai.ChatCompletionRequest{Model: "test"}, RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestStreamReportRetry(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"message":"overloaded"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"# Re\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"port\"}}]}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = server.URL
+	handler := &recordingHandler{}
+	report := NewReportSubagent(openai.NewClientWithConfig(cfg), "gpt-4o", false, handler,
+		WithRetryPolicy(RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond}))
+
+	out, err := report.streamReport(context.Background(), openai.ChatCompletionRequest{Model: "test"})
+	require.NoError(t, err)
+	assert.Equal(t, "# Report", out)
+	assert.Equal(t, []string{"# Re", "port"}, handler.chunks)
+	assert.Equal(t, 2, calls)
+}
+
+func TestAnalysisSubagentFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"bad request"}}`))
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = server.URL
+	analysis := NewAnalysisSubagent(openai.NewClientWithConfig(cfg), "gpt-4o", false, nil,
+		WithRetryPolicy(RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond}))
+	task := Task{
+		Type:        TaskTypeAnalyze,
+		Description: "compare",
+		Parameters:  map[string]interface{}{"context": []string{"Output from SEARCH task:\nfindings"}},
+	}
+
+	// The input is not echoed back as the output of a failed analysis
+	result, err := analysis.Execute(context.Background(), task)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Empty(t, result.Output)
+	assert.Contains(t, result.Error, "LLM request failed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = analysis.Execute(ctx, task)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, result.Success)
+}
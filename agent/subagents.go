@@ -2,48 +2,421 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/smallnest/goskills/tool"
 
-	markdown "github.com/MichaelMure/go-term-markdown"
 	gomarkdown "github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
+	"github.com/google/jsonschema-go/jsonschema"
 	openai "github.com/sashabaranov/go-openai"
 )
 
 // SearchSubagent performs web searches.
 type SearchSubagent struct {
-	client             *openai.Client
-	model              string
-	verbose            bool
+	client      LLMClient
+	model       string
+	temperature float32
+	verbose     bool
+	wikipedia   WikipediaPolicy
+	// searchFunc, when set, replaces searchProviders and skips images
+	searchFunc func(ctx context.Context, query string) (string, error)
+	verboseLog
 	interactionHandler InteractionHandler
 }
 
-// NewSearchSubagent creates a new SearchSubagent.
-func NewSearchSubagent(client *openai.Client, model string, verbose bool, interactionHandler InteractionHandler) *SearchSubagent {
+// NewSearchSubagent creates a new SearchSubagent. The options override the
+// model and temperature of its reflection step and, with WithWikipedia, when
+// it adds a Wikipedia lookup to the web results.
+func NewSearchSubagent(client LLMClient, model string, verbose bool, interactionHandler InteractionHandler, opts ...SubagentOption) *SearchSubagent {
+	o := applySubagentOptions(model, 0.1, opts)
 	return &SearchSubagent{
 		client:             client,
-		model:              model,
+		model:              o.model,
+		temperature:        o.temperature,
 		verbose:            verbose,
+		wikipedia:          o.wikipedia,
 		interactionHandler: interactionHandler,
 	}
 }
 
+const (
+	// DefaultWikipediaMinSources is the number of web sources below which
+	// the SearchSubagent adds a Wikipedia lookup.
+	DefaultWikipediaMinSources = 3
+	// DefaultWikipediaMinLength is the length of the web results, in bytes,
+	// below which the SearchSubagent adds a Wikipedia lookup.
+	DefaultWikipediaMinLength = 2000
+)
+
+// WikipediaPolicy controls when the SearchSubagent adds a Wikipedia lookup to
+// its web results. The zero value looks Wikipedia up only when the results
+// are sparse: they have fewer than DefaultWikipediaMinSources sources or are
+// shorter than DefaultWikipediaMinLength bytes.
+type WikipediaPolicy struct {
+	// Disabled never looks Wikipedia up.
+	Disabled bool
+	// Always looks Wikipedia up, however rich the web results are.
+	Always bool
+	// MinSources overrides DefaultWikipediaMinSources.
+	MinSources int
+	// MinLength overrides DefaultWikipediaMinLength.
+	MinLength int
+}
+
+// WithWikipedia sets when the SearchSubagent adds a Wikipedia lookup to its
+// web results.
+func WithWikipedia(policy WikipediaPolicy) SubagentOption {
+	return func(o *subagentOptions) {
+		o.wikipedia = policy
+	}
+}
+
+// wanted reports whether web results with the given sources need a
+// Wikipedia lookup.
+func (p WikipediaPolicy) wanted(results string, sources int) bool {
+	switch {
+	case p.Disabled:
+		return false
+	case p.Always:
+		return true
+	}
+	minSources, minLength := p.MinSources, p.MinLength
+	if minSources <= 0 {
+		minSources = DefaultWikipediaMinSources
+	}
+	if minLength <= 0 {
+		minLength = DefaultWikipediaMinLength
+	}
+	return sources < minSources || len(results) < minLength
+}
+
+// wikipediaSearch looks up the Wikipedia summary of a query.
+var wikipediaSearch = observed("wikipedia_search", tool.WikipediaSearch)
+
 // Type returns the task type this subagent handles.
 func (s *SearchSubagent) Type() TaskType {
 	return TaskTypeSearch
 }
 
+// searchProvider is a web search backend of the SearchSubagent.
+type searchProvider struct {
+	name   string
+	search func(ctx context.Context, query string) (string, error)
+	// searchWith, when set, searches with the result limit and domain
+	// filter of a request. Without it the results of search are cut to the
+	// limit and filtered afterwards.
+	searchWith func(ctx context.Context, query string, req searchRequest) (string, error)
+}
+
+// searchRequest holds the optional settings of a search.
+type searchRequest struct {
+	// limit is the number of results, or 0 for the provider's default.
+	limit   int
+	domains tool.DomainFilter
+}
+
+// searchProviders are the web search backends in order of preference.
+var searchProviders = []searchProvider{
+	{
+		name:   "Tavily",
+		search: observed("tavily_search", tool.TavilySearch),
+		searchWith: observedWith("tavily_search", func(ctx context.Context, query string, req searchRequest) (string, error) {
+			limit := req.limit
+			if limit == 0 {
+				limit = 20 // as TavilySearch
+			}
+			return tool.TavilySearchFiltered(ctx, query, limit, req.domains)
+		}),
+	},
+	{name: "Serper", search: observed("serper_search", tool.SerperSearch)},
+	{
+		name:   "DuckDuckGo",
+		search: observed("duckduckgo_search", tool.DuckDuckGoSearch),
+		searchWith: observedWith("duckduckgo_search", func(ctx context.Context, query string, req searchRequest) (string, error) {
+			return tool.DuckDuckGoSearchFiltered(ctx, query, req.domains)
+		}),
+	},
+}
+
+// imageProvider is an image search backend of the SearchSubagent.
+type imageProvider struct {
+	name   string
+	search func(ctx context.Context, query string) ([]tool.SearchImage, error)
+}
+
+// imageProviders are the image search backends in order of preference.
+var imageProviders = []imageProvider{
+	{name: "Tavily", search: observed("tavily_image_search", tool.TavilyImageSearch)},
+	{name: "Serper", search: observed("serper_image_search", tool.SerperImageSearch)},
+}
+
+// observed wraps the tool fn so its calls are reported to tool.Metrics
+// under name.
+func observed[T any](name string, fn func(ctx context.Context, query string) (T, error)) func(ctx context.Context, query string) (T, error) {
+	return func(ctx context.Context, query string) (T, error) {
+		start := time.Now()
+		result, err := fn(ctx, query)
+		tool.RecordToolCall(ctx, name, start, err)
+		return result, err
+	}
+}
+
+// observedWith is observed for search functions that take a searchRequest.
+func observedWith(name string, fn func(ctx context.Context, query string, req searchRequest) (string, error)) func(ctx context.Context, query string, req searchRequest) (string, error) {
+	return func(ctx context.Context, query string, req searchRequest) (string, error) {
+		start := time.Now()
+		result, err := fn(ctx, query, req)
+		tool.RecordToolCall(ctx, name, start, err)
+		return result, err
+	}
+}
+
+// searchLimit returns the "limit" parameter of task, the number of results
+// to search for, or 0 when it is not set.
+func searchLimit(task Task) int {
+	switch limit := task.Parameters["limit"].(type) {
+	case int:
+		return max(limit, 0)
+	case float64:
+		// Numbers decoded from JSON plans
+		return max(int(limit), 0)
+	}
+	return 0
+}
+
+// stringList returns a task parameter holding a list of strings: a JSON
+// array or a comma-separated string.
+func stringList(v interface{}) []string {
+	var list []string
+	switch v := v.(type) {
+	case []string:
+		list = v
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+	case string:
+		list = strings.Split(v, ",")
+	}
+	var trimmed []string
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s != "" {
+			trimmed = append(trimmed, s)
+		}
+	}
+	return trimmed
+}
+
+// searchDomains returns the domain filter of the "includeDomains" and
+// "excludeDomains" parameters of task.
+func searchDomains(task Task) tool.DomainFilter {
+	return tool.DomainFilter{
+		Include: stringList(task.Parameters["includeDomains"]),
+		Exclude: stringList(task.Parameters["excludeDomains"]),
+	}
+}
+
+// describeDomains describes the source scope of a domain filter for the
+// results passed on to later tasks.
+func describeDomains(filter tool.DomainFilter) string {
+	var parts []string
+	if len(filter.Include) > 0 {
+		parts = append(parts, "仅限域名 "+strings.Join(filter.Include, ", "))
+	}
+	if len(filter.Exclude) > 0 {
+		parts = append(parts, "排除域名 "+strings.Join(filter.Exclude, ", "))
+	}
+	return "搜索范围: " + strings.Join(parts, "; ")
+}
+
+// limitResults keeps the first limit results of search results in the
+// "Title: ...\nURL: ..." format, and the blocks that are not results.
+func limitResults(results string, limit int) string {
+	if limit <= 0 {
+		return results
+	}
+	blocks := strings.Split(results, "\n\n")
+	kept := blocks[:0]
+	found := 0
+	for _, block := range blocks {
+		if strings.HasPrefix(block, "Title: ") && strings.Contains(block, "\nURL: ") {
+			if found++; found > limit {
+				continue
+			}
+		}
+		kept = append(kept, block)
+	}
+	return strings.Join(kept, "\n\n")
+}
+
+// maxSearchImages limits the images a search passes on to later tasks.
+const maxSearchImages = 5
+
+// searchImages returns up to maxSearchImages images for query from the first
+// provider that finds any. Images are optional, so failures are only logged.
+func (s *SearchSubagent) searchImages(ctx context.Context, query string) []tool.SearchImage {
+	if s.searchFunc != nil {
+		return nil
+	}
+	for _, provider := range imageProviders {
+		images, err := provider.search(ctx, query)
+		if err != nil {
+			if s.verbose {
+				s.logf("  ⚠️ %s 图片搜索失败: %v\n", provider.name, err)
+			}
+			continue
+		}
+
+		var found []tool.SearchImage
+		for _, image := range images {
+			if image.URL != "" {
+				found = append(found, image)
+			}
+		}
+		if len(found) > 0 {
+			return found[:min(len(found), maxSearchImages)]
+		}
+	}
+	return nil
+}
+
+// formatSearchImages describes images for the context of later tasks, in the
+// Markdown image syntax the report and slides pick up.
+func formatSearchImages(images []tool.SearchImage) string {
+	var sb strings.Builder
+	sb.WriteString("Images from search task (URL and description):\n")
+	for _, image := range images {
+		description := strings.NewReplacer("[", "", "]", "").Replace(image.Description)
+		if description == "" {
+			description = "image"
+		}
+		sb.WriteString(fmt.Sprintf("- ![%s](%s)\n", description, image.URL))
+	}
+	return sb.String()
+}
+
+// search returns the results of the first provider that finds any, up to
+// the limit and from the domains of req. Any failure, such as a missing API
+// key or an exhausted quota, and empty results fall through to the next
+// provider. When providers succeed without results, it returns an empty
+// string and no error.
+func (s *SearchSubagent) search(ctx context.Context, query string, req searchRequest) (string, error) {
+	if s.searchFunc != nil {
+		result, err := s.searchFunc(ctx, query)
+		return limitResults(tool.FilterResults(result, req.domains), req.limit), err
+	}
+	var errs []error
+	succeeded := false
+	for i, provider := range searchProviders {
+		var result string
+		var err error
+		if provider.searchWith != nil && (req.limit > 0 || !req.domains.IsZero()) {
+			result, err = provider.searchWith(ctx, query, req)
+		} else {
+			result, err = provider.search(ctx, query)
+		}
+		var msg string
+		if err == nil {
+			result = limitResults(tool.FilterResults(result, req.domains), req.limit)
+			if !noResults(result) {
+				return result, nil
+			}
+			succeeded = true
+			if i == len(searchProviders)-1 {
+				break
+			}
+			msg = fmt.Sprintf("  ⚠️ %s 未找到结果。回退到 %s。", provider.name, searchProviders[i+1].name)
+		} else {
+			errs = append(errs, fmt.Errorf("%s: %w", provider.name, err))
+			if i == len(searchProviders)-1 {
+				break
+			}
+
+			reason := "搜索失败"
+			if errors.Is(err, tool.ErrSearchQuotaExceeded) {
+				reason = "配额已用尽"
+			}
+			msg = fmt.Sprintf("  ⚠️ %s %s: %v。回退到 %s。", provider.name, reason, err, searchProviders[i+1].name)
+		}
+		if s.verbose {
+			s.logln(msg)
+		}
+		if s.interactionHandler != nil {
+			s.interactionHandler.Log(msg)
+		}
+	}
+	if succeeded {
+		return "", nil
+	}
+	return "", errors.Join(errs...)
+}
+
+// noResults reports whether search results hold nothing but the "no
+// results" notes of the providers or an image list.
+func noResults(results string) bool {
+	text := strings.TrimSpace(results)
+	if i := strings.Index(text, "Relevant Images:"); i >= 0 {
+		text = strings.TrimSpace(text[:i])
+	}
+	switch text {
+	case "", "No results found.", "No relevant information found.":
+		return true
+	}
+	return false
+}
+
+// rephraseQuery asks the model for another wording of a query that found
+// nothing.
+func (s *SearchSubagent) rephraseQuery(ctx context.Context, query string) (string, error) {
+	if s.client == nil {
+		return "", errors.New("no LLM client")
+	}
+	resp, err := s.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: s.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "你是一个搜索优化助手。你改写没有找到结果的搜索查询。",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("搜索查询 %q 没有找到任何结果。请给出一个更通用、更可能找到结果的搜索查询。只回复新的查询，不要添加任何其他文本。", query),
+			},
+		},
+		Temperature: s.temperature,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("empty response")
+	}
+	rephrased := strings.Trim(strings.TrimSpace(resp.Choices[0].Message.Content), "\"'")
+	if rephrased == "" || rephrased == query {
+		return "", errors.New("no new query")
+	}
+	return rephrased, nil
+}
+
 // Execute performs a web search based on the task.
-func (s *SearchSubagent) Execute(ctx context.Context, task Task) (Result, error) {
+func (s *SearchSubagent) Execute(ctx context.Context, task Task) (result Result, err error) {
 	if s.verbose {
-		fmt.Println("🌐 网络搜索 Subagent")
+		s.logln("🌐 网络搜索 Subagent")
 	}
 	if s.interactionHandler != nil {
 		s.interactionHandler.Log(fmt.Sprintf("> 网络搜索 Subagent: %s", task.Description))
+		s.interactionHandler.OnTaskStart(task)
+		defer func() { s.interactionHandler.OnTaskComplete(result) }()
 	}
 
 	// Extract query from parameters
@@ -53,37 +426,104 @@ func (s *SearchSubagent) Execute(ctx context.Context, task Task) (Result, error)
 	}
 
 	if s.verbose {
-		fmt.Printf("  查询: %q\n", query)
+		s.logf("  查询: %q\n", query)
 	}
 	if s.interactionHandler != nil {
 		s.interactionHandler.Log(fmt.Sprintf("  查询: %q", query))
 	}
 
-	// Perform Tavily search
-	searchResult, err := tool.TavilySearch(query)
-	if err != nil {
-		// Fallback to DuckDuckGo if Tavily fails (e.g. missing key)
+	// Answer mode: simple factual queries use Tavily's synthesized answer
+	if mode, _ := task.Parameters["mode"].(string); strings.EqualFold(mode, "answer") {
+		answer, err := observed("tavily_answer", tool.TavilyAnswer)(ctx, query)
+		if err == nil {
+			if s.verbose {
+				s.logln("  ✓ 已获得直接答案")
+			}
+			if s.interactionHandler != nil {
+				s.interactionHandler.Log("✓ 已获得直接答案")
+			}
+			return Result{
+				TaskType: TaskTypeSearch,
+				Success:  true,
+				Output:   answer,
+				Metadata: map[string]interface{}{
+					"answer": true,
+				},
+			}, nil
+		}
 		if s.verbose {
-			fmt.Printf("  ⚠️ Tavily 搜索失败: %v。回退到 DuckDuckGo。\n", err)
+			s.logf("  ⚠️ 未获得直接答案: %v。回退到标准搜索。\n", err)
 		}
 		if s.interactionHandler != nil {
-			s.interactionHandler.Log(fmt.Sprintf("  ⚠️ Tavily 搜索失败: %v。回退到 DuckDuckGo。", err))
+			s.interactionHandler.Log(fmt.Sprintf("  ⚠️ 未获得直接答案: %v。回退到标准搜索。", err))
 		}
-		searchResult, err = tool.DuckDuckGoSearch(query)
-		if err != nil {
-			return Result{
-				TaskType: TaskTypeSearch,
-				Success:  false,
-				Error:    err.Error(),
-			}, err
+	}
+
+	req := searchRequest{limit: searchLimit(task), domains: searchDomains(task)}
+	searchResult, err := s.search(ctx, query, req)
+	if err != nil {
+		return Result{
+			TaskType: TaskTypeSearch,
+			Success:  false,
+			Error:    err.Error(),
+		}, err
+	}
+
+	// Retry a search without results once with a rephrased query
+	queries := []string{query}
+	if noResults(searchResult) {
+		if rephrased, err := s.rephraseQuery(ctx, query); err == nil {
+			if s.verbose {
+				s.logf("  🔄 未找到结果。改写查询: %q\n", rephrased)
+			}
+			if s.interactionHandler != nil {
+				s.interactionHandler.Log(fmt.Sprintf("  🔄 未找到结果。改写查询: %q", rephrased))
+			}
+			queries = append(queries, rephrased)
+			if searchResult, err = s.search(ctx, rephrased, req); err != nil {
+				searchResult = ""
+			}
 		}
 	}
+	// Without web results, Wikipedia is the only source left
+	var wikiResult string
+	if noResults(searchResult) && s.wikipedia.wanted("", 0) && req.domains.Allows("https://wikipedia.org/") {
+		if result, err := wikipediaSearch(ctx, query); err == nil && strings.TrimSpace(result) != "" {
+			wikiResult = result
+		}
+	}
+	if noResults(searchResult) && wikiResult == "" {
+		msg := fmt.Sprintf("未找到任何搜索结果 (已尝试查询: %s)。没有可用的资料，请勿编造信息。", strings.Join(queries, "; "))
+		if s.verbose {
+			s.logf("  ✗ %s\n", msg)
+		}
+		if s.interactionHandler != nil {
+			s.interactionHandler.Log("✗ " + msg)
+		}
+		return Result{
+			TaskType: TaskTypeSearch,
+			Success:  false,
+			Output:   msg,
+			Error:    fmt.Sprintf("no search results for %q", queries),
+			Metadata: map[string]interface{}{
+				"query":   query,
+				"queries": queries,
+			},
+		}, nil
+	}
 
 	// Reflection Loop
 	maxIterations := 3
 	accumulatedResults := searchResult
+	if wikiResult != "" {
+		accumulatedResults = "维基百科结果:\n" + wikiResult
+	}
 
 	for i := 0; i < maxIterations; i++ {
+		if shouldCancel(s.interactionHandler) {
+			break // Keep the results gathered so far
+		}
+
 		// Prepare prompt for reflection
 		reflectionPrompt := fmt.Sprintf(`用户查询: %s
 当前搜索结果:
@@ -110,12 +550,12 @@ func (s *SearchSubagent) Execute(ctx context.Context, task Task) (Result, error)
 					Content: reflectionPrompt,
 				},
 			},
-			Temperature: 0.1, // Low temp for decision making
+			Temperature: s.temperature, // Low temp for decision making
 		})
 
 		if err != nil {
 			if s.verbose {
-				fmt.Printf("  ⚠️ 反思失败: %v\n", err)
+				s.logf("  ⚠️ 反思失败: %v\n", err)
 			}
 			if s.interactionHandler != nil {
 				s.interactionHandler.Log(fmt.Sprintf("  ⚠️ 反思失败: %v", err))
@@ -128,7 +568,7 @@ func (s *SearchSubagent) Execute(ctx context.Context, task Task) (Result, error)
 		// Check if sufficient (case-insensitive check for robustness)
 		if strings.Contains(strings.ToUpper(decision), "SUFFICIENT") {
 			if s.verbose {
-				fmt.Println("  ✓ LLM 认为信息已充足。")
+				s.logln("  ✓ LLM 认为信息已充足。")
 			}
 			if s.interactionHandler != nil {
 				s.interactionHandler.Log("  ✓ LLM 认为信息已充足。")
@@ -142,7 +582,7 @@ func (s *SearchSubagent) Execute(ctx context.Context, task Task) (Result, error)
 		newQuery = strings.Trim(newQuery, "\"'")
 
 		if s.verbose {
-			fmt.Printf("  🔄 LLM 请求更多信息。新查询: %q\n", newQuery)
+			s.logf("  🔄 LLM 请求更多信息。新查询: %q\n", newQuery)
 		}
 		if s.interactionHandler != nil {
 			s.interactionHandler.Log(fmt.Sprintf("  🔄 LLM 请求更多信息。新查询: %q", newQuery))
@@ -152,46 +592,30 @@ func (s *SearchSubagent) Execute(ctx context.Context, task Task) (Result, error)
 		}
 
 		// Execute new search
-		newResults, err := tool.TavilySearch(newQuery)
-		if err != nil {
-			// Try DDG fallback
-			newResults, err = tool.DuckDuckGoSearch(newQuery)
-		}
+		newResults, err := s.search(ctx, newQuery, req)
 
-		if err == nil {
+		if err == nil && !noResults(newResults) {
 			accumulatedResults += "\n\n--- Additional Search Results ---\n" + newResults
 		}
 	}
 
-	// Also try Wikipedia if results are sparse (optional, keeping existing logic)
-	wikiResult, wikiErr := tool.WikipediaSearch(query)
-	if wikiErr == nil && wikiResult != "" {
-		accumulatedResults = fmt.Sprintf("网络搜索结果:\n%s\n\n维基百科结果:\n%s", accumulatedResults, wikiResult)
+	// Add Wikipedia when the web results are sparse and in scope
+	sources := parseSources(accumulatedResults)
+	if wikiResult == "" && s.wikipedia.wanted(accumulatedResults, len(sources)) && req.domains.Allows("https://wikipedia.org/") {
+		wikiResult, wikiErr := wikipediaSearch(ctx, query)
+		if wikiErr == nil && wikiResult != "" {
+			accumulatedResults = fmt.Sprintf("网络搜索结果:\n%s\n\n维基百科结果:\n%s", accumulatedResults, wikiResult)
+			sources = parseSources(accumulatedResults)
+		}
+	} else if s.verbose && wikiResult == "" {
+		s.logln("  ⏭️ 网络搜索结果已充足，跳过维基百科")
 	}
 
-	// Parse and log simplified results
+	// Log simplified results
 	var resultLog strings.Builder
 	resultLog.WriteString("已检索信息:\n")
-
-	// Simple parsing of the text format returned by TavilySearch
-	// Format: Title: ...\nURL: ...\nContent: ...\n\n
-	entries := strings.Split(accumulatedResults, "\n\n")
-	for _, entry := range entries {
-		if strings.TrimSpace(entry) == "" {
-			continue
-		}
-		lines := strings.Split(entry, "\n")
-		var title, url string
-		for _, line := range lines {
-			if strings.HasPrefix(line, "Title: ") {
-				title = strings.TrimPrefix(line, "Title: ")
-			} else if strings.HasPrefix(line, "URL: ") {
-				url = strings.TrimPrefix(line, "URL: ")
-			}
-		}
-		if title != "" && url != "" {
-			resultLog.WriteString(fmt.Sprintf("- [%s](%s)\n", title, url))
-		}
+	for _, source := range sources {
+		resultLog.WriteString(fmt.Sprintf("- [%s](%s)\n", source.Title, source.URL))
 	}
 
 	logContent := resultLog.String()
@@ -200,35 +624,64 @@ func (s *SearchSubagent) Execute(ctx context.Context, task Task) (Result, error)
 	}
 
 	if s.verbose {
-		fmt.Printf("\n  ✓ %s\n", logContent)
+		s.logf("\n  ✓ %s\n", logContent)
 	}
 	if s.interactionHandler != nil {
 		s.interactionHandler.Log(fmt.Sprintf("✓ %s", logContent))
 	}
 
+	metadata := map[string]interface{}{
+		"query": query,
+	}
+	if len(sources) > 0 {
+		metadata["sources"] = sources
+	}
+	// The source scope, for the report to note
+	if !req.domains.IsZero() {
+		metadata["include_domains"] = req.domains.Include
+		metadata["exclude_domains"] = req.domains.Exclude
+		accumulatedResults = describeDomains(req.domains) + "\n\n" + accumulatedResults
+	}
+	// Images for the report, only when the task sets "images" to true
+	if include, _ := task.Parameters["images"].(bool); include {
+		if images := s.searchImages(ctx, query); len(images) > 0 {
+			metadata["images"] = images
+		}
+	}
+
 	return Result{
 		TaskType: TaskTypeSearch,
 		Success:  true,
 		Output:   accumulatedResults,
-		Metadata: map[string]interface{}{
-			"query": query,
-		},
+		Metadata: metadata,
 	}, nil
 }
 
 // AnalysisSubagent analyzes and synthesizes information.
 type AnalysisSubagent struct {
-	client             *openai.Client
-	model              string
-	verbose            bool
+	client      LLMClient
+	model       string
+	temperature float32
+	retryPolicy RetryPolicy
+	prompts     map[string]*template.Template
+	// contextBudget limits the earlier outputs in the prompt
+	contextBudget int
+	verbose       bool
+	verboseLog
 	interactionHandler InteractionHandler
 }
 
 // NewAnalysisSubagent creates a new AnalysisSubagent.
-func NewAnalysisSubagent(client *openai.Client, model string, verbose bool, interactionHandler InteractionHandler) *AnalysisSubagent {
+// The model and temperature (0.3 by default) can be overridden with options.
+func NewAnalysisSubagent(client LLMClient, model string, verbose bool, interactionHandler InteractionHandler, opts ...SubagentOption) *AnalysisSubagent {
+	o := applySubagentOptions(model, 0.3, opts)
 	return &AnalysisSubagent{
 		client:             client,
-		model:              model,
+		model:              o.model,
+		temperature:        o.temperature,
+		retryPolicy:        o.retryPolicy,
+		prompts:            o.prompts,
+		contextBudget:      o.contextBudget,
 		verbose:            verbose,
 		interactionHandler: interactionHandler,
 	}
@@ -240,12 +693,14 @@ func (a *AnalysisSubagent) Type() TaskType {
 }
 
 // Execute analyzes information using the LLM.
-func (a *AnalysisSubagent) Execute(ctx context.Context, task Task) (Result, error) {
+func (a *AnalysisSubagent) Execute(ctx context.Context, task Task) (result Result, err error) {
 	if a.verbose {
-		fmt.Println("🔬 分析 Subagent")
+		a.logln("🔬 分析 Subagent")
 	}
 	if a.interactionHandler != nil {
 		a.interactionHandler.Log(fmt.Sprintf("> 分析 Subagent: %s", task.Description))
+		a.interactionHandler.OnTaskStart(task)
+		defer func() { a.interactionHandler.OnTaskComplete(result) }()
 	}
 
 	// Get context from parameters if available
@@ -253,20 +708,31 @@ func (a *AnalysisSubagent) Execute(ctx context.Context, task Task) (Result, erro
 
 	var prompt string
 	if hasContext && len(contextData) > 0 {
-		prompt = fmt.Sprintf("分析以下信息并 %s:\n\n%s", task.Description, strings.Join(contextData, "\n\n"))
+		fitted := trimContext(contextData, a.contextBudget, a.verbose, &a.verboseLog, a.interactionHandler)
+		prompt = fmt.Sprintf("分析以下信息并 %s:\n\n%s", task.Description, strings.Join(fitted, "\n\n"))
 	} else {
 		prompt = task.Description
 	}
 
 	// Check for global context
 	globalContext, _ := task.Parameters["global_context"].(string)
-	systemPrompt := "你是一个分析助手，负责综合和分析信息。请提供清晰、结构化的分析。\n" +
-		"如果提供的信息不足以完成分析，你可以请求更多信息。\n" +
-		"如果需要更多信息，请仅回复 'MISSING_INFO: <具体的搜索查询>'。\n" +
-		"例如: 'MISSING_INFO: 2024年Q3特斯拉财报数据'"
+	systemPrompt, err := renderPrompt(a.prompts, PromptAnalysis, PromptData{
+		Task:          task.Description,
+		GlobalContext: globalContext,
+	})
+	if err != nil {
+		return Result{
+			TaskType: TaskTypeAnalyze,
+			Success:  false,
+			Error:    err.Error(),
+		}, err
+	}
 
-	if globalContext != "" {
-		systemPrompt += "\n\n来自用户的重要上下文/指令：\n" + globalContext
+	// Sources found so far, numbered for citations
+	sources, _ := task.Parameters["sources"].([]Source)
+	if len(sources) > 0 {
+		systemPrompt += citationInstruction
+		prompt += "\n\n" + formatSources(sources)
 	}
 
 	messages := []openai.ChatCompletionMessage{
@@ -283,16 +749,28 @@ func (a *AnalysisSubagent) Execute(ctx context.Context, task Task) (Result, erro
 	req := openai.ChatCompletionRequest{
 		Model:       a.model,
 		Messages:    messages,
-		Temperature: 0.3,
+		Temperature: a.temperature,
 	}
 
-	resp, err := a.client.CreateChatCompletion(ctx, req)
+	resp, err := createChatCompletionWithRetry(ctx, a.client, req, a.retryPolicy)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return Result{
+				TaskType: TaskTypeAnalyze,
+				Success:  false,
+				Error:    ctxErr.Error(),
+			}, ctxErr
+		}
+		// Degrade gracefully: the orchestrator keeps the context gathered so
+		// far, so later steps can still show something
+		if a.interactionHandler != nil {
+			a.interactionHandler.Log(fmt.Sprintf("  ⚠️ LLM 调用失败 (已重试 %d 次): %v", a.retryPolicy.MaxRetries, err))
+		}
 		return Result{
 			TaskType: TaskTypeAnalyze,
 			Success:  false,
-			Error:    err.Error(),
-		}, err
+			Error:    fmt.Sprintf("LLM request failed after %d retries: %v", a.retryPolicy.MaxRetries, err),
+		}, nil
 	}
 
 	analysis := resp.Choices[0].Message.Content
@@ -302,7 +780,7 @@ func (a *AnalysisSubagent) Execute(ctx context.Context, task Task) (Result, erro
 		newQuery := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(analysis), "MISSING_INFO:"))
 
 		if a.verbose {
-			fmt.Printf("  🔄 分析发现信息缺失，请求新搜索: %q\n", newQuery)
+			a.logf("  🔄 分析发现信息缺失，请求新搜索: %q\n", newQuery)
 		}
 		if a.interactionHandler != nil {
 			a.interactionHandler.Log(fmt.Sprintf("🔄 分析发现信息缺失，请求新搜索: %q", newQuery))
@@ -330,32 +808,52 @@ func (a *AnalysisSubagent) Execute(ctx context.Context, task Task) (Result, erro
 	}
 
 	if a.verbose {
-		fmt.Printf("  ✓ 信息这已足够，分析完成 (%d 字节)\n", len(analysis))
+		a.logf("  ✓ 信息这已足够，分析完成 (%d 字节)\n", len(analysis))
 	}
 	if a.interactionHandler != nil {
 		a.interactionHandler.Log(fmt.Sprintf("✓ 信息这已足够，分析完成 (%d 字节)", len(analysis)))
 	}
 
-	return Result{
+	result = Result{
 		TaskType: TaskTypeAnalyze,
 		Success:  true,
 		Output:   analysis,
-	}, nil
+	}
+	if len(sources) > 0 {
+		result.Metadata = map[string]interface{}{
+			"sources": sources,
+		}
+	}
+	return result, nil
 }
 
 // ReportSubagent generates formatted reports.
 type ReportSubagent struct {
-	client             *openai.Client
-	model              string
-	verbose            bool
+	client      LLMClient
+	model       string
+	temperature float32
+	retryPolicy RetryPolicy
+	prompts     map[string]*template.Template
+	stream      bool
+	// contextBudget limits the earlier outputs in the prompt
+	contextBudget int
+	verbose       bool
+	verboseLog
 	interactionHandler InteractionHandler
 }
 
 // NewReportSubagent creates a new ReportSubagent.
-func NewReportSubagent(client *openai.Client, model string, verbose bool, interactionHandler InteractionHandler) *ReportSubagent {
+// The model and temperature (0.5 by default) can be overridden with options.
+func NewReportSubagent(client LLMClient, model string, verbose bool, interactionHandler InteractionHandler, opts ...SubagentOption) *ReportSubagent {
+	o := applySubagentOptions(model, 0.5, opts)
 	return &ReportSubagent{
 		client:             client,
-		model:              model,
+		model:              o.model,
+		temperature:        o.temperature,
+		retryPolicy:        o.retryPolicy,
+		prompts:            o.prompts,
+		stream:             o.stream,
+		contextBudget:      o.contextBudget,
 		verbose:            verbose,
 		interactionHandler: interactionHandler,
 	}
@@ -367,12 +865,14 @@ func (r *ReportSubagent) Type() TaskType {
 }
 
 // Execute generates a formatted report.
-func (r *ReportSubagent) Execute(ctx context.Context, task Task) (Result, error) {
+func (r *ReportSubagent) Execute(ctx context.Context, task Task) (result Result, err error) {
 	if r.verbose {
-		fmt.Println("📝 报告 Subagent")
+		r.logln("📝 报告 Subagent")
 	}
 	if r.interactionHandler != nil {
 		r.interactionHandler.Log(fmt.Sprintf("> 报告 Subagent: %s", task.Description))
+		r.interactionHandler.OnTaskStart(task)
+		defer func() { r.interactionHandler.OnTaskComplete(result) }()
 	}
 
 	// Get context from parameters if available
@@ -380,16 +880,53 @@ func (r *ReportSubagent) Execute(ctx context.Context, task Task) (Result, error)
 
 	var prompt string
 	if hasContext && len(contextData) > 0 {
-		prompt = fmt.Sprintf("基于以下信息，%s:\n\n%s", task.Description, strings.Join(contextData, "\n\n"))
+		fitted := trimContext(contextData, r.contextBudget, r.verbose, &r.verboseLog, r.interactionHandler)
+		prompt = fmt.Sprintf("基于以下信息，%s:\n\n%s", task.Description, strings.Join(fitted, "\n\n"))
 	} else {
 		prompt = task.Description
 	}
 
 	// Check for global context
 	globalContext, _ := task.Parameters["global_context"].(string)
-	systemPrompt := "你是一个报告写作助手，负责创建格式良好、清晰且全面的 Markdown 格式报告。使用适当的标题、列表和格式使报告易于阅读。如果提供的信息包含带有 URL 和描述的图片，请选择最相关的图片，并使用标准 Markdown 图片语法 `![描述](URL)` 将其嵌入报告中。将图片放置在相关文本部分附近。"
-	if globalContext != "" {
-		systemPrompt += "\n\n来自用户的重要上下文/指令：\n" + globalContext
+	promptName := PromptReport
+	promptData := PromptData{
+		Task:          task.Description,
+		GlobalContext: globalContext,
+	}
+
+	// JSON mode: emit a JSON object matching the caller-provided schema
+	format, _ := task.Parameters["format"].(string)
+	jsonMode := strings.EqualFold(format, "json")
+	var schema json.RawMessage
+	var resolved *jsonschema.Resolved
+	if jsonMode {
+		var err error
+		schema, resolved, err = reportSchema(task.Parameters["schema"])
+		if err != nil {
+			return Result{
+				TaskType: TaskTypeReport,
+				Success:  false,
+				Error:    err.Error(),
+			}, err
+		}
+		promptName = PromptReportJSON
+		promptData.Schema = string(schema)
+	}
+
+	systemPrompt, err := renderPrompt(r.prompts, promptName, promptData)
+	if err != nil {
+		return Result{
+			TaskType: TaskTypeReport,
+			Success:  false,
+			Error:    err.Error(),
+		}, err
+	}
+
+	// Sources found so far, numbered for citations
+	sources, _ := task.Parameters["sources"].([]Source)
+	if len(sources) > 0 && !jsonMode {
+		systemPrompt += citationInstruction + referencesInstruction
+		prompt += "\n\n" + formatSources(sources)
 	}
 
 	messages := []openai.ChatCompletionMessage{
@@ -406,48 +943,300 @@ func (r *ReportSubagent) Execute(ctx context.Context, task Task) (Result, error)
 	req := openai.ChatCompletionRequest{
 		Model:       r.model,
 		Messages:    messages,
-		Temperature: 0.5,
+		Temperature: r.temperature,
+	}
+	if jsonMode {
+		req.ResponseFormat = jsonResponseFormat(schema)
 	}
 
-	resp, err := r.client.CreateChatCompletion(ctx, req)
+	var report string
+	if r.stream && r.interactionHandler != nil && !jsonMode {
+		report, err = r.streamReport(ctx, req)
+	} else {
+		var resp openai.ChatCompletionResponse
+		resp, err = createChatCompletionWithRetry(ctx, r.client, req, r.retryPolicy)
+		if err != nil && req.ResponseFormat != nil {
+			// The backend may not support response_format; rely on the prompt instead
+			req.ResponseFormat = nil
+			resp, err = createChatCompletionWithRetry(ctx, r.client, req, r.retryPolicy)
+		}
+		if err == nil {
+			report = resp.Choices[0].Message.Content
+		}
+	}
+	if err == nil && jsonMode {
+		report, err = r.finalizeJSON(ctx, req, report, resolved)
+	}
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return Result{
+				TaskType: TaskTypeReport,
+				Success:  false,
+				Error:    ctxErr.Error(),
+			}, ctxErr
+		}
+		// Degrade gracefully: keep the gathered context so later steps can still show something
+		if r.interactionHandler != nil {
+			r.interactionHandler.Log(fmt.Sprintf("  ⚠️ LLM 调用失败 (已重试 %d 次): %v", r.retryPolicy.MaxRetries, err))
+		}
 		return Result{
 			TaskType: TaskTypeReport,
 			Success:  false,
-			Error:    err.Error(),
-		}, err
+			Output:   strings.Join(contextData, "\n\n"),
+			Error:    fmt.Sprintf("LLM request failed after %d retries: %v", r.retryPolicy.MaxRetries, err),
+			Metadata: map[string]interface{}{
+				"partial": true,
+			},
+		}, nil
 	}
 
-	report := resp.Choices[0].Message.Content
-
 	if r.verbose {
-		fmt.Printf("  ✓ 报告已生成 (%d 字节)\n", len(report))
+		r.logf("  ✓ 报告已生成 (%d 字节)\n", len(report))
 	}
 	if r.interactionHandler != nil {
 		r.interactionHandler.Log(fmt.Sprintf("✓ 报告已生成 (%d 字节)", len(report)))
 	}
 
-	return Result{
+	result = Result{
 		TaskType: TaskTypeReport,
 		Success:  true,
 		Output:   report,
-	}, nil
+	}
+	if jsonMode {
+		result.Metadata = map[string]interface{}{
+			"format": "json",
+		}
+	} else if len(sources) > 0 {
+		result.Metadata = map[string]interface{}{
+			"sources": sources,
+		}
+		if unmatched := CheckCitations(report, sources); len(unmatched) > 0 {
+			result.Metadata["unmatched_citations"] = unmatched
+			msg := fmt.Sprintf("  ⚠️ 报告中有 %d 个引用标记没有对应的来源: %s", len(unmatched), strings.Join(unmatched, " "))
+			if r.verbose {
+				r.logln(msg)
+			}
+			if r.interactionHandler != nil {
+				r.interactionHandler.Log(msg)
+			}
+		}
+	}
+	return result, nil
+}
+
+// streamingClient is implemented by *openai.Client.
+type streamingClient interface {
+	CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error)
+}
+
+// streamReport generates the report with a streaming completion, forwarding
+// each chunk to the interaction handler as it arrives. Clients without
+// streaming support deliver the whole report as a single chunk. Transient
+// errors are retried until the first chunk arrives; after that a retry would
+// repeat text the handler has already shown.
+func (r *ReportSubagent) streamReport(ctx context.Context, req openai.ChatCompletionRequest) (string, error) {
+	streamer, ok := r.client.(streamingClient)
+	if !ok {
+		resp, err := createChatCompletionWithRetry(ctx, r.client, req, r.retryPolicy)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Choices) == 0 {
+			return "", errors.New("no response from LLM")
+		}
+		report := resp.Choices[0].Message.Content
+		r.interactionHandler.StreamChunk(report)
+		return report, nil
+	}
+
+	req.Stream = true
+	var stream *openai.ChatCompletionStream
+	var chunk string
+	var chunkErr error
+	err := withRetry(ctx, r.retryPolicy, func() error {
+		s, err := streamer.CreateChatCompletionStream(ctx, req)
+		if err != nil {
+			return err
+		}
+		chunk, chunkErr = nextChunk(s)
+		if chunkErr != nil && !errors.Is(chunkErr, io.EOF) {
+			s.Close()
+			return chunkErr
+		}
+		stream = s
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	var sb strings.Builder
+	for chunkErr == nil {
+		sb.WriteString(chunk)
+		r.interactionHandler.StreamChunk(chunk)
+		chunk, chunkErr = nextChunk(stream)
+	}
+	if errors.Is(chunkErr, io.EOF) {
+		return sb.String(), nil
+	}
+	return sb.String(), chunkErr
+}
+
+// nextChunk returns the next non-empty piece of content of stream, or io.EOF
+// at its end.
+func nextChunk(stream *openai.ChatCompletionStream) (string, error) {
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Choices) > 0 && resp.Choices[0].Delta.Content != "" {
+			return resp.Choices[0].Delta.Content, nil
+		}
+	}
 }
 
 // RenderSubagent renders markdown to terminal-friendly format.
 type RenderSubagent struct {
-	verbose            bool
-	renderHTML         bool
+	verbose bool
+	verboseLog
+	format             RenderFormat
+	highlight          bool
+	width              int    // terminal wrap width; 0 detects it at render time
+	leftPad            int    // terminal left padding
+	theme              string // HTML color theme: ThemeLight or ThemeDark
+	wrapPolicy         WrapPolicy
+	assetDir           string // local directory for downloaded images; empty keeps remote URLs
+	maxImageSize       int64  // largest image to download, in bytes
+	outputDir          string
 	interactionHandler InteractionHandler
 }
 
-// NewRenderSubagent creates a new RenderSubagent.
-func NewRenderSubagent(verbose bool, renderHTML bool, interactionHandler InteractionHandler) *RenderSubagent {
-	return &RenderSubagent{
+// RenderFormat is the output format of the RenderSubagent.
+type RenderFormat string
+
+const (
+	// FormatTerminal renders markdown for the terminal, with ANSI styling
+	// when standard output is a terminal.
+	FormatTerminal RenderFormat = "terminal"
+	// FormatHTML renders markdown as a complete HTML page.
+	FormatHTML RenderFormat = "html"
+	// FormatPlain renders markdown as plain text without markup or ANSI escape codes.
+	FormatPlain RenderFormat = "plain"
+	// FormatPDF converts markdown to a PDF file in the output directory.
+	FormatPDF RenderFormat = "pdf"
+	// FormatJSON renders markdown as a JSON tree of DocumentNodes.
+	FormatJSON RenderFormat = "json"
+	// FormatXML renders markdown as an XML tree of DocumentNodes.
+	FormatXML RenderFormat = "xml"
+)
+
+// renderFormats are the known render formats.
+var renderFormats = map[RenderFormat]bool{
+	FormatTerminal: true,
+	FormatHTML:     true,
+	FormatPlain:    true,
+	FormatPDF:      true,
+	FormatJSON:     true,
+	FormatXML:      true,
+}
+
+// HTML color themes for the RenderSubagent.
+const (
+	ThemeLight = "light"
+	ThemeDark  = "dark"
+)
+
+// RenderOption customizes a RenderSubagent.
+type RenderOption func(*RenderSubagent)
+
+// WithPDF makes the RenderSubagent convert reports to PDF files written to
+// outputDir, i.e. sets FormatPDF.
+func WithPDF(outputDir string) RenderOption {
+	return func(r *RenderSubagent) {
+		r.format = FormatPDF
+		r.outputDir = outputDir
+	}
+}
+
+// WithSyntaxHighlighting enables or disables ANSI syntax highlighting of code
+// blocks in terminal output. Highlighting is on by default and is always
+// skipped when standard output is not a terminal.
+func WithSyntaxHighlighting(enabled bool) RenderOption {
+	return func(r *RenderSubagent) {
+		r.highlight = enabled
+	}
+}
+
+// WithWidth sets the terminal wrap width and left padding. A width of zero or
+// less uses the width of the controlling terminal, falling back to 80 columns.
+func WithWidth(width, leftPad int) RenderOption {
+	return func(r *RenderSubagent) {
+		r.width = width
+		r.leftPad = leftPad
+	}
+}
+
+// WithTheme selects the color theme (ThemeLight or ThemeDark) for HTML and PDF output.
+func WithTheme(theme string) RenderOption {
+	return func(r *RenderSubagent) {
+		r.theme = theme
+	}
+}
+
+// WithFormat overrides the output format, e.g. FormatPlain. An empty format
+// keeps the format given at construction.
+func WithFormat(format RenderFormat) RenderOption {
+	return func(r *RenderSubagent) {
+		if format != "" {
+			r.format = format
+		}
+	}
+}
+
+// WithImageAssets makes HTML and PDF output work offline by downloading
+// referenced images into assetDir and linking to the local copies. Images
+// larger than maxSize bytes are left as remote URLs; zero uses DefaultMaxImageSize.
+func WithImageAssets(assetDir string, maxSize int64) RenderOption {
+	return func(r *RenderSubagent) {
+		r.assetDir = assetDir
+		r.maxImageSize = maxSize
+	}
+}
+
+// NewRenderSubagent creates a new RenderSubagent rendering for the terminal,
+// or HTML when renderHTML is set.
+//
+// Deprecated: Use NewRenderSubagentWithFormat.
+func NewRenderSubagent(verbose bool, renderHTML bool, interactionHandler InteractionHandler, opts ...RenderOption) *RenderSubagent {
+	format := FormatTerminal
+	if renderHTML {
+		format = FormatHTML
+	}
+	return NewRenderSubagentWithFormat(verbose, format, interactionHandler, opts...)
+}
+
+// NewRenderSubagentWithFormat creates a new RenderSubagent rendering in
+// format. An empty format is FormatTerminal. A RENDER task may override the
+// format with its "format" parameter.
+func NewRenderSubagentWithFormat(verbose bool, format RenderFormat, interactionHandler InteractionHandler, opts ...RenderOption) *RenderSubagent {
+	if format == "" {
+		format = FormatTerminal
+	}
+	r := &RenderSubagent{
 		verbose:            verbose,
-		renderHTML:         renderHTML,
+		format:             format,
+		highlight:          true,
+		leftPad:            6,
+		theme:              ThemeLight,
+		outputDir:          "generated",
 		interactionHandler: interactionHandler,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // Type returns the task type this subagent handles.
@@ -456,23 +1245,28 @@ func (r *RenderSubagent) Type() TaskType {
 }
 
 // Execute renders markdown content.
-func (r *RenderSubagent) Execute(ctx context.Context, task Task) (Result, error) {
+func (r *RenderSubagent) Execute(ctx context.Context, task Task) (result Result, err error) {
 	if r.verbose {
-		fmt.Println("🎨 渲染 Subagent")
+		r.logln("🎨 渲染 Subagent")
 	}
 	if r.interactionHandler != nil {
 		r.interactionHandler.Log(fmt.Sprintf("> 渲染 Subagent: %s", task.Description))
+		r.interactionHandler.OnTaskStart(task)
+		defer func() { r.interactionHandler.OnTaskComplete(result) }()
 	}
 
-	// Get content from parameters or description
+	// Get content from parameters, the outputs of earlier tasks or description
 	content, ok := task.Parameters["content"].(string)
 	if !ok {
-		// Try to get from context (passed from previous task)
+		content, ok = renderInput(task)
+	}
+	if !ok {
+		// Legacy callers pass earlier outputs only as text in the context
 		if ctxContent, ok := task.Parameters["context"].([]string); ok && len(ctxContent) > 0 {
-			// Try to find the output from the REPORT task
+			// Try to find the output from the REPORT (or its TRANSLATE) task
 			var foundReport bool
 			for i := len(ctxContent) - 1; i >= 0; i-- {
-				if strings.Contains(ctxContent[i], "Output from REPORT task:") {
+				if strings.Contains(ctxContent[i], "Output from REPORT task:") || strings.Contains(ctxContent[i], "Output from TRANSLATE task:") {
 					content = ctxContent[i]
 					// Extract the content after the header
 					if idx := strings.Index(content, "\n"); idx != -1 {
@@ -500,26 +1294,49 @@ func (r *RenderSubagent) Execute(ctx context.Context, task Task) (Result, error)
 	}
 
 	if r.verbose {
-		fmt.Printf("  正在渲染 %d 字节的内容\n", len(content))
+		r.logf("  正在渲染 %d 字节的内容\n", len(content))
 	}
 	if r.interactionHandler != nil {
 		r.interactionHandler.Log(fmt.Sprintf("正在渲染 %d 字节的内容", len(content)))
 	}
 
+	// The task may ask for another known format
+	format := r.format
+	if name, _ := task.Parameters["format"].(string); renderFormats[RenderFormat(strings.ToLower(name))] {
+		format = RenderFormat(strings.ToLower(name))
+	}
+	if r.assetDir != "" && (format == FormatPDF || format == FormatHTML) {
+		content = r.localizeImages(ctx, content)
+	}
+
 	// Render markdown
 	var output string
-	if r.renderHTML {
-		extensions := parser.CommonExtensions | parser.AutoHeadingIDs
-		p := parser.NewWithExtensions(extensions)
-		doc := p.Parse([]byte(content))
-
-		htmlFlags := html.CommonFlags | html.HrefTargetBlank | html.CompletePage
-		opts := html.RendererOptions{Flags: htmlFlags, Title: "Agent Report"}
-		renderer := html.NewRenderer(opts)
-
-		output = string(gomarkdown.Render(doc, renderer))
-	} else {
-		output = string(markdown.Render(content, 80, 6))
+	switch format {
+	case FormatPDF:
+		return r.renderToPDF(ctx, content)
+	case FormatPlain:
+		output = renderPlainText(content)
+	case FormatHTML:
+		output = renderMarkdownHTML(content, r.theme)
+	case FormatJSON:
+		output, err = renderDocumentJSON(content)
+	case FormatXML:
+		output, err = renderDocumentXML(content)
+	case FormatTerminal:
+		width := r.width
+		if width <= 0 {
+			width = terminalWidth()
+		}
+		output = renderTerminalMarkdown(content, width, r.leftPad, r.highlight && stdoutIsTerminal(), r.wrapPolicy)
+	default:
+		err = fmt.Errorf("unknown render format: %s", format)
+	}
+	if err != nil {
+		return Result{
+			TaskType: TaskTypeRender,
+			Success:  false,
+			Error:    err.Error(),
+		}, err
 	}
 
 	return Result{
@@ -528,3 +1345,59 @@ func (r *RenderSubagent) Execute(ctx context.Context, task Task) (Result, error)
 		Output:   output,
 	}, nil
 }
+
+// renderInput picks the content to render from the typed outputs of earlier
+// tasks: the latest report or its translation, else the latest output.
+func renderInput(task Task) (string, bool) {
+	if output, ok := latestOutput(task, TaskTypeReport, TaskTypeTranslate); ok {
+		return strings.TrimSpace(output), true
+	}
+	outputs, _ := task.Parameters["outputs"].([]TaskOutput)
+	if len(outputs) == 0 {
+		return "", false
+	}
+	return strings.TrimSpace(outputs[len(outputs)-1].Output), true
+}
+
+// themeCSS holds the stylesheet embedded in HTML output for each theme.
+var themeCSS = map[string]string{
+	ThemeLight: `<style>
+body { background: #ffffff; color: #24292f; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.6; max-width: 900px; margin: 0 auto; padding: 2em; }
+a { color: #0969da; }
+pre, code { background: #f6f8fa; }
+pre { padding: 1em; overflow-x: auto; }
+blockquote { color: #57606a; border-left: 4px solid #d0d7de; margin-left: 0; padding-left: 1em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 6px 12px; }
+img { max-width: 100%; }
+</style>
+`,
+	ThemeDark: `<style>
+body { background: #0d1117; color: #c9d1d9; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.6; max-width: 900px; margin: 0 auto; padding: 2em; }
+a { color: #58a6ff; }
+pre, code { background: #161b22; }
+pre { padding: 1em; overflow-x: auto; }
+blockquote { color: #8b949e; border-left: 4px solid #30363d; margin-left: 0; padding-left: 1em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #30363d; padding: 6px 12px; }
+img { max-width: 100%; }
+</style>
+`,
+}
+
+// renderMarkdownHTML renders markdown content as a complete HTML page styled with the given theme.
+func renderMarkdownHTML(content, theme string) string {
+	extensions := parser.CommonExtensions | parser.AutoHeadingIDs
+	p := parser.NewWithExtensions(extensions)
+	doc := p.Parse([]byte(content))
+
+	htmlFlags := html.CommonFlags | html.HrefTargetBlank | html.CompletePage
+	css, ok := themeCSS[theme]
+	if !ok {
+		css = themeCSS[ThemeLight]
+	}
+	opts := html.RendererOptions{Flags: htmlFlags, Title: "Agent Report", Head: []byte(css)}
+	renderer := html.NewRenderer(opts)
+
+	return string(gomarkdown.Render(doc, renderer))
+}
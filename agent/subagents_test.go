@@ -0,0 +1,418 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubagentOptions(t *testing.T) {
+	analysis := NewAnalysisSubagent(nil, "gpt-4o", false, nil)
+	assert.Equal(t, "gpt-4o", analysis.model)
+	assert.Equal(t, float32(0.3), analysis.temperature)
+
+	report := NewReportSubagent(nil, "gpt-4o", false, nil, WithModel("gpt-4.1"), WithTemperature(0.9))
+	assert.Equal(t, "gpt-4.1", report.model)
+	assert.Equal(t, float32(0.9), report.temperature)
+
+	cfg := AgentConfig{
+		SubagentModels:       map[TaskType]string{TaskTypeAnalyze: "gpt-4o-mini"},
+		SubagentTemperatures: map[TaskType]float32{TaskTypeAnalyze: 0},
+	}
+	analysis = NewAnalysisSubagent(nil, "gpt-4o", false, nil, cfg.subagentOptions(TaskTypeAnalyze)...)
+	assert.Equal(t, "gpt-4o-mini", analysis.model)
+	assert.Equal(t, float32(0), analysis.temperature)
+}
+
+type recordingHandler struct {
+	BaseInteractionHandler
+	started   []Task
+	completed []Result
+	chunks    []string
+}
+
+func (h *recordingHandler) ReviewPlan(plan *Plan) (string, error)                { return "", nil }
+func (h *recordingHandler) ConfirmPodcastGeneration(report string) (bool, error) { return false, nil }
+func (h *recordingHandler) ConfirmCodeExecution(language, code string) (bool, error) {
+	return false, nil
+}
+func (h *recordingHandler) ApproveTool(tc openai.ToolCall, risk tool.RiskLevel) (bool, error) {
+	return false, nil
+}
+func (h *recordingHandler) Log(message string)           {}
+func (h *recordingHandler) StreamChunk(chunk string)     { h.chunks = append(h.chunks, chunk) }
+func (h *recordingHandler) OnTaskStart(task Task)        { h.started = append(h.started, task) }
+func (h *recordingHandler) OnTaskComplete(result Result) { h.completed = append(h.completed, result) }
+
+func TestSubagentProgressEvents(t *testing.T) {
+	handler := &recordingHandler{}
+	render := NewRenderSubagent(false, false, handler, WithFormat(FormatPlain))
+
+	_, err := render.Execute(context.Background(), Task{
+		Type:       TaskTypeRender,
+		Parameters: map[string]interface{}{"content": "# Title"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, handler.started, 1)
+	assert.Equal(t, TaskTypeRender, handler.started[0].Type)
+	require.Len(t, handler.completed, 1)
+	assert.True(t, handler.completed[0].Success)
+	assert.Equal(t, "TITLE\n", handler.completed[0].Output)
+}
+
+func TestRenderInput(t *testing.T) {
+	o := NewOrchestrator(false, nil)
+	o.Register(NewRenderSubagentWithFormat(false, FormatPlain, nil))
+	o.Register(&funcSubagent{taskType: TaskTypeReport, run: func(task Task) (Result, error) {
+		return Result{Success: true, Output: "# Report"}, nil
+	}})
+	// A later output that quotes the report header must not be rendered
+	o.Register(&funcSubagent{taskType: TaskTypeCritique, run: func(task Task) (Result, error) {
+		return Result{Success: true, Output: "Output from REPORT task:\nquoted draft"}, nil
+	}})
+
+	results, err := o.Run(context.Background(), []Task{{Type: TaskTypeReport}, {Type: TaskTypeCritique}, {Type: TaskTypeRender}})
+	require.NoError(t, err)
+	assert.Equal(t, "REPORT\n", results[2].Output)
+
+	// Without a report the latest output is rendered
+	results, err = o.Run(context.Background(), []Task{{Type: TaskTypeCritique}, {Type: TaskTypeRender}})
+	require.NoError(t, err)
+	assert.Equal(t, "Output from REPORT task:\nquoted draft\n", results[1].Output)
+
+	// Legacy callers passing only text context
+	render := NewRenderSubagentWithFormat(false, FormatPlain, nil)
+	result, err := render.Execute(context.Background(), Task{Type: TaskTypeRender, Parameters: map[string]interface{}{
+		"context": []string{"Output from REPORT task:\n# Legacy", "Output from SEARCH task:\nresults"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "LEGACY\n", result.Output)
+}
+
+type cancelingHandler struct {
+	recordingHandler
+}
+
+func (h *cancelingHandler) ShouldCancel() bool { return len(h.completed) > 0 }
+
+func TestExecuteCanceled(t *testing.T) {
+	handler := &cancelingHandler{}
+	orchestrator := NewOrchestrator(false, handler)
+	orchestrator.Register(NewRenderSubagent(false, false, handler, WithFormat(FormatPlain)))
+	a := &PlanningAgent{
+		config:             AgentConfig{},
+		interactionHandler: handler,
+		orchestrator:       orchestrator,
+	}
+
+	plan := &Plan{Tasks: []Task{
+		{Type: TaskTypeRender, Parameters: map[string]interface{}{"content": "first"}},
+		{Type: TaskTypeRender, Parameters: map[string]interface{}{"content": "second"}},
+	}}
+	results, err := a.Execute(context.Background(), plan)
+	assert.ErrorIs(t, err, ErrCanceled)
+	require.Len(t, results, 1)
+	assert.Equal(t, "first\n", results[0].Output)
+}
+
+func TestSearchProviderFallback(t *testing.T) {
+	old := searchProviders
+	t.Cleanup(func() { searchProviders = old })
+
+	var tried []string
+	provider := func(name, result string, err error) searchProvider {
+		return searchProvider{name: name, search: func(ctx context.Context, query string) (string, error) {
+			tried = append(tried, name)
+			return result, err
+		}}
+	}
+	searchProviders = []searchProvider{
+		provider("Tavily", "", fmt.Errorf("%w: status 432", tool.ErrSearchQuotaExceeded)),
+		provider("Serper", "Title: Go\nURL: https://go.dev\nContent: Go\n\n", nil),
+		provider("DuckDuckGo", "unused", nil),
+	}
+	handler := &recordingHandler{}
+	s := NewSearchSubagent(nil, "gpt-4o", false, handler)
+
+	result, err := s.search(context.Background(), "golang", searchRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "Title: Go\nURL: https://go.dev\nContent: Go\n\n", result)
+	assert.Equal(t, []string{"Tavily", "Serper"}, tried)
+
+	searchProviders = []searchProvider{
+		provider("Tavily", "", errors.New("no key")),
+		provider("DuckDuckGo", "", errors.New("offline")),
+	}
+	_, err = s.search(context.Background(), "golang", searchRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Tavily: no key")
+	assert.Contains(t, err.Error(), "DuckDuckGo: offline")
+}
+
+func TestSearchLimit(t *testing.T) {
+	old := searchProviders
+	t.Cleanup(func() { searchProviders = old })
+
+	results := "Title: A\nURL: https://a.example\nContent: a\n\n" +
+		"Title: B\nURL: https://b.example\nContent: b\n\n" +
+		"Title: C\nURL: https://c.example\nContent: c\n\n" +
+		"\nRelevant Images:\n- Image URL: https://a.example/a.png\n"
+	var limits []int
+	searchProviders = []searchProvider{
+		{
+			name:   "Tavily",
+			search: func(context.Context, string) (string, error) { return "", errors.New("no key") },
+			searchWith: func(ctx context.Context, query string, req searchRequest) (string, error) {
+				limits = append(limits, req.limit)
+				return "", errors.New("no key")
+			},
+		},
+		{name: "Serper", search: func(context.Context, string) (string, error) { return results, nil }},
+	}
+	s := NewSearchSubagent(nil, "gpt-4o", false, nil)
+
+	result, err := s.search(context.Background(), "golang", searchRequest{limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, limits)
+	assert.Contains(t, result, "Title: B")
+	assert.NotContains(t, result, "Title: C")
+	assert.Contains(t, result, "Relevant Images:")
+
+	result, err = s.search(context.Background(), "golang", searchRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, results, result)
+	assert.Equal(t, []int{2}, limits, "without a limit the plain search is used")
+
+	assert.Equal(t, 3, searchLimit(Task{Parameters: map[string]interface{}{"limit": 3}}))
+	assert.Equal(t, 4, searchLimit(Task{Parameters: map[string]interface{}{"limit": float64(4)}}))
+	assert.Equal(t, 0, searchLimit(Task{Parameters: map[string]interface{}{"limit": "many"}}))
+	assert.Equal(t, 0, searchLimit(Task{}))
+}
+
+func TestSearchDomains(t *testing.T) {
+	old := wikipediaSearch
+	t.Cleanup(func() { wikipediaSearch = old })
+	wikipediaSearch = func(ctx context.Context, query string) (string, error) {
+		t.Error("Wikipedia is out of scope")
+		return "", nil
+	}
+
+	s := NewSearchSubagent(sufficientClient{}, "gpt-4o", false, nil)
+	s.searchFunc = func(context.Context, string) (string, error) {
+		return "Title: CDC\nURL: https://www.cdc.gov/flu\nContent: Flu\n\nTitle: Farm\nURL: https://farm.example.com/flu\nContent: Spam\n\n", nil
+	}
+	result, err := s.Execute(context.Background(), Task{Type: TaskTypeSearch, Description: "flu", Parameters: map[string]interface{}{
+		"includeDomains": []interface{}{".gov", ".edu"},
+		"excludeDomains": "farm.example.com",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "搜索范围: 仅限域名 .gov, .edu; 排除域名 farm.example.com\n\nTitle: CDC\nURL: https://www.cdc.gov/flu\nContent: Flu\n\n", result.Output)
+	assert.Equal(t, []string{".gov", ".edu"}, result.Metadata["include_domains"])
+	assert.Equal(t, []string{"farm.example.com"}, result.Metadata["exclude_domains"])
+}
+
+// replyClient answers with replies in turn, then with "SUFFICIENT", and
+// records the last request.
+type replyClient struct {
+	replies []string
+	got     openai.ChatCompletionRequest
+}
+
+func (c *replyClient) CreateChatCompletion(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	c.got = req
+	reply := "SUFFICIENT"
+	if len(c.replies) > 0 {
+		reply, c.replies = c.replies[0], c.replies[1:]
+	}
+	return openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: reply}}}}, nil
+}
+
+func TestSearchNoResults(t *testing.T) {
+	oldWiki, oldProviders := wikipediaSearch, searchProviders
+	t.Cleanup(func() { wikipediaSearch, searchProviders = oldWiki, oldProviders })
+	wikipediaSearch = func(context.Context, string) (string, error) { return "", errors.New("offline") }
+
+	// Empty results fall through to the next provider
+	var tried []string
+	provider := func(name, result string) searchProvider {
+		return searchProvider{name: name, search: func(context.Context, string) (string, error) {
+			tried = append(tried, name)
+			return result, nil
+		}}
+	}
+	searchProviders = []searchProvider{provider("Tavily", "No results found."), provider("DuckDuckGo", "Go is a language (Source: https://go.dev)")}
+	s := NewSearchSubagent(nil, "gpt-4o", false, nil)
+	result, err := s.search(context.Background(), "golang", searchRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "Go is a language (Source: https://go.dev)", result)
+	assert.Equal(t, []string{"Tavily", "DuckDuckGo"}, tried)
+
+	searchProviders = []searchProvider{provider("Tavily", "\nRelevant Images:\n- Image URL: https://a.example/a.png\n"), provider("DuckDuckGo", "No relevant information found.")}
+	result, err = s.search(context.Background(), "golang", searchRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, result)
+
+	// A rephrased query is tried once
+	var queries []string
+	s = NewSearchSubagent(&replyClient{replies: []string{`"go language"`}}, "gpt-4o", false, nil)
+	s.searchFunc = func(ctx context.Context, query string) (string, error) {
+		queries = append(queries, query)
+		if query == "go language" {
+			return "Title: Go\nURL: https://go.dev\nContent: Go\n\n", nil
+		}
+		return "No results found.", nil
+	}
+	res, err := s.Execute(context.Background(), Task{Type: TaskTypeSearch, Description: "golnag"})
+	require.NoError(t, err)
+	assert.True(t, res.Success)
+	assert.Equal(t, []string{"golnag", "go language"}, queries)
+	assert.Contains(t, res.Output, "Title: Go")
+
+	// Without results the task fails with the queries tried
+	s = NewSearchSubagent(&replyClient{replies: []string{"xyzzy plugh"}}, "gpt-4o", false, nil)
+	s.searchFunc = func(context.Context, string) (string, error) { return "", nil }
+	res, err = s.Execute(context.Background(), Task{Type: TaskTypeSearch, Description: "xyzzy"})
+	require.NoError(t, err)
+	assert.False(t, res.Success)
+	assert.Contains(t, res.Error, "no search results")
+	assert.Contains(t, res.Output, "未找到任何搜索结果")
+	assert.Equal(t, []string{"xyzzy", "xyzzy plugh"}, res.Metadata["queries"])
+}
+
+func TestSearchImages(t *testing.T) {
+	old := imageProviders
+	t.Cleanup(func() { imageProviders = old })
+
+	var many []tool.SearchImage
+	for i := 0; i < 8; i++ {
+		many = append(many, tool.SearchImage{URL: fmt.Sprintf("https://example.com/%d.png", i)})
+	}
+	imageProviders = []imageProvider{
+		{name: "Tavily", search: func(context.Context, string) ([]tool.SearchImage, error) { return nil, errors.New("no key") }},
+		{name: "Serper", search: func(context.Context, string) ([]tool.SearchImage, error) { return many, nil }},
+	}
+	s := NewSearchSubagent(nil, "gpt-4o", false, nil)
+	assert.Equal(t, many[:maxSearchImages], s.searchImages(context.Background(), "gopher"))
+
+	imageProviders = nil
+	assert.Nil(t, s.searchImages(context.Background(), "gopher"))
+
+	assert.Equal(t, "Images from search task (URL and description):\n- ![A blue gopher](https://example.com/a.png)\n- ![image](https://example.com/b.png)\n",
+		formatSearchImages([]tool.SearchImage{
+			{URL: "https://example.com/a.png", Description: "A [blue] gopher"},
+			{URL: "https://example.com/b.png"},
+		}))
+}
+
+// sufficientClient answers every reflection with "SUFFICIENT".
+type sufficientClient struct{}
+
+func (sufficientClient) CreateChatCompletion(context.Context, openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "SUFFICIENT"}}}}, nil
+}
+
+func TestSearchWikipediaPolicy(t *testing.T) {
+	old := wikipediaSearch
+	t.Cleanup(func() { wikipediaSearch = old })
+	lookups := 0
+	wikipediaSearch = func(ctx context.Context, query string) (string, error) {
+		lookups++
+		return "Title: Go (programming language)\nURL: https://en.wikipedia.org/wiki/Go\n", nil
+	}
+
+	sparse := "Title: Go\nURL: https://go.dev\nContent: Go\n\n"
+	rich := sparse + "Title: Tour\nURL: https://go.dev/tour\nContent: Tour\n\n" +
+		"Title: Spec\nURL: https://go.dev/ref/spec\nContent: " + strings.Repeat("spec ", 400) + "\n\n"
+
+	run := func(results string, opts ...SubagentOption) Result {
+		s := NewSearchSubagent(sufficientClient{}, "gpt-4o", false, nil, opts...)
+		s.searchFunc = func(context.Context, string) (string, error) { return results, nil }
+		result, err := s.Execute(context.Background(), Task{Type: TaskTypeSearch, Description: "golang"})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := run(sparse)
+	assert.Equal(t, 1, lookups)
+	assert.Contains(t, result.Output, "维基百科结果:\nTitle: Go (programming language)")
+	assert.Len(t, result.Metadata["sources"], 2)
+
+	result = run(rich)
+	assert.Equal(t, 1, lookups, "rich results skip Wikipedia")
+	assert.Equal(t, rich, result.Output)
+
+	run(rich, WithWikipedia(WikipediaPolicy{Always: true}))
+	assert.Equal(t, 2, lookups)
+	run(sparse, WithWikipedia(WikipediaPolicy{Disabled: true}))
+	assert.Equal(t, 2, lookups)
+	run(rich, WithWikipedia(WikipediaPolicy{MinSources: 5}))
+	assert.Equal(t, 3, lookups)
+}
+
+func TestSearchImagesRequested(t *testing.T) {
+	oldSearch, oldImages := searchProviders, imageProviders
+	t.Cleanup(func() { searchProviders, imageProviders = oldSearch, oldImages })
+
+	searchProviders = []searchProvider{{name: "Serper", search: func(context.Context, string) (string, error) {
+		return "Title: Go\nURL: https://go.dev\nContent: Go\n\n", nil
+	}}}
+	calls := 0
+	imageProviders = []imageProvider{{name: "Serper", search: func(context.Context, string) ([]tool.SearchImage, error) {
+		calls++
+		return []tool.SearchImage{{URL: "https://go.dev/gopher.png"}}, nil
+	}}}
+	s := NewSearchSubagent(sufficientClient{}, "gpt-4o", false, nil, WithWikipedia(WikipediaPolicy{Disabled: true}))
+
+	result, err := s.Execute(context.Background(), Task{Type: TaskTypeSearch, Description: "golang"})
+	require.NoError(t, err)
+	assert.NotContains(t, result.Metadata, "images")
+	assert.Zero(t, calls)
+
+	result, err = s.Execute(context.Background(), Task{Type: TaskTypeSearch, Description: "golang", Parameters: map[string]interface{}{"images": true}})
+	require.NoError(t, err)
+	assert.Equal(t, []tool.SearchImage{{URL: "https://go.dev/gopher.png"}}, result.Metadata["images"])
+	assert.Equal(t, 1, calls)
+}
+
+func TestSearchWikipediaFallback(t *testing.T) {
+	old := wikipediaSearch
+	t.Cleanup(func() { wikipediaSearch = old })
+	wikipediaSearch = func(ctx context.Context, query string) (string, error) {
+		return "Title: Go (programming language)\nURL: https://en.wikipedia.org/wiki/Go\n", nil
+	}
+
+	s := NewSearchSubagent(sufficientClient{}, "gpt-4o", false, nil)
+	s.searchFunc = func(context.Context, string) (string, error) { return "No results found.", nil }
+	result, err := s.Execute(context.Background(), Task{Type: TaskTypeSearch, Description: "golang"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "维基百科结果:\nTitle: Go (programming language)\nURL: https://en.wikipedia.org/wiki/Go\n", result.Output)
+
+	s = NewSearchSubagent(sufficientClient{}, "gpt-4o", false, nil, WithWikipedia(WikipediaPolicy{Disabled: true}))
+	s.searchFunc = func(context.Context, string) (string, error) { return "No results found.", nil }
+	result, err = s.Execute(context.Background(), Task{Type: TaskTypeSearch, Description: "golang"})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+}
+
+func TestLogWriter(t *testing.T) {
+	var log bytes.Buffer
+	a, err := NewPlanningAgent(AgentConfig{APIKey: "test", Verbose: true, RenderFormat: FormatPlain, LogWriter: &log}, nil)
+	require.NoError(t, err)
+
+	_, err = a.orchestrator.subagents[TaskTypeRender].Execute(context.Background(), Task{
+		Type:       TaskTypeRender,
+		Parameters: map[string]interface{}{"content": "# Title"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, log.String(), "🎨 渲染 Subagent\n")
+}
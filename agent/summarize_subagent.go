@@ -0,0 +1,230 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultSummaryTargetLength = 4000  // runes
+	defaultSummaryChunkSize    = 12000 // runes
+	maxSummaryDepth            = 4
+)
+
+// SummarizeSubagent reduces large inputs into a summary under a target length.
+type SummarizeSubagent struct {
+	client  LLMClient
+	model   string
+	verbose bool
+	verboseLog
+	interactionHandler InteractionHandler
+	targetLength       int
+	chunkSize          int
+}
+
+// NewSummarizeSubagent creates a new SummarizeSubagent.
+// targetLength is the maximum summary length in runes; zero uses the default.
+func NewSummarizeSubagent(client LLMClient, model string, verbose bool, interactionHandler InteractionHandler, targetLength int) *SummarizeSubagent {
+	if targetLength <= 0 {
+		targetLength = defaultSummaryTargetLength
+	}
+	return &SummarizeSubagent{
+		client:             client,
+		model:              model,
+		verbose:            verbose,
+		interactionHandler: interactionHandler,
+		targetLength:       targetLength,
+		chunkSize:          defaultSummaryChunkSize,
+	}
+}
+
+// Type returns the task type this subagent handles.
+func (s *SummarizeSubagent) Type() TaskType {
+	return TaskTypeSummarize
+}
+
+// Execute summarizes the input content.
+func (s *SummarizeSubagent) Execute(ctx context.Context, task Task) (result Result, err error) {
+	if s.verbose {
+		s.logln("🗜️ 摘要 Subagent")
+	}
+	if s.interactionHandler != nil {
+		s.interactionHandler.Log(fmt.Sprintf("> 摘要 Subagent: %s", task.Description))
+		s.interactionHandler.OnTaskStart(task)
+		defer func() { s.interactionHandler.OnTaskComplete(result) }()
+	}
+
+	// Get content from parameters, previous tasks or description
+	content, ok := task.Parameters["content"].(string)
+	if !ok || content == "" {
+		if ctxContent, ok := task.Parameters["context"].([]string); ok && len(ctxContent) > 0 {
+			content = strings.Join(ctxContent, "\n\n")
+		} else {
+			content = task.Description
+		}
+	}
+
+	targetLength := s.targetLength
+	switch v := task.Parameters["target_length"].(type) {
+	case int:
+		targetLength = v
+	case float64:
+		targetLength = int(v)
+	}
+	if targetLength <= 0 {
+		targetLength = s.targetLength
+	}
+
+	if s.verbose {
+		s.logf("  正在摘要 %d 字符的内容 (目标: %d)\n", len([]rune(content)), targetLength)
+	}
+
+	summary, err := s.summarize(ctx, task.Description, content, targetLength, 0)
+	if errors.Is(err, ErrCanceled) {
+		// Not a failure of the task; the orchestrator stops the run
+		return Result{
+			TaskType: TaskTypeSummarize,
+			Success:  false,
+			Error:    err.Error(),
+		}, nil
+	}
+	if err != nil {
+		return Result{
+			TaskType: TaskTypeSummarize,
+			Success:  false,
+			Error:    fmt.Sprintf("生成摘要失败: %v", err),
+		}, err
+	}
+
+	if s.verbose {
+		s.logf("  ✓ 摘要完成 (%d 字符)\n", len([]rune(summary)))
+	}
+	if s.interactionHandler != nil {
+		s.interactionHandler.Log(fmt.Sprintf("✓ 摘要完成 (%d 字符)", len([]rune(summary))))
+	}
+
+	return Result{
+		TaskType: TaskTypeSummarize,
+		Success:  true,
+		Output:   summary,
+		Metadata: map[string]interface{}{
+			"original_length": len([]rune(content)),
+			"summary_length":  len([]rune(summary)),
+		},
+	}, nil
+}
+
+// summarize summarizes each chunk of content and recursively combines the
+// partial summaries until the result fits within targetLength.
+func (s *SummarizeSubagent) summarize(ctx context.Context, focus, content string, targetLength, depth int) (string, error) {
+	length := len([]rune(content))
+	if length <= targetLength {
+		return content, nil
+	}
+
+	chunks := splitIntoChunks(content, s.chunkSize)
+	if len(chunks) == 1 || depth >= maxSummaryDepth {
+		return s.summarizeChunk(ctx, focus, content, targetLength)
+	}
+
+	chunkTarget := targetLength / len(chunks)
+	if chunkTarget < 500 {
+		chunkTarget = 500
+	}
+
+	if s.interactionHandler != nil {
+		s.interactionHandler.Log(fmt.Sprintf("  正在分 %d 块进行摘要 (第 %d 轮)", len(chunks), depth+1))
+	}
+
+	partials := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		if shouldCancel(s.interactionHandler) {
+			return "", ErrCanceled
+		}
+		if s.interactionHandler != nil {
+			s.interactionHandler.OnStepProgress(float64(i)/float64(len(chunks)), fmt.Sprintf("摘要第 %d/%d 块", i+1, len(chunks)))
+		}
+		partial, err := s.summarizeChunk(ctx, focus, chunk, chunkTarget)
+		if err != nil {
+			return "", err
+		}
+		partials = append(partials, partial)
+	}
+
+	combined := strings.Join(partials, "\n\n")
+	if len([]rune(combined)) >= length {
+		// No progress was made, summarize the combined text directly
+		return s.summarizeChunk(ctx, focus, combined, targetLength)
+	}
+
+	return s.summarize(ctx, focus, combined, targetLength, depth+1)
+}
+
+func (s *SummarizeSubagent) summarizeChunk(ctx context.Context, focus, chunk string, targetLength int) (string, error) {
+	systemPrompt := fmt.Sprintf("你是一个摘要助手，负责压缩信息。保留关键事实、数据、名称、URL 和图片链接，删除重复和无关内容。摘要长度不得超过 %d 个字符。", targetLength)
+	if focus != "" {
+		systemPrompt += "\n\n摘要重点：" + focus
+	}
+
+	resp, err := s.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: s.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("请摘要以下内容:\n\n%s", chunk),
+			},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// splitIntoChunks splits text into chunks of at most size runes, preferring
+// paragraph boundaries.
+func splitIntoChunks(text string, size int) []string {
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+
+	flush := func() {
+		if currentLen > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentLen = 0
+		}
+	}
+
+	for _, para := range strings.Split(text, "\n\n") {
+		runes := []rune(para)
+		// Hard-split paragraphs that are larger than a whole chunk
+		for len(runes) > size {
+			flush()
+			chunks = append(chunks, string(runes[:size]))
+			runes = runes[size:]
+		}
+		if currentLen > 0 && currentLen+2+len(runes) > size {
+			flush()
+		}
+		if currentLen > 0 {
+			current.WriteString("\n\n")
+			currentLen += 2
+		}
+		current.WriteString(string(runes))
+		currentLen += len(runes)
+	}
+	flush()
+
+	return chunks
+}
@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitIntoChunks(t *testing.T) {
+	text := strings.Join([]string{"aaaa", "bbbb", "cccc"}, "\n\n")
+
+	chunks := splitIntoChunks(text, 10)
+	assert.Equal(t, []string{"aaaa\n\nbbbb", "cccc"}, chunks)
+
+	chunks = splitIntoChunks(text, 100)
+	assert.Equal(t, []string{text}, chunks)
+}
+
+func TestSplitIntoChunks_LongParagraph(t *testing.T) {
+	chunks := splitIntoChunks(strings.Repeat("字", 25), 10)
+	assert.Len(t, chunks, 3)
+	for _, c := range chunks {
+		assert.LessOrEqual(t, len([]rune(c)), 10)
+	}
+}
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// codeBlockPattern matches fenced Markdown code blocks.
+var codeBlockPattern = regexp.MustCompile("(?s)```.*?```")
+
+// TranslationSubagent translates text while preserving its Markdown structure.
+type TranslationSubagent struct {
+	client  LLMClient
+	model   string
+	verbose bool
+	verboseLog
+	interactionHandler InteractionHandler
+}
+
+// NewTranslationSubagent creates a new TranslationSubagent.
+func NewTranslationSubagent(client LLMClient, model string, verbose bool, interactionHandler InteractionHandler) *TranslationSubagent {
+	return &TranslationSubagent{
+		client:             client,
+		model:              model,
+		verbose:            verbose,
+		interactionHandler: interactionHandler,
+	}
+}
+
+// Type returns the task type this subagent handles.
+func (t *TranslationSubagent) Type() TaskType {
+	return TaskTypeTranslate
+}
+
+// Execute translates the input text into the target language.
+func (t *TranslationSubagent) Execute(ctx context.Context, task Task) (result Result, err error) {
+	if t.verbose {
+		t.logln("🌍 翻译 Subagent")
+	}
+	if t.interactionHandler != nil {
+		t.interactionHandler.Log(fmt.Sprintf("> 翻译 Subagent: %s", task.Description))
+		t.interactionHandler.OnTaskStart(task)
+		defer func() { t.interactionHandler.OnTaskComplete(result) }()
+	}
+
+	// Get text from parameters or the previous REPORT task
+	text, ok := task.Parameters["text"].(string)
+	if !ok || text == "" {
+		if ctxContent, ok := task.Parameters["context"].([]string); ok && len(ctxContent) > 0 {
+			text = ctxContent[len(ctxContent)-1]
+			for i := len(ctxContent) - 1; i >= 0; i-- {
+				if strings.Contains(ctxContent[i], "Output from REPORT task:") {
+					text = ctxContent[i]
+					break
+				}
+			}
+			// Strip the "Output from X task:" header
+			if strings.HasPrefix(text, "Output from ") {
+				if idx := strings.Index(text, "\n"); idx != -1 {
+					text = text[idx+1:]
+				}
+			}
+			text = strings.TrimSpace(text)
+		} else {
+			text = task.Description
+		}
+	}
+
+	targetLang, _ := task.Parameters["targetLang"].(string)
+	if targetLang == "" {
+		targetLang = "English"
+	}
+	sourceLang, _ := task.Parameters["sourceLang"].(string)
+
+	if t.verbose {
+		t.logf("  正在翻译 %d 字节的内容为 %s\n", len(text), targetLang)
+	}
+
+	// Replace code blocks with placeholders so they are never translated
+	var codeBlocks []string
+	protected := codeBlockPattern.ReplaceAllStringFunc(text, func(block string) string {
+		placeholder := fmt.Sprintf("@@CODE_BLOCK_%d@@", len(codeBlocks))
+		codeBlocks = append(codeBlocks, block)
+		return placeholder
+	})
+
+	var langInstruction string
+	if sourceLang != "" {
+		langInstruction = fmt.Sprintf("将以下 %s 文本翻译为 %s。", sourceLang, targetLang)
+	} else {
+		langInstruction = fmt.Sprintf("自动检测以下文本的语言，并将其翻译为 %s。", targetLang)
+	}
+
+	systemPrompt := "你是一个专业的翻译助手。" + langInstruction + "\n" +
+		"要求：\n" +
+		"- 完整保留 Markdown 结构（标题、列表、表格、链接、图片语法）。\n" +
+		"- 不要翻译 URL 和行内代码。\n" +
+		"- 原样保留形如 @@CODE_BLOCK_0@@ 的占位符。\n" +
+		"- 仅输出译文，不要添加任何解释。"
+
+	resp, err := t.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: t.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: protected,
+			},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return Result{
+			TaskType: TaskTypeTranslate,
+			Success:  false,
+			Error:    err.Error(),
+		}, err
+	}
+
+	translated := resp.Choices[0].Message.Content
+	for i, block := range codeBlocks {
+		translated = strings.Replace(translated, fmt.Sprintf("@@CODE_BLOCK_%d@@", i), block, 1)
+	}
+
+	if t.verbose {
+		t.logf("  ✓ 翻译完成 (%d 字节)\n", len(translated))
+	}
+	if t.interactionHandler != nil {
+		t.interactionHandler.Log(fmt.Sprintf("✓ 翻译完成 (%d 字节)", len(translated)))
+	}
+
+	return Result{
+		TaskType: TaskTypeTranslate,
+		Success:  true,
+		Output:   translated,
+		Metadata: map[string]interface{}{
+			"targetLang": targetLang,
+			"sourceLang": sourceLang,
+		},
+	}, nil
+}
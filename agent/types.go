@@ -1,24 +1,42 @@
 package agent
 
-import "context"
+import (
+	"context"
+	"errors"
+	"text/template"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/tool"
+)
 
 // TaskType represents the type of task to be executed by a subagent.
 type TaskType string
 
 const (
-	TaskTypeSearch  TaskType = "SEARCH"
-	TaskTypeAnalyze TaskType = "ANALYZE"
-	TaskTypeReport  TaskType = "REPORT"
-	TaskTypeRender  TaskType = "RENDER"
-	TaskTypePodcast TaskType = "PODCAST"
-	TaskTypePPT     TaskType = "PPT"
+	TaskTypeSearch    TaskType = "SEARCH"
+	TaskTypeAnalyze   TaskType = "ANALYZE"
+	TaskTypeReport    TaskType = "REPORT"
+	TaskTypeRender    TaskType = "RENDER"
+	TaskTypePodcast   TaskType = "PODCAST"
+	TaskTypePPT       TaskType = "PPT"
+	TaskTypeSummarize TaskType = "SUMMARIZE"
+	TaskTypeTranslate TaskType = "TRANSLATE"
+	TaskTypeCode      TaskType = "CODE"
+	TaskTypeCritique  TaskType = "CRITIQUE"
 )
 
 // Task represents a subtask to be executed by a subagent.
 type Task struct {
+	// ID names the task for the DependsOn lists of other tasks.
+	ID          string                 `json:"id,omitempty"`
 	Type        TaskType               `json:"type"`
 	Description string                 `json:"description"`
 	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	// DependsOn lists the IDs of the tasks whose outputs this task needs.
+	// When any task of a plan has dependencies, the plan runs as a graph:
+	// independent tasks run in parallel and each task's context holds only
+	// the outputs of its dependencies.
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
 // Result contains the output from a subagent execution.
@@ -31,6 +49,34 @@ type Result struct {
 	NewTasks []Task                 `json:"new_tasks,omitempty"`
 }
 
+// TaskOutput is the output of an earlier task. The Orchestrator passes the
+// outputs a task may use, oldest first, as its "outputs" parameter
+// ([]TaskOutput), next to the same outputs as text in "context".
+type TaskOutput struct {
+	ID      string
+	Type    TaskType
+	Success bool
+	Output  string
+}
+
+// latestOutput returns the output of the most recent successful task of one
+// of types among the "outputs" parameter of task. ok is false when task has
+// no such output.
+func latestOutput(task Task, types ...TaskType) (output string, ok bool) {
+	outputs, _ := task.Parameters["outputs"].([]TaskOutput)
+	for i := len(outputs) - 1; i >= 0; i-- {
+		if !outputs[i].Success {
+			continue
+		}
+		for _, taskType := range types {
+			if outputs[i].Type == taskType {
+				return outputs[i].Output, true
+			}
+		}
+	}
+	return "", false
+}
+
 // Plan represents a collection of tasks with dependencies.
 type Plan struct {
 	Tasks       []Task `json:"tasks"`
@@ -43,6 +89,68 @@ type Subagent interface {
 	Type() TaskType
 }
 
+// SubagentOption customizes the model and sampling settings of an LLM-backed subagent.
+type SubagentOption func(*subagentOptions)
+
+type subagentOptions struct {
+	model       string
+	temperature float32
+	retryPolicy RetryPolicy
+	stream      bool
+	prompts     map[string]*template.Template
+	// contextBudget limits the earlier outputs in the prompt, see
+	// WithContextBudget.
+	contextBudget int
+	// wikipedia is the Wikipedia policy of the SearchSubagent, see
+	// WithWikipedia.
+	wikipedia WikipediaPolicy
+	// allowedCommands restricts the shell scripts of the CodeSubagent, see
+	// WithAllowedShellCommands.
+	allowedCommands []string
+	// checkSyntax compiles the Python code of the CodeSubagent before
+	// running it, see WithPythonSyntaxCheck.
+	checkSyntax bool
+}
+
+// WithModel overrides the model used by a subagent.
+func WithModel(model string) SubagentOption {
+	return func(o *subagentOptions) {
+		if model != "" {
+			o.model = model
+		}
+	}
+}
+
+// WithTemperature overrides the sampling temperature used by a subagent.
+func WithTemperature(temperature float32) SubagentOption {
+	return func(o *subagentOptions) {
+		o.temperature = temperature
+	}
+}
+
+// WithRetryPolicy overrides how a subagent retries transient LLM errors.
+func WithRetryPolicy(policy RetryPolicy) SubagentOption {
+	return func(o *subagentOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithStreaming makes a subagent stream its output through InteractionHandler.StreamChunk.
+// Only the ReportSubagent supports streaming.
+func WithStreaming(stream bool) SubagentOption {
+	return func(o *subagentOptions) {
+		o.stream = stream
+	}
+}
+
+func applySubagentOptions(model string, temperature float32, opts []SubagentOption) subagentOptions {
+	o := subagentOptions{model: model, temperature: temperature, retryPolicy: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
 // InteractionHandler defines methods for human-in-the-loop interaction.
 type InteractionHandler interface {
 	// ReviewPlan asks the user to review and potentially modify the plan.
@@ -53,6 +161,68 @@ type InteractionHandler interface {
 	// Returns true if confirmed.
 	ConfirmPodcastGeneration(report string) (bool, error)
 
+	// ConfirmCodeExecution asks the user if generated code may be executed.
+	// Returns true if confirmed.
+	ConfirmCodeExecution(language, code string) (bool, error)
+
+	// ApproveTool asks the user if a tool call requested by the model may run.
+	// Implementations can show the tool name, its arguments and its risk
+	// level, e.g. to warn more strongly about destructive calls.
+	// Returns true if approved.
+	ApproveTool(tc openai.ToolCall, risk tool.RiskLevel) (bool, error)
+
+	// AskClarification asks the user a clarifying question about their
+	// request and returns the answer. An empty answer skips the question
+	// and any further ones.
+	AskClarification(question string) (string, error)
+
 	// Log sends a log message to the user interface.
 	Log(message string)
+
+	// StreamChunk sends a partial piece of generated output (e.g. the report
+	// being written) to the user interface as soon as it is available.
+	StreamChunk(chunk string)
+
+	// OnTaskStart is called when a subagent starts executing a task.
+	OnTaskStart(task Task)
+
+	// OnTaskComplete is called when a subagent finishes a task, successfully or not.
+	OnTaskComplete(result Result)
+
+	// OnStepProgress reports progress within a task or run, with pct in [0, 1].
+	OnStepProgress(pct float64, msg string)
+
+	// ShouldCancel reports whether the user asked to stop the run. Cancellation
+	// is cooperative: it is only checked at iteration boundaries (between plan
+	// steps, search refinements, code attempts, summary chunks and runner
+	// iterations), so an in-flight LLM call finishes first. The run then stops
+	// with ErrCanceled and returns the partial results gathered so far.
+	ShouldCancel() bool
 }
+
+// ErrCanceled is returned when a run is stopped through InteractionHandler.ShouldCancel.
+var ErrCanceled = errors.New("run canceled by user")
+
+// shouldCancel reports whether handler asks to stop the run. A nil handler never cancels.
+func shouldCancel(handler InteractionHandler) bool {
+	return handler != nil && handler.ShouldCancel()
+}
+
+// BaseInteractionHandler provides no-op progress events and never cancels.
+// Embed it in an InteractionHandler implementation that does not need them.
+type BaseInteractionHandler struct{}
+
+// OnTaskStart does nothing.
+func (BaseInteractionHandler) OnTaskStart(task Task) {}
+
+// OnTaskComplete does nothing.
+func (BaseInteractionHandler) OnTaskComplete(result Result) {}
+
+// OnStepProgress does nothing.
+func (BaseInteractionHandler) OnStepProgress(pct float64, msg string) {}
+
+// AskClarification skips every question.
+func (BaseInteractionHandler) AskClarification(question string) (string, error) { return "", nil }
+
+// ShouldCancel always returns false.
+func (BaseInteractionHandler) ShouldCancel() bool { return false }
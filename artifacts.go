@@ -0,0 +1,170 @@
+package goskills
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/tool"
+)
+
+// Artifact is a file a run created or modified, through write_file or as a
+// side effect of a script or generated code.
+type Artifact struct {
+	Path string `json:"path"` // Absolute path of the file
+	Size int64  `json:"size"`
+	Tool string `json:"tool"` // Tool call that last wrote the file
+	New  bool   `json:"new"`  // Created rather than modified by the run
+}
+
+// maxSnapshotFiles limits the files compared to find the artifacts of a
+// script, so that running in a large directory stays cheap.
+const maxSnapshotFiles = 10000
+
+// fileState is what a snapshot compares to detect a changed file.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshotDirs records the regular files below dirs, skipping .git
+// directories, up to maxSnapshotFiles.
+func snapshotDirs(dirs ...string) map[string]fileState {
+	files := make(map[string]fileState)
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if len(files) >= maxSnapshotFiles {
+				return filepath.SkipAll
+			}
+			if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+				files[path] = fileState{info.Size(), info.ModTime()}
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// runsCode reports whether the tool runs a script or generated code, whose
+// files can only be found by comparing snapshots.
+func runsCode(name string, scriptMap map[string]string) bool {
+	if tool.RunsCode(name) {
+		return true
+	}
+	_, ok := scriptMap[name]
+	return ok
+}
+
+// watchArtifacts prepares to record the files written by tc and returns the
+// function that records them once the call has finished. Scripts are
+// watched in the skill directory, or its sandbox, only.
+func (a *Agent) watchArtifacts(tc openai.ToolCall, scriptMap map[string]string, skill SkillPackage) func(err error) {
+	name := tc.Function.Name
+	switch {
+	case name == "write_file":
+		var params struct {
+			FilePath string `json:"filePath"`
+		}
+		if decodeToolArguments(tc, &params) != nil || params.FilePath == "" {
+			break
+		}
+		path := a.writeFilePath(skill, params.FilePath)
+		_, statErr := os.Stat(path)
+		return func(err error) {
+			if err == nil {
+				a.addArtifact(path, name, statErr != nil)
+			}
+		}
+	case runsCode(name, scriptMap) && skill.Path != "":
+		before := snapshotDirs(skill.Path)
+		return func(error) {
+			// Failed scripts may still have produced files
+			for path, state := range snapshotDirs(skill.Path) {
+				if old, ok := before[path]; !ok || old != state {
+					a.addArtifact(path, name, !ok)
+				}
+			}
+		}
+	}
+	return func(error) {}
+}
+
+// addArtifact records that tool wrote path. A file keeps being new when a
+// later call of the run modifies it again.
+func (a *Agent) addArtifact(path, tool string, created bool) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+	for i, artifact := range a.artifacts {
+		if artifact.Path == path {
+			a.artifacts[i].Size = info.Size()
+			a.artifacts[i].Tool = tool
+			return
+		}
+	}
+	a.artifacts = append(a.artifacts, Artifact{Path: path, Size: info.Size(), Tool: tool, New: created})
+}
+
+// Artifacts returns the files created or modified by the current or last
+// run, in the order they were first written. Files written by scripts are
+// found by comparing the skill directory before and after each script, so
+// changes by other processes at the same time are included too, while files
+// scripts write elsewhere are not. Before a sandbox is removed, the
+// artifacts in it are copied to a temporary directory, which the caller
+// removes; see RunnerConfig.KeepSandbox.
+func (a *Agent) Artifacts() []Artifact {
+	return slices.Clone(a.artifacts)
+}
+
+// WriteArtifactsZip writes a zip archive of the artifacts to w, with each
+// file stored under its base name, or its path if base names collide.
+// Artifacts that no longer exist are skipped.
+func WriteArtifactsZip(w io.Writer, artifacts []Artifact) error {
+	names := make(map[string]int)
+	for _, artifact := range artifacts {
+		names[filepath.Base(artifact.Path)]++
+	}
+
+	zw := zip.NewWriter(w)
+	for _, artifact := range artifacts {
+		f, err := os.Open(artifact.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(artifact.Path)
+		if names[name] > 1 {
+			name = strings.TrimPrefix(filepath.ToSlash(artifact.Path), "/")
+		}
+		entry, err := zw.Create(name)
+		if err == nil {
+			_, err = io.Copy(entry, f)
+		}
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,56 @@
+package goskills
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.txt"), []byte("old"), 0o644))
+	arguments := []string{
+		fmt.Sprintf(`{"filePath":%q,"content":"new"}`, filepath.Join(dir, "existing.txt")),
+		fmt.Sprintf(`{"code":"echo generated > %s"}`, filepath.Join(dir, "generated.txt")),
+	}
+
+	client := agenttest.NewFakeClient(
+		agenttest.CallTools(agenttest.ToolCall("call_1", "write_file", arguments[0])),
+		agenttest.CallTools(agenttest.ToolCall("call_2", "run_shell_code", arguments[1])),
+		agenttest.Reply("Done."),
+	)
+
+	a, err := NewAgent(RunnerConfig{Client: client, AutoApproveTools: true, AutoApproveDestructive: true}, nil)
+	require.NoError(t, err)
+	_, err = a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: dir})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Artifact{
+		{Path: filepath.Join(dir, "existing.txt"), Size: 3, Tool: "write_file", New: false},
+		{Path: filepath.Join(dir, "generated.txt"), Size: 10, Tool: "run_shell_code", New: true},
+	}, a.Artifacts())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteArtifactsZip(&buf, a.Artifacts()))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	contents := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		contents[f.Name] = string(data)
+	}
+	assert.Equal(t, map[string]string{"existing.txt": "new", "generated.txt": "generated\n"}, contents)
+}
@@ -0,0 +1,138 @@
+package goskills
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// DefaultMaxInlineAttachmentBytes is the size up to which a text attachment
+// is included in the conversation when RunnerConfig.MaxInlineAttachmentBytes
+// is zero.
+const DefaultMaxInlineAttachmentBytes = 32 << 10 // 32 KiB
+
+// Attachment is an input of a run besides the prompt, such as a document to
+// analyze. Small text attachments are added to the conversation as user
+// messages before the prompt; larger or binary ones are offered as files the
+// model reads with read_file.
+type Attachment struct {
+	// Name is shown to the model, e.g. "report.csv". Empty uses the base
+	// name of Path.
+	Name string
+	// Content is the content of the attachment. When empty, it is read from
+	// Path.
+	Content string
+	// Path is the file of the attachment when Content is empty.
+	Path string
+	// MimeType is the media type shown to the model, e.g. "text/csv".
+	MimeType string
+}
+
+// RunWithAttachments is like Run, adding attachments to the conversation
+// before userPrompt.
+func (a *Agent) RunWithAttachments(ctx context.Context, userPrompt string, attachments []Attachment) (string, error) {
+	return a.run(ctx, userPrompt, runInput{history: a.cfg.History, attachments: attachments})
+}
+
+// RunWithAttachments runs userPrompt with attachments on a fresh Agent. It
+// is safe for concurrent use.
+func (r *Runner) RunWithAttachments(ctx context.Context, userPrompt string, attachments []Attachment) (string, error) {
+	return r.NewAgent().RunWithAttachments(ctx, userPrompt, attachments)
+}
+
+// maxInlineAttachmentBytes returns the size up to which text attachments
+// are inlined.
+func (a *Agent) maxInlineAttachmentBytes() int {
+	if a.cfg.MaxInlineAttachmentBytes == 0 {
+		return DefaultMaxInlineAttachmentBytes
+	}
+	return a.cfg.MaxInlineAttachmentBytes
+}
+
+// attachmentMessages returns a user message per attachment. Attachments
+// given as Content that are too large or binary are written to files in a
+// temporary directory, which cleanup removes, and recorded for checkpoints.
+func (a *Agent) attachmentMessages(attachments []Attachment) (messages []openai.ChatCompletionMessage, cleanup func(), err error) {
+	var dir string
+	a.attachmentFiles = nil
+	cleanup = func() {
+		if dir != "" {
+			os.RemoveAll(dir)
+		}
+	}
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
+
+	for i, attachment := range attachments {
+		name := attachment.Name
+		if name == "" && attachment.Path != "" {
+			name = filepath.Base(attachment.Path)
+		}
+		if name == "" {
+			name = fmt.Sprintf("attachment-%d", i+1)
+		}
+		description := fmt.Sprintf("%q", name)
+		if attachment.MimeType != "" {
+			description += " (" + attachment.MimeType + ")"
+		}
+
+		content, path := attachment.Content, ""
+		if content == "" {
+			if attachment.Path == "" {
+				return nil, cleanup, fmt.Errorf("attachment %s has neither content nor path", description)
+			}
+			if path, err = filepath.Abs(attachment.Path); err != nil {
+				return nil, cleanup, err
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, cleanup, fmt.Errorf("attachment %s: %w", description, err)
+			}
+			if info.Size() <= int64(a.maxInlineAttachmentBytes()) {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return nil, cleanup, fmt.Errorf("attachment %s: %w", description, err)
+				}
+				content = string(data)
+			}
+		}
+
+		if content != "" && len(content) <= a.maxInlineAttachmentBytes() && utf8.ValidString(content) && !strings.ContainsRune(content, 0) {
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("Attached file %s:\n<attachment name=%q>\n%s\n</attachment>", description, name, strings.TrimSuffix(content, "\n")),
+			})
+			continue
+		}
+
+		if path == "" {
+			if dir == "" {
+				if dir, err = os.MkdirTemp("", "goskills-attachments-*"); err != nil {
+					return nil, cleanup, err
+				}
+			}
+			path = filepath.Join(dir, fmt.Sprintf("%d-%s", i+1, filepath.Base(name)))
+			if err = os.WriteFile(path, []byte(content), 0o600); err != nil {
+				return nil, cleanup, err
+			}
+			a.attachmentFiles = append(a.attachmentFiles, CheckpointFile{Path: path, Content: []byte(content)})
+		}
+		size := len(content)
+		if info, err := os.Stat(path); err == nil {
+			size = int(info.Size())
+		}
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("Attached file %s, %d bytes, is too large or not text to include here. Read it with read_file from: %s", description, size, path),
+		})
+	}
+	return messages, cleanup, nil
+}
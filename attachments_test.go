@@ -0,0 +1,67 @@
+package goskills
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithAttachments(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(notes, []byte("Meeting at 10.\n"), 0644))
+	large := filepath.Join(dir, "large.log")
+	require.NoError(t, os.WriteFile(large, []byte(strings.Repeat("line\n", 100)), 0644))
+
+	client := agenttest.NewFakeClient(agenttest.Reply("analyze"), agenttest.Reply("done"))
+	a, err := NewAgent(RunnerConfig{
+		Client:                   client,
+		SkillProvider:            staticSkills{"analyze": {Path: dir, Meta: SkillMeta{Name: "analyze", Description: "Analyzes documents."}}},
+		MaxInlineAttachmentBytes: 200,
+	}, nil)
+	require.NoError(t, err)
+
+	output, err := a.RunWithAttachments(context.Background(), "analyze these", []Attachment{
+		{Name: "data.csv", Content: "a,b\n1,2\n", MimeType: "text/csv"},
+		{Path: notes},
+		{Path: large},
+		{Name: "logo.png", Content: "\x89PNG\x00\x01", MimeType: "image/png"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", output)
+
+	requests := client.Requests()
+	require.Len(t, requests, 2)
+	var users []string
+	for _, msg := range requests[1].Messages {
+		if msg.Role == openai.ChatMessageRoleUser {
+			users = append(users, msg.Content)
+		}
+	}
+	require.Len(t, users, 5)
+	assert.Equal(t, "Attached file \"data.csv\" (text/csv):\n<attachment name=\"data.csv\">\na,b\n1,2\n</attachment>", users[0])
+	assert.Contains(t, users[1], "Meeting at 10.")
+	assert.Contains(t, users[2], "500 bytes")
+	assert.Contains(t, users[2], "read_file from: "+large)
+	assert.Contains(t, users[3], `"logo.png" (image/png), 6 bytes`)
+	assert.Equal(t, "analyze these", users[4])
+
+	// Content offered as a file is removed after the run
+	_, path, ok := strings.Cut(users[3], "read_file from: ")
+	require.True(t, ok)
+	assert.NoFileExists(t, path)
+	// The attachments of one call are not reused by later runs of the Agent
+	assert.Nil(t, a.cfg.Attachments)
+
+	a.cfg.Client = agenttest.NewFakeClient(agenttest.Reply("analyze"))
+	a.client = a.cfg.Client
+	_, err = a.RunWithAttachments(context.Background(), "analyze", []Attachment{{Name: "empty"}})
+	assert.ErrorContains(t, err, "neither content nor path")
+}
@@ -0,0 +1,119 @@
+package goskills
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Approval decisions recorded in a ToolAuditEvent.
+const (
+	AuditApproved     = "approved"      // approved by the user or the InteractionHandler
+	AuditAutoApproved = "auto_approved" // RunnerConfig.AutoApproveTools was set
+	AuditDenied       = "denied"        // rejected by the user or the InteractionHandler
+	AuditNotAllowed   = "not_allowed"   // the tool was not offered to the skill
+	AuditDryRun       = "dry_run"       // recorded but not executed
+	AuditDeduplicated = "deduplicated"  // answered with the result of an identical call in the same turn
+)
+
+// ToolAuditEvent describes one tool call requested by the model.
+type ToolAuditEvent struct {
+	Time       time.Time `json:"time"`
+	Skill      string    `json:"skill"`
+	Tool       string    `json:"tool"`
+	CallID     string    `json:"call_id"`
+	Arguments  string    `json:"arguments"`
+	Decision   string    `json:"decision"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	OutputSize int       `json:"output_size"`
+	// FullOutput holds the complete output when the result sent to the
+	// model was truncated (see RunnerConfig.MaxToolOutputBytes).
+	FullOutput string        `json:"full_output,omitempty"`
+	Duration   time.Duration `json:"duration_ns"`
+}
+
+// AuditSink receives an event for every tool call, including calls that were
+// denied or never executed. Set RunnerConfig.AuditSink to enable auditing.
+type AuditSink interface {
+	Record(event ToolAuditEvent)
+}
+
+// JSONLAuditSink appends audit events to a file, one JSON object per line.
+// It is safe for concurrent use.
+type JSONLAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+	err  error
+}
+
+// NewJSONLAuditSink opens path for appending, creating it if needed.
+func NewJSONLAuditSink(path string) (*JSONLAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLAuditSink{file: f}, nil
+}
+
+// Record writes event as a single line. The first write error is kept and
+// returned by Close.
+func (s *JSONLAuditSink) Record(event ToolAuditEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return
+	}
+	if _, err := s.file.Write(data); err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// Close closes the file and reports the first error seen while recording.
+func (s *JSONLAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return s.err
+	}
+	err := s.file.Close()
+	s.file = nil
+	if s.err != nil {
+		return s.err
+	}
+	return err
+}
+
+// audit records a tool call on the configured AuditSink, if any.
+func (a *Agent) audit(skill SkillPackage, tc openai.ToolCall, decision string, started time.Time, output string, truncated bool, err error) {
+	if a.cfg.AuditSink == nil {
+		return
+	}
+	event := ToolAuditEvent{
+		Time:       started,
+		Skill:      skill.QualifiedName(),
+		Tool:       tc.Function.Name,
+		CallID:     tc.ID,
+		Arguments:  a.redact(tc.Function.Arguments),
+		Decision:   decision,
+		Success:    err == nil && (decision == AuditApproved || decision == AuditAutoApproved),
+		OutputSize: len(output),
+		Duration:   time.Since(started),
+	}
+	if truncated {
+		event.FullOutput = a.redact(output)
+	}
+	if err != nil {
+		event.Error = a.redact(err.Error())
+	}
+	a.cfg.AuditSink.Record(event)
+}
@@ -0,0 +1,49 @@
+package goskills
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewJSONLAuditSink(path)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	sink.Record(ToolAuditEvent{Time: now, Skill: "pdf", Tool: "read_file", Arguments: `{"filePath":"a.txt"}`, Decision: AuditAutoApproved, Success: true, OutputSize: 42})
+	sink.Record(ToolAuditEvent{Time: now, Skill: "pdf", Tool: "run_shell_code", Arguments: `{"code":"rm -rf /"}`, Decision: AuditDenied})
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var events []ToolAuditEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event ToolAuditEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 2)
+
+	assert.Equal(t, "read_file", events[0].Tool)
+	assert.True(t, events[0].Success)
+	assert.Equal(t, 42, events[0].OutputSize)
+	assert.True(t, events[0].Time.Equal(now))
+
+	assert.Equal(t, AuditDenied, events[1].Decision)
+	assert.False(t, events[1].Success)
+	assert.Equal(t, `{"code":"rm -rf /"}`, events[1].Arguments)
+
+	// Recording after Close is a no-op
+	sink.Record(ToolAuditEvent{Tool: "late"})
+}
@@ -0,0 +1,294 @@
+package goskills
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrCheckpointNotFound is returned by CheckpointStore.Load for an unknown ID.
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// Checkpoint is the state of a run before an iteration of its tool-calling
+// loop, from which ResumeRun continues it.
+type Checkpoint struct {
+	ID     string `json:"id"`
+	Skill  string `json:"skill"` // Qualified name of the running skill
+	Prompt string `json:"prompt"`
+	// Messages is the conversation so far, including the system prompt.
+	Messages []openai.ChatCompletionMessage `json:"messages"`
+	// Iteration is the number of iterations the run has completed.
+	Iteration int `json:"iteration"`
+	// Response is the final answer collected so far, when the model's
+	// output limit cut it off and it is being continued.
+	Response string     `json:"response,omitempty"`
+	Usage    TokenUsage `json:"usage"`
+	// Files are the attachments the run wrote to temporary files, which the
+	// conversation refers to by path and Resume restores.
+	Files   []CheckpointFile `json:"files,omitempty"`
+	Updated time.Time        `json:"updated"`
+}
+
+// CheckpointFile is a temporary file of a checkpointed run.
+type CheckpointFile struct {
+	Path    string `json:"path"`
+	Content []byte `json:"content"`
+}
+
+// CheckpointStore keeps the checkpoints of runs; see
+// RunnerConfig.CheckpointStore. Implementations must be safe for concurrent
+// use by runs with different IDs.
+type CheckpointStore interface {
+	// Save stores checkpoint, replacing the one with the same ID.
+	Save(ctx context.Context, checkpoint *Checkpoint) error
+	// Load returns the checkpoint with id, or ErrCheckpointNotFound.
+	Load(ctx context.Context, id string) (*Checkpoint, error)
+	// Delete removes the checkpoint with id, if any.
+	Delete(ctx context.Context, id string) error
+}
+
+// FileCheckpointStore stores each checkpoint as an indented JSON file named
+// after its ID in Dir, which is created as needed.
+type FileCheckpointStore struct {
+	Dir string
+}
+
+func (s FileCheckpointStore) path(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid checkpoint id %q", id)
+	}
+	return filepath.Join(s.Dir, id+".json"), nil
+}
+
+// Save implements CheckpointStore. The file is replaced atomically, so a
+// crash while saving keeps the previous checkpoint.
+func (s FileCheckpointStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	path, err := s.path(checkpoint.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Dir, "."+checkpoint.ID+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load implements CheckpointStore.
+func (s FileCheckpointStore) Load(ctx context.Context, id string) (*Checkpoint, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrCheckpointNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", id, err)
+	}
+	return &checkpoint, nil
+}
+
+// Delete implements CheckpointStore.
+func (s FileCheckpointStore) Delete(ctx context.Context, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// CheckpointID returns the ID the run checkpoints under, to pass to
+// ResumeRun. It is empty without RunnerConfig.CheckpointStore.
+func (a *Agent) CheckpointID() string {
+	return a.checkpointID
+}
+
+// newCheckpointID returns a unique ID of the form 20060102-150405-1a2b3c4d.
+func newCheckpointID() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// saveCheckpoint stores the state of the run before iteration. Failures are
+// logged, as they do not affect the run itself.
+func (a *Agent) saveCheckpoint(ctx context.Context, skill SkillPackage, prompt string, iteration int, response string) {
+	if a.cfg.CheckpointStore == nil || a.cfg.DryRun {
+		return
+	}
+	if a.checkpointID == "" {
+		a.checkpointID = a.cfg.CheckpointID
+		if a.checkpointID == "" {
+			a.checkpointID = newCheckpointID()
+		}
+	}
+	// Secrets are masked, as checkpoints outlive the run
+	messages := make([]openai.ChatCompletionMessage, len(a.messages))
+	for i, msg := range a.messages {
+		messages[i] = a.redactMessage(msg)
+	}
+	checkpoint := &Checkpoint{
+		ID:        a.checkpointID,
+		Skill:     skill.QualifiedName(),
+		Prompt:    a.redact(prompt),
+		Messages:  messages,
+		Iteration: iteration,
+		Response:  a.redact(response),
+		Usage:     a.usage,
+		Files:     a.attachmentFiles,
+		Updated:   time.Now(),
+	}
+	if err := a.cfg.CheckpointStore.Save(ctx, checkpoint); err != nil {
+		a.logAlways(ctx, slog.LevelWarn, "failed to save checkpoint",
+			fmt.Sprintf("⚠️ Failed to save checkpoint %s: %v\n", a.checkpointID, err),
+			slog.String("checkpoint", a.checkpointID), slog.Any("error", err))
+	}
+}
+
+// deleteCheckpoint removes the checkpoint of a run that completed.
+func (a *Agent) deleteCheckpoint(ctx context.Context) {
+	if a.cfg.CheckpointStore == nil || a.checkpointID == "" {
+		return
+	}
+	if err := a.cfg.CheckpointStore.Delete(ctx, a.checkpointID); err != nil {
+		a.logAlways(ctx, slog.LevelWarn, "failed to delete checkpoint",
+			fmt.Sprintf("⚠️ Failed to delete checkpoint %s: %v\n", a.checkpointID, err),
+			slog.String("checkpoint", a.checkpointID), slog.Any("error", err))
+	}
+}
+
+// ResumeRun continues the run saved under checkpointID in
+// cfg.CheckpointStore, e.g. after a crash or cancellation, with a new Agent
+// for cfg. See Agent.Resume.
+func ResumeRun(ctx context.Context, checkpointID string, cfg RunnerConfig) (string, error) {
+	a, err := NewAgent(cfg, nil)
+	if err != nil {
+		return "", err
+	}
+	return a.Resume(ctx, checkpointID)
+}
+
+// Resume continues the run saved under checkpointID in
+// RunnerConfig.CheckpointStore: the skill is looked up by name again, and
+// its conversation, token usage, iteration count and attachment files are
+// restored. The run keeps checkpointing under the same ID, which is deleted
+// once it completes. Secrets in the conversation stay masked.
+func (a *Agent) Resume(ctx context.Context, checkpointID string) (output string, err error) {
+	if a.cfg.CheckpointStore == nil {
+		return "", errors.New("resuming a run requires a CheckpointStore")
+	}
+	checkpoint, err := a.cfg.CheckpointStore.Load(ctx, checkpointID)
+	if err != nil {
+		return "", err
+	}
+	skills, err := a.discoverSkills(a.cfg.SkillsDir)
+	if err != nil {
+		return "", err
+	}
+	skill, ok := skills[checkpoint.Skill]
+	if !ok {
+		return "", fmt.Errorf("skill %s of checkpoint %s not found", checkpoint.Skill, checkpointID)
+	}
+	if err := a.prepareSkill(skill); err != nil {
+		return "", err
+	}
+	cleanup, err := restoreFiles(checkpoint.Files)
+	if err != nil {
+		return "", fmt.Errorf("restore the files of checkpoint %s: %w", checkpointID, err)
+	}
+	defer cleanup()
+	a.logEvent(ctx, slog.LevelInfo, "resuming run",
+		fmt.Sprintf("⏯️ Resuming %s from checkpoint %s (iteration %d)\n", skill.QualifiedName(), checkpointID, checkpoint.Iteration),
+		slog.String("skill", skill.QualifiedName()), slog.String("checkpoint", checkpointID),
+		slog.Int("iteration", checkpoint.Iteration))
+
+	original := skill
+	skill, err = a.enterSandbox(skill)
+	if err != nil {
+		return "", err
+	}
+	defer func() { a.leaveSandbox(err) }()
+
+	a.checkpointID = checkpoint.ID
+	a.messages = checkpoint.Messages
+	a.usage = checkpoint.Usage
+	a.attachmentFiles = checkpoint.Files
+	a.fingerprints = nil
+	a.artifacts = nil
+	a.startTrace(checkpoint.Prompt, skill)
+	a.traceMessages(a.messages...)
+	defer func() { a.finishTrace(output, err) }()
+
+	output, err = a.runToolLoop(ctx, checkpoint.Prompt, skill, checkpoint.Iteration, checkpoint.Response)
+	if err != nil {
+		return output, err
+	}
+	return a.postProcess(ctx, original, output)
+}
+
+// restoreFiles writes the files that no longer exist, such as the attachments
+// the interrupted run removed, and returns a function that removes them again.
+func restoreFiles(files []CheckpointFile) (cleanup func(), err error) {
+	var written, dirs []string
+	cleanup = func() {
+		for _, path := range written {
+			os.Remove(path)
+		}
+		for i := len(dirs) - 1; i >= 0; i-- {
+			os.Remove(dirs[i]) // Only removed when empty
+		}
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file.Path); err == nil {
+			continue
+		}
+		dir := filepath.Dir(file.Path)
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			if err := os.MkdirAll(dir, 0o700); err != nil {
+				cleanup()
+				return nil, err
+			}
+			dirs = append(dirs, dir)
+		}
+		if err := os.WriteFile(file.Path, file.Content, 0o600); err != nil {
+			cleanup()
+			return nil, err
+		}
+		written = append(written, file.Path)
+	}
+	return cleanup, nil
+}
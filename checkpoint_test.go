@@ -0,0 +1,144 @@
+package goskills
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointAndResume(t *testing.T) {
+	store := FileCheckpointStore{Dir: filepath.Join(t.TempDir(), "checkpoints")}
+	skills := staticSkills{"research": {Path: t.TempDir(), Meta: SkillMeta{Name: "research", Description: "Researches."}}}
+	reads := 0
+	cfg := RunnerConfig{
+		SkillProvider:    skills,
+		AutoApproveTools: true,
+		CheckpointStore:  store,
+		CheckpointID:     "run-1",
+		Tools: map[string]ToolFunc{
+			"read_file": func(ctx context.Context, arguments string) (string, error) {
+				reads++
+				return "notes", nil
+			},
+		},
+	}
+
+	// The run fails after its first tool call
+	first := agenttest.NewFakeClient(
+		agenttest.Reply("research"),
+		agenttest.CallTools(agenttest.ToolCall("call_1", "read_file", map[string]string{"filePath": "notes.txt"})),
+	)
+	cfg.Client = first
+	a, err := NewAgent(cfg, nil)
+	require.NoError(t, err)
+	_, err = a.Run(context.Background(), "summarize the notes")
+	require.ErrorIs(t, err, agenttest.ErrNoResponse)
+	assert.Equal(t, "run-1", a.CheckpointID())
+
+	checkpoint, err := store.Load(context.Background(), "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, "research", checkpoint.Skill)
+	assert.Equal(t, "summarize the notes", checkpoint.Prompt)
+	assert.Equal(t, 1, checkpoint.Iteration)
+	last := checkpoint.Messages[len(checkpoint.Messages)-1]
+	assert.Equal(t, openai.ChatMessageRoleTool, last.Role)
+	assert.Equal(t, "notes", last.Content)
+
+	// Resuming continues after the tool call without repeating it
+	second := agenttest.NewFakeClient(agenttest.Reply("summary"))
+	cfg.Client = second
+	output, err := ResumeRun(context.Background(), "run-1", cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "summary", output)
+	assert.Equal(t, 1, reads)
+	requests := second.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, checkpoint.Messages, requests[0].Messages)
+
+	_, err = store.Load(context.Background(), "run-1")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+	_, err = ResumeRun(context.Background(), "run-1", cfg)
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+}
+
+func TestCheckpointRedactsAndRestoresAttachments(t *testing.T) {
+	store := FileCheckpointStore{Dir: filepath.Join(t.TempDir(), "checkpoints")}
+	skills := staticSkills{"research": {Path: t.TempDir(), Meta: SkillMeta{Name: "research", Description: "Researches."}}}
+	var read []string
+	cfg := RunnerConfig{
+		APIKey:           "hunter2-secret",
+		SkillProvider:    skills,
+		AutoApproveTools: true,
+		CheckpointStore:  store,
+		CheckpointID:     "run-1",
+		Tools: map[string]ToolFunc{
+			"read_file": func(ctx context.Context, arguments string) (string, error) {
+				var params struct {
+					FilePath string `json:"filePath"`
+				}
+				require.NoError(t, json.Unmarshal([]byte(arguments), &params))
+				if params.FilePath == "notes.txt" {
+					return "the key is hunter2-secret", nil
+				}
+				data, err := os.ReadFile(params.FilePath)
+				read = append(read, string(data))
+				return string(data), err
+			},
+		},
+	}
+
+	cfg.Client = agenttest.NewFakeClient(
+		agenttest.Reply("research"),
+		agenttest.CallTools(agenttest.ToolCall("call_1", "read_file", map[string]string{"filePath": "notes.txt"})),
+	)
+	a, err := NewAgent(cfg, nil)
+	require.NoError(t, err)
+	_, err = a.RunWithAttachments(context.Background(), "summarize", []Attachment{{Name: "data.bin", Content: "bin\x00ary"}})
+	require.ErrorIs(t, err, agenttest.ErrNoResponse)
+
+	checkpoint, err := store.Load(context.Background(), "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, "the key is [REDACTED]", checkpoint.Messages[len(checkpoint.Messages)-1].Content)
+	require.Len(t, checkpoint.Files, 1)
+	path := checkpoint.Files[0].Path
+	assert.Equal(t, []byte("bin\x00ary"), checkpoint.Files[0].Content)
+	assert.NoFileExists(t, path, "the run removes its attachment files")
+
+	// The resumed run reads the attachment it was offered
+	cfg.Client = agenttest.NewFakeClient(
+		agenttest.CallTools(agenttest.ToolCall("call_2", "read_file", map[string]string{"filePath": path})),
+		agenttest.Reply("done"),
+	)
+	output, err := ResumeRun(context.Background(), "run-1", cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "done", output)
+	assert.Equal(t, []string{"bin\x00ary"}, read)
+	assert.NoFileExists(t, path)
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	store := FileCheckpointStore{Dir: t.TempDir()}
+	ctx := context.Background()
+
+	require.NoError(t, store.Save(ctx, &Checkpoint{ID: "a", Skill: "s", Iteration: 2}))
+	require.NoError(t, store.Save(ctx, &Checkpoint{ID: "a", Skill: "s", Iteration: 3}))
+	checkpoint, err := store.Load(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 3, checkpoint.Iteration)
+	entries, err := os.ReadDir(store.Dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, store.Delete(ctx, "a"))
+	require.NoError(t, store.Delete(ctx, "a"))
+	assert.Error(t, store.Save(ctx, &Checkpoint{ID: "../escape"}))
+	_, err = store.Load(ctx, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package goskills
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// defaultMaxClarifications is the number of clarifying questions asked when
+// RunnerConfig.MaxClarifications is zero.
+const defaultMaxClarifications = 3
+
+// clarifyReady is the reply of the model when the request needs no
+// clarification.
+const clarifyReady = "READY"
+
+// clarifySystemPrompt asks the model for one clarifying question at a time.
+const clarifySystemPrompt = `You decide whether a request is specific enough to be carried out with the skill described below. ` +
+	`If essential information is missing or the request is ambiguous, reply with one short clarifying question for the user, and nothing else. ` +
+	`If the request and the answers so far are enough, or further questions would not change the result much, reply with only ` + clarifyReady + `.`
+
+// maxClarifications returns the configured number of clarifying questions.
+func (a *Agent) maxClarifications() int {
+	if a.cfg.MaxClarifications > 0 {
+		return a.cfg.MaxClarifications
+	}
+	return defaultMaxClarifications
+}
+
+// clarify lets the model ask the user clarifying questions about userPrompt
+// when RunnerConfig.Clarify or the skill's clarify setting asks for it, and
+// returns userPrompt with the answers appended. The user skips the remaining
+// questions with an empty answer. Failures are logged and leave the prompt
+// as it is.
+func (a *Agent) clarify(ctx context.Context, userPrompt string, skill SkillPackage) string {
+	if !a.cfg.Clarify && !skill.Meta.Clarify {
+		return userPrompt
+	}
+
+	var answers []string
+	for range a.maxClarifications() {
+		question, err := a.clarifyingQuestion(ctx, userPrompt, skill, answers)
+		if err != nil {
+			a.logAlways(ctx, slog.LevelWarn, "clarification failed",
+				fmt.Sprintf("⚠️ Could not ask clarifying questions: %v\n", err),
+				slog.Any("error", err))
+			break
+		}
+		if question == "" {
+			break
+		}
+		answer, err := a.askUser(question)
+		if err != nil {
+			a.logAlways(ctx, slog.LevelWarn, "clarification failed",
+				fmt.Sprintf("⚠️ Could not ask clarifying questions: %v\n", err),
+				slog.Any("error", err))
+			break
+		}
+		if answer == "" {
+			break
+		}
+		a.logEvent(ctx, slog.LevelInfo, "clarification answered", "",
+			slog.String("question", question), slog.String("answer", a.redact(answer)))
+		answers = append(answers, fmt.Sprintf("Q: %s\nA: %s", question, answer))
+	}
+
+	if len(answers) == 0 {
+		return userPrompt
+	}
+	return userPrompt + "\n\nClarifications:\n" + strings.Join(answers, "\n")
+}
+
+// clarifyingQuestion returns the next question of the model, or "" when the
+// request is clear enough.
+func (a *Agent) clarifyingQuestion(ctx context.Context, userPrompt string, skill SkillPackage, answers []string) (string, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Skill: %s\nDescription: %s\n", skill.QualifiedName(), skill.Meta.Description)
+	for _, input := range skill.Meta.Inputs {
+		fmt.Fprintf(&sb, "Input %s: %s\n", input.Name, input.Description)
+	}
+	fmt.Fprintf(&sb, "\nUser Request: %s\n", userPrompt)
+	if len(answers) > 0 {
+		fmt.Fprintf(&sb, "\nAnswers so far:\n%s\n", strings.Join(answers, "\n"))
+	}
+
+	resp, err := a.createChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: a.skillModel(skill),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: clarifySystemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: sb.String()},
+		},
+		Temperature: 0,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty response")
+	}
+	question := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if question == "" || strings.EqualFold(strings.Trim(question, ".\"'"), clarifyReady) {
+		return "", nil
+	}
+	return question, nil
+}
+
+// askUser asks a clarifying question, preferring the configured
+// InteractionHandler and falling back to a stdin prompt.
+func (a *Agent) askUser(question string) (string, error) {
+	if a.cfg.InteractionHandler != nil {
+		answer, err := a.cfg.InteractionHandler.AskClarification(question)
+		return strings.TrimSpace(answer), err
+	}
+	a.logf("❓ %s\n   (press Enter to skip): ", question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", nil
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,67 @@
+package goskills
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clarifyClient asks "Which city?" until the last request of the user
+// mentions the clarifications, and records the requests as JSON.
+func clarifyClient(requests *[]string) *agenttest.FakeClient {
+	client := agenttest.NewFakeClient()
+	client.Respond = func(req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		body, _ := json.Marshal(req)
+		*requests = append(*requests, string(body))
+		switch {
+		case strings.Contains(string(body), "Answers so far") && strings.Contains(string(body), "Paris"):
+			return agenttest.Reply("READY"), nil
+		case !strings.Contains(string(body), "clarifying question"):
+			return agenttest.Reply("done"), nil
+		}
+		return agenttest.Reply("Which city?"), nil
+	}
+	return client
+}
+
+func TestClarify(t *testing.T) {
+	var requests []string
+	handler := &agenttest.TestInteractionHandler{Answers: []string{"Paris"}}
+	a, err := NewAgent(RunnerConfig{Client: clarifyClient(&requests), InteractionHandler: handler}, nil)
+	require.NoError(t, err)
+
+	skill := SkillPackage{Path: t.TempDir(), Meta: SkillMeta{Name: "weather", Description: "Reports the weather.", Clarify: true}}
+	output, err := a.executeSkillWithTools(context.Background(), "what's the weather?", skill)
+	require.NoError(t, err)
+	assert.Equal(t, "done", output)
+	assert.Equal(t, []string{"Which city?"}, handler.Questions())
+	require.Len(t, requests, 3)
+	assert.Contains(t, requests[2], `what's the weather?\n\nClarifications:\nQ: Which city?\nA: Paris`)
+}
+
+func TestClarifyBoundsAndSkip(t *testing.T) {
+	var requests []string
+	handler := &agenttest.TestInteractionHandler{Answers: []string{"London", "Rome", "Oslo"}}
+	a, err := NewAgent(RunnerConfig{Client: clarifyClient(&requests), InteractionHandler: handler, Clarify: true, MaxClarifications: 2}, nil)
+	require.NoError(t, err)
+	output := a.clarify(context.Background(), "weather", SkillPackage{Meta: SkillMeta{Name: "weather"}})
+	assert.Equal(t, "weather\n\nClarifications:\nQ: Which city?\nA: London\nQ: Which city?\nA: Rome", output)
+	assert.Len(t, handler.Questions(), 2)
+
+	// An empty answer skips the remaining questions
+	handler = &agenttest.TestInteractionHandler{}
+	a.cfg.InteractionHandler = handler
+	assert.Equal(t, "weather", a.clarify(context.Background(), "weather", SkillPackage{}))
+	assert.Len(t, handler.Questions(), 1)
+
+	// Skills that do not ask for it are not clarified
+	a.cfg.Clarify = false
+	assert.Equal(t, "weather", a.clarify(context.Background(), "weather", SkillPackage{}))
+	assert.Len(t, handler.Questions(), 1)
+}
@@ -2,18 +2,23 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
+	openai "github.com/sashabaranov/go-openai"
 	"github.com/smallnest/goskills/agent"
 	"github.com/smallnest/goskills/config"
+	"github.com/smallnest/goskills/tool"
 	"github.com/spf13/cobra"
 )
 
 // CLIInteractionHandler implements agent.InteractionHandler for the CLI.
 type CLIInteractionHandler struct {
+	agent.BaseInteractionHandler
 	scanner *bufio.Scanner
 }
 
@@ -57,10 +62,51 @@ func (h *CLIInteractionHandler) ConfirmPodcastGeneration(report string) (bool, e
 	return strings.EqualFold(input, "y") || strings.EqualFold(input, "yes"), nil
 }
 
+func (h *CLIInteractionHandler) ConfirmCodeExecution(language, code string) (bool, error) {
+	fmt.Printf("\n💻 Generated %s code:\n%s\n", language, code)
+	fmt.Print("\n\033[1;33mDo you want to execute this code? (y/N):\033[0m ")
+	if !h.scanner.Scan() {
+		return false, h.scanner.Err()
+	}
+	input := strings.TrimSpace(h.scanner.Text())
+
+	return strings.EqualFold(input, "y") || strings.EqualFold(input, "yes"), nil
+}
+
+func (h *CLIInteractionHandler) ApproveTool(tc openai.ToolCall, risk tool.RiskLevel) (bool, error) {
+	fmt.Printf("\n⚙️ Tool call: %s (%s)\n", tc.Function.Name, risk)
+	var args bytes.Buffer
+	if err := json.Indent(&args, []byte(tc.Function.Arguments), "", "  "); err == nil {
+		fmt.Println(args.String())
+	} else {
+		fmt.Println(tc.Function.Arguments)
+	}
+	fmt.Print("\033[1;33mAllow this tool execution? (y/N):\033[0m ")
+	if !h.scanner.Scan() {
+		return false, h.scanner.Err()
+	}
+	input := strings.TrimSpace(h.scanner.Text())
+
+	return strings.EqualFold(input, "y") || strings.EqualFold(input, "yes"), nil
+}
+
+func (h *CLIInteractionHandler) AskClarification(question string) (string, error) {
+	fmt.Printf("\n❓ %s\n", question)
+	fmt.Print("\033[1;33mAnswer (press Enter to skip):\033[0m ")
+	if !h.scanner.Scan() {
+		return "", h.scanner.Err()
+	}
+	return strings.TrimSpace(h.scanner.Text()), nil
+}
+
 func (h *CLIInteractionHandler) Log(message string) {
 	fmt.Println(message)
 }
 
+func (h *CLIInteractionHandler) StreamChunk(chunk string) {
+	fmt.Print(chunk)
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "agent-cli",
 	Short: "A deep agents CLI tool with planning and specialized subagents.",
@@ -86,10 +132,20 @@ Special commands:
 		}
 
 		agentConfig := agent.AgentConfig{
-			APIKey:  cfg.APIKey,
-			APIBase: cfg.APIBase,
-			Model:   cfg.Model,
-			Verbose: cfg.Verbose,
+			Provider: cfg.Provider,
+			APIKey:   cfg.APIKey,
+			APIBase:  cfg.APIBase,
+			Model:    cfg.Model,
+			Verbose:  cfg.Verbose,
+			// The CODE subagent runs generated code, which is destructive
+			AutoApproveTools:     cfg.AutoApproveTools && cfg.AutoApproveDestructive,
+			AllowedShellCommands: cfg.AllowedShellCommands,
+			CheckPythonSyntax:    cfg.CheckPythonSyntax,
+			Seed:                 cfg.Seed,
+		}
+
+		if cfg.CacheDir != "" {
+			tool.SetResultCache(&tool.ResultCache{Dir: cfg.CacheDir, TTL: cfg.CacheTTL, Bypass: cfg.NoCache})
 		}
 
 		ctx := context.Background()
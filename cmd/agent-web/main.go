@@ -4,6 +4,7 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
@@ -11,9 +12,12 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	openai "github.com/sashabaranov/go-openai"
 	"github.com/smallnest/goskills/agent"
+	"github.com/smallnest/goskills/tool"
 	"github.com/spf13/cobra"
 )
 
@@ -21,13 +25,14 @@ import (
 var uiAssets embed.FS
 
 var (
-	apiKey  string
-	apiBase string
-	model   string
-	addr    string
-	verbose bool
-	ppt     bool
-	podcast bool
+	provider string
+	apiKey   string
+	apiBase  string
+	model    string
+	addr     string
+	verbose  bool
+	ppt      bool
+	podcast  bool
 )
 
 // WebInteractionHandler implements agent.InteractionHandler for the web interface.
@@ -38,15 +43,19 @@ type WebInteractionHandler struct {
 	mu           sync.Mutex
 	sessionID    string
 	userRequest  string
+	canceled     atomic.Bool
 }
 
 type Event struct {
-	Type      string      `json:"type"`
-	Content   string      `json:"content,omitempty"`
-	Plan      *agent.Plan `json:"plan,omitempty"`
-	Podcast   interface{} `json:"podcast,omitempty"`
-	PPT       string      `json:"ppt,omitempty"`
-	Timestamp time.Time   `json:"timestamp"`
+	Type      string        `json:"type"`
+	Content   string        `json:"content,omitempty"`
+	Plan      *agent.Plan   `json:"plan,omitempty"`
+	Task      *agent.Task   `json:"task,omitempty"`
+	Result    *agent.Result `json:"result,omitempty"`
+	Progress  float64       `json:"progress,omitempty"`
+	Podcast   interface{}   `json:"podcast,omitempty"`
+	PPT       string        `json:"ppt,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
 }
 
 func NewWebInteractionHandler(sessionID, userRequest string) *WebInteractionHandler {
@@ -76,6 +85,23 @@ func (h *WebInteractionHandler) ConfirmPodcastGeneration(report string) (bool, e
 	return true, nil
 }
 
+func (h *WebInteractionHandler) ConfirmCodeExecution(language, code string) (bool, error) {
+	// The web interface has no approval dialog for code, so deny by default
+	h.Log(fmt.Sprintf("⚠️ Code execution (%s) requires approval and is disabled in the web interface.", language))
+	return false, nil
+}
+
+func (h *WebInteractionHandler) ApproveTool(tc openai.ToolCall, risk tool.RiskLevel) (bool, error) {
+	// The web interface has no approval dialog for tools, so deny by default
+	h.Log(fmt.Sprintf("⚠️ Tool call %s (%s) requires approval and is disabled in the web interface.", tc.Function.Name, risk))
+	return false, nil
+}
+
+func (h *WebInteractionHandler) AskClarification(question string) (string, error) {
+	// The web interface has no question dialog, so skip clarifications
+	return "", nil
+}
+
 func (h *WebInteractionHandler) Log(message string) {
 	h.Broadcast(Event{
 		Type:      "log",
@@ -84,6 +110,48 @@ func (h *WebInteractionHandler) Log(message string) {
 	})
 }
 
+func (h *WebInteractionHandler) StreamChunk(chunk string) {
+	// Stream chunks are not recorded in the session; the final report is,
+	// with the task_complete event of the REPORT task.
+	h.eventChan <- Event{
+		Type:      "stream",
+		Content:   chunk,
+		Timestamp: time.Now(),
+	}
+}
+
+func (h *WebInteractionHandler) OnTaskStart(task agent.Task) {
+	// Drop the parameters, they carry the full accumulated context
+	h.Broadcast(Event{
+		Type: "task_start",
+		Task: &agent.Task{Type: task.Type, Description: task.Description},
+	})
+}
+
+func (h *WebInteractionHandler) OnTaskComplete(result agent.Result) {
+	h.Broadcast(Event{
+		Type:   "task_complete",
+		Result: &result,
+	})
+}
+
+func (h *WebInteractionHandler) OnStepProgress(pct float64, msg string) {
+	h.Broadcast(Event{
+		Type:     "progress",
+		Content:  msg,
+		Progress: pct,
+	})
+}
+
+func (h *WebInteractionHandler) ShouldCancel() bool {
+	return h.canceled.Load()
+}
+
+// Cancel asks the running plan to stop at the next step boundary.
+func (h *WebInteractionHandler) Cancel() {
+	h.canceled.Store(true)
+}
+
 func (h *WebInteractionHandler) Broadcast(event Event) {
 	if event.Timestamp.IsZero() {
 		event.Timestamp = time.Now()
@@ -218,6 +286,7 @@ func main() {
 		Run:   runServer,
 	}
 
+	rootCmd.Flags().StringVar(&provider, "provider", "", "LLM provider: openai (default), anthropic or gemini")
 	rootCmd.Flags().StringVar(&apiKey, "api-key", os.Getenv("OPENAI_API_KEY"), "OpenAI API Key")
 	rootCmd.Flags().StringVar(&apiBase, "api-base", os.Getenv("OPENAI_API_BASE"), "OpenAI API Base URL")
 	rootCmd.Flags().StringVar(&model, "model", os.Getenv("OPENAI_MODEL"), "OpenAI Model")
@@ -239,6 +308,7 @@ func runServer(cmd *cobra.Command, args []string) {
 
 	// Initialize agent config template
 	configTemplate := agent.AgentConfig{
+		Provider:   provider,
 		APIKey:     apiKey,
 		APIBase:    apiBase,
 		Model:      model,
@@ -340,6 +410,8 @@ func runServer(cmd *cobra.Command, args []string) {
 		session.Handler.mu.Lock()
 		session.Handler.userRequest = req.Message
 		session.Handler.mu.Unlock()
+		// A new request starts uncanceled
+		session.Handler.canceled.Store(false)
 
 		// Run agent in a goroutine
 		go func() {
@@ -388,7 +460,10 @@ func runServer(cmd *cobra.Command, args []string) {
 
 			// Execute
 			results, err := planningAgent.Execute(context.Background(), plan)
-			if err != nil {
+			if errors.Is(err, agent.ErrCanceled) {
+				// Show whatever was produced before the stop
+				handler.Log("⏹️ 已停止，显示部分结果")
+			} else if err != nil {
 				handler.Broadcast(Event{
 					Type:    "error",
 					Content: err.Error(),
@@ -479,6 +554,30 @@ func runServer(cmd *cobra.Command, args []string) {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	http.HandleFunc("/api/cancel", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			SessionID string `json:"session_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		session := sessionManager.GetSession(req.SessionID)
+		if session == nil {
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+
+		session.Handler.Cancel()
+		w.WriteHeader(http.StatusOK)
+	})
+
 	http.HandleFunc("/api/config", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]bool{
@@ -16,19 +16,25 @@ Claude skill packages and prints a summary of each one found.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		skillsRoot := args[0]
 
-		packages, err := goskills.ParseSkillPackages(skillsRoot)
+		skills, err := goskills.ListSkills(skillsRoot)
 		if err != nil {
 			return fmt.Errorf("could not parse skills in directory '%s': %w", skillsRoot, err)
 		}
 
 		fmt.Printf("--- Skills found in %s ---\n", skillsRoot)
-		if len(packages) == 0 {
-			fmt.Println("No valid skills found.")
-			return nil
+		found := false
+		for _, skill := range skills {
+			if !skill.Valid {
+				continue
+			}
+			found = true
+			fmt.Printf("- %-20s: %s\n", skill.Name, skill.Description)
+			for _, warning := range skill.Warnings {
+				fmt.Printf("  ⚠️  %s\n", warning)
+			}
 		}
-
-		for _, skillPackage := range packages {
-			fmt.Printf("- %-20s: %s\n", skillPackage.Meta.Name, skillPackage.Meta.Description)
+		if !found {
+			fmt.Println("No valid skills found.")
 		}
 
 		return nil
@@ -37,6 +37,9 @@ var parseCmd = &cobra.Command{
 		if skillPackage.Meta.Version != "" {
 			fmt.Printf("Version: %s\n", skillPackage.Meta.Version)
 		}
+		if skillPackage.Meta.MinGoskillsVersion != "" {
+			fmt.Printf("Min goskills Version: %s\n", skillPackage.Meta.MinGoskillsVersion)
+		}
 		if skillPackage.Meta.License != "" {
 			fmt.Printf("License: %s\n", skillPackage.Meta.License)
 		}
@@ -32,7 +32,7 @@ var searchCmd = &cobra.Command{
 			description := strings.ToLower(skillPackage.Meta.Description)
 
 			if strings.Contains(name, query) || strings.Contains(description, query) {
-				fmt.Printf("- %-20s: %s\n", skillPackage.Meta.Name, skillPackage.Meta.Description)
+				fmt.Printf("- %-20s: %s\n", skillPackage.QualifiedName(), skillPackage.Meta.Description)
 				foundCount++
 			}
 		}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/smallnest/goskills"
+	"github.com/spf13/cobra"
+)
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools [path]",
+	Short: "Lists the tools a skill offers to the model.",
+	Long: `The tools command parses a skill package and lists the tools, with their
+parameters, that the runner offers to the model for this skill, including one
+tool per script. Use it to check why a script is not offered or how its schema
+looks.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		skillPackage, err := goskills.ParseSkillPackage(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to parse skill: %w", err)
+		}
+
+		fmt.Print(goskills.DescribeTools(*skillPackage))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(toolsCmd)
+}
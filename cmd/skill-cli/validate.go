@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/smallnest/goskills"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Validates all skills in a given directory.",
+	Long: `The validate command parses every skill package in a directory and reports
+skills that fail to parse, are missing a name, description or body, or reuse
+the name of another skill. It exits with an error if any problem is found,
+so it can be used to lint a skills directory in CI.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		skillsRoot := args[0]
+
+		packages, problems, err := goskills.ParseSkillPackagesStrict(skillsRoot)
+		if err != nil {
+			return fmt.Errorf("could not parse skills in directory '%s': %w", skillsRoot, err)
+		}
+
+		for _, problem := range problems {
+			fmt.Printf("✗ %v\n", problem)
+		}
+		fmt.Printf("%d valid skills, %d problems found.\n", len(packages), len(problems))
+
+		if len(problems) > 0 {
+			return fmt.Errorf("skill validation failed")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
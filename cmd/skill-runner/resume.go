@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/smallnest/goskills"
+	"github.com/smallnest/goskills/config"
+	"github.com/spf13/cobra"
+)
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume <checkpoint-id>",
+	Short: "Continues a run from its checkpoint.",
+	Long: `Continues a run that was interrupted, e.g. by Ctrl-C or a crash, from the checkpoint
+it saved with --checkpoint-dir. Pass the same --checkpoint-dir and skills directory as the run.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if cfg.CheckpointDir == "" {
+			return errors.New("--checkpoint-dir is required")
+		}
+
+		runnerCfg := newRunnerConfig(cfg)
+		if cfg.AuditLog != "" {
+			auditSink, err := goskills.NewJSONLAuditSink(cfg.AuditLog)
+			if err != nil {
+				return fmt.Errorf("failed to open audit log: %w", err)
+			}
+			defer auditSink.Close()
+			runnerCfg.AuditSink = auditSink
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		mcpClient := newMCPClient(ctx, cfg)
+		if mcpClient != nil {
+			defer mcpClient.Close()
+		}
+
+		agent, err := goskills.NewAgent(runnerCfg, mcpClient)
+		if err != nil {
+			return fmt.Errorf("failed to create agent: %w", err)
+		}
+
+		result, err := agent.Resume(ctx, args[0])
+		if err != nil {
+			if result != "" && (ctx.Err() != nil || errors.Is(err, goskills.ErrBudgetExceeded)) {
+				fmt.Println(result)
+			}
+			printResumeHint(agent, cfg.CheckpointDir)
+			return err
+		}
+		fmt.Println(result)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(resumeCmd)
+	config.SetupFlags(resumeCmd)
+}
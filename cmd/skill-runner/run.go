@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/smallnest/goskills"
@@ -44,59 +46,29 @@ You can specify a custom model and API base URL using flags.`,
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		runnerCfg := goskills.RunnerConfig{
-			APIKey:           cfg.APIKey,
-			APIBase:          cfg.APIBase,
-			Model:            cfg.Model,
-			SkillsDir:        cfg.SkillsDir,
-			Verbose:          cfg.Verbose,
-			AutoApproveTools: cfg.AutoApproveTools,
-			AllowedScripts:   cfg.AllowedScripts,
-			Loop:             cfg.Loop,
+		runnerCfg := newRunnerConfig(cfg)
+		attachPaths, err := cmd.Flags().GetStringSlice("attach")
+		if err != nil {
+			return err
 		}
-
-		ctx := context.Background()
-
-		// Initialize MCP Client
-		var mcpClient *goskills_mcp.Client
-		var mcpConfigPath string
-
-		if cfg.McpConfig != "" {
-			mcpConfigPath = cfg.McpConfig
-		} else {
-			// Check local mcp.json
-			if _, err := os.Stat("mcp.json"); err == nil {
-				mcpConfigPath = "mcp.json"
-			} else {
-				// // Check ~/.claude.json
-				// homeDir, err := os.UserHomeDir()
-				// if err == nil {
-				// 	path := fmt.Sprintf("%s/.claude.json", homeDir)
-				// 	if _, err := os.Stat(path); err == nil {
-				// 		mcpConfigPath = path
-				// 	}
-				// }
-			}
+		for _, path := range attachPaths {
+			runnerCfg.Attachments = append(runnerCfg.Attachments, goskills.Attachment{Path: path})
 		}
 
-		if mcpConfigPath != "" {
-			if cfg.Verbose {
-				fmt.Printf("📂 Loading MCP config from: %s\n", mcpConfigPath)
-			}
-			mcpConfig, err := goskills_mcp.LoadConfig(mcpConfigPath)
+		if cfg.AuditLog != "" {
+			auditSink, err := goskills.NewJSONLAuditSink(cfg.AuditLog)
 			if err != nil {
-				fmt.Printf("⚠️ Failed to load MCP config: %v\n", err)
-			} else {
-				mcpClient, err = goskills_mcp.NewClient(ctx, mcpConfig)
-				if err != nil {
-					fmt.Printf("⚠️ Failed to create MCP client: %v\n", err)
-				} else {
-					defer mcpClient.Close()
-					if cfg.Verbose {
-						fmt.Println("✅ MCP Client initialized.")
-					}
-				}
+				return fmt.Errorf("failed to open audit log: %w", err)
 			}
+			defer auditSink.Close()
+			runnerCfg.AuditSink = auditSink
+		}
+
+		ctx := context.Background()
+
+		mcpClient := newMCPClient(ctx, cfg)
+		if mcpClient != nil {
+			defer mcpClient.Close()
 		}
 
 		agent, err := goskills.NewAgent(runnerCfg, mcpClient)
@@ -108,17 +80,119 @@ You can specify a custom model and API base URL using flags.`,
 			return agent.RunLoop(ctx, userPrompt)
 		}
 
+		// Ctrl-C stops the run but still prints what it produced so far
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+
 		result, err := agent.Run(ctx, userPrompt)
 		if err != nil {
+			if result != "" && (ctx.Err() != nil || errors.Is(err, goskills.ErrBudgetExceeded)) {
+				fmt.Println(result)
+			}
+			printResumeHint(agent, cfg.CheckpointDir)
 			return err
 		}
 
 		fmt.Println(result)
+		if artifacts := agent.Artifacts(); len(artifacts) > 0 {
+			fmt.Fprintln(os.Stderr, "📦 Files written by the skill:")
+			for _, artifact := range artifacts {
+				fmt.Fprintf(os.Stderr, "  - %s (%d bytes)\n", artifact.Path, artifact.Size)
+			}
+		}
 		return nil
 	},
 }
 
+// newRunnerConfig returns the runner configuration of the command line
+// flags shared by run and resume.
+func newRunnerConfig(cfg *config.Config) goskills.RunnerConfig {
+	runnerCfg := goskills.RunnerConfig{
+		Provider:               cfg.Provider,
+		APIKey:                 cfg.APIKey,
+		APIBase:                cfg.APIBase,
+		Model:                  cfg.Model,
+		SkillsDir:              cfg.SkillsDir,
+		Verbose:                cfg.Verbose,
+		AutoApproveTools:       cfg.AutoApproveTools,
+		AutoApproveDestructive: cfg.AutoApproveDestructive,
+		AllowedScripts:         cfg.AllowedScripts,
+		AllowedShellCommands:   cfg.AllowedShellCommands,
+		DisabledTools:          cfg.DisabledTools,
+		CacheDir:               cfg.CacheDir,
+		CacheTTL:               cfg.CacheTTL,
+		CacheBypass:            cfg.NoCache,
+		DefaultSkill:           cfg.DefaultSkill,
+		KeywordPreMatch:        cfg.KeywordMatch,
+		Loop:                   cfg.Loop,
+		DryRun:                 cfg.DryRun,
+		CheckPythonSyntax:      cfg.CheckPythonSyntax,
+		ScriptOutputEncoding:   cfg.ScriptOutputEncoding,
+		Seed:                   cfg.Seed,
+		TraceFile:              cfg.TraceFile,
+		Sandbox:                cfg.Sandbox,
+		Clarify:                cfg.Clarify,
+		KeepSandbox:            cfg.KeepSandbox,
+	}
+	if cfg.CheckpointDir != "" {
+		runnerCfg.CheckpointStore = goskills.FileCheckpointStore{Dir: cfg.CheckpointDir}
+	}
+	return runnerCfg
+}
+
+// newMCPClient connects to the MCP servers of --mcp-config, or of mcp.json in
+// the working directory. Failures are reported and yield a nil client, so the
+// run continues without MCP tools.
+func newMCPClient(ctx context.Context, cfg *config.Config) *goskills_mcp.Client {
+	var mcpClient *goskills_mcp.Client
+	var mcpConfigPath string
+
+	if cfg.McpConfig != "" {
+		mcpConfigPath = cfg.McpConfig
+	} else {
+		// Check local mcp.json
+		if _, err := os.Stat("mcp.json"); err == nil {
+			mcpConfigPath = "mcp.json"
+		} else {
+			// // Check ~/.claude.json
+			// homeDir, err := os.UserHomeDir()
+			// if err == nil {
+			// 	path := fmt.Sprintf("%s/.claude.json", homeDir)
+			// 	if _, err := os.Stat(path); err == nil {
+			// 		mcpConfigPath = path
+			// 	}
+			// }
+		}
+	}
+
+	if mcpConfigPath != "" {
+		if cfg.Verbose {
+			fmt.Printf("📂 Loading MCP config from: %s\n", mcpConfigPath)
+		}
+		mcpConfig, err := goskills_mcp.LoadConfig(mcpConfigPath)
+		if err != nil {
+			fmt.Printf("⚠️ Failed to load MCP config: %v\n", err)
+		} else {
+			mcpClient, err = goskills_mcp.NewClient(ctx, mcpConfig)
+			if err != nil {
+				fmt.Printf("⚠️ Failed to create MCP client: %v\n", err)
+			} else if cfg.Verbose {
+				fmt.Println("✅ MCP Client initialized.")
+			}
+		}
+	}
+	return mcpClient
+}
+
+// printResumeHint tells how to resume a failed run from its checkpoint.
+func printResumeHint(agent *goskills.Agent, checkpointDir string) {
+	if id := agent.CheckpointID(); id != "" {
+		fmt.Fprintf(os.Stderr, "⏸️ Run checkpointed as %s; continue it with: goskills-runner resume --checkpoint-dir %s %s\n", id, checkpointDir, id)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(runCmd)
 	config.SetupFlags(runCmd)
+	runCmd.Flags().StringSlice("attach", nil, "Attach a file to the request, e.g. a document to analyze (repeatable)")
 }
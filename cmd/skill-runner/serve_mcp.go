@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/smallnest/goskills"
+	"github.com/smallnest/goskills/config"
+	"github.com/spf13/cobra"
+)
+
+var serveMCPCmd = &cobra.Command{
+	Use:   "serve-mcp",
+	Short: "Exposes the skills and built-in tools as an MCP server.",
+	Long: `Starts an MCP (Model Context Protocol) server over streamable HTTP.
+
+Each discovered skill becomes a "skill_<name>" tool taking a prompt, and the
+built-in tools are exposed with their usual schemas, so MCP clients can use
+goskills as a tool provider.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		addr, err := cmd.Flags().GetString("addr")
+		if err != nil {
+			return err
+		}
+
+		runnerCfg := goskills.RunnerConfig{
+			Provider:               cfg.Provider,
+			APIKey:                 cfg.APIKey,
+			APIBase:                cfg.APIBase,
+			Model:                  cfg.Model,
+			SkillsDir:              cfg.SkillsDir,
+			Verbose:                cfg.Verbose,
+			AutoApproveTools:       cfg.AutoApproveTools,
+			AutoApproveDestructive: cfg.AutoApproveDestructive,
+			AllowedScripts:         cfg.AllowedScripts,
+			AllowedShellCommands:   cfg.AllowedShellCommands,
+			CheckPythonSyntax:      cfg.CheckPythonSyntax,
+			ScriptOutputEncoding:   cfg.ScriptOutputEncoding,
+			Seed:                   cfg.Seed,
+			CacheDir:               cfg.CacheDir,
+			CacheTTL:               cfg.CacheTTL,
+			CacheBypass:            cfg.NoCache,
+		}
+
+		if cfg.AuditLog != "" {
+			auditSink, err := goskills.NewJSONLAuditSink(cfg.AuditLog)
+			if err != nil {
+				return fmt.Errorf("failed to open audit log: %w", err)
+			}
+			defer auditSink.Close()
+			runnerCfg.AuditSink = auditSink
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔌 MCP server listening on http://%s\n", listener.Addr())
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return goskills.ServeMCP(ctx, runnerCfg, listener)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveMCPCmd)
+	config.SetupFlags(serveMCPCmd)
+	serveMCPCmd.Flags().String("addr", "127.0.0.1:8765", "Address to listen on")
+}
@@ -0,0 +1,143 @@
+package goskills
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Defaults for history compaction, used when the RunnerConfig fields are zero.
+const (
+	DefaultCompactThresholdTokens = 96000
+	DefaultCompactKeepMessages    = 6
+)
+
+// compactionInputLimit caps each message in the transcript sent for
+// summarization, so compaction itself cannot overflow the context.
+const compactionInputLimit = 4000
+
+// compactionPrompt instructs the model that summarizes older history.
+const compactionPrompt = "You compress the history of an agent that uses tools to complete a task. " +
+	"Summarize the conversation below in a compact note for the agent to continue from. " +
+	"Keep every fact, number, file path, URL, command and error that may still matter, and what has already been tried. " +
+	"Drop raw tool output that has been fully used. Respond with the note only."
+
+// estimateTokens roughly estimates the prompt size of messages, at about four
+// bytes per token plus a small per-message overhead.
+func estimateTokens(messages []openai.ChatCompletionMessage) int {
+	total := 0
+	for _, msg := range messages {
+		size := len(msg.Content)
+		for _, tc := range msg.ToolCalls {
+			size += len(tc.Function.Name) + len(tc.Function.Arguments)
+		}
+		total += size/4 + 4
+	}
+	return total
+}
+
+// compactHistory replaces older messages with a model-written summary once the
+// history is estimated to exceed the compaction threshold. The leading system
+// prompt, the first user request and the most recent messages are kept
+// verbatim. Failing to summarize is not fatal; the history is left as is.
+func (a *Agent) compactHistory(ctx context.Context) {
+	threshold := a.cfg.CompactThresholdTokens
+	if threshold == 0 {
+		threshold = DefaultCompactThresholdTokens
+	}
+	if threshold < 0 || estimateTokens(a.messages) <= threshold {
+		return
+	}
+
+	start, end := compactionRange(a.messages, a.compactKeepMessages())
+	if end-start < 2 {
+		return
+	}
+
+	summary, err := a.summarizeMessages(ctx, a.messages[start:end])
+	if err != nil {
+		a.logEvent(ctx, slog.LevelWarn, "history compaction failed",
+			fmt.Sprintf("⚠️ History compaction failed: %v\n", err),
+			slog.Any("error", err))
+		return
+	}
+
+	compacted := make([]openai.ChatCompletionMessage, 0, len(a.messages)-(end-start)+1)
+	compacted = append(compacted, a.messages[:start]...)
+	summaryMessage := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: "Summary of the earlier conversation, compacted to save context:\n" + summary,
+	}
+	compacted = append(compacted, summaryMessage)
+	compacted = append(compacted, a.messages[end:]...)
+	a.traceMessages(summaryMessage)
+
+	a.logEvent(ctx, slog.LevelInfo, "history compacted",
+		fmt.Sprintf("🗜️ Compacted %d messages (~%d tokens) into a summary\n", end-start, estimateTokens(a.messages[start:end])),
+		slog.Int("messages", end-start), slog.Int("tokens", estimateTokens(a.messages[start:end])))
+	a.messages = compacted
+}
+
+// compactKeepMessages returns how many recent messages compaction keeps.
+func (a *Agent) compactKeepMessages() int {
+	if a.cfg.CompactKeepMessages > 0 {
+		return a.cfg.CompactKeepMessages
+	}
+	return DefaultCompactKeepMessages
+}
+
+// compactionRange returns the messages[start:end] that may be summarized.
+// It skips the leading system messages and the first user message, and never
+// separates tool results from the assistant message that requested them.
+func compactionRange(messages []openai.ChatCompletionMessage, keep int) (start, end int) {
+	for start < len(messages) && messages[start].Role == openai.ChatMessageRoleSystem {
+		start++
+	}
+	if start < len(messages) && messages[start].Role == openai.ChatMessageRoleUser {
+		start++
+	}
+
+	end = len(messages) - keep
+	for end > start && messages[end].Role == openai.ChatMessageRoleTool {
+		end--
+	}
+	if end < start {
+		end = start
+	}
+	return start, end
+}
+
+// summarizeMessages asks the compaction model for a summary of messages.
+func (a *Agent) summarizeMessages(ctx context.Context, messages []openai.ChatCompletionMessage) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		content, _ := truncateToolOutput(msg.Content, compactionInputLimit, true)
+		fmt.Fprintf(&transcript, "[%s] %s\n", msg.Role, content)
+		for _, tc := range msg.ToolCalls {
+			fmt.Fprintf(&transcript, "[tool call] %s %s\n", tc.Function.Name, tc.Function.Arguments)
+		}
+	}
+
+	model := a.cfg.CompactionModel
+	if model == "" {
+		model = a.cfg.Model
+	}
+	resp, err := a.createChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: compactionPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: transcript.String()},
+		},
+		Temperature: 0,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
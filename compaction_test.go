@@ -0,0 +1,71 @@
+package goskills
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolTurn returns an assistant tool call followed by its result.
+func toolTurn(id, output string) []openai.ChatCompletionMessage {
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{{ID: id, Function: openai.FunctionCall{Name: "read_file", Arguments: `{"filePath":"a.txt"}`}}}},
+		{Role: openai.ChatMessageRoleTool, ToolCallID: id, Content: output},
+	}
+}
+
+func TestCompactionRange(t *testing.T) {
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "skill"},
+		{Role: openai.ChatMessageRoleUser, Content: "task"},
+	}
+	messages = append(messages, toolTurn("1", "one")...)
+	messages = append(messages, toolTurn("2", "two")...)
+	messages = append(messages, toolTurn("3", "three")...)
+
+	// Keeping 3 would split call 2 from its result, so call 2 is kept too
+	start, end := compactionRange(messages, 3)
+	assert.Equal(t, 2, start)
+	assert.Equal(t, 4, end)
+
+	start, end = compactionRange(messages[:2], 6)
+	assert.Equal(t, start, end)
+}
+
+func TestCompactHistory(t *testing.T) {
+	client := agenttest.NewFakeClient(agenttest.Reply("a.txt was read twice."))
+	a, err := NewAgent(RunnerConfig{
+		Client:                 client,
+		CompactThresholdTokens: 100,
+		CompactKeepMessages:    2,
+		CompactionModel:        "cheap-model",
+	}, nil)
+	require.NoError(t, err)
+
+	a.messages = []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "skill"},
+		{Role: openai.ChatMessageRoleUser, Content: "task"},
+	}
+	a.messages = append(a.messages, toolTurn("1", strings.Repeat("x", 1000))...)
+	a.messages = append(a.messages, toolTurn("2", strings.Repeat("y", 1000))...)
+	a.messages = append(a.messages, toolTurn("3", "latest")...)
+
+	a.compactHistory(context.Background())
+	assert.Equal(t, "cheap-model", client.Requests()[0].Model)
+	require.Len(t, a.messages, 5)
+	assert.Equal(t, "task", a.messages[1].Content)
+	assert.Equal(t, openai.ChatMessageRoleSystem, a.messages[2].Role)
+	assert.Contains(t, a.messages[2].Content, "a.txt was read twice.")
+	assert.Equal(t, "latest", a.messages[4].Content)
+}
+
+func TestCompactHistoryBelowThreshold(t *testing.T) {
+	a := &Agent{messages: toolTurn("1", "small")}
+	a.compactHistory(context.Background())
+	assert.Len(t, a.messages, 2)
+}
@@ -4,21 +4,53 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 // Config holds the application configuration
 type Config struct {
+	Provider         string
 	SkillsDir        string
 	Model            string
 	APIBase          string
 	APIKey           string
 	AutoApproveTools bool
-	AllowedScripts   []string
-	Verbose          bool
-	Loop             bool
-	McpConfig        string
+	// AutoApproveDestructive also auto-approves tools that modify state.
+	AutoApproveDestructive bool
+	AllowedScripts         []string
+	AllowedShellCommands   []string
+	Verbose                bool
+	Loop                   bool
+	DryRun                 bool
+	AuditLog               string
+	// DisabledTools are never offered to skills.
+	DisabledTools []string
+	// TraceFile receives the full transcript of the run as JSON when set.
+	TraceFile string
+	// CheckpointDir stores checkpoints of runs, so they can be resumed.
+	CheckpointDir string
+	// Sandbox runs the skill in a temporary copy of its directory.
+	Sandbox     bool
+	KeepSandbox bool
+	// Clarify lets the model ask clarifying questions before a skill runs.
+	Clarify bool
+	// CacheDir caches web fetches and searches on disk when set.
+	CacheDir  string
+	CacheTTL  time.Duration
+	NoCache   bool
+	McpConfig string
+	// DefaultSkill runs when no skill fits the request.
+	DefaultSkill string
+	// KeywordMatch selects obvious skills by keyword without an LLM call.
+	KeywordMatch bool
+	// CheckPythonSyntax compiles Python code before running it.
+	CheckPythonSyntax bool
+	// ScriptOutputEncoding is the encoding of script output that is not UTF-8.
+	ScriptOutputEncoding string
+	// Seed, when set, is sent with every LLM request for reproducible runs.
+	Seed *int
 }
 
 // LoadConfig loads configuration from flags and environment variables
@@ -27,6 +59,10 @@ func LoadConfig(cmd *cobra.Command) (*Config, error) {
 
 	// 1. Load from flags (if set)
 	var err error
+	cfg.Provider, err = cmd.Flags().GetString("provider")
+	if err != nil {
+		return nil, err
+	}
 	cfg.SkillsDir, err = cmd.Flags().GetString("skills-dir")
 	if err != nil {
 		return nil, err
@@ -47,6 +83,10 @@ func LoadConfig(cmd *cobra.Command) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	cfg.AutoApproveDestructive, err = cmd.Flags().GetBool("auto-approve-destructive")
+	if err != nil {
+		return nil, err
+	}
 	cfg.Verbose, err = cmd.Flags().GetBool("verbose")
 	if err != nil {
 		return nil, err
@@ -55,14 +95,85 @@ func LoadConfig(cmd *cobra.Command) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	cfg.DryRun, err = cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return nil, err
+	}
+	cfg.AuditLog, err = cmd.Flags().GetString("audit-log")
+	if err != nil {
+		return nil, err
+	}
+	cfg.TraceFile, err = cmd.Flags().GetString("trace-file")
+	if err != nil {
+		return nil, err
+	}
+	cfg.CheckpointDir, err = cmd.Flags().GetString("checkpoint-dir")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Clarify, err = cmd.Flags().GetBool("clarify")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Sandbox, err = cmd.Flags().GetBool("sandbox")
+	if err != nil {
+		return nil, err
+	}
+	cfg.KeepSandbox, err = cmd.Flags().GetBool("keep-sandbox")
+	if err != nil {
+		return nil, err
+	}
+	cfg.CacheDir, err = cmd.Flags().GetString("cache-dir")
+	if err != nil {
+		return nil, err
+	}
+	cfg.CacheTTL, err = cmd.Flags().GetDuration("cache-ttl")
+	if err != nil {
+		return nil, err
+	}
+	cfg.NoCache, err = cmd.Flags().GetBool("no-cache")
+	if err != nil {
+		return nil, err
+	}
 	cfg.AllowedScripts, err = cmd.Flags().GetStringSlice("allow-scripts")
 	if err != nil {
 		return nil, err
 	}
+	cfg.AllowedShellCommands, err = cmd.Flags().GetStringSlice("allow-shell-commands")
+	if err != nil {
+		return nil, err
+	}
+	cfg.DisabledTools, err = cmd.Flags().GetStringSlice("disable-tools")
+	if err != nil {
+		return nil, err
+	}
 	cfg.McpConfig, err = cmd.Flags().GetString("mcp-config")
 	if err != nil {
 		return nil, err
 	}
+	cfg.DefaultSkill, err = cmd.Flags().GetString("default-skill")
+	if err != nil {
+		return nil, err
+	}
+	cfg.KeywordMatch, err = cmd.Flags().GetBool("keyword-match")
+	if err != nil {
+		return nil, err
+	}
+	cfg.CheckPythonSyntax, err = cmd.Flags().GetBool("check-python-syntax")
+	if err != nil {
+		return nil, err
+	}
+	cfg.ScriptOutputEncoding, err = cmd.Flags().GetString("script-output-encoding")
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Flags().Changed("seed") {
+		seed, err := cmd.Flags().GetInt("seed")
+		if err != nil {
+			return nil, err
+		}
+		cfg.Seed = &seed
+	}
 
 	// 2. Load from environment variables (fallback if flag not set or empty, except bools)
 	// Note: Cobra flags usually handle defaults, but we check env vars here for precedence if needed
@@ -96,12 +207,31 @@ func LoadConfig(cmd *cobra.Command) (*Config, error) {
 // SetupFlags registers the flags with the command
 func SetupFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP("skills-dir", "d", "./examples/skills", "Path to the skills directory")
+	cmd.Flags().String("provider", "", "LLM provider: openai (default), anthropic or gemini")
 	cmd.Flags().StringP("model", "m", "", "OpenAI-compatible model name (falls back to OPENAI_MODEL env var)")
 	cmd.Flags().StringP("api-base", "b", "", "OpenAI-compatible API base URL (falls back to OPENAI_API_BASE env var)")
 	cmd.Flags().StringP("api-key", "k", "", "OpenAI-compatible API key (falls back to OPENAI_API_KEY env var)")
-	cmd.Flags().Bool("auto-approve", false, "Auto-approve all tool calls (WARNING: potentially unsafe)")
+	cmd.Flags().Bool("auto-approve", false, "Auto-approve read-only tool calls")
+	cmd.Flags().Bool("auto-approve-destructive", false, "With --auto-approve, also auto-approve tools that write files, run code or change remote state (WARNING: potentially unsafe)")
 	cmd.Flags().StringSlice("allow-scripts", nil, "Comma-separated list of allowed script names (e.g. 'run_myscript_py')")
+	cmd.Flags().StringSlice("allow-shell-commands", nil, "Comma-separated list of the only commands generated shell code may run (e.g. 'ls,cat,grep')")
+	cmd.Flags().StringSlice("disable-tools", nil, "Comma-separated list of tools, or globs such as '*_search', never offered to skills")
+	cmd.Flags().String("default-skill", "", "Skill to run when no skill fits the request")
+	cmd.Flags().Bool("keyword-match", false, "Select a skill without asking the LLM when its name, aliases or keywords clearly match the request")
 	cmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
 	cmd.Flags().BoolP("loop", "l", false, "Enable interactive loop mode")
+	cmd.Flags().Bool("dry-run", false, "Show the tool calls a skill would make without executing them")
+	cmd.Flags().Bool("check-python-syntax", false, "Compile Python code before running it, so syntax errors are reported without side effects")
+	cmd.Flags().Int("seed", 0, "Send this seed with every LLM request, so backends that support it give reproducible outputs")
+	cmd.Flags().String("script-output-encoding", "", "Transcode script output that is not UTF-8 from this encoding, e.g. gbk or windows-1252, instead of replacing the invalid bytes")
+	cmd.Flags().String("audit-log", "", "Append a JSON line for every tool call to this file")
+	cmd.Flags().String("trace-file", "", "Write the full transcript of the run to this file as JSON")
+	cmd.Flags().String("checkpoint-dir", "", "Checkpoint the run in this directory before every iteration, so it can be resumed")
+	cmd.Flags().Bool("clarify", false, "Let the model ask clarifying questions about a vague request before running the skill")
+	cmd.Flags().Bool("sandbox", false, "Run the skill in a temporary copy of its directory, kept for inspection if the run fails")
+	cmd.Flags().Bool("keep-sandbox", false, "With --sandbox, also keep the copy of a successful run")
+	cmd.Flags().String("cache-dir", "", "Cache web fetches and search results in this directory")
+	cmd.Flags().Duration("cache-ttl", 24*time.Hour, "How long cached fetches and search results are used")
+	cmd.Flags().Bool("no-cache", false, "Fetch fresh results instead of using the cache")
 	cmd.Flags().String("mcp-config", "", "Path to MCP configuration file")
 }
@@ -0,0 +1,100 @@
+package goskills
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrExtractionInvalid is returned by extract_structured when the model's
+// output still does not match the schema after a retry.
+var ErrExtractionInvalid = errors.New("extracted data does not match the schema")
+
+// extractSystemPrompt instructs the model used by extract_structured.
+const extractSystemPrompt = `You extract structured data from text.
+Respond with a single JSON value that matches this JSON schema, and nothing else:
+%s
+Only use information found in the text. Leave out optional fields that the text does not provide.`
+
+// extractStructured asks the model to extract data matching schema from text
+// and returns it as compact JSON. The output is validated against the schema,
+// and the model gets one more try, told what was wrong, if it does not match.
+func (a *Agent) extractStructured(ctx context.Context, schema json.RawMessage, text, instructions string) (string, error) {
+	var s jsonschema.Schema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return "", fmt.Errorf("invalid schema: %w", err)
+	}
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid schema: %w", err)
+	}
+
+	user := text
+	if instructions != "" {
+		user = instructions + "\n\nText:\n" + text
+	}
+	req := openai.ChatCompletionRequest{
+		Model: a.cfg.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: fmt.Sprintf(extractSystemPrompt, schema)},
+			{Role: openai.ChatMessageRoleUser, Content: user},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   "extraction",
+				Schema: schema,
+			},
+		},
+	}
+
+	var verr error
+	for attempt := 0; attempt < 2; attempt++ {
+		resp, err := a.createChatCompletion(ctx, req)
+		if err != nil {
+			return "", fmt.Errorf("extraction request failed: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return "", errors.New("extraction request returned no choices")
+		}
+		content := trimJSONFence(resp.Choices[0].Message.Content)
+
+		var value interface{}
+		if verr = json.Unmarshal([]byte(content), &value); verr == nil {
+			if verr = resolved.Validate(value); verr == nil {
+				var out bytes.Buffer
+				if err := json.Compact(&out, []byte(content)); err != nil {
+					return "", err
+				}
+				return out.String(), nil
+			}
+		}
+
+		req.Messages = append(req.Messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("That output is invalid: %v. Respond again with JSON that matches the schema.", verr),
+			},
+		)
+	}
+	return "", fmt.Errorf("%w: %v", ErrExtractionInvalid, verr)
+}
+
+// trimJSONFence removes a Markdown code fence around a JSON response.
+func trimJSONFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,57 @@
+package goskills
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const peopleSchema = `{"type":"object","properties":{"people":{"type":"array","items":{"type":"object","properties":{"name":{"type":"string"},"age":{"type":"integer"}},"required":["name"]}}},"required":["people"]}`
+
+func TestExtractStructuredRetries(t *testing.T) {
+	client := agenttest.NewFakeClient(
+		agenttest.Reply(`{"people":[{"age":"forty"}]}`),
+		agenttest.Reply("```json\n{\"people\": [{\"name\": \"Ada\", \"age\": 36}]}\n```"),
+	)
+	a, err := NewAgent(RunnerConfig{Client: client}, nil)
+	require.NoError(t, err)
+
+	tc := openai.ToolCall{Function: openai.FunctionCall{
+		Name:      "extract_structured",
+		Arguments: `{"schema":` + peopleSchema + `,"text":"Ada Lovelace was 36."}`,
+	}}
+	output, err := a.executeToolCall(context.Background(), tc, nil, SkillPackage{})
+	require.NoError(t, err)
+	assert.Equal(t, `{"people":[{"name":"Ada","age":36}]}`, output)
+
+	requests := client.Requests()
+	require.Len(t, requests, 2)
+	first := requests[0]
+	require.NotNil(t, first.ResponseFormat)
+	assert.Equal(t, openai.ChatCompletionResponseFormatTypeJSONSchema, first.ResponseFormat.Type)
+	retry := requests[1].Messages
+	assert.Contains(t, retry[len(retry)-1].Content, "That output is invalid")
+}
+
+func TestExtractStructuredInvalid(t *testing.T) {
+	client := agenttest.NewFakeClient(agenttest.Reply(`{"people":"none"}`), agenttest.Reply(`not json`))
+	a, err := NewAgent(RunnerConfig{Client: client}, nil)
+	require.NoError(t, err)
+
+	_, err = a.extractStructured(context.Background(), json.RawMessage(peopleSchema), "no one", "")
+	require.ErrorIs(t, err, ErrExtractionInvalid)
+	assert.Len(t, client.Requests(), 2)
+}
+
+func TestExtractStructuredSchemaArgument(t *testing.T) {
+	a := &Agent{}
+	tc := openai.ToolCall{Function: openai.FunctionCall{Name: "extract_structured", Arguments: `{"schema":"{not json","text":"x"}`}}
+	_, err := a.executeToolCall(context.Background(), tc, nil, SkillPackage{})
+	var argErr *ToolArgumentsError
+	require.ErrorAs(t, err, &argErr)
+}
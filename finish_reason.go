@@ -0,0 +1,33 @@
+package goskills
+
+import (
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// DefaultMaxContinuations is how many times a final answer cut off by the
+// model's output limit is continued when RunnerConfig.MaxContinuations is zero.
+const DefaultMaxContinuations = 3
+
+// continuePrompt asks the model to continue an answer that was cut off.
+const continuePrompt = "Your previous response was cut off by the output limit. Continue exactly where it stopped, without repeating anything."
+
+// truncationNotice is appended to an answer that is still cut off after the
+// last continuation.
+const truncationNotice = "\n\n[response truncated: the model reached its output limit]"
+
+// FinishReason returns why the model stopped generating its last response,
+// e.g. openai.FinishReasonLength when the final answer was truncated.
+func (a *Agent) FinishReason() openai.FinishReason {
+	return a.finishReason
+}
+
+// maxContinuations returns how many times a truncated answer is continued.
+func (a *Agent) maxContinuations() int {
+	if a.cfg.MaxContinuations < 0 {
+		return 0
+	}
+	if a.cfg.MaxContinuations == 0 {
+		return DefaultMaxContinuations
+	}
+	return a.cfg.MaxContinuations
+}
@@ -0,0 +1,64 @@
+package goskills
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// truncatingClient answers with "part N" and finish_reason "length" until
+// the final part, which finishes with "stop".
+func truncatingClient(parts int) *agenttest.FakeClient {
+	client := agenttest.NewFakeClient()
+	calls := 0
+	client.Respond = func(openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		calls++
+		resp := agenttest.Reply(fmt.Sprintf("part %d ", calls))
+		if calls < parts {
+			resp.Choices[0].FinishReason = openai.FinishReasonLength
+		}
+		return resp, nil
+	}
+	return client
+}
+
+func TestContinueTruncatedAnswer(t *testing.T) {
+	client := truncatingClient(3)
+	a, err := NewAgent(RunnerConfig{Client: client}, nil)
+	require.NoError(t, err)
+
+	output, err := a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	require.NoError(t, err)
+	assert.Len(t, client.Requests(), 3)
+	assert.Equal(t, "part 1 part 2 part 3 ", output)
+	assert.Equal(t, openai.FinishReasonStop, a.FinishReason())
+}
+
+func TestTruncatedAnswerNotice(t *testing.T) {
+	client := truncatingClient(5)
+	a, err := NewAgent(RunnerConfig{Client: client, MaxContinuations: -1}, nil)
+	require.NoError(t, err)
+
+	output, err := a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	require.NoError(t, err)
+	assert.Len(t, client.Requests(), 1)
+	assert.Equal(t, "part 1 "+truncationNotice, output)
+	assert.Equal(t, openai.FinishReasonLength, a.FinishReason())
+}
+
+func TestCancelKeepsContinuedAnswer(t *testing.T) {
+	client := truncatingClient(3)
+	handler := &agenttest.TestInteractionHandler{Cancel: func() bool { return len(client.Requests()) == 2 }}
+	a, err := NewAgent(RunnerConfig{Client: client, InteractionHandler: handler}, nil)
+	require.NoError(t, err)
+
+	output, err := a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	require.ErrorIs(t, err, agent.ErrCanceled)
+	assert.Equal(t, "part 1 part 2 ", output)
+}
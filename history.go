@@ -0,0 +1,31 @@
+package goskills
+
+import (
+	"context"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// RunWithHistory is like Run, continuing the conversation in history, and
+// returns the updated history to pass to the next turn. The history excludes
+// the skill's system prompt, which is rebuilt for every turn.
+func (a *Agent) RunWithHistory(ctx context.Context, userPrompt string, history []openai.ChatCompletionMessage) (string, []openai.ChatCompletionMessage, error) {
+	a.messages = nil
+	output, err := a.run(ctx, userPrompt, runInput{history: history, attachments: a.cfg.Attachments})
+	return output, a.History(), err
+}
+
+// History returns the conversation so far without the skill's system prompt,
+// suitable for RunnerConfig.History or RunWithHistory.
+func (a *Agent) History() []openai.ChatCompletionMessage {
+	return conversationHistory(a.messages)
+}
+
+// conversationHistory returns messages without their leading system messages.
+func conversationHistory(messages []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	start := 0
+	for start < len(messages) && messages[start].Role == openai.ChatMessageRoleSystem {
+		start++
+	}
+	return append([]openai.ChatCompletionMessage(nil), messages[start:]...)
+}
@@ -0,0 +1,57 @@
+package goskills
+
+import (
+	"context"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistorySeedsConversation(t *testing.T) {
+	client := agenttest.NewFakeClient(agenttest.Reply("Your name is Ada."))
+	history := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "old skill prompt"},
+		{Role: openai.ChatMessageRoleUser, Content: "My name is Ada."},
+		{Role: openai.ChatMessageRoleAssistant, Content: "Nice to meet you, Ada."},
+	}
+	a, err := NewAgent(RunnerConfig{Client: client, History: history}, nil)
+	require.NoError(t, err)
+
+	output, err := a.executeSkillWithTools(context.Background(), "What is my name?", SkillPackage{Path: t.TempDir()})
+	require.NoError(t, err)
+	assert.Equal(t, "Your name is Ada.", output)
+
+	// The old system prompt is replaced by the current skill's
+	got := client.Requests()[0]
+	require.Len(t, got.Messages, 4)
+	assert.Equal(t, openai.ChatMessageRoleSystem, got.Messages[0].Role)
+	assert.NotEqual(t, "old skill prompt", got.Messages[0].Content)
+	assert.Equal(t, "My name is Ada.", got.Messages[1].Content)
+	assert.Equal(t, "What is my name?", got.Messages[3].Content)
+
+	updated := a.History()
+	require.Len(t, updated, 4)
+	assert.Equal(t, "My name is Ada.", updated[0].Content)
+	assert.Equal(t, "Your name is Ada.", updated[3].Content)
+}
+
+func TestRunWithHistoryKeepsConfig(t *testing.T) {
+	client := agenttest.NewFakeClient(agenttest.Reply("chat"), agenttest.Reply("Your name is Ada."))
+	a, err := NewAgent(RunnerConfig{
+		Client:        client,
+		SkillProvider: staticSkills{"chat": {Path: t.TempDir(), Meta: SkillMeta{Name: "chat", Description: "Chats."}}},
+	}, nil)
+	require.NoError(t, err)
+
+	history := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "My name is Ada."}}
+	output, updated, err := a.RunWithHistory(context.Background(), "What is my name?", history)
+	require.NoError(t, err)
+	assert.Equal(t, "Your name is Ada.", output)
+	assert.Len(t, updated, 3)
+	assert.Equal(t, "My name is Ada.", client.Requests()[1].Messages[1].Content)
+	// The history of one call is not reused by later runs of the Agent
+	assert.Nil(t, a.cfg.History)
+}
@@ -0,0 +1,74 @@
+package goskills
+
+import (
+	"path"
+	"strings"
+	"unicode"
+)
+
+// Scores of the keyword pre-match. A skill is selected without asking the
+// model when its score reaches keywordMatchMinScore and no other skill scores
+// as high, so a single alias or skill name, or two keywords, are enough.
+const (
+	aliasMatchScore      = 2
+	keywordMatchScore    = 1
+	keywordMatchMinScore = 2
+)
+
+// matchSkillKeywords returns the skill whose name, aliases and keywords
+// clearly match prompt, for RunnerConfig.KeywordPreMatch. Terms match whole
+// words, case-insensitively.
+func matchSkillKeywords(prompt string, skills map[string]SkillPackage) (string, bool) {
+	text := " " + strings.Join(keywordWords(prompt), " ") + " "
+	matches := func(term string) bool {
+		words := keywordWords(term)
+		return len(words) > 0 && strings.Contains(text, " "+strings.Join(words, " ")+" ")
+	}
+
+	best, bestScore, tied := "", 0, false
+	for name, skill := range skills {
+		score := 0
+		for _, alias := range append([]string{path.Base(name), skill.Meta.Name}, skill.Meta.Aliases...) {
+			if matches(alias) {
+				score = aliasMatchScore
+				break
+			}
+		}
+		for _, keyword := range skill.Meta.Keywords {
+			if matches(keyword) {
+				score += keywordMatchScore
+			}
+		}
+
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = name, score, false
+		case score == bestScore:
+			tied = true
+		}
+	}
+	if bestScore < keywordMatchMinScore || tied {
+		return "", false
+	}
+	return best, true
+}
+
+// keywordWords splits s into lower-case words of letters and digits.
+func keywordWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// resolveSkillAlias returns the skill that has name as an alias, for a
+// selection answer that names an alias instead of the skill.
+func resolveSkillAlias(name string, skills map[string]SkillPackage) (string, bool) {
+	for skillName, skill := range skills {
+		for _, alias := range skill.Meta.Aliases {
+			if strings.EqualFold(alias, name) {
+				return skillName, true
+			}
+		}
+	}
+	return "", false
+}
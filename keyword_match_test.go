@@ -0,0 +1,38 @@
+package goskills
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchSkillKeywords(t *testing.T) {
+	skills := map[string]SkillPackage{
+		"research/digest": {Meta: SkillMeta{Name: "digest", Aliases: []string{"summarize", "tl;dr"}, Keywords: []string{"article", "paper"}}},
+		"pdf":             {Meta: SkillMeta{Name: "pdf", Keywords: []string{"form", "merge"}}},
+		"charts":          {Meta: SkillMeta{Name: "charts", Keywords: []string{"plot", "paper"}}},
+	}
+
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		{"Please SUMMARIZE this for me", "research/digest"},
+		{"give me a digest of the news", "research/digest"},
+		{"fill in and merge these forms", ""}, // "forms" is not "form"
+		{"merge the form fields", "pdf"},
+		{"plot the results", ""}, // a single keyword
+		{"plot the paper results", "charts"},
+		{"summarize the PDF", ""}, // tie
+		{"write a poem", ""},
+	}
+	for _, tt := range tests {
+		got, ok := matchSkillKeywords(tt.prompt, skills)
+		assert.Equal(t, tt.want, got, tt.prompt)
+		assert.Equal(t, tt.want != "", ok, tt.prompt)
+	}
+
+	name, ok := resolveSkillAlias("Summarize", skills)
+	assert.True(t, ok)
+	assert.Equal(t, "research/digest", name)
+}
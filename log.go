@@ -0,0 +1,40 @@
+package goskills
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// logf writes verbose output, warnings and prompts to RunnerConfig.LogWriter.
+func (a *Agent) logf(format string, args ...any) {
+	w := a.cfg.LogWriter
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+// logEvent records a diagnostic event. With RunnerConfig.Logger, it is a
+// structured record of msg and attrs at level; otherwise text is written to
+// LogWriter in verbose mode. An empty text makes the event structured only.
+func (a *Agent) logEvent(ctx context.Context, level slog.Level, msg, text string, attrs ...slog.Attr) {
+	a.emit(ctx, a.cfg.Verbose, level, msg, text, attrs)
+}
+
+// logAlways is like logEvent, but writes text even when not in verbose mode.
+func (a *Agent) logAlways(ctx context.Context, level slog.Level, msg, text string, attrs ...slog.Attr) {
+	a.emit(ctx, true, level, msg, text, attrs)
+}
+
+// emit logs an event for logEvent and logAlways.
+func (a *Agent) emit(ctx context.Context, printText bool, level slog.Level, msg, text string, attrs []slog.Attr) {
+	if a.cfg.Logger != nil {
+		a.cfg.Logger.LogAttrs(ctx, level, msg, attrs...)
+		return
+	}
+	if printText && text != "" {
+		a.logf("%s", text)
+	}
+}
@@ -0,0 +1,72 @@
+package goskills
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxRepeatedToolErrors is how many times in a row the same tool call
+// may fail with the same error before the model is told to change its
+// approach, when RunnerConfig.MaxRepeatedToolErrors is zero.
+const DefaultMaxRepeatedToolErrors = 3
+
+// ErrToolLoop is returned, together with a diagnostic, when the model keeps
+// repeating a failing tool call after being told to try something else.
+var ErrToolLoop = errors.New("tool call loop detected")
+
+// toolLoopNudge asks the model to stop repeating a failing call.
+const toolLoopNudge = "The call %s with these arguments has failed %d times in a row with the same error:\n%s\nRepeating it will not help. Try a different approach: change the arguments, use another tool, or answer with what you have."
+
+// toolLoopDetector tracks consecutive identical failing tool calls.
+type toolLoopDetector struct {
+	name   string
+	key    string
+	result string
+	count  int
+	// nudgedAt is the count at which the model was nudged, or 0.
+	nudgedAt int
+}
+
+// record notes the result of a tool call. Any successful call or a different
+// failure starts the count over.
+func (d *toolLoopDetector) record(name, arguments, result string, failed bool) {
+	key := name + "\x00" + arguments
+	if !failed {
+		*d = toolLoopDetector{}
+		return
+	}
+	if key == d.key && result == d.result {
+		d.count++
+		return
+	}
+	*d = toolLoopDetector{name: name, key: key, result: result, count: 1}
+}
+
+// maxRepeatedToolErrors returns the repeat threshold, or 0 when loop
+// detection is disabled.
+func (a *Agent) maxRepeatedToolErrors() int {
+	if a.cfg.MaxRepeatedToolErrors < 0 {
+		return 0
+	}
+	if a.cfg.MaxRepeatedToolErrors == 0 {
+		return DefaultMaxRepeatedToolErrors
+	}
+	return a.cfg.MaxRepeatedToolErrors
+}
+
+// check is called after the tool calls of a turn. When the same call has
+// failed limit times in a row it returns a nudge for the model; when the call
+// fails again after the nudge it returns a diagnostic and stop is true. A
+// limit of 0 disables detection.
+func (d *toolLoopDetector) check(limit int) (message string, stop bool) {
+	switch {
+	case limit == 0 || d.count < limit:
+		return "", false
+	case d.nudgedAt == 0:
+		d.nudgedAt = d.count
+		return fmt.Sprintf(toolLoopNudge, d.name, d.count, d.result), false
+	case d.count > d.nudgedAt:
+		return fmt.Sprintf("[%v: %s failed %d times in a row with the same arguments and error; stopping early]\n%s", ErrToolLoop, d.name, d.count, d.result), true
+	}
+	return "", false
+}
@@ -0,0 +1,67 @@
+package goskills
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingCallClient always asks to read a file that does not exist.
+func failingCallClient() *agenttest.FakeClient {
+	client := agenttest.NewFakeClient()
+	calls := 0
+	client.Respond = func(openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		calls++
+		return agenttest.CallTools(agenttest.ToolCall(fmt.Sprintf("call_%d", calls), "read_file", map[string]string{"filePath": "missing.txt"})), nil
+	}
+	return client
+}
+
+func TestToolLoopDetection(t *testing.T) {
+	client := failingCallClient()
+	a, err := NewAgent(RunnerConfig{Client: client, AutoApproveTools: true, MaxRepeatedToolErrors: 2}, nil)
+	require.NoError(t, err)
+
+	output, err := a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	require.ErrorIs(t, err, ErrToolLoop)
+	assert.Len(t, client.Requests(), 3)
+	assert.Contains(t, output, "read_file failed 3 times in a row")
+
+	var nudges int
+	for _, msg := range a.messages {
+		if msg.Role == openai.ChatMessageRoleUser && msg.Content != "task" {
+			nudges++
+			assert.Contains(t, msg.Content, "has failed 2 times in a row")
+		}
+	}
+	assert.Equal(t, 1, nudges)
+}
+
+func TestToolLoopDetectionDisabled(t *testing.T) {
+	client := failingCallClient()
+	a, err := NewAgent(RunnerConfig{Client: client, AutoApproveTools: true, MaxRepeatedToolErrors: -1}, nil)
+	require.NoError(t, err)
+
+	_, err = a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrToolLoop)
+	assert.Len(t, client.Requests(), 10)
+}
+
+func TestToolLoopDetectorResets(t *testing.T) {
+	var d toolLoopDetector
+	d.record("read_file", `{"filePath":"a"}`, "Error: missing", true)
+	d.record("read_file", `{"filePath":"a"}`, "Error: missing", true)
+	d.record("read_file", `{"filePath":"b"}`, "Error: missing", true)
+	assert.Equal(t, 1, d.count)
+
+	d.record("read_file", `{"filePath":"b"}`, "ok", false)
+	message, stop := d.check(1)
+	assert.Empty(t, message)
+	assert.False(t, stop)
+}
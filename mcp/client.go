@@ -65,6 +65,13 @@ func (c *Client) connectToServer(ctx context.Context, name string, server MCPSer
 		}
 	}
 
+	return c.Connect(ctx, name, transport)
+}
+
+// Connect connects to the server reachable through transport, whose tools
+// are then named "name__tool", e.g. to a server in the same process through
+// mcp.NewInMemoryTransports.
+func (c *Client) Connect(ctx context.Context, name string, transport mcp.Transport) error {
 	mcpClient := mcp.NewClient(&mcp.Implementation{
 		Name:    "goskills",
 		Version: "0.1.0",
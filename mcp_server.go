@@ -0,0 +1,168 @@
+package goskills
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/tool"
+)
+
+// mcpSkillToolPrefix prefixes the MCP tools that run a whole skill.
+const mcpSkillToolPrefix = "skill_"
+
+// mcpSkillSchema is the input schema of every skill tool.
+var mcpSkillSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"prompt": map[string]any{
+			"type":        "string",
+			"description": "The request for the skill to handle.",
+		},
+	},
+	"required": []string{"prompt"},
+}
+
+// ServeMCP exposes the skills in cfg and the built-in tools as MCP tools over
+// streamable HTTP on listener, until ctx is done. See NewMCPServer.
+func ServeMCP(ctx context.Context, cfg RunnerConfig, listener net.Listener) error {
+	server, err := NewMCPServer(cfg)
+	if err != nil {
+		return err
+	}
+
+	handler := mcpsdk.NewStreamableHTTPHandler(func(*http.Request) *mcpsdk.Server { return server }, nil)
+	httpServer := &http.Server{Handler: handler}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NewMCPServer builds an MCP server with one tool per skill, named
+// "skill_<name>" and taking a "prompt" argument, plus the built-in tools with
+// the schemas from GenerateToolDefinitions. Use it directly to serve over
+// another transport, such as stdio.
+//
+// The MCP client is responsible for approving calls to the built-in tools.
+// Tool calls made while a skill runs still go through cfg, so set
+// AutoApproveTools (and AutoApproveDestructive) or an InteractionHandler; the
+// stdin prompt is not usable from a server.
+func NewMCPServer(cfg RunnerConfig) (*mcpsdk.Server, error) {
+	r, err := NewRunner(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	skills, err := r.NewAgent().discoverSkills(cfg.SkillsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover skills: %w", err)
+	}
+
+	server := mcpsdk.NewServer(&mcpsdk.Implementation{
+		Name:    "goskills",
+		Version: Version,
+	}, nil)
+
+	names := make([]string, 0, len(skills))
+	for name := range skills {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		skill := skills[name]
+		server.AddTool(&mcpsdk.Tool{
+			Name:        mcpSkillToolPrefix + mcpToolName(name),
+			Description: skill.Meta.Description,
+			InputSchema: mcpSkillSchema,
+		}, skillToolHandler(r, skill))
+	}
+
+	for _, t := range tool.GetBaseTools() {
+		if matchesAnyTool(t.Function.Name, cfg.DisabledTools) {
+			continue
+		}
+		server.AddTool(&mcpsdk.Tool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: t.Function.Parameters,
+		}, baseToolHandler(r))
+	}
+	return server, nil
+}
+
+// skillToolHandler runs skill with a fresh Agent for each call.
+func skillToolHandler(r *Runner, skill SkillPackage) mcpsdk.ToolHandler {
+	return func(ctx context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+		var args struct {
+			Prompt string `json:"prompt"`
+		}
+		if err := json.Unmarshal(req.Params.Arguments, &args); err != nil || strings.TrimSpace(args.Prompt) == "" {
+			return mcpToolResult("", errors.New("a non-empty prompt is required")), nil
+		}
+
+		a := r.NewAgent()
+		if err := a.prepareSkill(skill); err != nil {
+			return mcpToolResult("", err), nil
+		}
+		output, err := a.executeSkillWithTools(ctx, args.Prompt, skill)
+		return mcpToolResult(output, err), nil
+	}
+}
+
+// baseToolHandler runs a built-in tool through executeToolCall.
+func baseToolHandler(r *Runner) mcpsdk.ToolHandler {
+	return func(ctx context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+		a := r.NewAgent()
+		tc := openai.ToolCall{
+			Type: openai.ToolTypeFunction,
+			Function: openai.FunctionCall{
+				Name:      req.Params.Name,
+				Arguments: string(req.Params.Arguments),
+			},
+		}
+
+		started := time.Now()
+		output, err := a.executeToolCall(ctx, tc, nil, SkillPackage{})
+		content, truncated := truncateToolOutput(output, a.toolOutputLimit(), a.cfg.KeepToolOutputTail)
+		a.audit(SkillPackage{}, tc, AuditApproved, started, output, truncated, err)
+		return mcpToolResult(a.redact(content), err), nil
+	}
+}
+
+// mcpToolResult reports output, or err as a tool error the client can show
+// to its model.
+func mcpToolResult(output string, err error) *mcpsdk.CallToolResult {
+	if err != nil {
+		return &mcpsdk.CallToolResult{
+			Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: err.Error()}},
+			IsError: true,
+		}
+	}
+	return &mcpsdk.CallToolResult{
+		Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: output}},
+	}
+}
+
+// mcpToolName replaces the characters MCP clients reject in tool names, such
+// as the "/" of a namespaced skill, with underscores.
+func mcpToolName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+}
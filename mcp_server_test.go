@@ -0,0 +1,58 @@
+package goskills
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPServer(t *testing.T) {
+	root := t.TempDir()
+	skillPath := filepath.Join(root, "research", "summarize")
+	require.NoError(t, os.MkdirAll(skillPath, 0755))
+	content := "---\nname: summarize\ndescription: Summarizes documents.\n---\n# Body\n"
+	require.NoError(t, os.WriteFile(filepath.Join(skillPath, "SKILL.md"), []byte(content), 0644))
+
+	dataFile := filepath.Join(root, "data.txt")
+	require.NoError(t, os.WriteFile(dataFile, []byte("hello mcp"), 0644))
+
+	server, err := NewMCPServer(RunnerConfig{APIKey: "test", SkillsDir: root})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcpsdk.NewInMemoryTransports()
+	_, err = server.Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+
+	client := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	tools, err := session.ListTools(ctx, nil)
+	require.NoError(t, err)
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	assert.Contains(t, names, "skill_research_summarize")
+	assert.Contains(t, names, "read_file")
+
+	args, _ := json.Marshal(map[string]string{"filePath": dataFile})
+	result, err := session.CallTool(ctx, &mcpsdk.CallToolParams{Name: "read_file", Arguments: json.RawMessage(args)})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "hello mcp", result.Content[0].(*mcpsdk.TextContent).Text)
+
+	// A skill call without a prompt is reported as a tool error
+	result, err = session.CallTool(ctx, &mcpsdk.CallToolParams{Name: "skill_research_summarize", Arguments: map[string]any{"prompt": ""}})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
@@ -0,0 +1,45 @@
+package goskills
+
+import (
+	"errors"
+	"strings"
+)
+
+// NoSkillName is the answer the model gives during skill selection when none
+// of the available skills fits the request.
+const NoSkillName = "none"
+
+// ErrNoSuitableSkill matches a *NoSuitableSkillError with errors.Is.
+var ErrNoSuitableSkill = errors.New("no suitable skill")
+
+// NoSuitableSkillError is returned by Run when the model finds no skill that
+// fits the request and no RunnerConfig.DefaultSkill is configured. Its
+// message lists the available skills.
+type NoSuitableSkillError struct {
+	// Skills are the available skills, sorted by name.
+	Skills []SkillSummary
+}
+
+func (e *NoSuitableSkillError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("No suitable skill was found for this request.")
+	if len(e.Skills) > 0 {
+		sb.WriteString(" Available skills:\n")
+		for _, skill := range e.Skills {
+			sb.WriteString("- " + skill.Name + ": " + skill.Description + "\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (e *NoSuitableSkillError) Is(target error) bool { return target == ErrNoSuitableSkill }
+
+// isNoSkill reports whether the selection answer means no skill fits. A skill
+// actually named "none" takes precedence.
+func isNoSkill(name string, skills map[string]SkillPackage) bool {
+	name = strings.TrimRight(name, ".")
+	if _, ok := skills[name]; ok {
+		return false
+	}
+	return strings.EqualFold(name, NoSkillName)
+}
@@ -0,0 +1,51 @@
+package goskills
+
+import (
+	"context"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticSkills is a SkillProvider with a fixed set of skills.
+type staticSkills map[string]SkillPackage
+
+func (s staticSkills) Skills() map[string]SkillPackage { return s }
+
+// noSkillClient answers the skill selection with "none" and every later
+// request with "done".
+func noSkillClient() *agenttest.FakeClient {
+	client := agenttest.NewFakeClient(agenttest.Reply("None."))
+	client.Respond = func(openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		return agenttest.Reply("done"), nil
+	}
+	return client
+}
+
+func TestRunNoSuitableSkill(t *testing.T) {
+	skills := staticSkills{
+		"pdf":  {Path: t.TempDir(), Meta: SkillMeta{Name: "pdf", Description: "Works with PDF files."}},
+		"tidy": {Path: t.TempDir(), Meta: SkillMeta{Name: "tidy", Description: "Tidies code."}},
+	}
+	a, err := NewAgent(RunnerConfig{Client: noSkillClient(), SkillProvider: skills}, nil)
+	require.NoError(t, err)
+
+	output, err := a.Run(context.Background(), "book a flight")
+	require.ErrorIs(t, err, ErrNoSuitableSkill)
+	assert.Equal(t, "No suitable skill was found for this request. Available skills:\n- pdf: Works with PDF files.\n- tidy: Tidies code.", output)
+
+	a, err = NewAgent(RunnerConfig{Client: noSkillClient(), SkillProvider: skills, DefaultSkill: "tidy"}, nil)
+	require.NoError(t, err)
+	output, err = a.Run(context.Background(), "book a flight")
+	require.NoError(t, err)
+	assert.Equal(t, "done", output)
+}
+
+func TestIsNoSkill(t *testing.T) {
+	assert.True(t, isNoSkill("NONE", nil))
+	assert.False(t, isNoSkill("pdf", nil))
+	assert.False(t, isNoSkill("none", map[string]SkillPackage{"none": {}}))
+}
@@ -0,0 +1,43 @@
+package goskills
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/smallnest/goskills/tool"
+)
+
+// ErrPostProcess is returned, instead of the output, when the post_process
+// script of a skill or RunnerConfig.PostProcess rejects the final output.
+var ErrPostProcess = errors.New("post-processing failed")
+
+// postProcess applies the post_process script of skill, then
+// RunnerConfig.PostProcess, to the final output of a run. The output of the
+// script, which runs with the secrets of the skill, is redacted again.
+func (a *Agent) postProcess(ctx context.Context, skill SkillPackage, output string) (string, error) {
+	if a.cfg.DryRun {
+		return output, nil
+	}
+	if script := skill.Meta.PostProcess; script != "" {
+		processed, err := tool.RunFilterScript(tool.WithOptions(ctx, a.toolOptions), filepath.Join(skill.Path, script), a.scriptEnv, output)
+		if err != nil {
+			return "", fmt.Errorf("%w for skill %s: %w", ErrPostProcess, skill.QualifiedName(), err)
+		}
+		output = a.redact(strings.TrimSuffix(processed, "\n"))
+		a.logEvent(ctx, slog.LevelDebug, "output post-processed",
+			fmt.Sprintf("🧹 Post-processed output with %s\n", script),
+			slog.String("skill", skill.QualifiedName()), slog.String("script", script))
+	}
+	if a.cfg.PostProcess != nil {
+		processed, err := a.cfg.PostProcess(skill.QualifiedName(), output)
+		if err != nil {
+			return "", fmt.Errorf("%w for skill %s: %w", ErrPostProcess, skill.QualifiedName(), err)
+		}
+		output = processed
+	}
+	return output, nil
+}
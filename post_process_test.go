@@ -0,0 +1,80 @@
+package goskills
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostProcess(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "scripts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "scripts", "strip.sh"),
+		[]byte("sed '/^# Report$/d'\necho ignored >&2\n"), 0644))
+	skill := SkillPackage{
+		Path:      dir,
+		Meta:      SkillMeta{Name: "report", Description: "Reports.", PostProcess: "scripts/strip.sh"},
+		Body:      "Write a report.",
+		Resources: SkillResources{Scripts: []string{"scripts/strip.sh"}},
+	}
+	assert.Empty(t, ValidateSkillPackage(&skill))
+
+	run := func(postProcess func(skill, output string) (string, error)) (string, error) {
+		client := agenttest.NewFakeClient(agenttest.Reply("report"), agenttest.Reply("# Report\nAll good."))
+		a, err := NewAgent(RunnerConfig{
+			Client:        client,
+			SkillProvider: staticSkills{"report": skill},
+			PostProcess:   postProcess,
+		}, nil)
+		require.NoError(t, err)
+		return a.Run(context.Background(), "write the report")
+	}
+
+	output, err := run(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "All good.", output)
+
+	var skills []string
+	output, err = run(func(skill, output string) (string, error) {
+		skills = append(skills, skill)
+		return strings.ToUpper(output), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ALL GOOD.", output)
+	assert.Equal(t, []string{"report"}, skills)
+
+	output, err = run(func(skill, output string) (string, error) {
+		return "", errors.New("too short")
+	})
+	assert.ErrorIs(t, err, ErrPostProcess)
+	assert.ErrorContains(t, err, "too short")
+	assert.Empty(t, output)
+
+	skill.Meta.PostProcess = "scripts/missing.sh"
+	assert.NotEmpty(t, ValidateSkillPackage(&skill))
+}
+
+func TestPostProcessRedactedAndTraced(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sign.sh"), []byte("cat\necho\necho \"signed with $SIGNING_KEY\"\n"), 0644))
+	skill := SkillPackage{Path: dir, Meta: SkillMeta{Name: "report", Description: "Reports.", PostProcess: "sign.sh"}}
+
+	a, err := NewAgent(RunnerConfig{
+		Client:        agenttest.NewFakeClient(agenttest.Reply("report"), agenttest.Reply("All good.")),
+		SkillProvider: staticSkills{"report": skill},
+		Secrets:       map[string]string{"SIGNING_KEY": "k3y-value"},
+		RecordTrace:   true,
+	}, nil)
+	require.NoError(t, err)
+	output, err := a.Run(context.Background(), "write the report")
+	require.NoError(t, err)
+	assert.Equal(t, "All good.\nsigned with [REDACTED]", output)
+	assert.Equal(t, output, a.Trace().Output)
+}
@@ -0,0 +1,98 @@
+package goskills
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Default skill selection prompt templates, see RunnerConfig.SelectionPrompt.
+const (
+	DefaultSelectionSystemPrompt = "You are an expert assistant that selects the most appropriate skill to handle a user's request. Your response must be only the exact name of the chosen skill, with no other text or explanation."
+
+	DefaultSelectionPrompt = "User Request: {{.UserPrompt}}\n\n" +
+		"Available Skills:\n" +
+		"{{range .Skills}}- {{.Name}}: {{.Description}}" +
+		"{{if .Aliases}} (also known as: {{join .Aliases \", \"}}){{end}}" +
+		"{{if .Keywords}} [keywords: {{join .Keywords \", \"}}]{{end}}\n{{end}}" +
+		"\nBased on the user request, which single skill is the most appropriate to use? Respond with only the name of the skill, including any namespace prefix (e.g. \"research/summarize\"). If none of the skills fits the request, respond with \"none\"."
+)
+
+// SelectionPromptData is the data the skill selection templates are rendered with.
+type SelectionPromptData struct {
+	UserPrompt string
+	// Skills are the available skills, sorted by name.
+	Skills []SkillSummary
+}
+
+// SkillSummary describes a skill offered for selection.
+type SkillSummary struct {
+	Name        string
+	Description string
+	Aliases     []string
+	Keywords    []string
+}
+
+// skillSummaries returns the summaries of skills, sorted by name.
+func skillSummaries(skills map[string]SkillPackage) []SkillSummary {
+	summaries := make([]SkillSummary, 0, len(skills))
+	for name, skill := range skills {
+		summaries = append(summaries, SkillSummary{
+			Name:        name,
+			Description: skill.Meta.Description,
+			Aliases:     skill.Meta.Aliases,
+			Keywords:    skill.Meta.Keywords,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+// selectionPrompts holds the parsed skill selection templates.
+type selectionPrompts struct {
+	system *template.Template
+	user   *template.Template
+}
+
+// parseSelectionPrompts parses the configured selection templates, falling
+// back to the defaults. Templates may use the "join" function, strings.Join.
+func parseSelectionPrompts(cfg RunnerConfig) (selectionPrompts, error) {
+	parse := func(name, text, fallback string) (*template.Template, error) {
+		if text == "" {
+			text = fallback
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", name, err)
+		}
+		return tmpl, nil
+	}
+
+	var prompts selectionPrompts
+	var err error
+	if prompts.system, err = parse("selection system prompt", cfg.SelectionSystemPrompt, DefaultSelectionSystemPrompt); err != nil {
+		return selectionPrompts{}, err
+	}
+	if prompts.user, err = parse("selection prompt", cfg.SelectionPrompt, DefaultSelectionPrompt); err != nil {
+		return selectionPrompts{}, err
+	}
+	return prompts, nil
+}
+
+// render renders the system and user selection prompts.
+func (p selectionPrompts) render(userPrompt string, skills map[string]SkillPackage) (system, user string, err error) {
+	data := SelectionPromptData{UserPrompt: userPrompt, Skills: skillSummaries(skills)}
+
+	var sb strings.Builder
+	if err := p.system.Execute(&sb, data); err != nil {
+		return "", "", fmt.Errorf("failed to render selection system prompt: %w", err)
+	}
+	system = sb.String()
+
+	sb.Reset()
+	if err := p.user.Execute(&sb, data); err != nil {
+		return "", "", fmt.Errorf("failed to render selection prompt: %w", err)
+	}
+	return system, sb.String(), nil
+}
@@ -0,0 +1,42 @@
+package goskills
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectionPrompts(t *testing.T) {
+	skills := map[string]SkillPackage{
+		"pdf":      {Meta: SkillMeta{Description: "Work with PDF files"}},
+		"markdown": {Meta: SkillMeta{Description: "Convert to Markdown"}},
+	}
+
+	prompts, err := parseSelectionPrompts(RunnerConfig{})
+	require.NoError(t, err)
+	system, user, err := prompts.render("convert a.pdf", skills)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSelectionSystemPrompt, system)
+	assert.Contains(t, user, "User Request: convert a.pdf\n\nAvailable Skills:\n- markdown: Convert to Markdown\n- pdf: Work with PDF files\n")
+
+	prompts, err = parseSelectionPrompts(RunnerConfig{
+		SelectionSystemPrompt: "Choisissez une compétence.",
+		SelectionPrompt:       "Demande : {{.UserPrompt}}{{range .Skills}}\n{{.Name}}{{end}}",
+	})
+	require.NoError(t, err)
+	system, user, err = prompts.render("convertir a.pdf", skills)
+	require.NoError(t, err)
+	assert.Equal(t, "Choisissez une compétence.", system)
+	assert.Equal(t, "Demande : convertir a.pdf\nmarkdown\npdf", user)
+
+	skills["digest"] = SkillPackage{Meta: SkillMeta{Description: "Summarize text", Aliases: []string{"summarize", "tldr"}, Keywords: []string{"article"}}}
+	prompts, err = parseSelectionPrompts(RunnerConfig{})
+	require.NoError(t, err)
+	_, user, err = prompts.render("summarize this", skills)
+	require.NoError(t, err)
+	assert.Contains(t, user, "- digest: Summarize text (also known as: summarize, tldr) [keywords: article]\n")
+
+	_, err = NewAgent(RunnerConfig{APIKey: "test", SelectionPrompt: "{{.Missing"}, nil)
+	assert.Error(t, err)
+}
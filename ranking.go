@@ -0,0 +1,101 @@
+package goskills
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent"
+)
+
+// ScoredSkill is a skill with the model's score for how well it fits a request.
+type ScoredSkill struct {
+	Name string `json:"name"`
+	// Score is between 0 (does not fit) and 1 (perfect fit).
+	Score float64 `json:"score"`
+	// Reason is the model's short explanation of the score.
+	Reason string `json:"reason,omitempty"`
+}
+
+// rankingSystemPrompt asks the model to score every skill.
+const rankingSystemPrompt = `You are an expert assistant that rates how well each available skill fits a user's request.
+Respond with a single JSON object and nothing else, in this form:
+{"rankings": [{"name": "<skill name>", "score": <number from 0 to 1>, "reason": "<one short sentence>"}]}
+Rate every skill, using its exact name including any namespace prefix.`
+
+// RankSkills asks the model to score how well each of skills fits prompt, for
+// comparing skill descriptions in offline evaluations. The result has one
+// entry per skill, sorted by descending score and then by name; skills the
+// model leaves out score 0. Agent.Run keeps selecting a single skill.
+func RankSkills(ctx context.Context, client agent.LLMClient, model, prompt string, skills map[string]SkillPackage) ([]ScoredSkill, error) {
+	if len(skills) == 0 {
+		return nil, errors.New("no skills to rank")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("User Request: " + prompt + "\n\nAvailable Skills:\n")
+	for _, skill := range skillSummaries(skills) {
+		sb.WriteString("- " + skill.Name + ": " + skill.Description + "\n")
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: rankingSystemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: sb.String()},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:    0,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("skill ranking returned no choices")
+	}
+
+	var result struct {
+		Rankings []ScoredSkill `json:"rankings"`
+	}
+	if err := json.Unmarshal([]byte(trimJSONFence(resp.Choices[0].Message.Content)), &result); err != nil {
+		return nil, fmt.Errorf("invalid skill ranking: %w", err)
+	}
+	return mergeRankings(result.Rankings, skills), nil
+}
+
+// mergeRankings keeps the first score given for each known skill, adds the
+// skills the model left out with a score of 0, and sorts the result.
+func mergeRankings(rankings []ScoredSkill, skills map[string]SkillPackage) []ScoredSkill {
+	scored := make(map[string]ScoredSkill, len(skills))
+	for _, r := range rankings {
+		r.Name = strings.Trim(strings.TrimSpace(r.Name), "'\"")
+		if _, ok := skills[r.Name]; !ok {
+			continue
+		}
+		if _, seen := scored[r.Name]; seen {
+			continue
+		}
+		r.Score = min(max(r.Score, 0), 1)
+		scored[r.Name] = r
+	}
+
+	ranked := make([]ScoredSkill, 0, len(skills))
+	for name := range skills {
+		r, ok := scored[name]
+		if !ok {
+			r = ScoredSkill{Name: name}
+		}
+		ranked = append(ranked, r)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	return ranked
+}
@@ -0,0 +1,42 @@
+package goskills
+
+import (
+	"context"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankSkills(t *testing.T) {
+	skills := map[string]SkillPackage{
+		"pdf":                {Meta: SkillMeta{Description: "Works with PDF files."}},
+		"research/summarize": {Meta: SkillMeta{Description: "Summarizes documents."}},
+		"tidy":               {Meta: SkillMeta{Description: "Tidies code."}},
+	}
+	client := agenttest.NewFakeClient(agenttest.Reply(`{"rankings": [
+		{"name": "research/summarize", "score": 0.9, "reason": "Summaries."},
+		{"name": "pdf", "score": 1.5},
+		{"name": "unknown", "score": 1},
+		{"name": "pdf", "score": 0.1}
+	]}`))
+
+	ranked, err := RankSkills(context.Background(), client, "gpt-4o", "summarize this PDF", skills)
+	require.NoError(t, err)
+	assert.Equal(t, []ScoredSkill{
+		{Name: "pdf", Score: 1},
+		{Name: "research/summarize", Score: 0.9, Reason: "Summaries."},
+		{Name: "tidy", Score: 0},
+	}, ranked)
+
+	require.Len(t, client.Requests(), 1)
+	req := client.Requests()[0]
+	assert.Equal(t, "gpt-4o", req.Model)
+	assert.Equal(t, openai.ChatCompletionResponseFormatTypeJSONObject, req.ResponseFormat.Type)
+	assert.Contains(t, req.Messages[1].Content, "- research/summarize: Summarizes documents.\n")
+
+	_, err = RankSkills(context.Background(), agenttest.NewFakeClient(agenttest.Reply("pdf")), "gpt-4o", "x", skills)
+	require.Error(t, err)
+}
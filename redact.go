@@ -0,0 +1,137 @@
+package goskills
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// RedactFunc masks secrets in text before it is logged or returned to the
+// caller. Set RunnerConfig.Redact to replace the default redaction; custom
+// functions can call RedactSecrets to keep the built-in patterns.
+type RedactFunc func(text string) string
+
+// redactedPlaceholder replaces every masked secret.
+const redactedPlaceholder = "[REDACTED]"
+
+// minSecretLength avoids masking short values such as "1" or "true" that
+// happen to be stored in an environment variable.
+const minSecretLength = 6
+
+// secretPatterns match well-known credential formats. When a pattern has a
+// capture group, the group (e.g. the "api_key=" prefix) is kept.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`),                 // OpenAI and Anthropic style keys
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),                    // AWS access key IDs
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}`),            // GitHub tokens
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),          // Slack tokens
+	regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9._~+/=-]{8,}`), // Authorization headers
+	regexp.MustCompile(`(?i)((?:api[_-]?key|access[_-]?token|secret|password|passwd|token)"?\s*[:=]\s*"?)[^"\s,&}]{4,}`),
+}
+
+// RedactSecrets masks the given secret values and anything matching a known
+// credential pattern in text.
+func RedactSecrets(text string, secrets ...string) string {
+	// Replace longer secrets first so a secret containing another is fully masked
+	sorted := append([]string(nil), secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, secret := range sorted {
+		if len(secret) >= minSecretLength {
+			text = strings.ReplaceAll(text, secret, redactedPlaceholder)
+		}
+	}
+
+	for _, re := range secretPatterns {
+		if re.NumSubexp() > 0 {
+			text = re.ReplaceAllString(text, "${1}"+redactedPlaceholder)
+		} else {
+			text = re.ReplaceAllString(text, redactedPlaceholder)
+		}
+	}
+	return text
+}
+
+// redact masks secrets in text using RunnerConfig.Redact, or RedactSecrets
+// with the API key and the selected skill's environment when it is unset.
+func (a *Agent) redact(text string) string {
+	if a.cfg.Redact != nil {
+		return a.cfg.Redact(text)
+	}
+	return RedactSecrets(text, a.secrets...)
+}
+
+// redactMessage returns a copy of msg with the secrets in its content and
+// tool call arguments masked.
+func (a *Agent) redactMessage(msg openai.ChatCompletionMessage) openai.ChatCompletionMessage {
+	msg.Content = a.redact(msg.Content)
+	if msg.ToolCalls != nil {
+		calls := make([]openai.ToolCall, len(msg.ToolCalls))
+		for i, tc := range msg.ToolCalls {
+			tc.Function.Arguments = a.redact(tc.Function.Arguments)
+			calls[i] = tc
+		}
+		msg.ToolCalls = calls
+	}
+	return msg
+}
+
+// secretNames returns the sorted names of the configured secrets, which the
+// model is told about.
+func secretNames(secrets map[string]string) []string {
+	var names []string
+	for name, value := range secrets {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hideSecrets masks the values of the configured secrets in a tool result
+// before it is sent to the model.
+func hideSecrets(text string, secrets map[string]string) string {
+	values := make([]string, 0, len(secrets))
+	for _, value := range secrets {
+		values = append(values, value)
+	}
+	// Replace longer secrets first so a secret containing another is fully masked
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, value := range values {
+		if value != "" {
+			text = strings.ReplaceAll(text, value, redactedPlaceholder)
+		}
+	}
+	return text
+}
+
+// checkSecrets rejects configured secrets too short to be masked without
+// garbling unrelated text, which hideSecrets would otherwise have to leave
+// visible to the model.
+func checkSecrets(secrets map[string]string) error {
+	for _, name := range secretNames(secrets) {
+		if len(secrets[name]) < minSecretLength {
+			return fmt.Errorf("secret %s is shorter than %d characters", name, minSecretLength)
+		}
+	}
+	return nil
+}
+
+// skillSecrets collects the values that must never appear in logs for skill:
+// the API key, the entries of the skill's .env file and its required variables.
+func skillSecrets(apiKey string, skill SkillPackage, scriptEnv []string) []string {
+	secrets := []string{apiKey}
+	for _, entry := range scriptEnv {
+		if _, value, ok := strings.Cut(entry, "="); ok {
+			secrets = append(secrets, value)
+		}
+	}
+	for _, name := range skill.Meta.RequiredEnv {
+		secrets = append(secrets, os.Getenv(name))
+	}
+	return secrets
+}
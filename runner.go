@@ -6,90 +6,445 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent"
 	"github.com/smallnest/goskills/mcp"
 	"github.com/smallnest/goskills/tool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Agent manages the skill discovery, selection, and execution process.
 type Agent struct {
-	client    *openai.Client
-	cfg       RunnerConfig
-	messages  []openai.ChatCompletionMessage // Stores the conversation history
-	mcpClient *mcp.Client
+	client          agent.LLMClient
+	cfg             RunnerConfig
+	messages        []openai.ChatCompletionMessage // Stores the conversation history
+	mcpClient       *mcp.Client
+	scriptEnv       []string // Extra environment for scripts of the selected skill
+	secrets         []string // Values masked by redact
+	usage           TokenUsage
+	fingerprints    []string // System fingerprints of the responses of the run
+	checkpointID    string   // ID the run checkpoints under
+	prompts         selectionPrompts
+	finishReason    openai.FinishReason // Why the model stopped its last response
+	trace           *ExecutionTrace     // Transcript of the current run, when tracing
+	sandboxDir      string              // Working copy of the skill, when sandboxed
+	artifacts       []Artifact          // Files written by the current run
+	toolOptions     tool.Options        // Settings of the tools the run calls
+	attachmentFiles []CheckpointFile    // Temporary attachment files, kept in checkpoints
 }
 
 // RunnerConfig holds all the necessary configuration for the runner.
 type RunnerConfig struct {
+	// Provider selects the LLM backend: agent.ProviderOpenAI (default),
+	// agent.ProviderAnthropic or agent.ProviderGemini.
+	Provider         string
 	APIKey           string
 	APIBase          string
 	Model            string
 	SkillsDir        string
 	Verbose          bool
 	AutoApproveTools bool
-	AllowedScripts   []string
-	Loop             bool
+	// AutoApproveDestructive also auto-approves destructive tool calls, such
+	// as write_file and code execution. Without it, AutoApproveTools only
+	// covers safe, read-only tools. See tool.ToolRisk.
+	AutoApproveDestructive bool
+	AllowedScripts         []string
+	// AllowedShellCommands, when not empty, limits run_shell_code to scripts
+	// that only run these commands, e.g. []string{"ls", "cat", "grep"}.
+	AllowedShellCommands []string
+	Loop                 bool
+	// SkillProvider, when set, supplies the skills instead of parsing SkillsDir
+	// on every run, e.g. a SkillWatcher for live skill reloading.
+	SkillProvider SkillProvider
+	// DryRun lets the model propose tool calls without executing them. Each
+	// call gets a synthetic "(dry-run: not executed)" result and Run returns
+	// the list of intended calls.
+	DryRun bool
+	// InteractionHandler, when set, is used to approve tool calls instead of
+	// prompting on stdin, receives progress events for each iteration and can
+	// stop the tool-calling loop through ShouldCancel.
+	InteractionHandler agent.InteractionHandler
+	// AuditSink, when set, records every tool call the model makes, whether
+	// it was executed, denied or skipped.
+	AuditSink AuditSink
+	// Redact, when set, replaces the default masking of secrets in verbose
+	// logs, audit events and returned output. See RedactSecrets.
+	Redact RedactFunc
+	// PostProcess, when set, is applied to the final output of every run,
+	// after the post_process script of the skill, with the qualified name of
+	// the skill. The output it returns replaces that of the run; an error
+	// fails the run with ErrPostProcess. Dry runs are not post-processed.
+	PostProcess func(skill, output string) (string, error)
+	// CheckPythonSyntax compiles Python code and scripts before running
+	// them, so a syntax error is returned to the model without running any
+	// of the code and causing side effects.
+	CheckPythonSyntax bool
+	// ScriptOutputEncoding is the encoding that script and code output which
+	// is not valid UTF-8 is transcoded from, e.g. "windows-1252", "gbk" or
+	// "shift_jis". Empty replaces the invalid bytes with U+FFFD instead.
+	// Binary output is base64-encoded with a note either way.
+	ScriptOutputEncoding string
+	// Secrets are set as environment variables, keyed by name, for the
+	// scripts and code the skills run, overriding the process environment
+	// and the skill's .env file. The model is told their names, so generated
+	// code can read e.g. $MY_API_KEY, but never sees their values: they are
+	// masked in tool results as well as in logs and output. Values shorter
+	// than 6 characters are rejected, as they cannot be masked reliably.
+	Secrets map[string]string
+	// Tracer, when set, receives OpenTelemetry spans for skill selection,
+	// skill execution, each LLM request and each tool call.
+	Tracer trace.Tracer
+	// MaxToolOutputBytes limits the size of a tool result sent back to the
+	// model; longer results are truncated with a marker and recorded in full
+	// in the audit log. Zero uses DefaultMaxToolOutputBytes and a negative
+	// value disables the limit.
+	MaxToolOutputBytes int
+	// KeepToolOutputTail keeps the end of a truncated tool result as well as
+	// its beginning.
+	KeepToolOutputTail bool
+	// CompactThresholdTokens is the estimated history size above which older
+	// messages are summarized to stay within the model's context. Zero uses
+	// DefaultCompactThresholdTokens and a negative value disables compaction.
+	CompactThresholdTokens int
+	// CompactKeepMessages is how many recent messages compaction keeps
+	// verbatim. Zero uses DefaultCompactKeepMessages.
+	CompactKeepMessages int
+	// CompactionModel summarizes the history. Empty uses Model.
+	CompactionModel string
+	// MaxCostUSD stops a run once its estimated LLM cost reaches this amount,
+	// returning the partial result and ErrBudgetExceeded. Zero means no limit.
+	// Costs use ModelPrices, then DefaultModelPrices.
+	MaxCostUSD float64
+	// MaxTokens stops a run once it has used this many tokens, like MaxCostUSD.
+	MaxTokens int
+	// Seed, when set, is sent with every LLM request of a run, from skill
+	// selection to the tool-calling loop, so backends that support it sample
+	// deterministically on a best-effort basis, e.g. for regression tests of
+	// skills. Agent.SystemFingerprints tells whether the backend changed.
+	Seed *int
+	// ModelPrices overrides DefaultModelPrices, keyed by model name.
+	ModelPrices map[string]ModelPrice
+	// SelectionSystemPrompt and SelectionPrompt override the text/template
+	// prompts used to select a skill, rendered with SelectionPromptData. Empty
+	// uses DefaultSelectionSystemPrompt and DefaultSelectionPrompt.
+	SelectionSystemPrompt string
+	SelectionPrompt       string
+	// CacheDir, when set, caches the results of web_fetch and the search
+	// tools on disk for CacheTTL (zero uses tool.DefaultCacheTTL).
+	CacheDir string
+	CacheTTL time.Duration
+	// CacheBypass fetches fresh results instead of using cached ones.
+	CacheBypass bool
+	// MaxContinuations is how many times a final answer cut off by the
+	// model's output limit is continued with a follow-up request. Zero uses
+	// DefaultMaxContinuations and a negative value disables continuation;
+	// an answer that stays cut off ends with a truncation notice.
+	MaxContinuations int
+	// MaxRepeatedToolErrors is how many times in a row the same tool call may
+	// fail with the same error before the model is told to try a different
+	// approach; if it repeats the call once more, the run stops with
+	// ErrToolLoop. Zero uses DefaultMaxRepeatedToolErrors and a negative
+	// value disables loop detection.
+	MaxRepeatedToolErrors int
+	// Stream streams the model's text through InteractionHandler.StreamChunk
+	// (or stdout without a handler) while the skill runs, including the text
+	// that precedes tool calls. Backends without streaming send each response
+	// as a single chunk.
+	Stream bool
+	// DefaultSkill is the qualified name of the skill to run when the model
+	// finds that no skill fits the request. Without it, Run returns a
+	// NoSuitableSkillError listing the available skills.
+	DefaultSkill string
+	// KeywordPreMatch selects a skill without asking the model when the
+	// prompt clearly matches its name, one of its aliases or several of its
+	// keywords, and no other skill matches as well.
+	KeywordPreMatch bool
+	// EmbeddingModel embeds the skill documents searched by the retrieve_docs
+	// tool. Empty uses DefaultEmbeddingModel, or a local word-hashing
+	// embedding for backends without an embeddings API. Setting it for such
+	// a backend makes retrieve_docs fail rather than fall back.
+	EmbeddingModel string
+	// SQLDSN, when set, offers the sql_query tool for this database, given
+	// as "<driver>:<data source>" (see tool.SQLTool). The driver must be
+	// registered by importing it. The model never sees the DSN.
+	SQLDSN string
+	// SQLAllowWrites lets sql_query run statements that modify the database.
+	// Without it, queries run in a read-only transaction. Every sql_query
+	// call needs approval as a destructive tool call.
+	SQLAllowWrites bool
+	// RecordTrace keeps the complete transcript of each run, returned by
+	// Agent.Trace. See ExecutionTrace.
+	RecordTrace bool
+	// TraceFile, when set, records traces like RecordTrace and writes the
+	// trace of each run to this file as JSON, replacing the previous one.
+	TraceFile string
+	// Client, when set, sends the LLM requests instead of a client created
+	// from Provider, APIKey and APIBase, e.g. an agenttest.FakeClient in
+	// tests. APIKey is then optional.
+	Client agent.LLMClient
+	// Tools, when set, replace the built-in or MCP tools of the same name,
+	// e.g. with fakes in tests. They are still offered to the model, and
+	// approved, as the tools they replace.
+	Tools map[string]ToolFunc
+	// HTTPClient, when set, sends the LLM requests and the requests of the
+	// network tools, e.g. through a proxy or with custom TLS roots. Nil uses
+	// the default clients.
+	HTTPClient *http.Client
+	// LogWriter receives the verbose output, warnings and tool approval
+	// prompts, keeping stdout for the results. Nil uses os.Stderr.
+	LogWriter io.Writer
+	// Logger, when set, receives structured records of the run, such as the
+	// selected skill and each tool call with its duration and error, instead
+	// of the text written to LogWriter. Records are emitted whether or not
+	// Verbose is set; filter them by level with the handler.
+	Logger *slog.Logger
+	// Metrics, when set, observes every tool call with its duration and
+	// error, e.g. to export per-tool counts, latencies and error rates.
+	Metrics tool.Metrics
+	// Sandbox runs each skill in a temporary copy of its directory, so that
+	// scripts and write_file leave shared or read-only skills untouched.
+	// Scripts and code run in the copy, relative write_file and git paths
+	// are resolved in it, and delete_file and git are confined to it. The
+	// copy is removed after a successful run, once its artifacts are copied
+	// out, and kept after a failed one; see Agent.SandboxDir and
+	// Agent.Artifacts.
+	Sandbox bool
+	// Clarify lets the model ask the user up to MaxClarifications clarifying
+	// questions about an underspecified request before any skill runs,
+	// through InteractionHandler.AskClarification or a stdin prompt. Skills
+	// can ask for this with "clarify: true" in their metadata.
+	Clarify bool
+	// MaxClarifications limits the clarifying questions of a run. Zero uses
+	// 3.
+	MaxClarifications int
+	// DisabledTools lists tools, by name or path.Match glob such as
+	// "*_search", that are never offered to skills, e.g. to run without
+	// network access. Skills whose required_tools are disabled are not
+	// selected.
+	DisabledTools []string
+	// KeepSandbox also keeps the working copy of successful runs, e.g. to
+	// collect the files a skill produced.
+	KeepSandbox bool
+	// History seeds the conversation of Run with earlier turns, placed after
+	// the skill's system prompt and before the new user prompt. Agent.History
+	// returns the updated conversation for the next turn; see RunWithHistory.
+	History []openai.ChatCompletionMessage
+	// Attachments are added to the conversation of Run after History and
+	// before the user prompt, e.g. documents to analyze; see
+	// RunWithAttachments.
+	Attachments []Attachment
+	// MaxInlineAttachmentBytes is the size up to which text attachments are
+	// included in the conversation; larger ones are offered as files to read
+	// with read_file. Zero uses DefaultMaxInlineAttachmentBytes and a
+	// negative value never inlines attachments.
+	MaxInlineAttachmentBytes int
+	// CheckpointStore, when set, receives a Checkpoint of the run before
+	// every iteration of the tool-calling loop, so that ResumeRun can
+	// continue it after a crash or cancellation. The checkpoint is deleted
+	// once the run completes. Dry runs are not checkpointed.
+	CheckpointStore CheckpointStore
+	// CheckpointID is the ID runs checkpoint under. Empty generates one per
+	// run; see Agent.CheckpointID.
+	CheckpointID string
 }
 
+// ToolFunc runs a tool call with its JSON arguments and returns the output
+// for the model; see RunnerConfig.Tools.
+type ToolFunc func(ctx context.Context, arguments string) (string, error)
+
 // NewAgent creates and initializes a new Agent.
 func NewAgent(cfg RunnerConfig, mcpClient *mcp.Client) (*Agent, error) {
-	if cfg.APIKey == "" {
+	r, err := NewRunner(cfg, mcpClient)
+	if err != nil {
+		return nil, err
+	}
+	return r.NewAgent(), nil
+}
+
+// Runner runs skills with one configuration. An Agent keeps the state of its
+// run and must not be shared, while a Runner is safe for concurrent use: each
+// of its runs gets a fresh Agent sharing the Runner's LLM client, and with it
+// the connection pool, and the result cache. Build one Runner per
+// configuration in servers that run many skills.
+type Runner struct {
+	cfg         RunnerConfig
+	client      agent.LLMClient
+	mcpClient   *mcp.Client
+	prompts     selectionPrompts
+	toolOptions tool.Options
+}
+
+// NewRunner creates the LLM client and the tool settings for cfg, such as the
+// HTTP client and the result cache. The tool settings apply to the runs of
+// the Runner only.
+func NewRunner(cfg RunnerConfig, mcpClient *mcp.Client) (*Runner, error) {
+	if cfg.APIKey == "" && cfg.Client == nil {
 		return nil, errors.New("API key is not set")
 	}
 	if cfg.Model == "" {
-		cfg.Model = "gpt-4o" // Default model
+		cfg.Model = agent.DefaultModel(cfg.Provider)
 	}
 
-	openaiConfig := openai.DefaultConfig(cfg.APIKey)
-	if cfg.APIBase != "" {
-		openaiConfig.BaseURL = cfg.APIBase
+	client := cfg.Client
+	if client == nil {
+		var err error
+		client, err = agent.NewLLMClientWithHTTPClient(cfg.Provider, cfg.APIKey, cfg.APIBase, cfg.HTTPClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Seed != nil {
+		client = agent.WithSeed(client, *cfg.Seed)
+	}
+	prompts, err := parseSelectionPrompts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSecrets(cfg.Secrets); err != nil {
+		return nil, err
+	}
+	encoding, err := tool.LookupScriptOutputEncoding(cfg.ScriptOutputEncoding)
+	if err != nil {
+		return nil, err
 	}
-	client := openai.NewClientWithConfig(openaiConfig)
 
-	return &Agent{
-		client:    client,
+	r := &Runner{
 		cfg:       cfg,
-		messages:  []openai.ChatCompletionMessage{}, // Initialize empty message history
+		client:    client,
 		mcpClient: mcpClient,
-	}, nil
+		prompts:   prompts,
+		toolOptions: tool.Options{
+			HTTPClient:           cfg.HTTPClient,
+			Metrics:              cfg.Metrics,
+			ScriptOutputEncoding: encoding,
+		},
+	}
+	if cfg.CacheDir != "" {
+		logger := &Agent{cfg: cfg}
+		r.toolOptions.ResultCache = &tool.ResultCache{
+			Dir:    cfg.CacheDir,
+			TTL:    cfg.CacheTTL,
+			Bypass: cfg.CacheBypass,
+			OnHit: func(provider, key string) {
+				logger.logEvent(context.Background(), slog.LevelDebug, "cache hit",
+					fmt.Sprintf("💾 Cache hit (%s): %s\n", provider, key),
+					slog.String("provider", provider), slog.String("key", key))
+			},
+		}
+	}
+	return r, nil
+}
+
+// NewAgent returns a fresh Agent that shares the client of r, e.g. to read
+// its Trace or Artifacts after a run.
+func (r *Runner) NewAgent() *Agent {
+	return &Agent{
+		client:      r.client,
+		cfg:         r.cfg,
+		secrets:     []string{r.cfg.APIKey},
+		messages:    []openai.ChatCompletionMessage{}, // Initialize empty message history
+		mcpClient:   r.mcpClient,
+		prompts:     r.prompts,
+		toolOptions: r.toolOptions,
+	}
+}
+
+// Run runs userPrompt on a fresh Agent; see Agent.Run.
+func (r *Runner) Run(ctx context.Context, userPrompt string) (string, error) {
+	return r.NewAgent().Run(ctx, userPrompt)
+}
+
+// RunWithHistory runs userPrompt on a fresh Agent, continuing the
+// conversation in history; see Agent.RunWithHistory.
+func (r *Runner) RunWithHistory(ctx context.Context, userPrompt string, history []openai.ChatCompletionMessage) (string, []openai.ChatCompletionMessage, error) {
+	return r.NewAgent().RunWithHistory(ctx, userPrompt, history)
 }
 
 // Run executes the main skill selection and execution logic for a single turn.
 func (a *Agent) Run(ctx context.Context, userPrompt string) (string, error) {
+	return a.run(ctx, userPrompt, a.configInput())
+}
+
+// runInput is the input of a single run besides its prompt. It is passed
+// along rather than stored in the Agent's RunnerConfig, so one call does not
+// leak into the next.
+type runInput struct {
+	history     []openai.ChatCompletionMessage
+	attachments []Attachment
+}
+
+// configInput returns the input of RunnerConfig.History and
+// RunnerConfig.Attachments.
+func (a *Agent) configInput() runInput {
+	return runInput{history: a.cfg.History, attachments: a.cfg.Attachments}
+}
+
+// run is Run with the input in.
+func (a *Agent) run(ctx context.Context, userPrompt string, in runInput) (string, error) {
+	a.usage = TokenUsage{}
+	a.fingerprints = nil
+	a.checkpointID = ""
 	selectedSkill, err := a.selectAndPrepareSkill(ctx, userPrompt)
+	if errors.Is(err, ErrNoSuitableSkill) {
+		return err.Error(), err
+	}
 	if err != nil {
 		return "", err
 	}
 
 	// --- STEP 3: SKILL EXECUTION (with Tool Calling) ---
-	if a.cfg.Verbose {
-		fmt.Println("🚀 Executing skill (with potential tool calls).")
-		fmt.Println(strings.Repeat("-", 40))
-	}
+	a.logEvent(ctx, slog.LevelInfo, "executing skill",
+		"🚀 Executing skill (with potential tool calls).\n"+strings.Repeat("-", 40)+"\n",
+		slog.String("skill", selectedSkill.QualifiedName()))
 
-	return a.executeSkillWithTools(ctx, userPrompt, *selectedSkill)
+	return a.executeSkill(ctx, userPrompt, *selectedSkill, in)
 }
 
 // RunLoop starts an interactive session for a selected skill.
 func (a *Agent) RunLoop(ctx context.Context, initialPrompt string) error {
+	a.usage = TokenUsage{}
+	a.fingerprints = nil
+	a.checkpointID = ""
 	selectedSkill, err := a.selectAndPrepareSkill(ctx, initialPrompt)
 	if err != nil {
 		return err
 	}
 
+	inputs, err := a.extractSkillInputs(ctx, initialPrompt, *selectedSkill)
+	if err != nil {
+		return err
+	}
+	skill, err := a.enterSandbox(*selectedSkill)
+	if err != nil {
+		return err
+	}
+	selectedSkill = &skill
+	// The session ends at the user's request, not with a failure
+	defer a.leaveSandbox(nil)
+
 	// Prepare the system message once
-	var skillBody strings.Builder
-	skillBody.WriteString(selectedSkill.Body)
-	skillBody.WriteString("\n\n## SKILL CONTEXT\n")
-	skillBody.WriteString(fmt.Sprintf("Skill Root Path: %s\n", selectedSkill.Path))
-	a.messages = append(a.messages, openai.ChatCompletionMessage{
+	a.startTrace(initialPrompt, *selectedSkill)
+	a.artifacts = nil
+	a.addMessages(openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleSystem,
-		Content: skillBody.String(),
+		Content: skillSystemPrompt(*selectedSkill, inputs, secretNames(a.cfg.Secrets)),
 	})
+	attachments, cleanup, err := a.attachmentMessages(a.cfg.Attachments)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	a.addMessages(attachments...)
 
 	reader := bufio.NewReader(os.Stdin)
 	currentPrompt := initialPrompt
@@ -97,6 +452,11 @@ func (a *Agent) RunLoop(ctx context.Context, initialPrompt string) error {
 	for {
 		fmt.Println(strings.Repeat("-", 40))
 		finalOutput, err := a.continueSkillWithTools(ctx, currentPrompt, *selectedSkill)
+		a.finishTrace(finalOutput, err)
+		if errors.Is(err, ErrBudgetExceeded) {
+			fmt.Println(finalOutput)
+			return err
+		}
 		if err != nil {
 			fmt.Printf("❌ Error during execution: %v\n", err)
 		} else {
@@ -126,9 +486,9 @@ func (a *Agent) RunLoop(ctx context.Context, initialPrompt string) error {
 // selectAndPrepareSkill discovers and selects the appropriate skill.
 func (a *Agent) selectAndPrepareSkill(ctx context.Context, userPrompt string) (*SkillPackage, error) {
 	// --- STEP 1: SKILL DISCOVERY ---
-	if a.cfg.Verbose {
-		fmt.Printf("🔎 Discovering available skills in %s...\n", a.cfg.SkillsDir)
-	}
+	a.logEvent(ctx, slog.LevelDebug, "discovering skills",
+		fmt.Sprintf("🔎 Discovering available skills in %s...\n", a.cfg.SkillsDir),
+		slog.String("dir", a.cfg.SkillsDir))
 	availableSkills, err := a.discoverSkills(a.cfg.SkillsDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover skills: %w", err)
@@ -136,63 +496,152 @@ func (a *Agent) selectAndPrepareSkill(ctx context.Context, userPrompt string) (*
 	if len(availableSkills) == 0 {
 		return nil, errors.New("no valid skills found")
 	}
-	if a.cfg.Verbose {
-		fmt.Printf("✅ Found %d skills.\n\n", len(availableSkills))
-	}
+	a.logEvent(ctx, slog.LevelDebug, "skills discovered",
+		fmt.Sprintf("✅ Found %d skills.\n\n", len(availableSkills)),
+		slog.Int("count", len(availableSkills)))
 
 	// --- STEP 2: SKILL SELECTION ---
-	if a.cfg.Verbose {
-		fmt.Println("🧠 Asking LLM to select the best skill...")
-	}
-	selectedSkillName, err := a.selectSkill(ctx, userPrompt, availableSkills)
+	selectedSkillName, selectedSkill, matched, err := a.pickSkill(ctx, userPrompt, availableSkills)
 	if err != nil {
-		return nil, fmt.Errorf("failed during skill selection: %w", err)
+		return nil, err
+	}
+	if len(selectedSkill.Meta.RequiredTools) > 0 {
+		mcpTools := a.mcpTools(ctx)
+		if missing := a.missingTools(selectedSkill, mcpTools); len(missing) > 0 {
+			a.logAlways(ctx, slog.LevelWarn, "skill excluded for lack of tools",
+				fmt.Sprintf("⚠️ Skill %s needs unavailable tools (%s), selecting another skill\n", selectedSkillName, strings.Join(missing, ", ")),
+				slog.String("skill", selectedSkillName), slog.Any("missing_tools", missing))
+			selectedSkillName, selectedSkill, matched, err = a.pickSkill(ctx, userPrompt, a.runnableSkills(availableSkills, mcpTools))
+			if err != nil {
+				return nil, err
+			}
+			if missing := a.missingTools(selectedSkill, mcpTools); len(missing) > 0 {
+				return nil, fmt.Errorf("skill '%s' needs unavailable tools: %s", selectedSkillName, strings.Join(missing, ", "))
+			}
+		}
+	}
+	if err := a.prepareSkill(selectedSkill); err != nil {
+		return nil, err
 	}
+	a.logEvent(ctx, slog.LevelInfo, "skill selected",
+		fmt.Sprintf("✅ LLM selected skill: %s\n\n", selectedSkillName),
+		slog.String("skill", selectedSkillName), slog.Bool("keyword_match", matched))
+	return &selectedSkill, nil
+}
 
-	selectedSkill, ok := availableSkills[selectedSkillName]
+// pickSkill selects the skill for userPrompt among skills, by keyword when
+// RunnerConfig.KeywordPreMatch is set or else by asking the LLM, falling back
+// to the default skill. matched reports a keyword match.
+func (a *Agent) pickSkill(ctx context.Context, userPrompt string, skills map[string]SkillPackage) (name string, skill SkillPackage, matched bool, err error) {
+	if a.cfg.KeywordPreMatch {
+		name, matched = matchSkillKeywords(userPrompt, skills)
+		if matched {
+			a.logEvent(ctx, slog.LevelDebug, "keywords matched skill",
+				fmt.Sprintf("🔑 Keywords matched skill: %s\n", name),
+				slog.String("skill", name))
+		}
+	}
+	if !matched {
+		a.logEvent(ctx, slog.LevelDebug, "selecting skill", "🧠 Asking LLM to select the best skill...\n")
+		name, err = a.selectSkill(ctx, userPrompt, skills)
+		if err != nil {
+			return "", SkillPackage{}, false, fmt.Errorf("failed during skill selection: %w", err)
+		}
+	}
+
+	if isNoSkill(name, skills) {
+		if a.cfg.DefaultSkill == "" {
+			a.logEvent(ctx, slog.LevelInfo, "no suitable skill", "🤷 LLM found no suitable skill.\n")
+			return "", SkillPackage{}, false, &NoSuitableSkillError{Skills: skillSummaries(skills)}
+		}
+		if _, ok := skills[a.cfg.DefaultSkill]; !ok {
+			return "", SkillPackage{}, false, fmt.Errorf("default skill '%s' not found", a.cfg.DefaultSkill)
+		}
+		a.logEvent(ctx, slog.LevelInfo, "no suitable skill, using the default skill",
+			fmt.Sprintf("🤷 LLM found no suitable skill, falling back to %s\n", a.cfg.DefaultSkill),
+			slog.String("skill", a.cfg.DefaultSkill))
+		name = a.cfg.DefaultSkill
+	}
+
+	skill, ok := skills[name]
 	if !ok {
-		return nil, fmt.Errorf("⚠️ LLM selected a non-existent skill '%s'. Aborting", selectedSkillName)
+		if resolved, found := resolveSkillAlias(name, skills); found {
+			name, skill, ok = resolved, skills[resolved], true
+		}
 	}
-	if a.cfg.Verbose {
-		fmt.Printf("✅ LLM selected skill: %s\n\n", selectedSkillName)
+	if !ok {
+		return "", SkillPackage{}, false, fmt.Errorf("⚠️ LLM selected a non-existent skill '%s'. Aborting", name)
 	}
-	return &selectedSkill, nil
+	return name, skill, matched, nil
+}
+
+// prepareSkill checks that skill can run and loads its script environment.
+func (a *Agent) prepareSkill(skill SkillPackage) error {
+	if err := CheckSkillCompatibility(&skill); err != nil {
+		return err
+	}
+	scriptEnv, err := prepareSkillEnv(skill, a.cfg.Secrets)
+	if err != nil {
+		return err
+	}
+	a.scriptEnv = scriptEnv
+	a.secrets = skillSecrets(a.cfg.APIKey, skill, scriptEnv)
+	return nil
 }
 
 func (a *Agent) discoverSkills(skillsRoot string) (map[string]SkillPackage, error) {
-	packages, err := ParseSkillPackages(skillsRoot)
+	if a.cfg.SkillProvider != nil {
+		return a.cfg.SkillProvider.Skills(), nil
+	}
+
+	packages, problems, err := ParseSkillPackagesStrict(skillsRoot)
 	if err != nil {
 		return nil, err
 	}
+	for _, problem := range problems {
+		a.logEvent(context.Background(), slog.LevelWarn, "skipping invalid skill",
+			fmt.Sprintf("⚠️ Skipping invalid skill %v\n", problem),
+			slog.Any("error", problem))
+	}
 
 	skills := make(map[string]SkillPackage, len(packages))
 	for _, pkg := range packages {
 		if pkg != nil {
-			skills[pkg.Meta.Name] = *pkg
+			skills[pkg.QualifiedName()] = *pkg
+			version := pkg.Meta.Version
+			if version == "" {
+				version = "unversioned"
+			}
+			a.logEvent(context.Background(), slog.LevelDebug, "skill found",
+				fmt.Sprintf("  - %s (%s)\n", pkg.QualifiedName(), version),
+				slog.String("skill", pkg.QualifiedName()), slog.String("version", version))
 		}
 	}
 
 	return skills, nil
 }
 
-func (a *Agent) selectSkill(ctx context.Context, userPrompt string, skills map[string]SkillPackage) (string, error) {
-	var sb strings.Builder
-	sb.WriteString("User Request: " + "" + userPrompt + "" + "\n\n")
-	sb.WriteString("Available Skills:\n")
-	for name, skill := range skills {
-		sb.WriteString(fmt.Sprintf("- %s: %s\n", name, skill.Meta.Description))
+func (a *Agent) selectSkill(ctx context.Context, userPrompt string, skills map[string]SkillPackage) (skillName string, err error) {
+	ctx, span := a.startSpan(ctx, "goskills.select_skill", attribute.Int("skill.available", len(skills)))
+	defer func() {
+		span.SetAttributes(attribute.String("skill.name", skillName))
+		endSpan(span, err)
+	}()
+
+	systemPrompt, prompt, err := a.prompts.render(userPrompt, skills)
+	if err != nil {
+		return "", err
 	}
-	sb.WriteString("\nBased on the user request, which single skill is the most appropriate to use? Respond with only the name of the skill.")
 
 	// Use a temporary message history for skill selection
 	selectionMessages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
-			Content: "You are an expert assistant that selects the most appropriate skill to handle a user's request. Your response must be only the exact name of the chosen skill, with no other text or explanation.",
+			Content: systemPrompt,
 		},
 		{
 			Role:    openai.ChatMessageRoleUser,
-			Content: sb.String(),
+			Content: prompt,
 		},
 	}
 
@@ -202,250 +651,729 @@ func (a *Agent) selectSkill(ctx context.Context, userPrompt string, skills map[s
 		Temperature: 0,
 	}
 
-	resp, err := a.client.CreateChatCompletion(ctx, req)
+	resp, err := a.createChatCompletion(ctx, req)
 	if err != nil {
 		return "", err
 	}
 
-	skillName := strings.TrimSpace(resp.Choices[0].Message.Content)
+	skillName = strings.TrimSpace(resp.Choices[0].Message.Content)
 	skillName = strings.Trim(skillName, "'\"")
 
 	return skillName, nil
 }
 
-// executeSkillWithTools sets up the initial system prompt and starts the tool-use conversation.
+// lastAssistantContent returns the latest non-empty assistant message, used as
+// the partial result of a canceled run.
+func (a *Agent) lastAssistantContent() string {
+	for i := len(a.messages) - 1; i >= 0; i-- {
+		if a.messages[i].Role == openai.ChatMessageRoleAssistant && a.messages[i].Content != "" {
+			return a.messages[i].Content
+		}
+	}
+	return ""
+}
+
+// partialResponse returns the answer assembled so far when a run is
+// interrupted: the continued parts of a cut-off answer, or else the latest
+// assistant message.
+func (a *Agent) partialResponse(finalResponse *strings.Builder) string {
+	if finalResponse.Len() > 0 {
+		return finalResponse.String()
+	}
+	return a.lastAssistantContent()
+}
+
+// addMessages appends messages to the conversation and the trace.
+func (a *Agent) addMessages(messages ...openai.ChatCompletionMessage) {
+	a.messages = append(a.messages, messages...)
+	a.traceMessages(messages...)
+}
+
+// approveTool asks whether a tool call may run, preferring the configured
+// InteractionHandler and falling back to a stdin prompt.
+func (a *Agent) approveTool(tc openai.ToolCall, risk tool.RiskLevel) bool {
+	if a.cfg.InteractionHandler != nil {
+		approved, err := a.cfg.InteractionHandler.ApproveTool(tc, risk)
+		if err != nil {
+			a.logAlways(context.Background(), slog.LevelWarn, "tool approval failed",
+				fmt.Sprintf("⚠️ Tool approval failed: %v\n", err),
+				slog.String("tool", tc.Function.Name), slog.Any("error", err))
+			return false
+		}
+		return approved
+	}
+
+	if risk == tool.RiskDestructive {
+		a.logf("⚠️  %s may modify files, run code or change remote state. Allow it? [y/N]: ", tc.Function.Name)
+	} else {
+		a.logf("⚠️  Allow this tool execution? [y/N]: ")
+	}
+	var input string
+	fmt.Scanln(&input)
+	return strings.ToLower(strings.TrimSpace(input)) == "y"
+}
+
+// autoApproves reports whether tool calls of the given risk run without asking.
+// Destructive calls need AutoApproveDestructive in addition to AutoApproveTools.
+func (a *Agent) autoApproves(risk tool.RiskLevel) bool {
+	if !a.cfg.AutoApproveTools {
+		return false
+	}
+	return risk == tool.RiskSafe || a.cfg.AutoApproveDestructive
+}
+
+// skillModel returns the model that runs skill: the model of its metadata,
+// or RunnerConfig.Model.
+func (a *Agent) skillModel(skill SkillPackage) string {
+	if skill.Meta.Model != "" {
+		return skill.Meta.Model
+	}
+	return a.cfg.Model
+}
+
+// skillTemperature returns the temperature of the requests that run skill,
+// zero for the backend's default. go-openai omits a zero temperature, so a
+// skill asking for 0 gets the smallest positive temperature instead.
+func skillTemperature(skill SkillPackage) float32 {
+	if skill.Meta.Temperature == nil {
+		return 0
+	}
+	if *skill.Meta.Temperature == 0 {
+		return math.SmallestNonzeroFloat32
+	}
+	return *skill.Meta.Temperature
+}
+
+// executeSkillWithTools sets up the initial system prompt and starts the
+// tool-use conversation, post-processing its final output.
 func (a *Agent) executeSkillWithTools(ctx context.Context, userPrompt string, skill SkillPackage) (string, error) {
+	return a.executeSkill(ctx, userPrompt, skill, a.configInput())
+}
+
+// executeSkill is executeSkillWithTools with the input in.
+func (a *Agent) executeSkill(ctx context.Context, userPrompt string, skill SkillPackage, in runInput) (output string, err error) {
+	ctx, span := a.startSpan(ctx, "goskills.execute_skill",
+		attribute.String("skill.name", skill.QualifiedName()),
+		attribute.String("llm.model", a.skillModel(skill)),
+	)
+	defer func() { endSpan(span, err) }()
+
+	original := skill
+	skill, err = a.enterSandbox(skill)
+	if err != nil {
+		return "", err
+	}
+	defer func() { a.leaveSandbox(err) }()
+
+	a.startTrace(userPrompt, skill)
+	defer func() { a.finishTrace(output, err) }()
+	a.artifacts = nil
+
+	userPrompt = a.clarify(ctx, userPrompt, skill)
+	inputs, err := a.extractSkillInputs(ctx, userPrompt, skill)
+	if err != nil {
+		return "", err
+	}
+
 	// Prepare the system message once
-	var skillBody strings.Builder
-	skillBody.WriteString(skill.Body)
-	skillBody.WriteString("\n\n## SKILL CONTEXT\n")
-	skillBody.WriteString(fmt.Sprintf("Skill Root Path: %s\n", skill.Path))
-	a.messages = append(a.messages, openai.ChatCompletionMessage{
+	a.addMessages(openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleSystem,
-		Content: skillBody.String(),
+		Content: skillSystemPrompt(skill, inputs, secretNames(a.cfg.Secrets)),
 	})
+	a.addMessages(conversationHistory(in.history)...)
+	attachments, cleanup, err := a.attachmentMessages(in.attachments)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	a.addMessages(attachments...)
 
-	return a.continueSkillWithTools(ctx, userPrompt, skill)
+	output, err = a.continueSkillWithTools(ctx, userPrompt, skill)
+	if err != nil {
+		return output, err
+	}
+	return a.postProcess(ctx, original, output)
 }
 
 // continueSkillWithTools continues a conversation with a new user prompt.
 func (a *Agent) continueSkillWithTools(ctx context.Context, userPrompt string, skill SkillPackage) (string, error) {
-	a.messages = append(a.messages, openai.ChatCompletionMessage{
+	a.addMessages(openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleUser,
 		Content: userPrompt,
 	})
+	return a.runToolLoop(ctx, userPrompt, skill, 0, "")
+}
 
-	availableTools, scriptMap := GenerateToolDefinitions(skill)
+// runToolLoop runs the tool-calling loop and deletes the checkpoint of a run
+// that completes.
+func (a *Agent) runToolLoop(ctx context.Context, userPrompt string, skill SkillPackage, start int, response string) (string, error) {
+	output, err := a.toolLoop(ctx, userPrompt, skill, start, response)
+	if err == nil {
+		a.deleteCheckpoint(ctx)
+	}
+	return output, err
+}
 
-	// Add MCP tools if client is available
-	if a.mcpClient != nil {
-		mcpTools, err := a.mcpClient.GetTools(ctx)
-		if err != nil {
-			fmt.Printf("⚠️ Failed to get MCP tools: %v\n", err)
-		} else {
-			availableTools = append(availableTools, mcpTools...)
-		}
+// toolLoop runs the tool-calling loop from iteration start, with the final
+// answer collected so far in response, checkpointing before each iteration.
+func (a *Agent) toolLoop(ctx context.Context, userPrompt string, skill SkillPackage, start int, response string) (string, error) {
+	availableTools, scriptMap := a.offeredTools(skill, a.mcpTools(ctx))
+
+	offeredTools := make(map[string]bool, len(availableTools))
+	for _, t := range availableTools {
+		offeredTools[t.Function.Name] = true
 	}
 
 	var finalResponse strings.Builder
+	finalResponse.WriteString(response)
+	var dryRunCalls []openai.ToolCall
+	continuations := 0
+	var loop toolLoopDetector
+
+	for i := start; i < 10; i++ { // Limit to 10 iterations to prevent infinite loops
+		a.saveCheckpoint(ctx, skill, userPrompt, i, finalResponse.String())
+		if a.cfg.InteractionHandler != nil && a.cfg.InteractionHandler.ShouldCancel() {
+			return a.redact(a.partialResponse(&finalResponse)), agent.ErrCanceled
+		}
+		if a.cfg.InteractionHandler != nil {
+			a.cfg.InteractionHandler.OnStepProgress(float64(i)/10, fmt.Sprintf("Iteration %d", i+1))
+		}
+
+		a.compactHistory(ctx)
 
-	for i := 0; i < 10; i++ { // Limit to 10 iterations to prevent infinite loops
 		req := openai.ChatCompletionRequest{
-			Model:    a.cfg.Model,
-			Messages: a.messages, // Use agent's messages
-			Tools:    availableTools,
+			Model:       a.skillModel(skill),
+			Messages:    a.messages, // Use agent's messages
+			Tools:       availableTools,
+			Temperature: skillTemperature(skill),
 		}
 
-		resp, err := a.client.CreateChatCompletion(ctx, req)
+		resp, err := a.completeTurn(ctx, req)
 		if err != nil {
-			return "", fmt.Errorf("ChatCompletion error: %w", err)
+			err = fmt.Errorf("ChatCompletion error: %w", err)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return a.redact(a.partialResponse(&finalResponse)), errors.Join(ctxErr, err)
+			}
+			return "", err
 		}
 
 		msg := resp.Choices[0].Message
-		a.messages = append(a.messages, msg) // Append LLM's response
+		a.finishReason = resp.Choices[0].FinishReason
+
+		if reason := a.budgetExceeded(); reason != "" {
+			a.logEvent(ctx, slog.LevelWarn, "cost limit reached",
+				fmt.Sprintf("💸 Cost limit reached: %s\n", reason),
+				slog.String("skill", skill.QualifiedName()), slog.String("reason", reason),
+				slog.Int("total_tokens", a.usage.TotalTokens), slog.Float64("cost_usd", a.usage.CostUSD))
+			if msg.ToolCalls == nil {
+				finalResponse.WriteString(msg.Content)
+			}
+			// Tool calls that will not run are dropped, so the history stays
+			// valid for a later request without their results
+			msg.ToolCalls = nil
+			if msg.Content != "" {
+				a.addMessages(msg)
+			}
+			return a.redact(budgetNotice(a.partialResponse(&finalResponse), reason)), ErrBudgetExceeded
+		}
+		a.addMessages(msg) // Append LLM's response
 
 		if msg.ToolCalls == nil {
+			if a.cfg.DryRun {
+				return a.redact(formatDryRunPlan(dryRunCalls, msg.Content)), nil
+			}
 			finalResponse.WriteString(msg.Content)
-			return finalResponse.String(), nil
+			if a.finishReason == openai.FinishReasonLength {
+				if continuations < a.maxContinuations() {
+					continuations++
+					a.logEvent(ctx, slog.LevelInfo, "continuing truncated response",
+						fmt.Sprintf("⏩ Response cut off by the output limit, continuing (%d/%d)\n", continuations, a.maxContinuations()),
+						slog.Int("continuation", continuations), slog.Int("max", a.maxContinuations()))
+					a.addMessages(openai.ChatCompletionMessage{
+						Role:    openai.ChatMessageRoleUser,
+						Content: continuePrompt,
+					})
+					continue
+				}
+				finalResponse.WriteString(truncationNotice)
+			}
+			return a.redact(finalResponse.String()), nil
 		}
 
+		// Results of read-only calls in this turn, by dedupKey
+		turnResults := make(map[string]string)
 		for _, tc := range msg.ToolCalls {
-			if a.cfg.Verbose {
-				fmt.Printf("⚙️ Calling tool: %s with args: %s\n", tc.Function.Name, tc.Function.Arguments)
+			a.logEvent(ctx, slog.LevelInfo, "calling tool",
+				fmt.Sprintf("⚙️ Calling tool: %s with args: %s\n", tc.Function.Name, a.redact(tc.Function.Arguments)),
+				slog.String("skill", skill.QualifiedName()), slog.String("tool", tc.Function.Name),
+				slog.String("call_id", tc.ID), slog.String("arguments", a.redact(tc.Function.Arguments)))
+			started := time.Now()
+
+			if !offeredTools[tc.Function.Name] {
+				// The model may call tools the skill is not allowed to use
+				a.addMessages(openai.ChatCompletionMessage{
+					Role:       openai.ChatMessageRoleTool,
+					ToolCallID: tc.ID,
+					Content:    fmt.Sprintf("Error: tool %s is not available for this skill.", tc.Function.Name),
+				})
+				a.audit(skill, tc, AuditNotAllowed, started, "", false, nil)
+				continue
+			}
+
+			if a.cfg.DryRun {
+				dryRunCalls = append(dryRunCalls, tc)
+				a.addMessages(openai.ChatCompletionMessage{
+					Role:       openai.ChatMessageRoleTool,
+					ToolCallID: tc.ID,
+					Content:    "(dry-run: not executed)",
+				})
+				a.audit(skill, tc, AuditDryRun, started, "", false, nil)
+				continue
 			}
 
-			if !a.cfg.AutoApproveTools {
-				fmt.Print("⚠️  Allow this tool execution? [y/N]: ")
-				var input string
-				fmt.Scanln(&input)
-				if strings.ToLower(strings.TrimSpace(input)) != "y" {
-					fmt.Println("❌ Tool execution denied by user.")
-					a.messages = append(a.messages, openai.ChatCompletionMessage{
+			key, dedupable := dedupKey(tc)
+			if cached, ok := turnResults[key]; dedupable && ok {
+				a.logEvent(ctx, slog.LevelDebug, "reusing tool result",
+					fmt.Sprintf("♻️ Reusing the result of an identical %s call\n", tc.Function.Name),
+					slog.String("tool", tc.Function.Name), slog.String("call_id", tc.ID))
+				a.addMessages(openai.ChatCompletionMessage{
+					Role:       openai.ChatMessageRoleTool,
+					ToolCallID: tc.ID,
+					Content:    cached,
+				})
+				a.audit(skill, tc, AuditDeduplicated, started, cached, false, nil)
+				continue
+			}
+
+			decision := AuditAutoApproved
+			if risk := tool.ToolRisk(tc.Function.Name, tc.Function.Arguments); !a.autoApproves(risk) {
+				decision = AuditApproved
+				if !a.approveTool(tc, risk) {
+					a.logAlways(ctx, slog.LevelWarn, "tool call denied", "❌ Tool execution denied by user.\n",
+						slog.String("tool", tc.Function.Name), slog.String("call_id", tc.ID))
+					a.addMessages(openai.ChatCompletionMessage{
 						Role:       openai.ChatMessageRoleTool,
 						ToolCallID: tc.ID,
 						Content:    "Error: User denied tool execution.",
 					})
+					a.audit(skill, tc, AuditDenied, started, "", false, nil)
 					continue
 				}
 			}
 
 			var toolOutput string
 			var err error
+			toolCtx, toolSpan := a.startSpan(ctx, "goskills.tool_call",
+				attribute.String("skill.name", skill.QualifiedName()),
+				attribute.String("tool.name", tc.Function.Name),
+			)
 
-			// Check if it is an MCP tool
-			if a.mcpClient != nil && strings.Contains(tc.Function.Name, "__") {
-				var args map[string]interface{}
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-					toolOutput = fmt.Sprintf("Error unmarshalling arguments: %v", err)
-					err = fmt.Errorf("failed to unmarshal arguments: %w", err)
-				} else {
-					var result interface{}
-					result, err = a.mcpClient.CallTool(ctx, tc.Function.Name, args)
-					if err == nil {
-						// Convert result to string/JSON
-						resBytes, _ := json.Marshal(result)
-						toolOutput = string(resBytes)
-					}
-				}
+			// Check if it is an MCP tool, unless RunnerConfig.Tools replaces it
+			if _, replaced := a.cfg.Tools[tc.Function.Name]; !replaced && a.mcpClient != nil && strings.Contains(tc.Function.Name, "__") {
+				toolOutput, err = a.callMCPTool(toolCtx, tc)
 			} else {
-				toolOutput, err = a.executeToolCall(tc, scriptMap, skill.Path)
+				recordArtifacts := a.watchArtifacts(tc, scriptMap, skill)
+				toolOutput, err = a.executeToolCall(toolCtx, tc, scriptMap, skill)
+				recordArtifacts(err)
+			}
+			toolSpan.SetAttributes(attribute.Int("tool.output_size", len(toolOutput)))
+			endSpan(toolSpan, err)
+			content, truncated := truncateToolOutput(toolOutput, a.toolOutputLimit(), a.cfg.KeepToolOutputTail)
+			if truncated {
+				a.logEvent(ctx, slog.LevelDebug, "tool output truncated",
+					fmt.Sprintf("✂️ Truncated %s output from %d bytes\n", tc.Function.Name, len(toolOutput)),
+					slog.String("tool", tc.Function.Name), slog.Int("bytes", len(toolOutput)))
 			}
+			a.audit(skill, tc, decision, started, toolOutput, truncated, err)
 
 			if err != nil {
-				fmt.Printf("❌ Tool call failed: %v\n", err)
-				a.messages = append(a.messages, openai.ChatCompletionMessage{
-					Role:       openai.ChatMessageRoleTool,
-					ToolCallID: tc.ID,
-					Content:    fmt.Sprintf("Error: %v", err),
-				})
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					// The run itself was canceled or timed out; retrying cannot help
+					return a.redact(a.partialResponse(&finalResponse)), errors.Join(ctxErr, err)
+				}
+				text := fmt.Sprintf("❌ Tool call failed: %s\n", a.redact(err.Error()))
+				if tc.Function.Arguments != "" {
+					text += fmt.Sprintf("Raw Arguments: %s\n", a.redact(tc.Function.Arguments))
+				}
+				a.logAlways(ctx, slog.LevelError, "tool call failed", text,
+					slog.String("skill", skill.QualifiedName()), slog.String("tool", tc.Function.Name),
+					slog.String("call_id", tc.ID), slog.Duration("duration", time.Since(started)),
+					slog.String("error", a.redact(err.Error())))
+				// Report the error to the model so it can correct the call
+				content = toolErrorMessage(err)
 			} else {
-				a.messages = append(a.messages, openai.ChatCompletionMessage{
-					Role:       openai.ChatMessageRoleTool,
-					ToolCallID: tc.ID,
-					Content:    toolOutput,
-				})
+				a.logEvent(ctx, slog.LevelDebug, "tool call finished", "",
+					slog.String("skill", skill.QualifiedName()), slog.String("tool", tc.Function.Name),
+					slog.String("call_id", tc.ID), slog.Duration("duration", time.Since(started)),
+					slog.Int("output_bytes", len(toolOutput)))
+			}
+			content = hideSecrets(content, a.cfg.Secrets)
+			if dedupable {
+				turnResults[key] = content
 			}
+			loop.record(tc.Function.Name, tc.Function.Arguments, content, err != nil)
+			a.addMessages(openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				ToolCallID: tc.ID,
+				Content:    content,
+			})
+		}
+
+		if message, stop := loop.check(a.maxRepeatedToolErrors()); stop {
+			a.logEvent(ctx, slog.LevelError, "tool loop detected",
+				fmt.Sprintf("🔁 Stopping: %s keeps failing with the same error\n", loop.name),
+				slog.String("tool", loop.name), slog.Int("failures", loop.count))
+			return a.redact(message), ErrToolLoop
+		} else if message != "" {
+			a.logEvent(ctx, slog.LevelWarn, "repeated tool failures",
+				fmt.Sprintf("🔁 %s failed %d times in a row, asking the model to change approach\n", loop.name, loop.count),
+				slog.String("tool", loop.name), slog.Int("failures", loop.count))
+			a.addMessages(openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: message,
+			})
 		}
 	}
+	if a.cfg.DryRun {
+		return a.redact(formatDryRunPlan(dryRunCalls, "")), nil
+	}
 	return "", errors.New("exceeded maximum tool call iterations")
 }
 
-func (a *Agent) executeToolCall(toolCall openai.ToolCall, scriptMap map[string]string, skillPath string) (string, error) {
-	var toolOutput string
-	var err error
+// runPythonScript runs a Python script with the script environment of the
+// skill, checking its syntax first when CheckPythonSyntax is set.
+func (a *Agent) runPythonScript(ctx context.Context, scriptPath string, args []string) (string, error) {
+	if a.cfg.CheckPythonSyntax {
+		checkPath := scriptPath
+		if a.sandboxDir != "" && !filepath.IsAbs(checkPath) {
+			checkPath = filepath.Join(a.sandboxDir, checkPath)
+		}
+		if err := tool.CheckPythonSyntax(ctx, checkPath); err != nil {
+			return "", err
+		}
+	}
+	return tool.RunPythonScriptInDir(ctx, a.sandboxDir, scriptPath, args, a.scriptEnv)
+}
+
+// formatDryRunPlan describes the tool calls a dry run would have made,
+// followed by the model's final response.
+func formatDryRunPlan(calls []openai.ToolCall, response string) string {
+	var sb strings.Builder
+	if len(calls) == 0 {
+		sb.WriteString("Dry run: no tool calls would be made.\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("Dry run: the skill would make %d tool call(s):\n", len(calls)))
+		for i, tc := range calls {
+			sb.WriteString(fmt.Sprintf("%d. %s %s\n", i+1, tc.Function.Name, tc.Function.Arguments))
+		}
+	}
+	if response != "" {
+		sb.WriteString("\nModel response:\n")
+		sb.WriteString(response)
+	}
+	return sb.String()
+}
+
+// callMCPTool calls the MCP tool of tc and returns its result as JSON.
+func (a *Agent) callMCPTool(ctx context.Context, tc openai.ToolCall) (toolOutput string, err error) {
+	ctx = tool.WithOptions(ctx, a.toolOptions)
+	defer func(start time.Time) { tool.RecordToolCall(ctx, tc.Function.Name, start, err) }(time.Now())
+
+	var args map[string]interface{}
+	if err = decodeToolArguments(tc, &args); err != nil {
+		return "", err
+	}
+	result, err := a.mcpClient.CallTool(ctx, tc.Function.Name, args)
+	if err != nil {
+		return "", err
+	}
+	resBytes, _ := json.Marshal(result)
+	return string(resBytes), nil
+}
+
+func (a *Agent) executeToolCall(ctx context.Context, toolCall openai.ToolCall, scriptMap map[string]string, skill SkillPackage) (toolOutput string, err error) {
+	ctx = tool.WithOptions(ctx, a.toolOptions)
+	defer func(start time.Time) { tool.RecordToolCall(ctx, toolCall.Function.Name, start, err) }(time.Now())
+
+	if fn, ok := a.cfg.Tools[toolCall.Function.Name]; ok {
+		return fn(ctx, toolCall.Function.Arguments)
+	}
 
 	switch toolCall.Function.Name {
 	case "run_shell_code":
 		var params struct {
-			Code string         `json:"code"`
-			Args map[string]any `json:"args"`
+			Code  string         `json:"code"`
+			Args  map[string]any `json:"args"`
+			Stdin string         `json:"stdin"`
 		}
-		if err = json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-			return "", fmt.Errorf("failed to unmarshal run_shell_code arguments: %w", err)
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
 		}
-		shellTool := tool.ShellTool{}
-		toolOutput, err = shellTool.Run(params.Args, params.Code)
+		shellTool := tool.ShellTool{Env: a.scriptEnv, Stdin: params.Stdin, Dir: a.sandboxDir, AllowedCommands: a.cfg.AllowedShellCommands}
+		toolOutput, err = shellTool.Run(ctx, params.Args, params.Code)
 	case "run_shell_script":
 		var params struct {
 			ScriptPath string   `json:"scriptPath"`
 			Args       []string `json:"args"`
 		}
-		if err = json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-			return "", fmt.Errorf("failed to unmarshal run_shell_script arguments: %w", err)
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
 		}
-		toolOutput, err = tool.RunShellScript(params.ScriptPath, params.Args)
+		toolOutput, err = tool.RunShellScriptInDir(ctx, a.sandboxDir, params.ScriptPath, params.Args, a.scriptEnv)
 	case "run_python_code":
 		var params struct {
-			Code string         `json:"code"`
-			Args map[string]any `json:"args"`
+			Code  string         `json:"code"`
+			Args  map[string]any `json:"args"`
+			Stdin string         `json:"stdin"`
 		}
-		if err = json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-			return "", fmt.Errorf("failed to unmarshal run_python_code arguments: %w", err)
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
 		}
-		pythonTool := tool.PythonTool{}
-		toolOutput, err = pythonTool.Run(params.Args, params.Code)
+		pythonTool := tool.PythonTool{Env: a.scriptEnv, Stdin: params.Stdin, Dir: a.sandboxDir, CheckSyntax: a.cfg.CheckPythonSyntax}
+		toolOutput, err = pythonTool.Run(ctx, params.Args, params.Code)
+	case "run_python_json":
+		var params struct {
+			Code  string         `json:"code"`
+			Args  map[string]any `json:"args"`
+			Stdin string         `json:"stdin"`
+		}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		pythonTool := tool.PythonTool{Env: a.scriptEnv, Stdin: params.Stdin, Dir: a.sandboxDir, CheckSyntax: a.cfg.CheckPythonSyntax}
+		toolOutput, err = pythonTool.RunJSON(ctx, params.Args, params.Code)
 	case "run_python_script":
 		var params struct {
 			ScriptPath string   `json:"scriptPath"`
 			Args       []string `json:"args"`
 		}
-		if err = json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-			return "", fmt.Errorf("failed to unmarshal run_python_script arguments: %w", err)
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
 		}
-		toolOutput, err = tool.RunPythonScript(params.ScriptPath, params.Args)
+		toolOutput, err = a.runPythonScript(ctx, params.ScriptPath, params.Args)
 	case "read_file":
 		var params struct {
 			FilePath string `json:"filePath"`
+			Offset   int    `json:"offset"`
+			Limit    int    `json:"limit"`
+			Unit     string `json:"unit"`
 		}
-		if err = json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-			return "", fmt.Errorf("failed to unmarshal read_file arguments: %w", err)
-		}
-		path := params.FilePath
-		if !filepath.IsAbs(path) && skillPath != "" {
-			resolvedPath := filepath.Join(skillPath, path)
-			if _, err := os.Stat(resolvedPath); err == nil {
-				path = resolvedPath
-			}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
 		}
-		toolOutput, err = tool.ReadFile(path)
+		toolOutput, err = tool.ReadFileWithOptions(resolveSkillFile(skill, params.FilePath), tool.ReadFileOptions{
+			Offset: params.Offset,
+			Limit:  params.Limit,
+			Bytes:  params.Unit == "bytes",
+		})
 	case "write_file":
 		var params struct {
 			FilePath string `json:"filePath"`
 			Content  string `json:"content"`
 		}
-		if err = json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-			return "", fmt.Errorf("failed to unmarshal write_file arguments: %w", err)
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
 		}
-		err = tool.WriteFile(params.FilePath, params.Content)
+		err = tool.WriteFile(a.writeFilePath(skill, params.FilePath), params.Content)
 		if err == nil {
 			toolOutput = fmt.Sprintf("Successfully wrote to file: %s", params.FilePath)
 		}
+	case "delete_file":
+		var params struct {
+			FilePath string `json:"filePath"`
+		}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		toolOutput, err = tool.DeleteFile(resolveSkillFile(skill, params.FilePath), a.fileRoots(skill)...)
+	case "git_clone", "git_status", "git_diff", "git_commit":
+		var params struct {
+			URL     string   `json:"url"`
+			Dir     string   `json:"dir"`
+			Staged  bool     `json:"staged"`
+			Paths   []string `json:"paths"`
+			Message string   `json:"message"`
+		}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		git := tool.GitTool{Roots: a.fileRoots(skill), Env: a.scriptEnv}
+		if params.Dir != "" {
+			params.Dir = a.writeFilePath(skill, params.Dir)
+		}
+		switch toolCall.Function.Name {
+		case "git_clone":
+			toolOutput, err = git.Clone(ctx, params.URL, params.Dir)
+		case "git_status":
+			toolOutput, err = git.Status(ctx, params.Dir)
+		case "git_diff":
+			toolOutput, err = git.Diff(ctx, params.Dir, params.Staged, params.Paths)
+		case "git_commit":
+			toolOutput, err = git.Commit(ctx, params.Dir, params.Message, params.Paths)
+		}
+	case "sql_query":
+		var params struct {
+			Query  string `json:"query"`
+			Format string `json:"format"`
+		}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		if a.cfg.SQLDSN == "" {
+			return "", errors.New("no database is configured")
+		}
+		sqlTool := tool.SQLTool{DSN: a.cfg.SQLDSN, AllowWrites: a.cfg.SQLAllowWrites, JSON: params.Format == "json"}
+		toolOutput, err = sqlTool.Query(ctx, params.Query)
 	case "duckduckgo_search":
 		var params struct {
-			Query string `json:"query"`
+			Query          string   `json:"query"`
+			IncludeDomains []string `json:"includeDomains"`
+			ExcludeDomains []string `json:"excludeDomains"`
 		}
-		if err = json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-			return "", fmt.Errorf("failed to unmarshal duckduckgo_search arguments: %w", err)
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
 		}
-		toolOutput, err = tool.DuckDuckGoSearch(params.Query)
+		toolOutput, err = tool.DuckDuckGoSearchFiltered(ctx, params.Query, tool.DomainFilter{Include: params.IncludeDomains, Exclude: params.ExcludeDomains})
 	case "wikipedia_search":
 		var params struct {
-			Query string `json:"query"`
+			Query       string `json:"query"`
+			Language    string `json:"language"`
+			FullArticle bool   `json:"fullArticle"`
+			Section     string `json:"section"`
 		}
-		if err = json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-			return "", fmt.Errorf("failed to unmarshal wikipedia_search arguments: %w", err)
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
 		}
-		toolOutput, err = tool.WikipediaSearch(params.Query)
+		toolOutput, err = tool.WikipediaSearchWithOptions(ctx, params.Query, tool.WikipediaOptions{
+			Language:    params.Language,
+			FullArticle: params.FullArticle,
+			Section:     params.Section,
+		})
 	case "tavily_search":
 		var params struct {
-			Query string `json:"query"`
+			Query          string   `json:"query"`
+			IncludeDomains []string `json:"includeDomains"`
+			ExcludeDomains []string `json:"excludeDomains"`
 		}
-		if err = json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-			return "", fmt.Errorf("failed to unmarshal tavily_search arguments: %w", err)
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
 		}
-		toolOutput, err = tool.TavilySearch(params.Query)
+		toolOutput, err = tool.TavilySearchFiltered(ctx, params.Query, 20, tool.DomainFilter{Include: params.IncludeDomains, Exclude: params.ExcludeDomains})
 	case "web_fetch":
 		var params struct {
 			URL string `json:"url"`
 		}
-		if err = json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-			return "", fmt.Errorf("failed to unmarshal web_fetch arguments: %w", err)
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		toolOutput, err = tool.WebFetch(ctx, params.URL)
+	case "web_crawl":
+		var params struct {
+			URL      string `json:"url"`
+			Depth    int    `json:"depth"`
+			Breadth  int    `json:"breadth"`
+			MaxPages int    `json:"maxPages"`
+		}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		toolOutput, err = tool.WebCrawl(ctx, params.URL, tool.CrawlOptions{
+			Depth:    params.Depth,
+			Breadth:  params.Breadth,
+			MaxPages: params.MaxPages,
+		})
+	case "http_request":
+		var params struct {
+			Method  string            `json:"method"`
+			URL     string            `json:"url"`
+			Headers map[string]string `json:"headers"`
+			Body    string            `json:"body"`
+		}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		toolOutput, err = tool.HTTPRequest(ctx, params.Method, params.URL, params.Headers, params.Body)
+	case "extract_structured":
+		var params struct {
+			Schema       interface{} `json:"schema"`
+			Text         string      `json:"text"`
+			Instructions string      `json:"instructions"`
+		}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		schema, schemaErr := tool.SchemaArgument(params.Schema)
+		if schemaErr != nil {
+			return "", &ToolArgumentsError{Tool: toolCall.Function.Name, Err: schemaErr}
+		}
+		toolOutput, err = a.extractStructured(ctx, schema, params.Text, params.Instructions)
+	case "calculate":
+		var params struct {
+			Expression string `json:"expression"`
+		}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		var result float64
+		if result, err = tool.Calculate(params.Expression); err == nil {
+			toolOutput = tool.FormatNumber(result)
+		}
+	case "current_time":
+		var params struct {
+			Timezone string `json:"timezone"`
+		}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		toolOutput, err = tool.CurrentTime(params.Timezone)
+	case "date_math":
+		var params struct {
+			Operation string `json:"operation"`
+			Date      string `json:"date"`
+			Duration  string `json:"duration"`
+			To        string `json:"to"`
+			Timezone  string `json:"timezone"`
 		}
-		toolOutput, err = tool.WebFetch(params.URL)
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		switch params.Operation {
+		case "add":
+			toolOutput, err = tool.DateAdd(params.Date, params.Duration, params.Timezone)
+		case "diff":
+			toolOutput, err = tool.DateDiff(params.Date, params.To, params.Timezone)
+		default:
+			return "", &ToolArgumentsError{Tool: toolCall.Function.Name, Err: fmt.Errorf("unknown operation %q, want add or diff", params.Operation)}
+		}
+	case "retrieve_docs":
+		var params struct {
+			Query string `json:"query"`
+			TopK  int    `json:"top_k"`
+		}
+		if err = decodeToolArguments(toolCall, &params); err != nil {
+			return "", err
+		}
+		toolOutput, err = a.retrieveDocs(ctx, skill, params.Query, params.TopK)
 	default:
 		if scriptPath, ok := scriptMap[toolCall.Function.Name]; ok {
-			var params struct {
-				Args []string `json:"args"`
-			}
-			if toolCall.Function.Arguments != "" {
-				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
-					return "", fmt.Errorf("failed to unmarshal script arguments: %w", err)
+			var args []string
+			if spec, ok := scriptSpecForTool(skill, toolCall.Function.Name); ok {
+				if args, err = scriptArguments(toolCall, spec); err != nil {
+					return "", err
+				}
+			} else {
+				var params struct {
+					Args []string `json:"args"`
 				}
+				if err = decodeToolArguments(toolCall, &params); err != nil {
+					return "", err
+				}
+				args = params.Args
 			}
 			if strings.HasSuffix(scriptPath, ".py") {
-				toolOutput, err = tool.RunPythonScript(scriptPath, params.Args)
+				toolOutput, err = a.runPythonScript(ctx, scriptPath, args)
 			} else {
-				toolOutput, err = tool.RunShellScript(scriptPath, params.Args)
+				toolOutput, err = tool.RunShellScriptInDir(ctx, a.sandboxDir, scriptPath, args, a.scriptEnv)
 			}
 		} else {
 			return "", fmt.Errorf("unknown tool: %s", toolCall.Function.Name)
@@ -453,11 +1381,78 @@ func (a *Agent) executeToolCall(toolCall openai.ToolCall, scriptMap map[string]s
 	}
 
 	if err != nil {
-		fmt.Printf("❌ Tool execution failed for %s: %v\n", toolCall.Function.Name, err)
-		if toolCall.Function.Arguments != "" {
-			fmt.Printf("Raw Arguments: %s\n", toolCall.Function.Arguments)
-		}
 		return "", fmt.Errorf("tool execution failed for %s: %w", toolCall.Function.Name, err)
 	}
 	return toolOutput, nil
 }
+
+// resolveSkillFile resolves a relative path against the skill directory when
+// the file exists there, and leaves it relative to the working directory
+// otherwise.
+func resolveSkillFile(skill SkillPackage, path string) string {
+	if !filepath.IsAbs(path) && skill.Path != "" {
+		resolvedPath := filepath.Join(skill.Path, path)
+		if _, err := os.Stat(resolvedPath); err == nil {
+			return resolvedPath
+		}
+	}
+	return path
+}
+
+// writeFilePath resolves the path of a write_file or git call, which is
+// relative to the working directory, or to the skill's working copy when
+// sandboxed.
+func (a *Agent) writeFilePath(skill SkillPackage, path string) string {
+	if a.cfg.Sandbox && !filepath.IsAbs(path) && skill.Path != "" {
+		return filepath.Join(skill.Path, path)
+	}
+	return path
+}
+
+// fileRoots returns the directories delete_file and the git tools may work
+// in: the working directory and the skill, or only the skill's working copy
+// when sandboxed.
+func (a *Agent) fileRoots(skill SkillPackage) []string {
+	if a.sandboxDir != "" {
+		return []string{a.sandboxDir}
+	}
+	roots := []string{"."}
+	if skill.Path != "" {
+		roots = append(roots, skill.Path)
+	}
+	return roots
+}
+
+// ToolArgumentsError reports tool call arguments that are not valid JSON for
+// the tool. It is sent back to the model so it can retry the call.
+type ToolArgumentsError struct {
+	Tool string
+	Err  error
+}
+
+func (e *ToolArgumentsError) Error() string {
+	return fmt.Sprintf("invalid arguments for %s: %v", e.Tool, e.Err)
+}
+
+func (e *ToolArgumentsError) Unwrap() error { return e.Err }
+
+// decodeToolArguments unmarshals the arguments of tc into params. Empty
+// arguments are treated as an empty object.
+func decodeToolArguments(tc openai.ToolCall, params any) error {
+	if strings.TrimSpace(tc.Function.Arguments) == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(tc.Function.Arguments), params); err != nil {
+		return &ToolArgumentsError{Tool: tc.Function.Name, Err: err}
+	}
+	return nil
+}
+
+// toolErrorMessage is the tool result sent to the model for a failed call.
+func toolErrorMessage(err error) string {
+	var argErr *ToolArgumentsError
+	if errors.As(err, &argErr) {
+		return fmt.Sprintf("Error: %v. Call %s again with arguments that are a single valid JSON object matching its parameter schema.", argErr, argErr.Tool)
+	}
+	return fmt.Sprintf("Error: %v", err)
+}
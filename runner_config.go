@@ -0,0 +1,247 @@
+package goskills
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/smallnest/goskills/agent"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSkillsDir is the skills directory used by LoadRunnerConfig when none is configured.
+const DefaultSkillsDir = "~/.claude/skills"
+
+// runnerConfigFile is the on-disk form of RunnerConfig.
+type runnerConfigFile struct {
+	Provider               string        `yaml:"provider" toml:"provider"`
+	APIKey                 string        `yaml:"api_key" toml:"api_key"`
+	APIBase                string        `yaml:"api_base" toml:"api_base"`
+	Model                  string        `yaml:"model" toml:"model"`
+	SkillsDir              string        `yaml:"skills_dir" toml:"skills_dir"`
+	Verbose                bool          `yaml:"verbose" toml:"verbose"`
+	AutoApproveTools       bool          `yaml:"auto_approve_tools" toml:"auto_approve_tools"`
+	AutoApproveDestructive bool          `yaml:"auto_approve_destructive" toml:"auto_approve_destructive"`
+	AllowedScripts         []string      `yaml:"allowed_scripts" toml:"allowed_scripts"`
+	AllowedShellCommands   []string      `yaml:"allowed_shell_commands" toml:"allowed_shell_commands"`
+	Loop                   bool          `yaml:"loop" toml:"loop"`
+	DryRun                 bool          `yaml:"dry_run" toml:"dry_run"`
+	SelectionSystemPrompt  string        `yaml:"selection_system_prompt" toml:"selection_system_prompt"`
+	SelectionPrompt        string        `yaml:"selection_prompt" toml:"selection_prompt"`
+	CacheDir               string        `yaml:"cache_dir" toml:"cache_dir"`
+	CacheTTL               time.Duration `yaml:"cache_ttl" toml:"cache_ttl"`
+	CacheBypass            bool          `yaml:"cache_bypass" toml:"cache_bypass"`
+	DefaultSkill           string        `yaml:"default_skill" toml:"default_skill"`
+	KeywordPreMatch        bool          `yaml:"keyword_pre_match" toml:"keyword_pre_match"`
+	EmbeddingModel         string        `yaml:"embedding_model" toml:"embedding_model"`
+	SQLDSN                 string        `yaml:"sql_dsn" toml:"sql_dsn"`
+	SQLAllowWrites         bool          `yaml:"sql_allow_writes" toml:"sql_allow_writes"`
+	TraceFile              string        `yaml:"trace_file" toml:"trace_file"`
+	Sandbox                bool          `yaml:"sandbox" toml:"sandbox"`
+	KeepSandbox            bool          `yaml:"keep_sandbox" toml:"keep_sandbox"`
+	DisabledTools          []string      `yaml:"disabled_tools" toml:"disabled_tools"`
+	Clarify                bool          `yaml:"clarify" toml:"clarify"`
+	MaxClarifications      int           `yaml:"max_clarifications" toml:"max_clarifications"`
+	CheckPythonSyntax      bool          `yaml:"check_python_syntax" toml:"check_python_syntax"`
+	ScriptOutputEncoding   string        `yaml:"script_output_encoding" toml:"script_output_encoding"`
+	Seed                   *int          `yaml:"seed" toml:"seed"`
+}
+
+// LoadRunnerConfig reads a RunnerConfig from a YAML or TOML file (chosen by
+// the .toml extension) and overlays GOSKILLS_* environment variables, which
+// take precedence over the file:
+//
+//	GOSKILLS_PROVIDER, GOSKILLS_API_KEY, GOSKILLS_API_BASE, GOSKILLS_MODEL,
+//	GOSKILLS_SKILLS_DIR, GOSKILLS_VERBOSE, GOSKILLS_AUTO_APPROVE_TOOLS,
+//	GOSKILLS_AUTO_APPROVE_DESTRUCTIVE, GOSKILLS_ALLOWED_SCRIPTS,
+//	GOSKILLS_ALLOWED_SHELL_COMMANDS and GOSKILLS_DISABLED_TOOLS (comma
+//	separated), GOSKILLS_LOOP, GOSKILLS_DRY_RUN, GOSKILLS_CACHE_DIR,
+//	GOSKILLS_CACHE_BYPASS,
+//	GOSKILLS_DEFAULT_SKILL, GOSKILLS_KEYWORD_PRE_MATCH,
+//	GOSKILLS_EMBEDDING_MODEL, GOSKILLS_SQL_DSN, GOSKILLS_SQL_ALLOW_WRITES,
+//	GOSKILLS_TRACE_FILE, GOSKILLS_SANDBOX, GOSKILLS_KEEP_SANDBOX,
+//	GOSKILLS_CLARIFY, GOSKILLS_CHECK_PYTHON_SYNTAX,
+//	GOSKILLS_SCRIPT_OUTPUT_ENCODING, GOSKILLS_SEED
+//
+// The API key, base URL and model fall back to OPENAI_API_KEY,
+// OPENAI_API_BASE and OPENAI_MODEL, then to the provider's default model.
+// An empty path loads the configuration from the environment only. A leading
+// "~" in the skills directory is expanded to the home directory.
+func LoadRunnerConfig(path string) (RunnerConfig, error) {
+	var file runnerConfigFile
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return RunnerConfig{}, fmt.Errorf("failed to read config: %w", err)
+		}
+		if err := decodeRunnerConfig(path, data, &file); err != nil {
+			return RunnerConfig{}, fmt.Errorf("invalid config %s: %w", path, err)
+		}
+	}
+
+	cfg := RunnerConfig{
+		Provider:               file.Provider,
+		APIKey:                 file.APIKey,
+		APIBase:                file.APIBase,
+		Model:                  file.Model,
+		SkillsDir:              file.SkillsDir,
+		Verbose:                file.Verbose,
+		AutoApproveTools:       file.AutoApproveTools,
+		AutoApproveDestructive: file.AutoApproveDestructive,
+		AllowedScripts:         file.AllowedScripts,
+		AllowedShellCommands:   file.AllowedShellCommands,
+		Loop:                   file.Loop,
+		DryRun:                 file.DryRun,
+		SelectionSystemPrompt:  file.SelectionSystemPrompt,
+		SelectionPrompt:        file.SelectionPrompt,
+		CacheDir:               file.CacheDir,
+		CacheTTL:               file.CacheTTL,
+		CacheBypass:            file.CacheBypass,
+		DefaultSkill:           file.DefaultSkill,
+		KeywordPreMatch:        file.KeywordPreMatch,
+		EmbeddingModel:         file.EmbeddingModel,
+		SQLDSN:                 file.SQLDSN,
+		SQLAllowWrites:         file.SQLAllowWrites,
+		TraceFile:              file.TraceFile,
+		Sandbox:                file.Sandbox,
+		KeepSandbox:            file.KeepSandbox,
+		DisabledTools:          file.DisabledTools,
+		Clarify:                file.Clarify,
+		MaxClarifications:      file.MaxClarifications,
+		CheckPythonSyntax:      file.CheckPythonSyntax,
+		ScriptOutputEncoding:   file.ScriptOutputEncoding,
+		Seed:                   file.Seed,
+	}
+	if err := overlayRunnerEnv(&cfg); err != nil {
+		return RunnerConfig{}, err
+	}
+
+	if cfg.APIKey == "" {
+		cfg.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if cfg.APIBase == "" {
+		cfg.APIBase = os.Getenv("OPENAI_API_BASE")
+	}
+	cfg.APIBase = strings.TrimSuffix(cfg.APIBase, "/")
+	if cfg.Model == "" {
+		cfg.Model = os.Getenv("OPENAI_MODEL")
+	}
+	if cfg.Model == "" {
+		cfg.Model = agent.DefaultModel(cfg.Provider)
+	}
+	if cfg.SkillsDir == "" {
+		cfg.SkillsDir = DefaultSkillsDir
+	}
+	if rest, ok := strings.CutPrefix(cfg.SkillsDir, "~"); ok && (rest == "" || rest[0] == '/') {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return RunnerConfig{}, fmt.Errorf("failed to expand skills_dir: %w", err)
+		}
+		cfg.SkillsDir = filepath.Join(home, rest)
+	}
+	return cfg, nil
+}
+
+// decodeRunnerConfig decodes data, rejecting unknown keys so typos are reported.
+func decodeRunnerConfig(path string, data []byte, file *runnerConfigFile) error {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		meta, err := toml.Decode(string(data), file)
+		if err != nil {
+			return err
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown key %q", undecoded[0].String())
+		}
+		return nil
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(file); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// overlayRunnerEnv applies the GOSKILLS_* environment variables that are set
+// to a non-empty value.
+func overlayRunnerEnv(cfg *RunnerConfig) error {
+	texts := map[string]*string{
+		"GOSKILLS_PROVIDER":               &cfg.Provider,
+		"GOSKILLS_API_KEY":                &cfg.APIKey,
+		"GOSKILLS_API_BASE":               &cfg.APIBase,
+		"GOSKILLS_MODEL":                  &cfg.Model,
+		"GOSKILLS_SKILLS_DIR":             &cfg.SkillsDir,
+		"GOSKILLS_CACHE_DIR":              &cfg.CacheDir,
+		"GOSKILLS_DEFAULT_SKILL":          &cfg.DefaultSkill,
+		"GOSKILLS_EMBEDDING_MODEL":        &cfg.EmbeddingModel,
+		"GOSKILLS_SQL_DSN":                &cfg.SQLDSN,
+		"GOSKILLS_TRACE_FILE":             &cfg.TraceFile,
+		"GOSKILLS_SCRIPT_OUTPUT_ENCODING": &cfg.ScriptOutputEncoding,
+	}
+	for name, field := range texts {
+		if value := os.Getenv(name); value != "" {
+			*field = value
+		}
+	}
+
+	bools := map[string]*bool{
+		"GOSKILLS_VERBOSE":                  &cfg.Verbose,
+		"GOSKILLS_AUTO_APPROVE_TOOLS":       &cfg.AutoApproveTools,
+		"GOSKILLS_AUTO_APPROVE_DESTRUCTIVE": &cfg.AutoApproveDestructive,
+		"GOSKILLS_LOOP":                     &cfg.Loop,
+		"GOSKILLS_DRY_RUN":                  &cfg.DryRun,
+		"GOSKILLS_CACHE_BYPASS":             &cfg.CacheBypass,
+		"GOSKILLS_KEYWORD_PRE_MATCH":        &cfg.KeywordPreMatch,
+		"GOSKILLS_SQL_ALLOW_WRITES":         &cfg.SQLAllowWrites,
+		"GOSKILLS_SANDBOX":                  &cfg.Sandbox,
+		"GOSKILLS_KEEP_SANDBOX":             &cfg.KeepSandbox,
+		"GOSKILLS_CLARIFY":                  &cfg.Clarify,
+		"GOSKILLS_CHECK_PYTHON_SYNTAX":      &cfg.CheckPythonSyntax,
+	}
+	for name, field := range bools {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %q is not a boolean", name, value)
+		}
+		*field = b
+	}
+
+	if value := os.Getenv("GOSKILLS_SEED"); value != "" {
+		seed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid GOSKILLS_SEED: %q is not an integer", value)
+		}
+		cfg.Seed = &seed
+	}
+
+	lists := map[string]*[]string{
+		"GOSKILLS_ALLOWED_SCRIPTS":        &cfg.AllowedScripts,
+		"GOSKILLS_ALLOWED_SHELL_COMMANDS": &cfg.AllowedShellCommands,
+		"GOSKILLS_DISABLED_TOOLS":         &cfg.DisabledTools,
+	}
+	for name, field := range lists {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		*field = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*field = append(*field, item)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package goskills
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/smallnest/goskills/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func clearRunnerEnv(t *testing.T) {
+	for _, name := range []string{
+		"GOSKILLS_PROVIDER", "GOSKILLS_API_KEY", "GOSKILLS_API_BASE", "GOSKILLS_MODEL", "GOSKILLS_SKILLS_DIR",
+		"GOSKILLS_VERBOSE", "GOSKILLS_AUTO_APPROVE_TOOLS", "GOSKILLS_AUTO_APPROVE_DESTRUCTIVE", "GOSKILLS_ALLOWED_SCRIPTS",
+		"GOSKILLS_ALLOWED_SHELL_COMMANDS", "GOSKILLS_LOOP", "GOSKILLS_DRY_RUN", "GOSKILLS_CACHE_DIR", "GOSKILLS_CACHE_BYPASS", "OPENAI_API_KEY", "OPENAI_API_BASE", "OPENAI_MODEL",
+	} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestLoadRunnerConfigYAML(t *testing.T) {
+	clearRunnerEnv(t)
+	path := filepath.Join(t.TempDir(), "goskills.yaml")
+	content := "api_key: file-key\nmodel: file-model\nskills_dir: /srv/skills\nverbose: true\nallowed_scripts: [run_a_py]\ncache_ttl: 2h\nseed: 7\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	t.Setenv("GOSKILLS_MODEL", "env-model")
+	t.Setenv("GOSKILLS_DRY_RUN", "true")
+
+	cfg, err := LoadRunnerConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "file-key", cfg.APIKey)
+	assert.Equal(t, "env-model", cfg.Model)
+	assert.Equal(t, "/srv/skills", cfg.SkillsDir)
+	assert.True(t, cfg.Verbose)
+	assert.True(t, cfg.DryRun)
+	assert.Equal(t, []string{"run_a_py"}, cfg.AllowedScripts)
+	assert.Equal(t, 2*time.Hour, cfg.CacheTTL)
+	require.NotNil(t, cfg.Seed)
+	assert.Equal(t, 7, *cfg.Seed)
+}
+
+func TestLoadRunnerConfigTOML(t *testing.T) {
+	clearRunnerEnv(t)
+	path := filepath.Join(t.TempDir(), "goskills.toml")
+	content := "api_base = \"https://llm.example.com/v1/\"\nauto_approve_tools = true\ncache_ttl = \"30m\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	t.Setenv("OPENAI_API_KEY", "openai-key")
+	t.Setenv("GOSKILLS_ALLOWED_SCRIPTS", "run_a_py, run_b_sh")
+	t.Setenv("GOSKILLS_ALLOWED_SHELL_COMMANDS", "ls,cat")
+
+	cfg, err := LoadRunnerConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "openai-key", cfg.APIKey)
+	assert.Equal(t, "https://llm.example.com/v1", cfg.APIBase)
+	assert.True(t, cfg.AutoApproveTools)
+	assert.Equal(t, 30*time.Minute, cfg.CacheTTL)
+	assert.Equal(t, agent.DefaultModel(agent.ProviderOpenAI), cfg.Model)
+	assert.Equal(t, []string{"run_a_py", "run_b_sh"}, cfg.AllowedScripts)
+	assert.Equal(t, []string{"ls", "cat"}, cfg.AllowedShellCommands)
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".claude/skills"), cfg.SkillsDir)
+}
+
+func TestLoadRunnerConfigErrors(t *testing.T) {
+	clearRunnerEnv(t)
+	dir := t.TempDir()
+
+	_, err := LoadRunnerConfig(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+
+	typo := filepath.Join(dir, "typo.yaml")
+	require.NoError(t, os.WriteFile(typo, []byte("modle: gpt-4o\n"), 0644))
+	_, err = LoadRunnerConfig(typo)
+	assert.ErrorContains(t, err, "modle")
+
+	badTOML := filepath.Join(dir, "bad.toml")
+	require.NoError(t, os.WriteFile(badTOML, []byte("model = \n"), 0644))
+	_, err = LoadRunnerConfig(badTOML)
+	assert.ErrorContains(t, err, "bad.toml")
+
+	t.Setenv("GOSKILLS_VERBOSE", "maybe")
+	_, err = LoadRunnerConfig("")
+	assert.ErrorContains(t, err, "GOSKILLS_VERBOSE")
+}
@@ -0,0 +1,367 @@
+package goskills
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/smallnest/goskills/mcp"
+	"github.com/smallnest/goskills/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatDryRunPlan(t *testing.T) {
+	calls := []openai.ToolCall{
+		{Function: openai.FunctionCall{Name: "read_file", Arguments: `{"filePath":"data.csv"}`}},
+		{Function: openai.FunctionCall{Name: "http_request", Arguments: `{"method":"GET","url":"https://example.com"}`}},
+	}
+
+	plan := formatDryRunPlan(calls, "Done.")
+	assert.Equal(t, "Dry run: the skill would make 2 tool call(s):\n"+
+		"1. read_file {\"filePath\":\"data.csv\"}\n"+
+		"2. http_request {\"method\":\"GET\",\"url\":\"https://example.com\"}\n"+
+		"\nModel response:\nDone.", plan)
+
+	assert.Equal(t, "Dry run: no tool calls would be made.\n", formatDryRunPlan(nil, ""))
+}
+
+func TestMalformedToolArguments(t *testing.T) {
+	a := &Agent{}
+	tc := openai.ToolCall{Function: openai.FunctionCall{Name: "read_file", Arguments: `{"filePath": "a.txt"`}}
+
+	_, err := a.executeToolCall(context.Background(), tc, nil, SkillPackage{})
+	require.Error(t, err)
+
+	var argErr *ToolArgumentsError
+	require.True(t, errors.As(err, &argErr))
+	assert.Equal(t, "read_file", argErr.Tool)
+	assert.Contains(t, toolErrorMessage(err), "Call read_file again with arguments that are a single valid JSON object")
+
+	assert.Equal(t, "Error: boom", toolErrorMessage(errors.New("boom")))
+}
+
+func TestDecodeToolArgumentsEmpty(t *testing.T) {
+	var params struct {
+		Args []string `json:"args"`
+	}
+	tc := openai.ToolCall{Function: openai.FunctionCall{Name: "run_script_py", Arguments: "  "}}
+	require.NoError(t, decodeToolArguments(tc, &params))
+	assert.Empty(t, params.Args)
+}
+
+func TestAutoApproves(t *testing.T) {
+	a := &Agent{}
+	assert.False(t, a.autoApproves(tool.RiskSafe))
+
+	a.cfg.AutoApproveTools = true
+	assert.True(t, a.autoApproves(tool.RiskSafe))
+	assert.False(t, a.autoApproves(tool.RiskDestructive))
+
+	a.cfg.AutoApproveDestructive = true
+	assert.True(t, a.autoApproves(tool.RiskDestructive))
+}
+
+func TestSkillModelAndTemperature(t *testing.T) {
+	client := agenttest.NewFakeClient(agenttest.Reply("done"), agenttest.Reply("done"))
+	a, err := NewAgent(RunnerConfig{Client: client, Model: "gpt-4o"}, nil)
+	require.NoError(t, err)
+
+	_, err = a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	require.NoError(t, err)
+	req := client.Requests()[0]
+	assert.Equal(t, "gpt-4o", req.Model)
+	assert.Zero(t, req.Temperature)
+
+	zero := float32(0)
+	skill := SkillPackage{Path: t.TempDir(), Meta: SkillMeta{Model: "gpt-4o-mini", Temperature: &zero}}
+	_, err = a.executeSkillWithTools(context.Background(), "task", skill)
+	require.NoError(t, err)
+	req = client.Requests()[1]
+	assert.Equal(t, "gpt-4o-mini", req.Model)
+	assert.Equal(t, float32(math.SmallestNonzeroFloat32), req.Temperature)
+}
+
+func TestPartialResultOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	found := agenttest.CallTools(agenttest.ToolCall("1", "read_file", map[string]string{"filePath": "a.txt"}))
+	found.Choices[0].Message.Content = "Found three files so far."
+	client := agenttest.NewFakeClient(found)
+	client.Respond = func(openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		cancel() // The user interrupts the run while the model is answering
+		return openai.ChatCompletionResponse{}, context.Canceled
+	}
+
+	a, err := NewAgent(RunnerConfig{Client: client, AutoApproveTools: true}, nil)
+	require.NoError(t, err)
+
+	output, err := a.executeSkillWithTools(ctx, "task", SkillPackage{Path: t.TempDir()})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "Found three files so far.", output)
+}
+
+func TestLogWriter(t *testing.T) {
+	var log bytes.Buffer
+	a, err := NewAgent(RunnerConfig{Client: failingCallClient(), AutoApproveTools: true, Verbose: true, LogWriter: &log}, nil)
+	require.NoError(t, err)
+
+	_, err = a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	require.ErrorIs(t, err, ErrToolLoop)
+	assert.Contains(t, log.String(), `⚙️ Calling tool: read_file with args: {"filePath":"missing.txt"}`)
+	assert.Contains(t, log.String(), "❌ Tool call failed")
+}
+
+func TestStructuredLogger(t *testing.T) {
+	var text, records bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&records, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	a, err := NewAgent(RunnerConfig{Client: failingCallClient(), AutoApproveTools: true, Verbose: true, LogWriter: &text, Logger: logger}, nil)
+	require.NoError(t, err)
+
+	_, err = a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir(), Meta: SkillMeta{Name: "notes"}})
+	require.ErrorIs(t, err, ErrToolLoop)
+	assert.Empty(t, text.String())
+
+	levels := map[string]string{}
+	var failed map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(records.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		msg := record["msg"].(string)
+		levels[msg] = record["level"].(string)
+		if msg == "tool call failed" && failed == nil {
+			failed = record
+		}
+	}
+	assert.Equal(t, "INFO", levels["calling tool"])
+	assert.Equal(t, "ERROR", levels["tool call failed"])
+	assert.Equal(t, "WARN", levels["repeated tool failures"])
+	assert.Equal(t, "ERROR", levels["tool loop detected"])
+	require.NotNil(t, failed)
+	assert.Equal(t, "notes", failed["skill"])
+	assert.Equal(t, "read_file", failed["tool"])
+	assert.Equal(t, "call_1", failed["call_id"])
+	assert.Contains(t, failed["error"], "missing.txt")
+	assert.Contains(t, failed, "duration")
+}
+
+// recordingMetrics counts the observed tool calls and failures per tool.
+type recordingMetrics struct {
+	mu     sync.Mutex
+	calls  map[string]int
+	errors map[string]int
+}
+
+func (m *recordingMetrics) ObserveToolCall(name string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls[name]++
+	if err != nil {
+		m.errors[name]++
+	}
+}
+
+func TestToolMetrics(t *testing.T) {
+	client := failingCallClient()
+	metrics := &recordingMetrics{calls: map[string]int{}, errors: map[string]int{}}
+	a, err := NewAgent(RunnerConfig{Client: client, AutoApproveTools: true, Metrics: metrics}, nil)
+	require.NoError(t, err)
+	// A Runner built later with other metrics does not take over the calls of a.
+	other := &recordingMetrics{calls: map[string]int{}, errors: map[string]int{}}
+	_, err = NewRunner(RunnerConfig{Client: client, Metrics: other}, nil)
+	require.NoError(t, err)
+
+	_, err = a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	require.ErrorIs(t, err, ErrToolLoop)
+	calls := len(client.Requests())
+	assert.Equal(t, map[string]int{"read_file": calls}, metrics.calls)
+	assert.Equal(t, map[string]int{"read_file": calls}, metrics.errors)
+	assert.Empty(t, other.calls)
+
+	tool.RecordToolCall(context.Background(), "read_file", time.Now(), nil)
+	assert.Equal(t, calls, metrics.calls["read_file"])
+}
+
+func TestRunnerConcurrentRuns(t *testing.T) {
+	client := agenttest.NewFakeClient()
+	client.Respond = func(openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		return agenttest.Reply("tidy"), nil
+	}
+	skills := staticSkills{"tidy": {Path: t.TempDir(), Meta: SkillMeta{Name: "tidy", Description: "Tidies code."}}}
+
+	r, err := NewRunner(RunnerConfig{Client: client, SkillProvider: skills}, nil)
+	require.NoError(t, err)
+	assert.Same(t, r.NewAgent().client, r.NewAgent().client)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			output, history, err := r.RunWithHistory(context.Background(), fmt.Sprintf("task %d", i), nil)
+			if err == nil && (output != "tidy" || len(history) != 2 || history[0].Content != fmt.Sprintf("task %d", i)) {
+				err = fmt.Errorf("run %d: unexpected output %q and history %v", i, output, history)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	_, err = NewRunner(RunnerConfig{}, nil)
+	assert.Error(t, err)
+}
+
+func TestFakeClientAndTools(t *testing.T) {
+	client := agenttest.NewFakeClient(
+		agenttest.CallTools(agenttest.ToolCall("call_1", "read_file", map[string]string{"filePath": "go.txt"})),
+		agenttest.Reply("Go is a language."),
+	)
+	var searched []string
+	a, err := NewAgent(RunnerConfig{
+		Client:           client,
+		AutoApproveTools: true,
+		Tools: map[string]ToolFunc{
+			"read_file": func(ctx context.Context, arguments string) (string, error) {
+				searched = append(searched, arguments)
+				return "Go: a programming language", nil
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	output, err := a.executeSkillWithTools(context.Background(), "what is go?", SkillPackage{Path: t.TempDir()})
+	require.NoError(t, err)
+	assert.Equal(t, "Go is a language.", output)
+	assert.Equal(t, []string{`{"filePath":"go.txt"}`}, searched)
+
+	requests := client.Requests()
+	require.Len(t, requests, 2)
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	assert.Equal(t, openai.ChatMessageRoleTool, last.Role)
+	assert.Equal(t, "Go: a programming language", last.Content)
+}
+
+func TestSecretsHiddenFromModel(t *testing.T) {
+	client := agenttest.NewFakeClient(
+		agenttest.CallTools(agenttest.ToolCall("call_1", "run_shell_code", map[string]string{"code": "echo key=$MY_API_KEY"})),
+		agenttest.Reply("done"),
+	)
+	a, err := NewAgent(RunnerConfig{
+		Client:                 client,
+		AutoApproveTools:       true,
+		AutoApproveDestructive: true,
+		Secrets:                map[string]string{"MY_API_KEY": "s3cr3t-value"},
+	}, nil)
+	require.NoError(t, err)
+	skill := SkillPackage{Path: t.TempDir()}
+	require.NoError(t, a.prepareSkill(skill))
+
+	_, err = a.executeSkillWithTools(context.Background(), "call the api", skill)
+	require.NoError(t, err)
+
+	requests := client.Requests()
+	require.Len(t, requests, 2)
+	assert.Contains(t, requests[0].Messages[0].Content, "## SECRETS\n")
+	assert.Contains(t, requests[0].Messages[0].Content, "- MY_API_KEY\n")
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	assert.Equal(t, "key=[REDACTED]\n", last.Content)
+	for _, req := range requests {
+		for _, msg := range req.Messages {
+			assert.NotContains(t, msg.Content, "s3cr3t-value")
+		}
+	}
+}
+
+func TestShortSecretsRejected(t *testing.T) {
+	_, err := NewRunner(RunnerConfig{Client: agenttest.NewFakeClient(), Secrets: map[string]string{"PIN": "1234"}}, nil)
+	assert.EqualError(t, err, "secret PIN is shorter than 6 characters")
+	_, err = NewRunner(RunnerConfig{Client: agenttest.NewFakeClient(), Secrets: map[string]string{"UNSET": ""}}, nil)
+	assert.NoError(t, err)
+}
+
+func TestSeedAndSystemFingerprints(t *testing.T) {
+	reply := func(fingerprint string) openai.ChatCompletionResponse {
+		resp := agenttest.Reply("done")
+		resp.SystemFingerprint = fingerprint
+		return resp
+	}
+	client := agenttest.NewFakeClient(reply("fp_a"), reply("fp_a"), reply("fp_b"))
+	seed := 42
+	a, err := NewAgent(RunnerConfig{Client: client, Seed: &seed}, nil)
+	require.NoError(t, err)
+
+	skill := SkillPackage{Path: t.TempDir()}
+	for i := 0; i < 3; i++ {
+		_, err = a.executeSkillWithTools(context.Background(), "do it", skill)
+		require.NoError(t, err)
+	}
+	for _, req := range client.Requests() {
+		require.NotNil(t, req.Seed)
+		assert.Equal(t, 42, *req.Seed)
+	}
+	assert.Equal(t, []string{"fp_a", "fp_b"}, a.SystemFingerprints())
+}
+
+func TestToolsReplaceMCPTools(t *testing.T) {
+	ctx := context.Background()
+	server := mcpsdk.NewServer(&mcpsdk.Implementation{Name: "docs", Version: "0.0.1"}, nil)
+	for _, name := range []string{"lookup", "echo"} {
+		server.AddTool(&mcpsdk.Tool{Name: name, InputSchema: map[string]any{"type": "object"}},
+			func(context.Context, *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+				return &mcpsdk.CallToolResult{Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: "from " + name}}}, nil
+			})
+	}
+	serverTransport, clientTransport := mcpsdk.NewInMemoryTransports()
+	_, err := server.Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+	mcpClient, err := mcp.NewClient(ctx, &mcp.Config{})
+	require.NoError(t, err)
+	require.NoError(t, mcpClient.Connect(ctx, "docs", clientTransport))
+	t.Cleanup(func() { mcpClient.Close() })
+
+	client := agenttest.NewFakeClient(
+		agenttest.CallTools(
+			agenttest.ToolCall("call_1", "docs__lookup", map[string]string{"topic": "go"}),
+			agenttest.ToolCall("call_2", "docs__echo", map[string]string{}),
+		),
+		agenttest.Reply("Go is a language."),
+	)
+	metrics := &recordingMetrics{calls: map[string]int{}, errors: map[string]int{}}
+	a, err := NewAgent(RunnerConfig{
+		Client:                 client,
+		AutoApproveTools:       true,
+		AutoApproveDestructive: true,
+		Metrics:                metrics,
+		Tools: map[string]ToolFunc{
+			"docs__lookup": func(ctx context.Context, arguments string) (string, error) {
+				return "Go: a programming language", nil
+			},
+		},
+	}, mcpClient)
+	require.NoError(t, err)
+
+	_, err = a.executeSkillWithTools(ctx, "what is go?", SkillPackage{Path: t.TempDir()})
+	require.NoError(t, err)
+	messages := client.Requests()[1].Messages
+	assert.Equal(t, "Go: a programming language", messages[len(messages)-2].Content)
+	assert.Contains(t, messages[len(messages)-1].Content, "from echo")
+	// MCP calls are recorded like the others
+	assert.Equal(t, map[string]int{"docs__lookup": 1, "docs__echo": 1}, metrics.calls)
+	assert.Empty(t, metrics.errors)
+}
@@ -0,0 +1,149 @@
+package goskills
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// enterSandbox copies the skill directory to a temporary working copy when
+// RunnerConfig.Sandbox is set and returns the skill rooted there, so that
+// scripts and file tools leave the original untouched.
+func (a *Agent) enterSandbox(skill SkillPackage) (SkillPackage, error) {
+	a.sandboxDir = ""
+	if !a.cfg.Sandbox || skill.Path == "" {
+		return skill, nil
+	}
+	dir, err := os.MkdirTemp("", "goskills-sandbox-*")
+	if err != nil {
+		return skill, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	if err := copySkillDir(skill.Path, dir); err != nil {
+		os.RemoveAll(dir)
+		return skill, fmt.Errorf("failed to copy skill %s to sandbox: %w", skill.QualifiedName(), err)
+	}
+	a.sandboxDir = dir
+	skill.Path = dir
+	return skill, nil
+}
+
+// leaveSandbox removes the working copy after a successful run, unless
+// RunnerConfig.KeepSandbox is set, after copying the artifacts out of it.
+// After a failed run it is kept for inspection.
+func (a *Agent) leaveSandbox(err error) {
+	if a.sandboxDir == "" {
+		return
+	}
+	if err == nil && !a.cfg.KeepSandbox {
+		a.saveSandboxArtifacts()
+		os.RemoveAll(a.sandboxDir)
+		a.sandboxDir = ""
+		return
+	}
+	a.logAlways(context.Background(), slog.LevelInfo, "sandbox kept",
+		fmt.Sprintf("📁 Skill working copy kept in %s\n", a.sandboxDir),
+		slog.String("dir", a.sandboxDir))
+}
+
+// SandboxDir returns the working copy of the skill used by the last run when
+// RunnerConfig.Sandbox is set and the copy was kept, i.e. the run failed or
+// KeepSandbox is set. It is empty otherwise.
+func (a *Agent) SandboxDir() string {
+	return a.sandboxDir
+}
+
+// saveSandboxArtifacts copies the artifacts in the sandbox to a new temporary
+// directory, at the same paths relative to it, and points the artifacts at
+// the copies.
+func (a *Agent) saveSandboxArtifacts() {
+	var dir string
+	for i, artifact := range a.artifacts {
+		rel, err := filepath.Rel(a.sandboxDir, artifact.Path)
+		if err != nil || !filepath.IsLocal(rel) {
+			continue
+		}
+		if dir == "" {
+			if dir, err = os.MkdirTemp("", "goskills-artifacts-*"); err != nil {
+				a.logAlways(context.Background(), slog.LevelWarn, "failed to save artifacts",
+					fmt.Sprintf("⚠️ Failed to save the artifacts of the sandbox: %v\n", err),
+					slog.Any("error", err))
+				return
+			}
+		}
+		path := filepath.Join(dir, rel)
+		if err := copyFile(artifact.Path, path); err != nil {
+			a.logAlways(context.Background(), slog.LevelWarn, "failed to save artifact",
+				fmt.Sprintf("⚠️ Failed to save artifact %s: %v\n", rel, err),
+				slog.String("path", rel), slog.Any("error", err))
+			continue
+		}
+		a.artifacts[i].Path = path
+	}
+	if dir != "" {
+		a.logEvent(context.Background(), slog.LevelInfo, "artifacts saved",
+			fmt.Sprintf("📦 Artifacts of the sandbox saved to %s\n", dir),
+			slog.String("dir", dir))
+	}
+}
+
+// copySkillDir copies the skill directory src to dst. Symbolic links are
+// recreated when their relative target stays inside the skill, so that they
+// keep pointing into the copy, and skipped otherwise, like other files that
+// are neither regular nor directories.
+func copySkillDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if filepath.IsAbs(link) || !filepath.IsLocal(filepath.Join(filepath.Dir(rel), link)) {
+				return nil
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target)
+		}
+		return nil
+	})
+}
+
+// copyFile copies the regular file src to dst, creating its directory.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
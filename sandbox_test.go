@@ -0,0 +1,128 @@
+package goskills
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writingClient asks to write out.txt, then finishes.
+func writingClient() *agenttest.FakeClient {
+	return agenttest.NewFakeClient(
+		agenttest.CallTools(agenttest.ToolCall("call_1", "write_file", map[string]string{"filePath": "out.txt", "content": "result"})),
+		agenttest.Reply("Done."),
+	)
+}
+
+func TestSandbox(t *testing.T) {
+	skillDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("---\nname: writer\n---\nWrite."), 0o644))
+
+	a, err := NewAgent(RunnerConfig{Client: writingClient(), AutoApproveTools: true, AutoApproveDestructive: true, Sandbox: true, KeepSandbox: true}, nil)
+	require.NoError(t, err)
+	output, err := a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: skillDir, Meta: SkillMeta{Name: "writer"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Done.", output)
+
+	dir := a.SandboxDir()
+	require.NotEmpty(t, dir)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	data, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "result", string(data))
+	assert.FileExists(t, filepath.Join(dir, "SKILL.md"))
+	assert.NoFileExists(t, filepath.Join(skillDir, "out.txt"))
+	assert.Contains(t, a.messages[0].Content, "Skill Root Path: "+dir)
+
+	// Without KeepSandbox, the copy of a successful run is removed
+	a, err = NewAgent(RunnerConfig{Client: writingClient(), AutoApproveTools: true, AutoApproveDestructive: true, Sandbox: true}, nil)
+	require.NoError(t, err)
+	_, err = a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: skillDir, Meta: SkillMeta{Name: "writer"}})
+	require.NoError(t, err)
+	assert.Empty(t, a.SandboxDir())
+	assert.NoFileExists(t, filepath.Join(skillDir, "out.txt"))
+
+	// Its artifacts are copied out first
+	artifacts := a.Artifacts()
+	require.Len(t, artifacts, 1)
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(artifacts[0].Path)) })
+	assert.Equal(t, "out.txt", filepath.Base(artifacts[0].Path))
+	data, err = os.ReadFile(artifacts[0].Path)
+	require.NoError(t, err)
+	assert.Equal(t, "result", string(data))
+}
+
+func TestSandboxConfinesTools(t *testing.T) {
+	cwd := t.TempDir()
+	t.Chdir(cwd)
+	require.NoError(t, os.WriteFile("keep.txt", []byte("original"), 0o644))
+	skillDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("---\nname: writer\n---\nWrite."), 0o644))
+
+	client := agenttest.NewFakeClient(
+		agenttest.CallTools(agenttest.ToolCall("call_1", "run_shell_code", map[string]string{"code": "pwd > where.txt; rm -f keep.txt"})),
+		agenttest.CallTools(agenttest.ToolCall("call_2", "delete_file", map[string]string{"filePath": filepath.Join(cwd, "keep.txt")})),
+		agenttest.Reply("Done."),
+	)
+	a, err := NewAgent(RunnerConfig{Client: client, AutoApproveTools: true, AutoApproveDestructive: true, Sandbox: true, KeepSandbox: true}, nil)
+	require.NoError(t, err)
+	_, err = a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: skillDir, Meta: SkillMeta{Name: "writer"}})
+	require.NoError(t, err)
+
+	dir := a.SandboxDir()
+	require.NotEmpty(t, dir)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	where, err := os.ReadFile(filepath.Join(dir, "where.txt"))
+	require.NoError(t, err)
+	resolved, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	assert.Equal(t, resolved+"\n", string(where))
+	assert.FileExists(t, filepath.Join(cwd, "keep.txt"))
+	last := client.Requests()[2].Messages
+	assert.Contains(t, last[len(last)-1].Content, "refusing to delete")
+}
+
+func TestSandboxKeptOnFailure(t *testing.T) {
+	a, err := NewAgent(RunnerConfig{Client: failingCallClient(), AutoApproveTools: true, Sandbox: true}, nil)
+	require.NoError(t, err)
+
+	_, err = a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	require.ErrorIs(t, err, ErrToolLoop)
+	dir := a.SandboxDir()
+	require.NotEmpty(t, dir)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	assert.DirExists(t, dir)
+}
+
+func TestCopySkillDirSymlinks(t *testing.T) {
+	src := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "scripts"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "scripts", "run.sh"), []byte("echo hi\n"), 0o755))
+	require.NoError(t, os.Symlink("scripts/run.sh", filepath.Join(src, "run.sh")))
+	require.NoError(t, os.Symlink("../run.sh", filepath.Join(src, "scripts", "again.sh")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(src, "absolute.txt")))
+	require.NoError(t, os.Symlink("../../escape", filepath.Join(src, "scripts", "escape")))
+
+	dst := t.TempDir()
+	require.NoError(t, copySkillDir(src, dst))
+
+	info, err := os.Stat(filepath.Join(dst, "scripts", "run.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	link, err := os.Readlink(filepath.Join(dst, "run.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, "scripts/run.sh", link)
+	data, err := os.ReadFile(filepath.Join(dst, "scripts", "again.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, "echo hi\n", string(data))
+	assert.NoFileExists(t, filepath.Join(dst, "absolute.txt"))
+	_, err = os.Lstat(filepath.Join(dst, "scripts", "escape"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,111 @@
+package goskills
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ScriptSpec describes a script of the skill to the model. It is declared in
+// the "scripts" metadata, keyed by the script path relative to the skill:
+//
+//	scripts:
+//	  scripts/resize.py:
+//	    description: Resizes an image.
+//	    parameters:
+//	      - name: path
+//	        required: true
+//	      - name: width
+//	        type: integer
+//
+// The script tool then takes these named parameters instead of a generic
+// "args" list, and passes them to the script as positional arguments in the
+// declared order.
+type ScriptSpec struct {
+	Description string       `yaml:"description,omitempty" json:"description,omitempty"`
+	Parameters  []SkillInput `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
+
+// scriptParametersSchema returns the JSON schema of a script tool with
+// declared parameters.
+func scriptParametersSchema(spec ScriptSpec) map[string]interface{} {
+	properties := make(map[string]interface{}, len(spec.Parameters))
+	required := []string{}
+	for _, param := range spec.Parameters {
+		typ := param.Type
+		if typ == "" {
+			typ = "string"
+		}
+		prop := map[string]interface{}{"type": typ}
+		if param.Description != "" {
+			prop["description"] = param.Description
+		}
+		properties[param.Name] = prop
+		if param.Required {
+			required = append(required, param.Name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// scriptArguments converts the named arguments of a call to a script tool
+// into positional script arguments, in declared order. A missing optional
+// parameter is passed as an empty string when a later parameter is given,
+// and left out otherwise. Arrays and objects are passed as JSON.
+func scriptArguments(tc openai.ToolCall, spec ScriptSpec) ([]string, error) {
+	var named map[string]interface{}
+	if err := decodeToolArguments(tc, &named); err != nil {
+		return nil, err
+	}
+	if err := validateSkillInputs(named, spec.Parameters); err != nil {
+		return nil, &ToolArgumentsError{Tool: tc.Function.Name, Err: err}
+	}
+
+	args := make([]string, len(spec.Parameters))
+	last := -1
+	for i, param := range spec.Parameters {
+		value, ok := named[param.Name]
+		if !ok {
+			continue
+		}
+		arg, err := scriptArgument(value)
+		if err != nil {
+			return nil, &ToolArgumentsError{Tool: tc.Function.Name, Err: fmt.Errorf("parameter %q: %w", param.Name, err)}
+		}
+		args[i] = arg
+		last = i
+	}
+	return args[:last+1], nil
+}
+
+// scriptArgument formats a decoded JSON value as a command-line argument.
+func scriptArgument(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+}
+
+// scriptSpecForTool returns the declared spec of the script behind a script
+// tool of skill.
+func scriptSpecForTool(skill SkillPackage, toolName string) (ScriptSpec, bool) {
+	for rel, spec := range skill.Meta.Scripts {
+		if scriptToolName(rel) == toolName && len(spec.Parameters) > 0 {
+			return spec, true
+		}
+	}
+	return ScriptSpec{}, false
+}
@@ -0,0 +1,379 @@
+package goskills
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent"
+)
+
+// DefaultEmbeddingModel embeds skill documents for retrieve_docs when
+// RunnerConfig.EmbeddingModel is empty.
+const DefaultEmbeddingModel = string(openai.SmallEmbedding3)
+
+// localEmbeddingModel names the word-hashing embedding used with backends
+// that have no embeddings API.
+const localEmbeddingModel = "local-word-hash"
+
+const (
+	// docChunkSize is the maximum length in bytes of a document chunk.
+	docChunkSize = 1500
+	// defaultDocResults is how many chunks retrieve_docs returns by default.
+	defaultDocResults = 4
+	// docIndexFile is the embedding cache, relative to the skill directory.
+	docIndexFile = ".goskills/docs_index.json"
+	// localEmbeddingSize is the dimension of the local embeddings.
+	localEmbeddingSize = 512
+	// embeddingBatchSize is how many chunks are embedded per request.
+	embeddingBatchSize = 100
+)
+
+// docChunk is a piece of a skill document and its embedding.
+type docChunk struct {
+	File      string    `json:"file"`
+	Text      string    `json:"text"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// docIndex is the embedding cache of a skill's documents. Files are keyed by
+// their path relative to the skill directory and re-embedded when the hash
+// of their content changes.
+type docIndex struct {
+	Model string                  `json:"model"`
+	Files map[string]docIndexItem `json:"files"`
+}
+
+type docIndexItem struct {
+	Hash   string     `json:"hash"`
+	Chunks []docChunk `json:"chunks"`
+}
+
+// embeddingClient is implemented by LLM clients with an embeddings API, such
+// as *openai.Client.
+type embeddingClient interface {
+	CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error)
+}
+
+// skillDocuments returns the .md and .txt files of skill, relative to its
+// directory: those next to SKILL.md and those among its references, assets
+// and templates.
+func skillDocuments(skill SkillPackage) []string {
+	isDoc := func(name string) bool {
+		ext := strings.ToLower(filepath.Ext(name))
+		return ext == ".md" || ext == ".txt"
+	}
+
+	var docs []string
+	if skill.Path != "" {
+		entries, _ := os.ReadDir(skill.Path)
+		for _, entry := range entries {
+			if !entry.IsDir() && isDoc(entry.Name()) && !strings.EqualFold(entry.Name(), "SKILL.md") {
+				docs = append(docs, entry.Name())
+			}
+		}
+	}
+	for _, files := range [][]string{skill.Resources.References, skill.Resources.Assets, skill.Resources.Templates} {
+		for _, file := range files {
+			if isDoc(file) {
+				docs = append(docs, file)
+			}
+		}
+	}
+	return docs
+}
+
+// generateRetrieveDocsTool returns the retrieve_docs tool definition.
+func generateRetrieveDocsTool() openai.Tool {
+	return openai.Tool{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        "retrieve_docs",
+			Description: "Searches the reference documents shipped with the skill and returns the passages most relevant to the query.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "What to look for in the skill's documents.",
+					},
+					"top_k": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("How many passages to return (default %d).", defaultDocResults),
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+	}
+}
+
+// retrieveDocs answers a retrieve_docs call with the topK chunks of the
+// skill's documents most similar to query.
+func (a *Agent) retrieveDocs(ctx context.Context, skill SkillPackage, query string, topK int) (string, error) {
+	if strings.TrimSpace(query) == "" {
+		return "", errors.New("query is required")
+	}
+	if topK <= 0 {
+		topK = defaultDocResults
+	}
+
+	index, err := a.indexSkillDocs(ctx, skill)
+	if err != nil {
+		return "", err
+	}
+	chunks, err := a.searchSkillDocs(ctx, index, query, topK)
+	if err != nil {
+		return "", err
+	}
+	if len(chunks) == 0 {
+		return "No matching passages found in the skill's documents.", nil
+	}
+
+	var sb strings.Builder
+	for i, chunk := range chunks {
+		fmt.Fprintf(&sb, "[%d] %s\n%s\n\n", i+1, chunk.File, chunk.Text)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// indexSkillDocs chunks and embeds the skill's documents, reusing the cached
+// embeddings of files whose content has not changed. The updated index is
+// saved back to the skill directory; a read-only skill directory only loses
+// the cache.
+func (a *Agent) indexSkillDocs(ctx context.Context, skill SkillPackage) (*docIndex, error) {
+	if _, ok := a.embeddingClient(); !ok && a.cfg.EmbeddingModel != "" {
+		// Do not silently fall back to local embeddings the caller did not ask for
+		return nil, fmt.Errorf("embedding model %s is configured, but the LLM client has no embeddings API", a.cfg.EmbeddingModel)
+	}
+	model := a.embeddingModel()
+	cachePath := filepath.Join(skill.Path, docIndexFile)
+
+	var cache docIndex
+	if data, err := os.ReadFile(cachePath); err == nil {
+		if json.Unmarshal(data, &cache) != nil || cache.Model != model {
+			cache = docIndex{}
+		}
+	}
+
+	index := &docIndex{Model: model, Files: make(map[string]docIndexItem)}
+	changed := false
+	for _, doc := range skillDocuments(skill) {
+		data, err := os.ReadFile(filepath.Join(skill.Path, doc))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", doc, err)
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		if item, ok := cache.Files[doc]; ok && item.Hash == hash {
+			index.Files[doc] = item
+			continue
+		}
+
+		texts := chunkDocument(string(data))
+		embeddings, err := a.embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed %s: %w", doc, err)
+		}
+		item := docIndexItem{Hash: hash}
+		for i, text := range texts {
+			item.Chunks = append(item.Chunks, docChunk{File: doc, Text: text, Embedding: embeddings[i]})
+		}
+		index.Files[doc] = item
+		changed = true
+	}
+
+	if changed || len(index.Files) != len(cache.Files) {
+		if data, err := json.Marshal(index); err == nil {
+			if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+				if err := os.WriteFile(cachePath, data, 0o644); err != nil {
+					a.logEvent(ctx, slog.LevelWarn, "could not cache document embeddings",
+						fmt.Sprintf("⚠️ Could not cache document embeddings: %v\n", err),
+						slog.Any("error", err))
+				}
+			}
+		}
+	}
+	return index, nil
+}
+
+// searchSkillDocs returns the topK chunks of index most similar to query by
+// cosine similarity, most similar first.
+func (a *Agent) searchSkillDocs(ctx context.Context, index *docIndex, query string, topK int) ([]docChunk, error) {
+	embeddings, err := a.embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	q := embeddings[0]
+
+	type scored struct {
+		chunk docChunk
+		score float64
+	}
+	var results []scored
+	for _, item := range index.Files {
+		for _, chunk := range item.Chunks {
+			if score := cosineSimilarity(q, chunk.Embedding); score > 0 {
+				results = append(results, scored{chunk, score})
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].chunk.File < results[j].chunk.File
+	})
+
+	chunks := make([]docChunk, 0, min(topK, len(results)))
+	for _, r := range results[:min(topK, len(results))] {
+		chunks = append(chunks, r.chunk)
+	}
+	return chunks, nil
+}
+
+// embeddingClient returns the client's embeddings API, looking through
+// wrappers such as the one of RunnerConfig.Seed.
+func (a *Agent) embeddingClient() (embeddingClient, bool) {
+	for client := a.client; client != nil; client = agent.UnwrapClient(client) {
+		if embedder, ok := client.(embeddingClient); ok {
+			return embedder, true
+		}
+	}
+	return nil, false
+}
+
+// embeddingModel returns the model that embeds documents, or
+// localEmbeddingModel when the client has no embeddings API.
+func (a *Agent) embeddingModel() string {
+	if _, ok := a.embeddingClient(); !ok {
+		return localEmbeddingModel
+	}
+	if a.cfg.EmbeddingModel != "" {
+		return a.cfg.EmbeddingModel
+	}
+	return DefaultEmbeddingModel
+}
+
+// embed returns one embedding per text.
+func (a *Agent) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	client, ok := a.embeddingClient()
+	if !ok {
+		embeddings := make([][]float32, len(texts))
+		for i, text := range texts {
+			embeddings[i] = localEmbedding(text)
+		}
+		return embeddings, nil
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		batch := texts[start:min(start+embeddingBatchSize, len(texts))]
+		resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+			Input: batch,
+			Model: openai.EmbeddingModel(a.embeddingModel()),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Data) != len(batch) {
+			return nil, fmt.Errorf("got %d embeddings for %d texts", len(resp.Data), len(batch))
+		}
+		sort.Slice(resp.Data, func(i, j int) bool { return resp.Data[i].Index < resp.Data[j].Index })
+		for _, data := range resp.Data {
+			embeddings = append(embeddings, data.Embedding)
+		}
+	}
+	return embeddings, nil
+}
+
+// chunkDocument splits text into chunks of at most docChunkSize bytes,
+// keeping paragraphs together where possible.
+func chunkDocument(text string) []string {
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		current.Reset()
+	}
+
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if current.Len() > 0 && current.Len()+len(paragraph)+2 > docChunkSize {
+			flush()
+		}
+		for len(paragraph) > docChunkSize {
+			cut := docChunkSize
+			for cut > 0 && !utf8.RuneStart(paragraph[cut]) {
+				cut--
+			}
+			current.WriteString(paragraph[:cut])
+			flush()
+			paragraph = paragraph[cut:]
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(paragraph)
+	}
+	flush()
+	return chunks
+}
+
+// localEmbedding embeds text as normalized counts of its hashed words, for
+// backends without an embeddings API. It matches shared words rather than
+// meaning.
+func localEmbedding(text string) []float32 {
+	vec := make([]float32, localEmbeddingSize)
+	for _, word := range keywordWords(text) {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		vec[h.Sum32()%localEmbeddingSize]++
+	}
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 when
+// they differ in length or one is zero.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
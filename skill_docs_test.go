@@ -0,0 +1,131 @@
+package goskills
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// docsSkill creates a skill with a reference document about colors and one
+// about shapes.
+func docsSkill(t *testing.T) SkillPackage {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: docs\n---\nBody"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "references"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "references", "colors.md"), []byte("# Colors\n\nThe brand color is teal, hex 008080."), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shapes.txt"), []byte("Logos use a rounded square shape."), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "references", "logo.png"), []byte("png"), 0o644))
+	return SkillPackage{
+		Path:      dir,
+		Meta:      SkillMeta{Name: "docs"},
+		Resources: SkillResources{References: []string{"references/colors.md", "references/logo.png"}},
+	}
+}
+
+func TestSkillDocuments(t *testing.T) {
+	skill := docsSkill(t)
+	assert.ElementsMatch(t, []string{"shapes.txt", "references/colors.md"}, skillDocuments(skill))
+	assert.Contains(t, toolNames(skill), "retrieve_docs")
+	assert.NotContains(t, toolNames(SkillPackage{}), "retrieve_docs")
+}
+
+func TestChunkDocument(t *testing.T) {
+	assert.Equal(t, []string{"one\n\ntwo"}, chunkDocument("one\r\n\r\ntwo\n\n\n"))
+
+	long := strings.Repeat("é", docChunkSize)
+	chunks := chunkDocument("intro\n\n" + long)
+	require.Len(t, chunks, 3)
+	assert.Equal(t, "intro", chunks[0])
+	for _, chunk := range chunks[1:] {
+		assert.LessOrEqual(t, len(chunk), docChunkSize)
+		assert.True(t, strings.Trim(chunk, "é") == "", "chunk split inside a rune")
+	}
+}
+
+func TestRetrieveDocs_LocalEmbedding(t *testing.T) {
+	skill := docsSkill(t)
+	a := &Agent{client: agenttest.NewFakeClient()}
+
+	out, err := a.retrieveDocs(context.Background(), skill, "what is the brand color", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "[1] references/colors.md\n# Colors\n\nThe brand color is teal, hex 008080.", out)
+
+	data, err := os.ReadFile(filepath.Join(skill.Path, docIndexFile))
+	require.NoError(t, err)
+	var index docIndex
+	require.NoError(t, json.Unmarshal(data, &index))
+	assert.Equal(t, localEmbeddingModel, index.Model)
+	assert.Len(t, index.Files, 2)
+
+	_, err = a.retrieveDocs(context.Background(), skill, " ", 0)
+	assert.Error(t, err)
+
+	// A configured embedding model is not silently replaced
+	a.cfg.EmbeddingModel = "embed-test"
+	_, err = a.retrieveDocs(context.Background(), skill, "what is the brand color", 1)
+	assert.ErrorContains(t, err, "has no embeddings API")
+}
+
+func TestRetrieveDocs_CachesEmbeddings(t *testing.T) {
+	var inputs atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+			Model string   `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "embed-test", req.Model)
+		inputs.Add(int32(len(req.Input)))
+
+		resp := openai.EmbeddingResponse{}
+		for i, text := range req.Input {
+			// Texts about shapes point one way, everything else the other.
+			vec := []float32{1, 0}
+			if strings.Contains(text, "shape") {
+				vec = []float32{0, 1}
+			}
+			resp.Data = append(resp.Data, openai.Embedding{Index: i, Embedding: vec})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	skill := docsSkill(t)
+	a, err := NewAgent(RunnerConfig{APIKey: "test", APIBase: server.URL, EmbeddingModel: "embed-test"}, nil)
+	require.NoError(t, err)
+
+	out, err := a.retrieveDocs(context.Background(), skill, "which shape", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "[1] shapes.txt\nLogos use a rounded square shape.", out)
+	assert.Equal(t, int32(3), inputs.Load())
+
+	// Unchanged documents are not embedded again, only the query.
+	_, err = a.retrieveDocs(context.Background(), skill, "which shape", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), inputs.Load())
+
+	// A changed document is.
+	require.NoError(t, os.WriteFile(filepath.Join(skill.Path, "shapes.txt"), []byte("Logos use a circle shape."), 0o644))
+	out, err = a.retrieveDocs(context.Background(), skill, "which shape", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "[1] shapes.txt\nLogos use a circle shape.", out)
+	assert.Equal(t, int32(6), inputs.Load())
+
+	// The embeddings API is found through the wrapper of a seed
+	seed := 1
+	seeded, err := NewAgent(RunnerConfig{APIKey: "test", APIBase: server.URL, EmbeddingModel: "embed-test", Seed: &seed}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "embed-test", seeded.embeddingModel())
+}
@@ -0,0 +1,86 @@
+package goskills
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// skillEnvFile is the optional per-skill file with environment variables for its scripts.
+const skillEnvFile = ".env"
+
+// loadSkillEnv reads KEY=VALUE pairs from the skill's .env file. Blank lines,
+// comments and a leading "export " are ignored, and surrounding quotes are
+// removed from values. A missing file yields an empty map.
+func loadSkillEnv(skillPath string) (map[string]string, error) {
+	env := make(map[string]string)
+
+	f, err := os.Open(filepath.Join(skillPath, skillEnvFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return env, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s line %d: expected KEY=VALUE", skillEnvFile, lineNo)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[key] = value
+	}
+	return env, scanner.Err()
+}
+
+// prepareSkillEnv loads the skill's .env file and checks that every variable
+// in RequiredEnv is set, either in the process environment, in the file or
+// in secrets. It returns the extra KEY=VALUE entries to pass to the skill's
+// scripts; variables already set in the process environment take precedence
+// over the file, and secrets over both.
+func prepareSkillEnv(skill SkillPackage, secrets map[string]string) ([]string, error) {
+	fileEnv, err := loadSkillEnv(skill.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s for skill %s: %w", skillEnvFile, skill.QualifiedName(), err)
+	}
+
+	var missing []string
+	for _, name := range skill.Meta.RequiredEnv {
+		if os.Getenv(name) == "" && fileEnv[name] == "" && secrets[name] == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("skill %s requires env var(s) %s", skill.QualifiedName(), strings.Join(missing, ", "))
+	}
+
+	var extra []string
+	for key, value := range fileEnv {
+		if _, ok := os.LookupEnv(key); !ok && secrets[key] == "" {
+			extra = append(extra, key+"="+value)
+		}
+	}
+	for key, value := range secrets {
+		if value != "" {
+			extra = append(extra, key+"="+value)
+		}
+	}
+	sort.Strings(extra)
+	return extra, nil
+}
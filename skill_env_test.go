@@ -0,0 +1,42 @@
+package goskills
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareSkillEnv(t *testing.T) {
+	dir := t.TempDir()
+	envFile := "# API settings\nexport WEATHER_API_KEY=\"secret\"\nWEATHER_REGION=eu\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(envFile), 0644))
+
+	skill := SkillPackage{Path: dir, Meta: SkillMeta{Name: "weather", RequiredEnv: []string{"WEATHER_API_KEY"}}}
+	env, err := prepareSkillEnv(skill, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"WEATHER_API_KEY=secret", "WEATHER_REGION=eu"}, env)
+
+	// The process environment takes precedence over the .env file
+	t.Setenv("WEATHER_REGION", "us")
+	env, err = prepareSkillEnv(skill, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"WEATHER_API_KEY=secret"}, env)
+
+	skill.Meta.RequiredEnv = []string{"WEATHER_API_KEY", "GOSKILLS_TEST_MISSING_A", "GOSKILLS_TEST_MISSING_B"}
+	_, err = prepareSkillEnv(skill, nil)
+	assert.EqualError(t, err, "skill weather requires env var(s) GOSKILLS_TEST_MISSING_A, GOSKILLS_TEST_MISSING_B")
+}
+
+func TestPrepareSkillEnv_Secrets(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("WEATHER_API_KEY=from-file\n"), 0644))
+	t.Setenv("WEATHER_API_KEY", "from-process")
+
+	skill := SkillPackage{Path: dir, Meta: SkillMeta{Name: "weather", RequiredEnv: []string{"WEATHER_TOKEN"}}}
+	env, err := prepareSkillEnv(skill, map[string]string{"WEATHER_API_KEY": "from-secrets", "WEATHER_TOKEN": "token-123"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"WEATHER_API_KEY=from-secrets", "WEATHER_TOKEN=token-123"}, env)
+}
@@ -0,0 +1,155 @@
+package goskills
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// SkillInput declares a parameter a skill expects from the user prompt.
+type SkillInput struct {
+	Name        string `yaml:"name" json:"name"`
+	Type        string `yaml:"type,omitempty" json:"type,omitempty"` // string (default), number, integer, boolean, array or object
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// validInputTypes lists the supported SkillInput types.
+var validInputTypes = map[string]bool{
+	"":        true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"array":   true,
+	"object":  true,
+}
+
+// extractSkillInputs asks the LLM to extract the skill's declared inputs from
+// the user prompt and validates the result. It returns nil if the skill
+// declares no inputs.
+func (a *Agent) extractSkillInputs(ctx context.Context, userPrompt string, skill SkillPackage) (map[string]interface{}, error) {
+	if len(skill.Meta.Inputs) == 0 {
+		return nil, nil
+	}
+
+	spec, err := json.MarshalIndent(skill.Meta.Inputs, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model: a.cfg.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You extract structured parameters from a user request. Respond with only a JSON object whose keys are the parameter names. Omit parameters the request does not provide; never invent values.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("Parameters:\n%s\n\nUser Request: %s", spec, userPrompt),
+			},
+		},
+		Temperature:    0,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+	}
+
+	resp, err := a.createChatCompletion(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract skill inputs: %w", err)
+	}
+
+	var inputs map[string]interface{}
+	if err := json.Unmarshal([]byte(trimJSONFence(resp.Choices[0].Message.Content)), &inputs); err != nil {
+		return nil, fmt.Errorf("failed to parse skill inputs: %w", err)
+	}
+
+	if err := validateSkillInputs(inputs, skill.Meta.Inputs); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+// validateSkillInputs checks that all required inputs are present and that
+// values have their declared types. Undeclared keys are removed.
+func validateSkillInputs(inputs map[string]interface{}, specs []SkillInput) error {
+	declared := make(map[string]bool, len(specs))
+	var missing []string
+	for _, spec := range specs {
+		declared[spec.Name] = true
+
+		value, ok := inputs[spec.Name]
+		if !ok || value == nil || value == "" {
+			delete(inputs, spec.Name)
+			if spec.Required {
+				missing = append(missing, spec.Name)
+			}
+			continue
+		}
+		if !inputHasType(value, spec.Type) {
+			return fmt.Errorf("input %q must be of type %s", spec.Name, spec.Type)
+		}
+	}
+
+	for key := range inputs {
+		if !declared[key] {
+			delete(inputs, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required input(s): %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// inputHasType reports whether a decoded JSON value matches the input type.
+func inputHasType(value interface{}, typ string) bool {
+	switch typ {
+	case "", "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// skillSystemPrompt builds the system message for a skill, including the
+// extracted inputs and the names of the secrets if there are any.
+func skillSystemPrompt(skill SkillPackage, inputs map[string]interface{}, secrets []string) string {
+	var skillBody strings.Builder
+	skillBody.WriteString(skill.Body)
+	skillBody.WriteString("\n\n## SKILL CONTEXT\n")
+	skillBody.WriteString(fmt.Sprintf("Skill Root Path: %s\n", skill.Path))
+	if len(inputs) > 0 {
+		data, _ := json.MarshalIndent(inputs, "", "  ")
+		skillBody.WriteString("\n## SKILL INPUTS\n")
+		skillBody.WriteString(string(data))
+		skillBody.WriteString("\n")
+	}
+	if len(secrets) > 0 {
+		skillBody.WriteString("\n## SECRETS\n")
+		skillBody.WriteString("These environment variables are set for the scripts and code you run. Their values are hidden from you; reference them by name, e.g. $NAME in shell or os.environ[\"NAME\"] in Python, and never ask for or print them:\n")
+		for _, name := range secrets {
+			skillBody.WriteString("- " + name + "\n")
+		}
+	}
+	return skillBody.String()
+}
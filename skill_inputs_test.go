@@ -0,0 +1,34 @@
+package goskills
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSkillInputs(t *testing.T) {
+	specs := []SkillInput{
+		{Name: "city", Required: true},
+		{Name: "days", Type: "integer"},
+		{Name: "metric", Type: "boolean"},
+	}
+
+	inputs := map[string]interface{}{"city": "Paris", "days": float64(3), "extra": "dropped"}
+	assert.NoError(t, validateSkillInputs(inputs, specs))
+	assert.Equal(t, map[string]interface{}{"city": "Paris", "days": float64(3)}, inputs)
+
+	err := validateSkillInputs(map[string]interface{}{"days": float64(3)}, specs)
+	assert.ErrorContains(t, err, "missing required input(s): city")
+
+	err = validateSkillInputs(map[string]interface{}{"city": "Paris", "days": 2.5}, specs)
+	assert.ErrorContains(t, err, `input "days" must be of type integer`)
+}
+
+func TestSkillSystemPrompt(t *testing.T) {
+	skill := SkillPackage{Path: "/skills/weather", Body: "# Weather"}
+	assert.NotContains(t, skillSystemPrompt(skill, nil, nil), "SKILL INPUTS")
+
+	prompt := skillSystemPrompt(skill, map[string]interface{}{"city": "Paris"}, nil)
+	assert.Contains(t, prompt, "## SKILL INPUTS")
+	assert.Contains(t, prompt, `"city": "Paris"`)
+}
@@ -0,0 +1,141 @@
+package goskills
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/smallnest/goskills/tool"
+)
+
+// SkillInfo summarizes a skill for catalogs, such as a list command or a
+// skill picker, without running it.
+type SkillInfo struct {
+	// Name is the qualified name of the skill, e.g. "research/summarize".
+	// It is empty for a skill whose SKILL.md could not be parsed.
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Path        string `json:"path"`
+	Version     string `json:"version,omitempty"`
+	// Tools are the tools the skill declares in allowed_tools or
+	// allowed-tools, which may be globs. Empty means the default tool set.
+	Tools []string `json:"tools,omitempty"`
+	// Valid is false for a skill the runner skips: one that fails to parse,
+	// fails ValidateSkillPackage or reuses the name of an earlier skill.
+	Valid bool `json:"valid"`
+	// Warnings are the problems that make the skill invalid, followed by
+	// those that may keep a valid skill from running, such as a missing
+	// required environment variable or a newer min-goskills-version.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ListSkills returns a SkillInfo for each skill under skillsDir, in the order
+// the runner discovers them, including the invalid ones the runner skips so
+// their problems can be shown. Only a failure to walk skillsDir is returned
+// as an error.
+func ListSkills(skillsDir string) ([]SkillInfo, error) {
+	packages, problems, err := ParseSkillPackagesStrict(skillsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make(map[string]*SkillPackage, len(packages))
+	for _, pkg := range packages {
+		valid[pkg.Path] = pkg
+	}
+	invalid := make(map[string][]string)
+	for _, problem := range problems {
+		var skillErr *SkillError
+		if errors.As(problem, &skillErr) {
+			invalid[skillErr.Path] = append(invalid[skillErr.Path], skillErr.Err.Error())
+		}
+	}
+
+	skillDirs, err := findSkillDirs(skillsDir)
+	if err != nil {
+		return nil, err
+	}
+	var infos []SkillInfo
+	for _, dir := range skillDirs {
+		if pkg, ok := valid[dir]; ok {
+			info := newSkillInfo(pkg)
+			info.Valid = true
+			info.Warnings = skillWarnings(pkg)
+			infos = append(infos, info)
+			continue
+		}
+		warnings, ok := invalid[dir]
+		if !ok {
+			continue
+		}
+		info := SkillInfo{Path: dir, Warnings: warnings}
+		if pkg, err := ParseSkillPackage(dir); err == nil {
+			pkg.Namespace = skillNamespace(skillsDir, dir)
+			info = newSkillInfo(pkg)
+			info.Warnings = warnings
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func newSkillInfo(pkg *SkillPackage) SkillInfo {
+	return SkillInfo{
+		Name:        pkg.QualifiedName(),
+		Description: pkg.Meta.Description,
+		Path:        pkg.Path,
+		Version:     pkg.Meta.Version,
+		Tools:       pkg.Meta.AllowedTools,
+	}
+}
+
+// skillWarnings returns the problems that may keep a valid skill from
+// running.
+func skillWarnings(pkg *SkillPackage) []string {
+	var warnings []string
+	if err := CheckSkillCompatibility(pkg); err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	fileEnv, err := loadSkillEnv(pkg.Path)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("failed to load %s: %v", skillEnvFile, err))
+	}
+	var missing []string
+	for _, name := range pkg.Meta.RequiredEnv {
+		if os.Getenv(name) == "" && fileEnv[name] == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		warnings = append(warnings, fmt.Sprintf("required env var(s) %s not set", strings.Join(missing, ", ")))
+	}
+
+	// MCP tools ("server__tool") are only known at run time
+	var builtin []string
+	for _, t := range append(tool.GetBaseTools(), tool.GetGitTools()...) {
+		builtin = append(builtin, t.Function.Name)
+	}
+	for _, script := range pkg.Resources.Scripts {
+		builtin = append(builtin, scriptToolName(script))
+	}
+	builtin = append(builtin, "retrieve_docs")
+	for _, pattern := range append(slices.Clone(pkg.Meta.AllowedTools), pkg.Meta.RequiredTools...) {
+		if strings.Contains(pattern, "__") {
+			continue
+		}
+		known := false
+		for _, name := range builtin {
+			if matchesAnyTool(name, []string{pattern}) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			warnings = append(warnings, fmt.Sprintf("tool %q matches no built-in tool", pattern))
+		}
+	}
+	return warnings
+}
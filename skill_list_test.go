@@ -0,0 +1,54 @@
+package goskills
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSkills(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSkill := func(dir, content string) {
+		skillPath := filepath.Join(tmpDir, dir)
+		require.NoError(t, os.MkdirAll(skillPath, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(skillPath, "SKILL.md"), []byte(content), 0644))
+	}
+
+	writeSkill("a-search", "---\nname: search\ndescription: Searches the web.\nversion: 1.2.0\nallowed_tools: [tavily_search, \"github__*\", no_such_tool]\n---\n# Body\n")
+	writeSkill("b-env", "---\nname: env\ndescription: Needs a key.\nrequired_env: [GOSKILLS_LIST_TEST_KEY]\nmin-goskills-version: 99.0.0\n---\n# Body\n")
+	writeSkill("c-broken", "no frontmatter")
+	writeSkill("research/summarize", "---\nname: summarize\ndescription: Summarizes.\n---\n")
+
+	skills, err := ListSkills(tmpDir)
+	require.NoError(t, err)
+	require.Len(t, skills, 4)
+
+	search := skills[0]
+	assert.Equal(t, "search", search.Name)
+	assert.Equal(t, "Searches the web.", search.Description)
+	assert.Equal(t, filepath.Join(tmpDir, "a-search"), search.Path)
+	assert.Equal(t, "1.2.0", search.Version)
+	assert.Equal(t, []string{"tavily_search", "github__*", "no_such_tool"}, search.Tools)
+	assert.True(t, search.Valid)
+	assert.Equal(t, []string{`tool "no_such_tool" matches no built-in tool`}, search.Warnings)
+
+	env := skills[1]
+	assert.True(t, env.Valid)
+	warnings := strings.Join(env.Warnings, "\n")
+	assert.Contains(t, warnings, "requires goskills 99.0.0 or newer")
+	assert.Contains(t, warnings, "GOSKILLS_LIST_TEST_KEY not set")
+
+	broken := skills[2]
+	assert.False(t, broken.Valid)
+	assert.Empty(t, broken.Name)
+	assert.Contains(t, strings.Join(broken.Warnings, "\n"), "no YAML frontmatter found")
+
+	summarize := skills[3]
+	assert.False(t, summarize.Valid)
+	assert.Equal(t, "research/summarize", summarize.Name)
+	assert.Equal(t, []string{"empty body"}, summarize.Warnings)
+}
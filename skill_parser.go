@@ -6,6 +6,8 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -14,20 +16,87 @@ import (
 // SkillPackage represents a fully and finely parsed Claude Skill package
 type SkillPackage struct {
 	Path      string         `json:"path"`
+	Namespace string         `json:"namespace,omitempty"` // Relative path of the parent directory for nested skills, e.g. "research"
 	Meta      SkillMeta      `json:"meta"`
 	Body      string         `json:"body"` // Raw Markdown content of SKILL.md body
 	Resources SkillResources `json:"resources"`
 }
 
+// QualifiedName returns the skill name prefixed with its namespace, e.g.
+// "research/summarize". Top-level skills use their plain name.
+func (p *SkillPackage) QualifiedName() string {
+	if p.Namespace == "" {
+		return p.Meta.Name
+	}
+	return p.Namespace + "/" + p.Meta.Name
+}
+
 // SkillMeta corresponds to the content of SKILL.md frontmatter
 type SkillMeta struct {
-	Name         string   `yaml:"name"`
-	Description  string   `yaml:"description"`
-	AllowedTools []string `yaml:"allowed-tools"`
-	Model        string   `yaml:"model,omitempty"`
-	Author       string   `yaml:"author,omitempty"`
-	Version      string   `yaml:"version,omitempty"`
-	License      string   `yaml:"license,omitempty"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// AllowedTools restricts the goskills tools (e.g. "read_file",
+	// "run_python_code") and MCP tools offered to the skill. Entries may be
+	// globs such as "github__*". An empty list keeps the default tool set.
+	// The frontmatter may spell it allowed-tools or allowed_tools.
+	AllowedTools       []string `yaml:"allowed-tools"`
+	Model              string   `yaml:"model,omitempty"` // Model that runs the skill instead of RunnerConfig.Model
+	Author             string   `yaml:"author,omitempty"`
+	Version            string   `yaml:"version,omitempty"`
+	License            string   `yaml:"license,omitempty"`
+	MinGoskillsVersion string   `yaml:"min-goskills-version,omitempty"` // Oldest goskills version the skill works with
+	// RequiredTools lists tools, by name or glob, the skill cannot work
+	// without, e.g. "tavily_search" or "github__*". A skill missing one of
+	// them, because it is disabled or its MCP server is not configured, is
+	// passed over during skill selection.
+	RequiredTools []string `yaml:"required_tools,omitempty"`
+	// Clarify lets the model ask the user clarifying questions before the
+	// skill runs, as RunnerConfig.Clarify does for every skill.
+	Clarify bool `yaml:"clarify,omitempty"`
+	// Inputs declares the parameters the skill expects. They are extracted
+	// from the user prompt and validated before the skill runs.
+	Inputs []SkillInput `yaml:"inputs,omitempty"`
+	// RequiredEnv lists environment variables the skill's scripts need. They
+	// may also be provided by a .env file in the skill directory.
+	RequiredEnv []string `yaml:"required_env,omitempty"`
+	// Scripts describes the skill's scripts and their parameters, keyed by
+	// the script path relative to the skill. See ScriptSpec.
+	Scripts map[string]ScriptSpec `yaml:"scripts,omitempty"`
+	// Aliases are other names users may call the skill by, e.g. "summarize"
+	// for a skill named "digest". Keywords are terms that suggest the skill.
+	// Both are shown to the model during skill selection and used by
+	// RunnerConfig.KeywordPreMatch.
+	Aliases  []string `yaml:"aliases,omitempty"`
+	Keywords []string `yaml:"keywords,omitempty"`
+	// Temperature is the sampling temperature, from 0 to 2, of the requests
+	// that run the skill, e.g. 0 for deterministic code generation. Unset
+	// uses the backend's default.
+	Temperature *float32 `yaml:"temperature,omitempty"`
+	// PostProcess is the path, relative to the skill, of one of its scripts
+	// that filters the final output: the output is written to its standard
+	// input and its standard output, without a trailing newline, replaces
+	// it. A script that fails fails the run. Use it for invariants the model
+	// cannot be trusted to keep, such as a maximum length.
+	PostProcess string `yaml:"post_process,omitempty"`
+}
+
+// UnmarshalYAML accepts allowed_tools as another spelling of allowed-tools.
+func (m *SkillMeta) UnmarshalYAML(value *yaml.Node) error {
+	type plain SkillMeta
+	var meta struct {
+		plain        `yaml:",inline"`
+		AllowedTools []string `yaml:"allowed_tools"`
+	}
+	if err := value.Decode(&meta); err != nil {
+		return err
+	}
+	*m = SkillMeta(meta.plain)
+	for _, name := range meta.AllowedTools {
+		if !slices.Contains(m.AllowedTools, name) {
+			m.AllowedTools = append(m.AllowedTools, name)
+		}
+	}
+	return nil
 }
 
 // SkillResources lists the relevant resource files in the skill package
@@ -153,9 +222,149 @@ func ParseSkillPackage(dirPath string) (*SkillPackage, error) {
 
 // ParseSkillPackages finds all skill packages in a given directory and its subdirectories.
 // A directory is considered a skill package if it contains a SKILL.md file.
+// Skills in nested directories get the relative path of their parent as Namespace.
 // It returns a slice of successfully parsed SkillPackage objects.
 
 func ParseSkillPackages(rootDir string) ([]*SkillPackage, error) {
+	skillDirs, err := findSkillDirs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*SkillPackage
+	for _, dir := range skillDirs {
+		pkg, err := ParseSkillPackage(dir)
+		if err == nil {
+			pkg.Namespace = skillNamespace(rootDir, dir)
+			packages = append(packages, pkg)
+		}
+
+		// Silently ignore packages that fail to parse
+	}
+
+	return packages, nil
+}
+
+// SkillError is a problem found in a single skill package.
+type SkillError struct {
+	Path string // Skill directory
+	Err  error
+}
+
+func (e *SkillError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *SkillError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateSkillPackage checks that a parsed skill has what skill selection
+// needs: a name, a description and a non-empty body. It returns one error per problem.
+func ValidateSkillPackage(pkg *SkillPackage) []error {
+	if pkg == nil {
+		return []error{fmt.Errorf("skill package is nil")}
+	}
+
+	var errs []error
+	if strings.TrimSpace(pkg.Meta.Name) == "" {
+		errs = append(errs, fmt.Errorf("missing name"))
+	}
+	if strings.TrimSpace(pkg.Meta.Description) == "" {
+		errs = append(errs, fmt.Errorf("missing description"))
+	}
+	if strings.TrimSpace(pkg.Body) == "" {
+		errs = append(errs, fmt.Errorf("empty body"))
+	}
+	for i, input := range pkg.Meta.Inputs {
+		if strings.TrimSpace(input.Name) == "" {
+			errs = append(errs, fmt.Errorf("input %d is missing a name", i+1))
+		}
+		if !validInputTypes[input.Type] {
+			errs = append(errs, fmt.Errorf("input %q has unsupported type %q", input.Name, input.Type))
+		}
+	}
+	for rel, spec := range pkg.Meta.Scripts {
+		if !slices.Contains(pkg.Resources.Scripts, rel) {
+			errs = append(errs, fmt.Errorf("scripts declares %q, which is not a script of the skill", rel))
+		}
+		for i, param := range spec.Parameters {
+			if strings.TrimSpace(param.Name) == "" {
+				errs = append(errs, fmt.Errorf("parameter %d of script %q is missing a name", i+1, rel))
+			}
+			if !validInputTypes[param.Type] {
+				errs = append(errs, fmt.Errorf("parameter %q of script %q has unsupported type %q", param.Name, rel, param.Type))
+			}
+		}
+	}
+	if pkg.Meta.PostProcess != "" && !slices.Contains(pkg.Resources.Scripts, pkg.Meta.PostProcess) {
+		errs = append(errs, fmt.Errorf("post_process %q is not a script of the skill", pkg.Meta.PostProcess))
+	}
+	if t := pkg.Meta.Temperature; t != nil && (*t < 0 || *t > 2) {
+		errs = append(errs, fmt.Errorf("temperature %g is not between 0 and 2", *t))
+	}
+	if pkg.Meta.MinGoskillsVersion != "" {
+		if _, err := parseVersion(pkg.Meta.MinGoskillsVersion); err != nil {
+			errs = append(errs, fmt.Errorf("invalid min-goskills-version: %w", err))
+		}
+	}
+	return errs
+}
+
+// ParseSkillPackagesStrict is like ParseSkillPackages, but validates every
+// skill and reports the problems instead of silently dropping them.
+// Skills that fail to parse, fail ValidateSkillPackage or reuse the name of
+// an earlier skill are left out of the returned packages, and each problem is
+// returned as a *SkillError. Only a failure to walk rootDir is returned as err.
+func ParseSkillPackagesStrict(rootDir string) ([]*SkillPackage, []error, error) {
+	skillDirs, err := findSkillDirs(rootDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var packages []*SkillPackage
+	var problems []error
+	seen := make(map[string]string) // name -> path
+	for _, dir := range skillDirs {
+		pkg, err := ParseSkillPackage(dir)
+		if err != nil {
+			problems = append(problems, &SkillError{Path: dir, Err: err})
+			continue
+		}
+		pkg.Namespace = skillNamespace(rootDir, dir)
+
+		if errs := ValidateSkillPackage(pkg); len(errs) > 0 {
+			for _, err := range errs {
+				problems = append(problems, &SkillError{Path: dir, Err: err})
+			}
+			continue
+		}
+
+		name := pkg.QualifiedName()
+		if other, ok := seen[name]; ok {
+			problems = append(problems, &SkillError{Path: dir, Err: fmt.Errorf("duplicate name %q (already used by %s)", name, other)})
+			continue
+		}
+		seen[name] = dir
+		packages = append(packages, pkg)
+	}
+
+	return packages, problems, nil
+}
+
+// skillNamespace returns the namespace of the skill in skillDir: the path of
+// its parent directory relative to rootDir, using forward slashes. Skills
+// directly under rootDir have no namespace.
+func skillNamespace(rootDir, skillDir string) string {
+	rel, err := filepath.Rel(rootDir, filepath.Dir(skillDir))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// findSkillDirs returns the sorted list of directories under rootDir that contain a SKILL.md file.
+func findSkillDirs(rootDir string) ([]string, error) {
 	skillDirs := make(map[string]struct{})
 
 	walkErr := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
@@ -175,15 +384,10 @@ func ParseSkillPackages(rootDir string) ([]*SkillPackage, error) {
 		return nil, fmt.Errorf("error walking directory %s: %w", rootDir, walkErr)
 	}
 
-	var packages []*SkillPackage
+	dirs := make([]string, 0, len(skillDirs))
 	for dir := range skillDirs {
-		pkg, err := ParseSkillPackage(dir)
-		if err == nil {
-			packages = append(packages, pkg)
-		}
-
-		// Silently ignore packages that fail to parse
+		dirs = append(dirs, dir)
 	}
-
-	return packages, nil
+	sort.Strings(dirs)
+	return dirs, nil
 }
@@ -184,6 +184,17 @@ allowed-tools: []
 	assert.Empty(t, pkg.Resources.Assets)
 }
 
+func TestExtractFrontmatterAllowedToolsSpellings(t *testing.T) {
+	meta, _, err := extractFrontmatterAndBody([]byte("---\nname: a\nallowed_tools: [read_file, \"git_*\"]\nmodel: gpt-4o\n---\nBody"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read_file", "git_*"}, meta.AllowedTools)
+	assert.Equal(t, "gpt-4o", meta.Model)
+
+	meta, _, err = extractFrontmatterAndBody([]byte("---\nname: a\nallowed-tools: [read_file]\nallowed_tools: [read_file, write_file]\n---\nBody"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read_file", "write_file"}, meta.AllowedTools)
+}
+
 func TestParseSkillPackage_SubdirectoriesInResources(t *testing.T) {
 	tmpDir := t.TempDir()
 	skillPath := filepath.Join(tmpDir, "sub-resources-skill")
@@ -221,3 +232,81 @@ func TestParseSkillPackages(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, skills, 27)
 }
+
+func TestParseSkillPackagesStrict(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSkill := func(dir, content string) {
+		skillPath := filepath.Join(tmpDir, dir)
+		require.NoError(t, os.Mkdir(skillPath, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(skillPath, "SKILL.md"), []byte(content), 0644))
+	}
+
+	writeSkill("a-valid", "---\nname: valid\ndescription: A valid skill.\n---\n# Body\n")
+	writeSkill("b-duplicate", "---\nname: valid\ndescription: Same name.\n---\n# Body\n")
+	writeSkill("c-no-name", "---\ndescription: No name.\n---\n# Body\n")
+	writeSkill("d-no-description", "---\nname: nodesc\n---\n# Body\n")
+	writeSkill("e-empty-body", "---\nname: empty\ndescription: Empty body.\n---\n")
+	writeSkill("f-broken", "no frontmatter")
+
+	packages, problems, err := ParseSkillPackagesStrict(tmpDir)
+	require.NoError(t, err)
+	require.Len(t, packages, 1)
+	assert.Equal(t, "valid", packages[0].Meta.Name)
+
+	var messages []string
+	for _, problem := range problems {
+		var skillErr *SkillError
+		require.ErrorAs(t, problem, &skillErr)
+		messages = append(messages, problem.Error())
+	}
+	all := strings.Join(messages, "\n")
+	assert.Len(t, problems, 5)
+	assert.Contains(t, all, "duplicate name")
+	assert.Contains(t, all, "missing name")
+	assert.Contains(t, all, "missing description")
+	assert.Contains(t, all, "empty body")
+	assert.Contains(t, all, "no YAML frontmatter found")
+}
+
+func TestValidateSkillPackage(t *testing.T) {
+	errs := ValidateSkillPackage(&SkillPackage{Meta: SkillMeta{Name: "n", Description: "d"}, Body: "body"})
+	assert.Empty(t, errs)
+
+	errs = ValidateSkillPackage(&SkillPackage{})
+	assert.Len(t, errs, 3)
+
+	errs = ValidateSkillPackage(&SkillPackage{
+		Meta: SkillMeta{Name: "n", Description: "d", Scripts: map[string]ScriptSpec{
+			"scripts/run.sh":   {Parameters: []SkillInput{{Name: "target"}}},
+			"scripts/other.sh": {Parameters: []SkillInput{{Name: "", Type: "date"}}},
+		}},
+		Body:      "body",
+		Resources: SkillResources{Scripts: []string{"scripts/run.sh"}},
+	})
+	assert.Len(t, errs, 3)
+
+	hot := float32(2.5)
+	errs = ValidateSkillPackage(&SkillPackage{Meta: SkillMeta{Name: "n", Description: "d", Temperature: &hot}, Body: "body"})
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "temperature 2.5 is not between 0 and 2")
+}
+
+func TestParseSkillPackages_Namespaces(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"summarize", "research/summarize", "devops/ci/summarize"} {
+		skillPath := filepath.Join(tmpDir, filepath.FromSlash(dir))
+		require.NoError(t, os.MkdirAll(skillPath, 0755))
+		content := "---\nname: summarize\ndescription: Summarizes text.\n---\n# Body\n"
+		require.NoError(t, os.WriteFile(filepath.Join(skillPath, "SKILL.md"), []byte(content), 0644))
+	}
+
+	packages, problems, err := ParseSkillPackagesStrict(tmpDir)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	var names []string
+	for _, pkg := range packages {
+		names = append(names, pkg.QualifiedName())
+	}
+	assert.ElementsMatch(t, []string{"summarize", "research/summarize", "devops/ci/summarize"}, names)
+}
@@ -0,0 +1,104 @@
+package goskills
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/tool"
+)
+
+// mcpTools returns the tools of the MCP servers, or nil when there are none
+// or they cannot be listed.
+func (a *Agent) mcpTools(ctx context.Context) []openai.Tool {
+	if a.mcpClient == nil {
+		return nil
+	}
+	tools, err := a.mcpClient.GetTools(ctx)
+	if err != nil {
+		a.logAlways(ctx, slog.LevelWarn, "failed to get MCP tools",
+			fmt.Sprintf("⚠️ Failed to get MCP tools: %v\n", err),
+			slog.Any("error", err))
+		return nil
+	}
+	return tools
+}
+
+// offeredTools returns the tools offered to skill, given the tools of the MCP
+// servers, and the script paths of its script tools. Tools matching
+// RunnerConfig.DisabledTools are left out.
+func (a *Agent) offeredTools(skill SkillPackage, mcpTools []openai.Tool) ([]openai.Tool, map[string]string) {
+	tools, scriptMap := GenerateToolDefinitions(skill)
+
+	if len(skill.Meta.AllowedTools) > 0 {
+		mcpTools = FilterAllowedTools(mcpTools, skill.Meta.AllowedTools)
+	}
+	tools = append(tools, mcpTools...)
+
+	if a.cfg.SQLDSN != "" {
+		sqlTools := []openai.Tool{tool.SQLQueryToolDefinition()}
+		if len(skill.Meta.AllowedTools) > 0 {
+			sqlTools = FilterAllowedTools(sqlTools, skill.Meta.AllowedTools)
+		}
+		tools = append(tools, sqlTools...)
+	}
+
+	if len(a.cfg.DisabledTools) > 0 {
+		enabled := tools[:0]
+		for _, t := range tools {
+			if t.Function != nil && !matchesAnyTool(t.Function.Name, a.cfg.DisabledTools) {
+				enabled = append(enabled, t)
+			}
+		}
+		tools = enabled
+	}
+	return tools, scriptMap
+}
+
+// matchesAnyTool reports whether the tool name matches one of patterns, as
+// in FilterAllowedTools.
+func matchesAnyTool(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// missingTools returns the entries of the skill's required_tools that match
+// none of the tools it would be offered.
+func (a *Agent) missingTools(skill SkillPackage, mcpTools []openai.Tool) []string {
+	if len(skill.Meta.RequiredTools) == 0 {
+		return nil
+	}
+	tools, _ := a.offeredTools(skill, mcpTools)
+	var missing []string
+	for _, required := range skill.Meta.RequiredTools {
+		found := false
+		for _, t := range tools {
+			if ok, _ := path.Match(required, t.Function.Name); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, required)
+		}
+	}
+	return missing
+}
+
+// runnableSkills returns the skills that have all their required tools,
+// plus the default skill, which is checked once it is chosen.
+func (a *Agent) runnableSkills(skills map[string]SkillPackage, mcpTools []openai.Tool) map[string]SkillPackage {
+	runnable := make(map[string]SkillPackage, len(skills))
+	for name, skill := range skills {
+		if name == a.cfg.DefaultSkill || len(a.missingTools(skill, mcpTools)) == 0 {
+			runnable[name] = skill
+		}
+	}
+	return runnable
+}
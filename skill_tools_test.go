@@ -0,0 +1,55 @@
+package goskills
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfferedToolsDisabled(t *testing.T) {
+	a := &Agent{cfg: RunnerConfig{DisabledTools: []string{"*_search", "http_request"}}}
+	tools, _ := a.offeredTools(SkillPackage{}, nil)
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Function.Name)
+	}
+	assert.Contains(t, names, "read_file")
+	assert.NotContains(t, names, "duckduckgo_search")
+	assert.NotContains(t, names, "tavily_search")
+	assert.NotContains(t, names, "http_request")
+
+	skill := SkillPackage{Meta: SkillMeta{RequiredTools: []string{"read_file", "tavily_*"}}}
+	assert.Equal(t, []string{"tavily_*"}, a.missingTools(skill, nil))
+	assert.Empty(t, (&Agent{}).missingTools(skill, nil))
+}
+
+func TestSelectionSkipsSkillsMissingTools(t *testing.T) {
+	client := agenttest.NewFakeClient(agenttest.Reply("research"), agenttest.Reply("notes"), agenttest.Reply("done"))
+
+	skills := staticSkills{
+		"research": {Path: t.TempDir(), Meta: SkillMeta{Name: "research", Description: "Researches the web.", RequiredTools: []string{"tavily_search"}}},
+		"notes":    {Path: t.TempDir(), Meta: SkillMeta{Name: "notes", Description: "Takes notes."}},
+	}
+	var log bytes.Buffer
+	a, err := NewAgent(RunnerConfig{Client: client, SkillProvider: skills, DisabledTools: []string{"tavily_search"}, LogWriter: &log}, nil)
+	require.NoError(t, err)
+
+	output, err := a.Run(context.Background(), "look this up")
+	require.NoError(t, err)
+	assert.Equal(t, "done", output)
+	var prompts []string
+	for _, req := range client.Requests() {
+		body, _ := json.Marshal(req)
+		prompts = append(prompts, string(body))
+	}
+	require.Len(t, prompts, 3)
+	assert.Contains(t, prompts[0], "Researches the web.")
+	assert.NotContains(t, prompts[1], "Researches the web.")
+	assert.Contains(t, log.String(), "Skill research needs unavailable tools (tavily_search)")
+	assert.NotContains(t, prompts[2], `"name":"tavily_search"`)
+}
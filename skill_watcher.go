@@ -0,0 +1,199 @@
+package goskills
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// SkillProvider supplies the skills available to the runner. Set
+// RunnerConfig.SkillProvider to use it instead of parsing SkillsDir on every run.
+type SkillProvider interface {
+	Skills() map[string]SkillPackage
+}
+
+// SkillWatcher keeps an in-memory set of the skills in a directory up to date,
+// re-parsing skill packages as their files are edited, added or removed.
+// It is safe for concurrent use and implements SkillProvider.
+type SkillWatcher struct {
+	dir     string
+	watcher *fsnotify.Watcher
+
+	mu     sync.RWMutex
+	byPath map[string]*SkillPackage // skill directory -> parsed package
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSkillWatcher parses the skills in dir and starts watching it for changes.
+// Call Close to stop watching.
+func NewSkillWatcher(dir string) (*SkillWatcher, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &SkillWatcher{
+		dir:     absDir,
+		watcher: watcher,
+		byPath:  make(map[string]*SkillPackage),
+		done:    make(chan struct{}),
+	}
+	if err := w.addTree(absDir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go w.loop()
+	return w, nil
+}
+
+// Skills returns the currently valid skills keyed by qualified name. When two
+// skills share a name, the one with the lexically smaller path wins.
+func (w *SkillWatcher) Skills() map[string]SkillPackage {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	paths := make([]string, 0, len(w.byPath))
+	for path := range w.byPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	skills := make(map[string]SkillPackage, len(paths))
+	for _, path := range paths {
+		pkg := w.byPath[path]
+		if len(ValidateSkillPackage(pkg)) > 0 {
+			continue
+		}
+		if _, ok := skills[pkg.QualifiedName()]; !ok {
+			skills[pkg.QualifiedName()] = *pkg
+		}
+	}
+	return skills
+}
+
+// Close stops watching the skills directory.
+func (w *SkillWatcher) Close() error {
+	select {
+	case <-w.done:
+		return nil
+	default:
+	}
+	close(w.done)
+	err := w.watcher.Close()
+	w.wg.Wait()
+	return err
+}
+
+func (w *SkillWatcher) loop() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			// Errors are transient (e.g. event overflow); keep watching
+		}
+	}
+}
+
+func (w *SkillWatcher) handleEvent(event fsnotify.Event) {
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			// A new directory may hold new skills; fsnotify watches are not recursive
+			w.addTree(event.Name)
+			return
+		}
+	}
+
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		w.dropMissing()
+	}
+
+	if dir := w.skillDirFor(event.Name); dir != "" {
+		w.reload(dir)
+	}
+}
+
+// addTree watches root and every directory below it, and parses the skills found there.
+func (w *SkillWatcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if err := w.watcher.Add(path); err != nil {
+				return err
+			}
+			return nil
+		}
+		if d.Name() == "SKILL.md" {
+			w.reload(filepath.Dir(path))
+		}
+		return nil
+	})
+}
+
+// skillDirFor returns the nearest directory at or above path, within the
+// watched directory, that contains a SKILL.md file.
+func (w *SkillWatcher) skillDirFor(path string) string {
+	dir := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	for strings.HasPrefix(dir, w.dir) {
+		if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err == nil {
+			return dir
+		}
+		if dir == w.dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	return ""
+}
+
+// reload re-parses the skill in dir. A skill that fails to parse (for example
+// while its SKILL.md is half written) keeps its previous version.
+func (w *SkillWatcher) reload(dir string) {
+	pkg, err := ParseSkillPackage(dir)
+	if err != nil {
+		return
+	}
+	pkg.Namespace = skillNamespace(w.dir, dir)
+	w.mu.Lock()
+	w.byPath[dir] = pkg
+	w.mu.Unlock()
+}
+
+// dropMissing forgets skills whose SKILL.md no longer exists.
+func (w *SkillWatcher) dropMissing() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for dir := range w.byPath {
+		if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err != nil {
+			delete(w.byPath, dir)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package goskills
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkillWatcher(t *testing.T) {
+	root := t.TempDir()
+	writeSkill := func(dir, name, description string) {
+		skillPath := filepath.Join(root, dir)
+		require.NoError(t, os.MkdirAll(skillPath, 0755))
+		content := "---\nname: " + name + "\ndescription: " + description + "\n---\n# Body\n"
+		require.NoError(t, os.WriteFile(filepath.Join(skillPath, "SKILL.md"), []byte(content), 0644))
+	}
+
+	writeSkill("first", "first", "The first skill.")
+
+	w, err := NewSkillWatcher(root)
+	require.NoError(t, err)
+	defer w.Close()
+
+	assert.Contains(t, w.Skills(), "first")
+
+	// A new skill is picked up
+	writeSkill("second", "second", "The second skill.")
+	assert.Eventually(t, func() bool {
+		_, ok := w.Skills()["second"]
+		return ok
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// Edits are re-parsed
+	writeSkill("first", "first", "Updated description.")
+	assert.Eventually(t, func() bool {
+		return w.Skills()["first"].Meta.Description == "Updated description."
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// Removed skills disappear
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "second")))
+	assert.Eventually(t, func() bool {
+		_, ok := w.Skills()["second"]
+		return !ok
+	}, 2*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, w.Close())
+}
@@ -0,0 +1,208 @@
+package goskills
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// streamingClient is implemented by *openai.Client.
+type streamingClient interface {
+	CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error)
+}
+
+// completeTurn sends a request of the tool-calling loop, streaming the
+// assistant text when RunnerConfig.Stream is set.
+func (a *Agent) completeTurn(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	if !a.cfg.Stream {
+		return a.createChatCompletion(ctx, req)
+	}
+	streamer, ok := a.client.(streamingClient)
+	if !ok {
+		// Backends without streaming deliver the whole text as one chunk
+		resp, err := a.createChatCompletion(ctx, req)
+		if err == nil && len(resp.Choices) > 0 && resp.Choices[0].Message.Content != "" {
+			a.streamChunk(a.redact(resp.Choices[0].Message.Content))
+		}
+		return resp, err
+	}
+	return a.streamChatCompletion(ctx, streamer, req)
+}
+
+// streamChatCompletion sends req as a streaming request, forwarding assistant
+// text as it arrives and rebuilding the complete response, including tool
+// calls whose arguments arrive in pieces.
+func (a *Agent) streamChatCompletion(ctx context.Context, streamer streamingClient, req openai.ChatCompletionRequest) (resp openai.ChatCompletionResponse, err error) {
+	ctx, span := a.startSpan(ctx, "goskills.llm_request",
+		attribute.String("llm.model", req.Model),
+		attribute.Int("llm.messages", len(req.Messages)),
+		attribute.Int("llm.tools", len(req.Tools)),
+		attribute.Bool("llm.stream", true),
+	)
+	defer func() { endSpan(span, err) }()
+
+	req.Stream = true
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := streamer.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer stream.Close()
+
+	msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	var finishReason openai.FinishReason
+	redactor := a.newStreamRedactor()
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return openai.ChatCompletionResponse{}, err
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+		if chunk.SystemFingerprint != "" {
+			resp.SystemFingerprint = chunk.SystemFingerprint
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		choice := chunk.Choices[0]
+		if choice.Delta.Content != "" {
+			msg.Content += choice.Delta.Content
+			if chunk := redactor.write(choice.Delta.Content); chunk != "" {
+				a.streamChunk(chunk)
+			}
+		}
+		msg.ToolCalls = accumulateToolCalls(msg.ToolCalls, choice.Delta.ToolCalls)
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
+		}
+	}
+	if chunk := redactor.flush(); chunk != "" {
+		a.streamChunk(chunk)
+	}
+	for i := range msg.ToolCalls {
+		msg.ToolCalls[i].Index = nil
+	}
+
+	a.recordUsage(req.Model, resp.Usage)
+	a.recordFingerprint(resp.SystemFingerprint)
+	span.SetAttributes(
+		attribute.Int("llm.prompt_tokens", resp.Usage.PromptTokens),
+		attribute.Int("llm.completion_tokens", resp.Usage.CompletionTokens),
+		attribute.Int("llm.total_tokens", resp.Usage.TotalTokens),
+	)
+	resp.Model = req.Model
+	resp.Choices = []openai.ChatCompletionChoice{{Message: msg, FinishReason: finishReason}}
+	return resp, nil
+}
+
+// accumulateToolCalls merges streamed tool call deltas into calls. The first
+// delta of a call carries its index, ID and name; later deltas for the same
+// index append to its arguments.
+func accumulateToolCalls(calls []openai.ToolCall, deltas []openai.ToolCall) []openai.ToolCall {
+	for _, delta := range deltas {
+		i := len(calls) - 1
+		switch {
+		case delta.Index != nil:
+			i = *delta.Index
+		case delta.ID != "" || i < 0:
+			i = len(calls)
+		}
+		for len(calls) <= i {
+			index := len(calls)
+			calls = append(calls, openai.ToolCall{Index: &index, Type: openai.ToolTypeFunction})
+		}
+
+		call := &calls[i]
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Type != "" {
+			call.Type = delta.Type
+		}
+		call.Function.Name += delta.Function.Name
+		call.Function.Arguments += delta.Function.Arguments
+	}
+	return calls
+}
+
+// streamChunk forwards redacted assistant text to the InteractionHandler, or
+// prints it when there is none.
+func (a *Agent) streamChunk(chunk string) {
+	if a.cfg.InteractionHandler != nil {
+		a.cfg.InteractionHandler.StreamChunk(chunk)
+		return
+	}
+	fmt.Print(chunk)
+}
+
+// streamRedactor redacts streamed text. A secret may be split across chunks,
+// so the tail of the text that could be the start of one is held back until
+// the next chunk shows whether it is.
+type streamRedactor struct {
+	redact  func(string) string
+	secrets []string
+	hold    int // Bytes held back: the length of the longest secret minus one
+	pending string
+}
+
+func (a *Agent) newStreamRedactor() *streamRedactor {
+	r := &streamRedactor{redact: a.redact}
+	for _, secret := range a.secrets {
+		if len(secret) >= minSecretLength {
+			r.secrets = append(r.secrets, secret)
+			r.hold = max(r.hold, len(secret)-1)
+		}
+	}
+	return r
+}
+
+// write adds chunk and returns the redacted text that is safe to emit.
+func (r *streamRedactor) write(chunk string) string {
+	r.pending += chunk
+	cut := len(r.pending) - r.hold
+	if cut <= 0 {
+		return ""
+	}
+	// Do not cut through a secret or a match of a credential pattern, which
+	// may continue in the next chunk
+	for _, secret := range r.secrets {
+		for start := max(cut-len(secret)+1, 0); start < cut; start++ {
+			if strings.HasPrefix(r.pending[start:], secret) {
+				cut = start
+				break
+			}
+		}
+	}
+	for _, re := range secretPatterns {
+		for _, m := range re.FindAllStringIndex(r.pending, -1) {
+			if m[0] < cut && (m[1] > cut || m[1] == len(r.pending)) {
+				cut = m[0]
+			}
+		}
+	}
+	for cut > 0 && cut < len(r.pending) && !utf8.RuneStart(r.pending[cut]) {
+		cut--
+	}
+	out := r.pending[:cut]
+	r.pending = r.pending[cut:]
+	return r.redact(out)
+}
+
+// flush returns the redacted text held back at the end of the stream.
+func (r *streamRedactor) flush() string {
+	out := r.pending
+	r.pending = ""
+	return r.redact(out)
+}
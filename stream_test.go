@@ -0,0 +1,108 @@
+package goskills
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccumulateToolCalls(t *testing.T) {
+	zero, one := 0, 1
+	calls := accumulateToolCalls(nil, []openai.ToolCall{
+		{Index: &zero, ID: "a", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "read_file", Arguments: `{"file`}},
+		{Index: &one, ID: "b", Function: openai.FunctionCall{Name: "web_fetch"}},
+	})
+	calls = accumulateToolCalls(calls, []openai.ToolCall{
+		{Index: &zero, Function: openai.FunctionCall{Arguments: `Path":"a.txt"}`}},
+		{Index: &one, Function: openai.FunctionCall{Arguments: `{}`}},
+	})
+
+	require.Len(t, calls, 2)
+	assert.Equal(t, "a", calls[0].ID)
+	assert.Equal(t, `{"filePath":"a.txt"}`, calls[0].Function.Arguments)
+	assert.Equal(t, "web_fetch", calls[1].Function.Name)
+	assert.Equal(t, openai.ToolTypeFunction, calls[1].Type)
+}
+
+func TestStreamToolLoop(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+
+	calls := 0
+	var secondRequest string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var events []string
+		if calls == 1 {
+			args := fmt.Sprintf(`{"filePath":%q}`, path)
+			events = []string{
+				`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Reading."}}]}`,
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"read_file","arguments":""}}]}}]}`,
+				fmt.Sprintf(`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":%q}}]}}]}`, args[:5]),
+				fmt.Sprintf(`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":%q}}]},"finish_reason":"tool_calls"}]}`, args[5:]),
+			}
+		} else {
+			body, _ := io.ReadAll(r.Body)
+			secondRequest = string(body)
+			events = []string{
+				`{"choices":[{"index":0,"delta":{"content":"It says "}}]}`,
+				`{"choices":[{"index":0,"delta":{"content":"hello."},"finish_reason":"stop"}]}`,
+				`{"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+			}
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	handler := &agenttest.TestInteractionHandler{ApproveTools: true}
+	a, err := NewAgent(RunnerConfig{
+		APIKey:             "test",
+		APIBase:            server.URL,
+		Stream:             true,
+		AutoApproveTools:   true,
+		InteractionHandler: handler,
+	}, nil)
+	require.NoError(t, err)
+
+	output, err := a.executeSkillWithTools(context.Background(), "what is in a.txt?", SkillPackage{Path: dir})
+	require.NoError(t, err)
+	assert.Equal(t, "It says hello.", output)
+	assert.Equal(t, []string{"Reading.", "It says ", "hello."}, handler.Chunks())
+	assert.Equal(t, 2, calls)
+	assert.Contains(t, secondRequest, `"tool_call_id":"call_1"`)
+	assert.Contains(t, secondRequest, `"content":"hello"`)
+	assert.Equal(t, 15, a.Usage().TotalTokens)
+}
+
+func TestStreamRedactor(t *testing.T) {
+	a := &Agent{secrets: []string{"hunter2-secret", "short"}}
+
+	r := a.newStreamRedactor()
+	var out strings.Builder
+	for _, chunk := range []string{"The password is hun", "ter2-se", "cret, and the key is sk-abcdefgh", "ijklmnopqrstuvwxyz. Done ✓"} {
+		out.WriteString(r.write(chunk))
+	}
+	out.WriteString(r.flush())
+	assert.Equal(t, "The password is [REDACTED], and the key is [REDACTED]. Done ✓", out.String())
+
+	// Without secrets, chunks pass through as they arrive
+	r = (&Agent{}).newStreamRedactor()
+	assert.Equal(t, "Hello", r.write("Hello"))
+	assert.Empty(t, r.flush())
+}
@@ -0,0 +1,165 @@
+package tool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheTTL is how long cached results are used when ResultCache.TTL is zero.
+const DefaultCacheTTL = 24 * time.Hour
+
+// ResultCache is an on-disk cache of the results of the network tools
+// (WebFetch, WebCrawl, TavilySearch, SerperSearch and DuckDuckGoSearch),
+// keyed by provider and URL or query. Errors are never cached. Install it
+// with SetResultCache or pass it in Options.
+type ResultCache struct {
+	// Dir is the cache directory, created when needed.
+	Dir string
+	// TTL is how long a result is used. Zero uses DefaultCacheTTL.
+	TTL time.Duration
+	// Bypass fetches fresh results, ignoring cached ones, but still stores
+	// the new results.
+	Bypass bool
+	// OnHit, when set, is called for every result served from the cache.
+	OnHit func(provider, key string)
+}
+
+// cacheEntry is the on-disk form of a cached result.
+type cacheEntry struct {
+	Provider string    `json:"provider"`
+	Key      string    `json:"key"`
+	Created  time.Time `json:"created"`
+	Value    string    `json:"value"`
+}
+
+var (
+	resultCacheMu sync.RWMutex
+	resultCache   *ResultCache
+)
+
+// SetResultCache installs the cache used by the network tools. Nil disables
+// caching, which is the default.
+func SetResultCache(c *ResultCache) {
+	resultCacheMu.Lock()
+	defer resultCacheMu.Unlock()
+	resultCache = c
+}
+
+// inflight shares the network round-trips of concurrent identical requests.
+var inflight singleflight.Group
+
+// cached returns the result for provider and key cached by the ResultCache of
+// the Options carried by ctx, or else by the installed one, or calls fetch and
+// caches its result. Reading and writing the cache is best effort.
+// Concurrent calls for the same provider, key and options share a single
+// fetch, which is not cancelled with the context of any one caller.
+func cached(ctx context.Context, provider, key string, fetch func(ctx context.Context) (string, error)) (string, error) {
+	opts := optionsFrom(ctx)
+	c := opts.ResultCache
+	if c == nil {
+		resultCacheMu.RLock()
+		c = resultCache
+		resultCacheMu.RUnlock()
+	}
+	shared := fmt.Sprintf("%s\x00%s\x00%p\x00%p", provider, key, opts.HTTPClient, c)
+	if c == nil || c.Dir == "" {
+		return fetchShared(ctx, shared, fetch)
+	}
+
+	path := c.path(provider, key)
+	if !c.Bypass {
+		if value, ok := c.load(path, provider, key); ok {
+			if c.OnHit != nil {
+				c.OnHit(provider, key)
+			}
+			return value, nil
+		}
+	}
+
+	return fetchShared(ctx, shared, func(ctx context.Context) (string, error) {
+		value, err := fetch(ctx)
+		if err != nil {
+			return "", err
+		}
+		c.store(path, cacheEntry{Provider: provider, Key: key, Created: time.Now(), Value: value})
+		return value, nil
+	})
+}
+
+// fetchShared calls fetch, or waits for the call already in flight for key,
+// until ctx is done. The fetch runs without the cancellation of ctx, so
+// callers that give up do not fail the others waiting for it. Results,
+// including errors, are only shared by callers waiting at the same time.
+func fetchShared(ctx context.Context, key string, fetch func(ctx context.Context) (string, error)) (string, error) {
+	fetchCtx := context.WithoutCancel(ctx)
+	ch := inflight.DoChan(key, func() (interface{}, error) {
+		return fetch(fetchCtx)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Err
+		}
+		return res.Val.(string), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// path returns the file of the entry for provider and key.
+func (c *ResultCache) path(provider, key string) string {
+	sum := sha256.Sum256([]byte(provider + "\x00" + key))
+	return filepath.Join(c.Dir, provider, hex.EncodeToString(sum[:])+".json")
+}
+
+// load reads a fresh entry from path.
+func (c *ResultCache) load(path, provider, key string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Provider != provider || entry.Key != key {
+		return "", false
+	}
+
+	ttl := c.TTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	if time.Since(entry.Created) > ttl {
+		return "", false
+	}
+	return entry.Value, true
+}
+
+// store writes entry to path, replacing the file atomically.
+func (c *ResultCache) store(path string, entry cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,163 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultCache(t *testing.T) {
+	var hits []string
+	c := &ResultCache{Dir: t.TempDir(), OnHit: func(provider, key string) { hits = append(hits, provider+" "+key) }}
+	SetResultCache(c)
+	t.Cleanup(func() { SetResultCache(nil) })
+
+	calls := 0
+	fetch := func(context.Context) (string, error) {
+		calls++
+		return "result", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		out, err := cached(context.Background(), "tavily", "golang", fetch)
+		require.NoError(t, err)
+		assert.Equal(t, "result", out)
+	}
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []string{"tavily golang"}, hits)
+
+	// Another provider has its own entries
+	_, err := cached(context.Background(), "duckduckgo", "golang", fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	c.Bypass = true
+	_, err = cached(context.Background(), "tavily", "golang", fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	c.Bypass = false
+	c.TTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	_, err = cached(context.Background(), "tavily", "golang", fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls)
+}
+
+func TestResultCacheSkipsErrors(t *testing.T) {
+	SetResultCache(&ResultCache{Dir: t.TempDir()})
+	t.Cleanup(func() { SetResultCache(nil) })
+
+	calls := 0
+	fetch := func(context.Context) (string, error) {
+		calls++
+		return "", errors.New("unavailable")
+	}
+	for i := 0; i < 2; i++ {
+		_, err := cached(context.Background(), "web_fetch", "https://example.com", fetch)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestFetchSharedConcurrent(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "page", nil
+	}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([]string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cached(context.Background(), "web_fetch", "https://example.com", fetch)
+		}(i)
+	}
+	// Let every caller join the in-flight fetch before it completes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, result := range results {
+		assert.Equal(t, "page", result)
+	}
+
+	// Errors are not remembered once the failing fetch is over
+	_, err := cached(context.Background(), "web_fetch", "https://example.com", func(context.Context) (string, error) { return "", errors.New("down") })
+	assert.Error(t, err)
+	out, err := cached(context.Background(), "web_fetch", "https://example.com", func(context.Context) (string, error) { return "up", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "up", out)
+}
+
+func TestFetchSharedCancelledCaller(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (string, error) {
+		close(started)
+		select {
+		case <-release:
+			return "page", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := cached(first, "web_fetch", "https://example.com/slow", fetch)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan string, 1)
+	go func() {
+		out, _ := cached(context.Background(), "web_fetch", "https://example.com/slow", fetch)
+		second <- out
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// The first caller gives up at once; the shared fetch goes on
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	close(release)
+	assert.Equal(t, "page", <-second)
+}
+
+func TestFetchSharedPerOptions(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "page", nil
+	}
+
+	var wg sync.WaitGroup
+	for _, opts := range []Options{{}, {HTTPClient: &http.Client{}}} {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			cached(ctx, "web_fetch", "https://example.com/proxied", fetch)
+		}(WithOptions(context.Background(), opts))
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,361 @@
+package tool
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxExpressionLength limits the size of an expression given to Calculate.
+const maxExpressionLength = 10000
+
+// calcConstants are the named constants of Calculate.
+var calcConstants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// calcFunctions are the functions of Calculate with the number of arguments
+// they take; -1 means one or more.
+var calcFunctions = map[string]struct {
+	args int
+	fn   func(args []float64) (float64, error)
+}{
+	"abs":      {1, oneArg(math.Abs)},
+	"sqrt":     {1, oneArg(math.Sqrt)},
+	"cbrt":     {1, oneArg(math.Cbrt)},
+	"exp":      {1, oneArg(math.Exp)},
+	"ln":       {1, oneArg(math.Log)},
+	"log":      {1, oneArg(math.Log)},
+	"log10":    {1, oneArg(math.Log10)},
+	"log2":     {1, oneArg(math.Log2)},
+	"floor":    {1, oneArg(math.Floor)},
+	"ceil":     {1, oneArg(math.Ceil)},
+	"round":    {1, oneArg(math.Round)},
+	"trunc":    {1, oneArg(math.Trunc)},
+	"sin":      {1, oneArg(math.Sin)},
+	"cos":      {1, oneArg(math.Cos)},
+	"tan":      {1, oneArg(math.Tan)},
+	"asin":     {1, oneArg(math.Asin)},
+	"acos":     {1, oneArg(math.Acos)},
+	"atan":     {1, oneArg(math.Atan)},
+	"pow":      {2, func(args []float64) (float64, error) { return math.Pow(args[0], args[1]), nil }},
+	"min":      {-1, func(args []float64) (float64, error) { return slices.Min(args), nil }},
+	"max":      {-1, func(args []float64) (float64, error) { return slices.Max(args), nil }},
+	"sum":      {-1, func(args []float64) (float64, error) { return calcSum(args), nil }},
+	"mean":     {-1, func(args []float64) (float64, error) { return calcSum(args) / float64(len(args)), nil }},
+	"avg":      {-1, func(args []float64) (float64, error) { return calcSum(args) / float64(len(args)), nil }},
+	"median":   {-1, func(args []float64) (float64, error) { return calcMedian(args), nil }},
+	"variance": {-1, calcVariance},
+	"stddev": {-1, func(args []float64) (float64, error) {
+		v, err := calcVariance(args)
+		return math.Sqrt(v), err
+	}},
+}
+
+// Calculate evaluates an arithmetic expression. It supports numbers, the
+// operators + - * / % and ^ (or **) for powers, parentheses, the constants
+// pi and e, and the functions abs, sqrt, cbrt, exp, ln (or log), log10,
+// log2, floor, ceil, round, trunc, sin, cos, tan, asin, acos, atan and pow,
+// as well as min, max, sum, mean (or avg), median, variance and stddev of
+// any number of arguments. variance and stddev are those of a sample.
+// Nothing but arithmetic is evaluated.
+func Calculate(expr string) (float64, error) {
+	if len(expr) > maxExpressionLength {
+		return 0, fmt.Errorf("expression is longer than %d bytes", maxExpressionLength)
+	}
+	tokens, err := calcTokens(expr)
+	if err != nil {
+		return 0, err
+	}
+	p := &calcParser{tokens: tokens}
+	value, err := p.expression()
+	if err != nil {
+		return 0, err
+	}
+	if p.pos < len(p.tokens) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].pos+1)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("the result is not a finite number")
+	}
+	return value, nil
+}
+
+// FormatNumber formats a result of Calculate with up to 15 significant
+// digits, which hides binary rounding errors such as 0.1+0.2 giving
+// 0.30000000000000004, and without an exponent for whole numbers.
+func FormatNumber(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 15, 64)
+}
+
+type calcToken struct {
+	text string
+	pos  int
+}
+
+// calcTokens splits expr into numbers, names and operators.
+func calcTokens(expr string) ([]calcToken, error) {
+	var tokens []calcToken
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			start := i
+			for i < len(expr) && (expr[i] >= '0' && expr[i] <= '9' || expr[i] == '.' || expr[i] == '_') {
+				i++
+			}
+			if i < len(expr) && (expr[i] == 'e' || expr[i] == 'E') {
+				j := i + 1
+				if j < len(expr) && (expr[j] == '+' || expr[j] == '-') {
+					j++
+				}
+				if j < len(expr) && expr[j] >= '0' && expr[j] <= '9' {
+					for i = j; i < len(expr) && expr[i] >= '0' && expr[i] <= '9'; i++ {
+					}
+				}
+			}
+			tokens = append(tokens, calcToken{expr[start:i], start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(expr) && (unicode.IsLetter(rune(expr[i])) || unicode.IsDigit(rune(expr[i])) || expr[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, calcToken{strings.ToLower(expr[start:i]), start})
+		case strings.HasPrefix(expr[i:], "**"):
+			tokens = append(tokens, calcToken{"^", i})
+			i += 2
+		case strings.ContainsRune("+-*/%^(),", c):
+			tokens = append(tokens, calcToken{string(c), i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", expr[i], i+1)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return tokens, nil
+}
+
+// calcParser evaluates tokens by recursive descent.
+type calcParser struct {
+	tokens []calcToken
+	pos    int
+}
+
+// accept consumes the next token if it is text.
+func (p *calcParser) accept(text string) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expression = term {("+" | "-") term}
+func (p *calcParser) expression() (float64, error) {
+	left, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.accept("+"):
+			right, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+			left += right
+		case p.accept("-"):
+			right, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+			left -= right
+		default:
+			return left, nil
+		}
+	}
+}
+
+// term = unary {("*" | "/" | "%") unary}
+func (p *calcParser) term() (float64, error) {
+	left, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		var op string
+		switch {
+		case p.accept("*"):
+			op = "*"
+		case p.accept("/"):
+			op = "/"
+		case p.accept("%"):
+			op = "%"
+		default:
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "*":
+			left *= right
+		case "/":
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left /= right
+		case "%":
+			if right == 0 {
+				return 0, fmt.Errorf("modulo by zero")
+			}
+			left = math.Mod(left, right)
+		}
+	}
+}
+
+// unary = ("-" | "+") unary | power
+func (p *calcParser) unary() (float64, error) {
+	if p.accept("-") {
+		v, err := p.unary()
+		return -v, err
+	}
+	if p.accept("+") {
+		return p.unary()
+	}
+	return p.power()
+}
+
+// power = primary ["^" unary], so that 2^3^2 is 2^9 and -2^2 is -4.
+func (p *calcParser) power() (float64, error) {
+	base, err := p.primary()
+	if err != nil {
+		return 0, err
+	}
+	if !p.accept("^") {
+		return base, nil
+	}
+	exponent, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exponent), nil
+}
+
+// primary = number | constant | function "(" expression {"," expression} ")" | "(" expression ")"
+func (p *calcParser) primary() (float64, error) {
+	if p.pos == len(p.tokens) {
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch {
+	case tok.text == "(":
+		v, err := p.expression()
+		if err != nil {
+			return 0, err
+		}
+		if !p.accept(")") {
+			return 0, fmt.Errorf("missing closing parenthesis for position %d", tok.pos+1)
+		}
+		return v, nil
+	case tok.text[0] >= '0' && tok.text[0] <= '9' || tok.text[0] == '.':
+		v, err := strconv.ParseFloat(strings.ReplaceAll(tok.text, "_", ""), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos+1)
+		}
+		return v, nil
+	case unicode.IsLetter(rune(tok.text[0])) || tok.text[0] == '_':
+		if f, ok := calcFunctions[tok.text]; ok {
+			args, err := p.arguments(tok)
+			if err != nil {
+				return 0, err
+			}
+			if f.args >= 0 && len(args) != f.args || len(args) == 0 {
+				want := "at least 1 argument"
+				if f.args >= 0 {
+					want = fmt.Sprintf("%d argument(s)", f.args)
+				}
+				return 0, fmt.Errorf("%s takes %s, got %d", tok.text, want, len(args))
+			}
+			return f.fn(args)
+		}
+		if v, ok := calcConstants[tok.text]; ok {
+			return v, nil
+		}
+		return 0, fmt.Errorf("unknown name %q at position %d", tok.text, tok.pos+1)
+	}
+	return 0, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+}
+
+// arguments parses the parenthesized arguments of the function fn.
+func (p *calcParser) arguments(fn calcToken) ([]float64, error) {
+	if !p.accept("(") {
+		return nil, fmt.Errorf("function %s at position %d needs arguments in parentheses", fn.text, fn.pos+1)
+	}
+	var args []float64
+	if p.accept(")") {
+		return args, nil
+	}
+	for {
+		v, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+		if p.accept(")") {
+			return args, nil
+		}
+		if !p.accept(",") {
+			return nil, fmt.Errorf("missing closing parenthesis for %s at position %d", fn.text, fn.pos+1)
+		}
+	}
+}
+
+func oneArg(fn func(float64) float64) func([]float64) (float64, error) {
+	return func(args []float64) (float64, error) { return fn(args[0]), nil }
+}
+
+func calcSum(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func calcMedian(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+// calcVariance returns the sample variance of values.
+func calcVariance(values []float64) (float64, error) {
+	if len(values) < 2 {
+		return 0, fmt.Errorf("variance and stddev need at least 2 values")
+	}
+	mean := calcSum(values) / float64(len(values))
+	total := 0.0
+	for _, v := range values {
+		total += (v - mean) * (v - mean)
+	}
+	return total / float64(len(values)-1), nil
+}
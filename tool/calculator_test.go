@@ -0,0 +1,54 @@
+package tool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculate(t *testing.T) {
+	for expr, want := range map[string]string{
+		"1 + 2 * 3":                      "7",
+		"(1 + 2) * 3":                    "9",
+		"2^3^2":                          "512",
+		"-2**2":                          "-4",
+		"10 % 4 - -1":                    "3",
+		"0.1 + 0.2":                      "0.3",
+		"1_000_000 * 1.5e3":              "1500000000",
+		"sqrt(16) + abs(-2) + round(PI)": "9",
+		"mean(12.5, 14, 9) * 1.2":        "14.2",
+		"median(5, 1, 3, 2)":             "2.5",
+		"sum(1, 2, 3) / max(1, 3)":       "2",
+		"stddev(2, 4, 4, 4, 5, 5, 7, 9)": "2.1380899352994",
+		"pow(2, 0.5) * pow(2, 0.5)":      "2",
+		"1e300 * 10":                     "1e+301",
+	} {
+		v, err := Calculate(expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, want, FormatNumber(v), expr)
+	}
+}
+
+func TestCalculate_Errors(t *testing.T) {
+	for expr, want := range map[string]string{
+		"":              "empty expression",
+		"1 / (2 - 2)":   "division by zero",
+		"2 +":           "unexpected end of expression",
+		"(1 + 2":        "missing closing parenthesis",
+		"1 2":           `unexpected "2" at position 3`,
+		"os.exit(1)":    `unknown name "os"`,
+		"foo(1)":        `unknown name "foo"`,
+		"sqrt":          "needs arguments in parentheses",
+		"pow(2)":        "pow takes 2 argument(s), got 1",
+		"max()":         "max takes at least 1 argument, got 0",
+		"variance(3)":   "at least 2 values",
+		"sqrt(-1)":      "not a finite number",
+		"1e308 * 10":    "not a finite number",
+		"1..2 + 3":      `invalid number "1..2"`,
+		"print(\"hi\")": `unexpected character '"'`,
+	} {
+		_, err := Calculate(expr)
+		assert.ErrorContains(t, err, want, expr)
+	}
+}
@@ -1,6 +1,11 @@
 package tool
 
 import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
 	openai "github.com/sashabaranov/go-openai"
 )
 
@@ -23,6 +28,10 @@ func GetBaseTools() []openai.Tool {
 							"type":        "object",
 							"description": "A map of key-value pairs to pass to the code.",
 						},
+						"stdin": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional data written to the standard input of the code, e.g. CSV content to process.",
+						},
 					},
 					"required": []string{"code"},
 				},
@@ -68,6 +77,35 @@ func GetBaseTools() []openai.Tool {
 							"type":        "object",
 							"description": "A map of key-value pairs to pass to the code.",
 						},
+						"stdin": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional data written to the standard input of the code, e.g. CSV content to process.",
+						},
+					},
+					"required": []string{"code"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "run_python_json",
+				Description: "Executes a Python code snippet that prints a single JSON value on stdout, and returns the validated, indented JSON. Fails if stdout is not valid JSON; use print(json.dumps(...)) and write diagnostics to stderr.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"code": map[string]interface{}{
+							"type":        "string",
+							"description": "The Python code snippet to execute.",
+						},
+						"args": map[string]interface{}{
+							"type":        "object",
+							"description": "A map of key-value pairs to pass to the code.",
+						},
+						"stdin": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional data written to the standard input of the code, e.g. CSV content to process.",
+						},
 					},
 					"required": []string{"code"},
 				},
@@ -101,7 +139,7 @@ func GetBaseTools() []openai.Tool {
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
 				Name:        "read_file",
-				Description: "Reads the content of a file and returns it as a string.",
+				Description: "Reads the content of a file and returns it as a string. DOCX, XLSX and PDF files are converted to text.",
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -109,6 +147,19 @@ func GetBaseTools() []openai.Tool {
 							"type":        "string",
 							"description": "The path to the file to read.",
 						},
+						"offset": map[string]interface{}{
+							"type":        "integer",
+							"description": "Optional number of lines (or bytes, see unit) to skip, to read a large file in parts.",
+						},
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": "Optional maximum number of lines (or bytes) to return. The result starts with the window's position and the file's total line count.",
+						},
+						"unit": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"lines", "bytes"},
+							"description": "Whether offset and limit count lines (default) or bytes.",
+						},
 					},
 					"required": []string{"filePath"},
 				},
@@ -135,6 +186,23 @@ func GetBaseTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "delete_file",
+				Description: "Deletes a file, such as an intermediate file that is no longer needed. Only files inside the working directory or the skill directory can be deleted; directories cannot.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"filePath": map[string]interface{}{
+							"type":        "string",
+							"description": "The path to the file to delete.",
+						},
+					},
+					"required": []string{"filePath"},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
@@ -147,6 +215,20 @@ func GetBaseTools() []openai.Tool {
 							"type":        "string",
 							"description": "The search query.",
 						},
+						"includeDomains": map[string]interface{}{
+							"type":        "array",
+							"description": "Only return results from these domains, e.g. [\"who.int\"] or [\".gov\", \".edu\"].",
+							"items": map[string]interface{}{
+								"type": "string",
+							},
+						},
+						"excludeDomains": map[string]interface{}{
+							"type":        "array",
+							"description": "Never return results from these domains.",
+							"items": map[string]interface{}{
+								"type": "string",
+							},
+						},
 					},
 					"required": []string{"query"},
 				},
@@ -156,7 +238,7 @@ func GetBaseTools() []openai.Tool {
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
 				Name:        "wikipedia_search",
-				Description: "Performs a search on Wikipedia for the given query and returns a summary of the relevant entry.",
+				Description: "Performs a search on Wikipedia for the given query and returns a summary of the relevant entry with its URL. Optionally returns the full article in sections, or a single section.",
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -164,6 +246,18 @@ func GetBaseTools() []openai.Tool {
 							"type":        "string",
 							"description": "The search query for Wikipedia.",
 						},
+						"language": map[string]interface{}{
+							"type":        "string",
+							"description": "The Wikipedia language code, e.g. \"de\" or \"zh\". Defaults to \"en\".",
+						},
+						"fullArticle": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Return the full article split into sections instead of the summary.",
+						},
+						"section": map[string]interface{}{
+							"type":        "string",
+							"description": "Return only the section with this title.",
+						},
 					},
 					"required": []string{"query"},
 				},
@@ -181,11 +275,182 @@ func GetBaseTools() []openai.Tool {
 							"type":        "string",
 							"description": "The search query.",
 						},
+						"includeDomains": map[string]interface{}{
+							"type":        "array",
+							"description": "Only return results from these domains, e.g. [\"who.int\"] or [\".gov\", \".edu\"].",
+							"items": map[string]interface{}{
+								"type": "string",
+							},
+						},
+						"excludeDomains": map[string]interface{}{
+							"type":        "array",
+							"description": "Never return results from these domains.",
+							"items": map[string]interface{}{
+								"type": "string",
+							},
+						},
 					},
 					"required": []string{"query"},
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "http_request",
+				Description: "Performs an HTTP request (GET, POST, PUT or DELETE) with optional headers and body, and returns the status code and response body. Use this to call REST APIs.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"method": map[string]interface{}{
+							"type":        "string",
+							"description": "The HTTP method to use.",
+							"enum":        []string{"GET", "POST", "PUT", "DELETE"},
+						},
+						"url": map[string]interface{}{
+							"type":        "string",
+							"description": "The full URL to request, including the protocol (e.g., 'https://api.example.com/items').",
+						},
+						"headers": map[string]interface{}{
+							"type":        "object",
+							"description": "A map of HTTP header names to values.",
+							"additionalProperties": map[string]interface{}{
+								"type": "string",
+							},
+						},
+						"body": map[string]interface{}{
+							"type":        "string",
+							"description": "The raw request body, e.g. a JSON document.",
+						},
+					},
+					"required": []string{"method", "url"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "web_crawl",
+				Description: "Fetches a web page and the pages on the same site it links to, breadth first, and returns their readable text with a separator line per page. Respects robots.txt. Use it to research a site instead of fetching its pages one by one.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"url": map[string]interface{}{
+							"type":        "string",
+							"description": "The full URL of the start page, including the protocol (e.g., 'https://example.com/docs').",
+						},
+						"depth": map[string]interface{}{
+							"type":        "integer",
+							"description": "How many links away from the start page to go, 1 to 3. Defaults to 1.",
+						},
+						"breadth": map[string]interface{}{
+							"type":        "integer",
+							"description": "How many links of each page to follow. Defaults to 5.",
+						},
+						"maxPages": map[string]interface{}{
+							"type":        "integer",
+							"description": "The most pages to fetch, including the start page. Defaults to and is capped at 20.",
+						},
+					},
+					"required": []string{"url"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "extract_structured",
+				Description: "Extracts structured data from text, such as a table of entities, and returns it as JSON validated against the given JSON schema.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type":        "object",
+							"description": "The JSON schema the extracted data must match.",
+						},
+						"text": map[string]interface{}{
+							"type":        "string",
+							"description": "The text to extract the data from.",
+						},
+						"instructions": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional guidance on what to extract.",
+						},
+					},
+					"required": []string{"schema", "text"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name: "calculate",
+				Description: "Evaluates an arithmetic or statistical expression exactly and returns the number. " +
+					"Supports + - * / % ^, parentheses, pi, e, sqrt, abs, ln, log10, exp, round, floor, ceil, trigonometry, pow, " +
+					"and min, max, sum, mean, median, variance and stddev of several values, e.g. 'mean(12.5, 14, 9) * 1.2'. " +
+					"Use it instead of doing arithmetic yourself.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"expression": map[string]interface{}{
+							"type":        "string",
+							"description": "The expression to evaluate.",
+						},
+					},
+					"required": []string{"expression"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "current_time",
+				Description: "Returns the current date, time and weekday. Use it whenever the task depends on today's date or the time of day.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"timezone": map[string]interface{}{
+							"type":        "string",
+							"description": "An IANA timezone such as 'Asia/Tokyo' or 'UTC'. Defaults to the local timezone.",
+						},
+					},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "date_math",
+				Description: "Adds a duration to a date, or computes the time between two dates.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"operation": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"add", "diff"},
+							"description": "'add' adds duration to date; 'diff' returns the time from date to to.",
+						},
+						"date": map[string]interface{}{
+							"type":        "string",
+							"description": "The date, e.g. '2025-03-01', '2025-03-01 14:30', an RFC 3339 timestamp or 'now'.",
+						},
+						"duration": map[string]interface{}{
+							"type":        "string",
+							"description": "For 'add': amounts with units y, mo, w, d, h, m or s, e.g. '1y2mo', '-3d' or '2w4h30m'.",
+						},
+						"to": map[string]interface{}{
+							"type":        "string",
+							"description": "For 'diff': the second date, in the same formats as date.",
+						},
+						"timezone": map[string]interface{}{
+							"type":        "string",
+							"description": "The IANA timezone of dates without an offset. Defaults to the local timezone.",
+						},
+					},
+					"required": []string{"operation", "date"},
+				},
+			},
+		},
 		// {
 		// 	Type: openai.ToolTypeFunction,
 		// 	Function: &openai.FunctionDefinition{
@@ -205,3 +470,81 @@ func GetBaseTools() []openai.Tool {
 		// },
 	}
 }
+
+// RiskLevel describes what a tool call can do to the system it runs on.
+type RiskLevel string
+
+const (
+	// RiskSafe tools only read data.
+	RiskSafe RiskLevel = "safe"
+	// RiskDestructive tools may modify files, run code or change remote state.
+	RiskDestructive RiskLevel = "destructive"
+)
+
+// baseToolRisks is the risk level of each base tool.
+var baseToolRisks = map[string]RiskLevel{
+	"run_shell_code":     RiskDestructive,
+	"run_shell_script":   RiskDestructive,
+	"run_python_code":    RiskDestructive,
+	"run_python_json":    RiskDestructive,
+	"run_python_script":  RiskDestructive,
+	"read_file":          RiskSafe,
+	"write_file":         RiskDestructive,
+	"delete_file":        RiskDestructive,
+	"git_clone":          RiskDestructive,
+	"git_status":         RiskSafe,
+	"git_diff":           RiskSafe,
+	"git_commit":         RiskDestructive,
+	"duckduckgo_search":  RiskSafe,
+	"wikipedia_search":   RiskSafe,
+	"tavily_search":      RiskSafe,
+	"web_fetch":          RiskSafe,
+	"web_crawl":          RiskSafe,
+	"http_request":       RiskDestructive,
+	"extract_structured": RiskSafe,
+	"calculate":          RiskSafe,
+	"current_time":       RiskSafe,
+	"date_math":          RiskSafe,
+	// retrieve_docs is offered by skills with documents, see goskills.GenerateToolDefinitions.
+	"retrieve_docs": RiskSafe,
+}
+
+// ToolRisk returns the risk level of a call to the named tool with the given
+// JSON arguments. An http_request is safe only for GET requests. Unknown
+// tools, such as sql_query, skill scripts and MCP tools, are treated as
+// destructive.
+func ToolRisk(name, arguments string) RiskLevel {
+	if name == "http_request" {
+		var params struct {
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal([]byte(arguments), &params); err == nil {
+			if method := strings.ToUpper(strings.TrimSpace(params.Method)); method == "" || method == http.MethodGet {
+				return RiskSafe
+			}
+		}
+		return RiskDestructive
+	}
+	if risk, ok := baseToolRisks[name]; ok {
+		return risk
+	}
+	return RiskDestructive
+}
+
+// codeTools are the names of the base tools that run a script or generated
+// code, the run_* tools of GetBaseTools.
+var codeTools = sync.OnceValue(func() map[string]bool {
+	names := make(map[string]bool)
+	for _, t := range GetBaseTools() {
+		if t.Function != nil && strings.HasPrefix(t.Function.Name, "run_") {
+			names[t.Function.Name] = true
+		}
+	}
+	return names
+})
+
+// RunsCode reports whether the named base tool runs a script or generated
+// code, such as run_shell_code or run_python_json.
+func RunsCode(name string) bool {
+	return codeTools()[name]
+}
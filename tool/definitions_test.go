@@ -0,0 +1,25 @@
+package tool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolRisk(t *testing.T) {
+	assert.Equal(t, RiskSafe, ToolRisk("read_file", `{"filePath":"a.txt"}`))
+	assert.Equal(t, RiskDestructive, ToolRisk("write_file", `{"filePath":"a.txt","content":"x"}`))
+	assert.Equal(t, RiskDestructive, ToolRisk("run_python_code", `{"code":"print(1)"}`))
+	assert.Equal(t, RiskSafe, ToolRisk("http_request", `{"method":"get","url":"https://example.com"}`))
+	assert.Equal(t, RiskDestructive, ToolRisk("http_request", `{"method":"DELETE","url":"https://example.com"}`))
+	assert.Equal(t, RiskDestructive, ToolRisk("run_scripts_build_sh", ""))
+	assert.Equal(t, RiskDestructive, ToolRisk("github__create_issue", "{}"))
+}
+
+func TestRunsCode(t *testing.T) {
+	for _, name := range []string{"run_shell_code", "run_shell_script", "run_python_code", "run_python_json", "run_python_script"} {
+		assert.True(t, RunsCode(name), name)
+	}
+	assert.False(t, RunsCode("write_file"))
+	assert.False(t, RunsCode("run_scripts_build_sh"), "skill scripts are not base tools")
+}
@@ -0,0 +1,602 @@
+package tool
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Document formats that ReadFile converts to text.
+const (
+	formatText  = "text"
+	formatDOCX  = "docx"
+	formatXLSX  = "xlsx"
+	formatPDF   = "pdf"
+	formatUTF16 = "utf-16"
+	formatBin   = "binary"
+)
+
+// binarySniffLen is how much of a file is checked for NUL bytes to tell
+// binary files from text.
+const binarySniffLen = 8000
+
+// maxZipPartSize limits the uncompressed size of a part of a DOCX or XLSX
+// file, so that a small, highly compressed file cannot exhaust memory.
+const maxZipPartSize = 64 << 20 // 64 MiB
+
+// detectFormat returns the format of a file from its extension and its first
+// bytes. Office files are zip archives recognized by their main part.
+func detectFormat(filePath string, content []byte) string {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	switch {
+	case ext == ".pdf" || bytes.HasPrefix(content, []byte("%PDF-")):
+		return formatPDF
+	case ext == ".docx" || ext == ".xlsx" || bytes.HasPrefix(content, []byte("PK\x03\x04")):
+		r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return formatBin
+		}
+		for _, f := range r.File {
+			switch f.Name {
+			case "word/document.xml":
+				return formatDOCX
+			case "xl/workbook.xml":
+				return formatXLSX
+			}
+		}
+		return formatBin
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}) || bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		// UTF-16 text, e.g. saved by Windows tools, is full of NUL bytes
+		return formatUTF16
+	}
+	if bytes.IndexByte(content[:min(len(content), binarySniffLen)], 0) >= 0 {
+		return formatBin
+	}
+	return formatText
+}
+
+// documentText converts a DOCX, XLSX, PDF or UTF-16 file to text.
+func documentText(format string, content []byte) (string, error) {
+	switch format {
+	case formatUTF16:
+		return utf16Text(content), nil
+	case formatDOCX:
+		return docxText(content)
+	case formatXLSX:
+		return xlsxText(content)
+	case formatPDF:
+		return pdfText(content), nil
+	}
+	return "", fmt.Errorf("unsupported document format %q", format)
+}
+
+// utf16Text decodes UTF-16 text that starts with a byte order mark.
+func utf16Text(content []byte) string {
+	bigEndian := content[0] == 0xFE
+	units := make([]uint16, 0, len(content)/2)
+	for i := 2; i+1 < len(content); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(content[i])<<8|uint16(content[i+1]))
+		} else {
+			units = append(units, uint16(content[i+1])<<8|uint16(content[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// zipPart returns the content of the named file of a zip archive, up to
+// maxZipPartSize bytes.
+func zipPart(r *zip.Reader, name string) ([]byte, error) {
+	f, err := r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxZipPartSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxZipPartSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxZipPartSize)
+	}
+	return data, nil
+}
+
+// docxText returns the paragraphs of a Word document, one per line.
+func docxText(content []byte) (string, error) {
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", err
+	}
+	data, err := zipPart(r, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	inText := false
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid document.xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteString("\t")
+			case "br", "cr":
+				sb.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteString("\n")
+			case "tc":
+				sb.WriteString("\t")
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// xlsxText returns each sheet of a workbook as a "Sheet: <name>" heading
+// followed by its rows as CSV.
+func xlsxText(content []byte) (string, error) {
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", err
+	}
+
+	var shared []string
+	if data, err := zipPart(r, "xl/sharedStrings.xml"); err == nil {
+		var sst struct {
+			Items []xlsxRichText `xml:"si"`
+		}
+		if err := xml.Unmarshal(data, &sst); err != nil {
+			return "", fmt.Errorf("invalid sharedStrings.xml: %w", err)
+		}
+		for _, item := range sst.Items {
+			shared = append(shared, item.String())
+		}
+	}
+
+	data, err := zipPart(r, "xl/workbook.xml")
+	if err != nil {
+		return "", err
+	}
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(data, &workbook); err != nil {
+		return "", fmt.Errorf("invalid workbook.xml: %w", err)
+	}
+
+	targets := make(map[string]string)
+	if data, err := zipPart(r, "xl/_rels/workbook.xml.rels"); err == nil {
+		var rels struct {
+			Relationships []struct {
+				ID     string `xml:"Id,attr"`
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}
+		if err := xml.Unmarshal(data, &rels); err != nil {
+			return "", fmt.Errorf("invalid workbook.xml.rels: %w", err)
+		}
+		for _, rel := range rels.Relationships {
+			target := strings.TrimPrefix(rel.Target, "/")
+			if !strings.HasPrefix(target, "xl/") {
+				target = path.Join("xl", target)
+			}
+			targets[rel.ID] = target
+		}
+	}
+
+	var sb strings.Builder
+	for i, sheet := range workbook.Sheets {
+		target, ok := targets[sheet.ID]
+		if !ok {
+			target = fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		}
+		data, err := zipPart(r, target)
+		if err != nil {
+			return "", fmt.Errorf("sheet %q: %w", sheet.Name, err)
+		}
+		rows, err := xlsxRows(data, shared)
+		if err != nil {
+			return "", fmt.Errorf("sheet %q: %w", sheet.Name, err)
+		}
+
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "Sheet: %s\n", sheet.Name)
+		w := csv.NewWriter(&sb)
+		if err := w.WriteAll(rows); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// xlsxRichText is a string that may be split into formatted runs.
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) String() string {
+	s := t.Text
+	for _, run := range t.Runs {
+		s += run.Text
+	}
+	return s
+}
+
+// xlsxRows returns the cell values of a worksheet, placing each cell in the
+// column given by its reference so that empty cells keep their position.
+func xlsxRows(data []byte, shared []string) ([][]string, error) {
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string       `xml:"r,attr"`
+				Type   string       `xml:"t,attr"`
+				Value  string       `xml:"v"`
+				Inline xlsxRichText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(data, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		var values []string
+		for _, cell := range row.Cells {
+			col := len(values)
+			if c, ok := xlsxColumn(cell.Ref); ok && c >= col {
+				col = c
+			}
+			for len(values) < col {
+				values = append(values, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				if i, err := strconv.Atoi(value); err == nil && i >= 0 && i < len(shared) {
+					value = shared[i]
+				}
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = strconv.FormatBool(value == "1")
+			}
+			values = append(values, value)
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// xlsxColumn returns the zero-based column of a cell reference such as "C7".
+func xlsxColumn(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	return col - 1, n > 0
+}
+
+// pdfText extracts the text shown by the content streams of a PDF file. It
+// handles uncompressed and Flate-compressed streams with simple font
+// encodings, which covers most generated documents but not scanned pages or
+// fonts with custom encodings.
+func pdfText(content []byte) string {
+	var sb strings.Builder
+	for pos := 0; ; {
+		i := bytes.Index(content[pos:], []byte("stream"))
+		if i < 0 {
+			break
+		}
+		start := pos + i
+		pos = start + len("stream")
+		if start >= 3 && string(content[start-3:start]) == "end" {
+			continue
+		}
+
+		// The stream data starts after the end of line following the keyword.
+		dataStart := pos
+		if dataStart < len(content) && content[dataStart] == '\r' {
+			dataStart++
+		}
+		if dataStart < len(content) && content[dataStart] == '\n' {
+			dataStart++
+		}
+		end := bytes.Index(content[dataStart:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		data := content[dataStart : dataStart+end]
+		pos = dataStart + end + len("endstream")
+
+		dict := content[:start]
+		if obj := bytes.LastIndex(dict, []byte(" obj")); obj >= 0 {
+			dict = dict[obj:]
+		}
+		if text := pdfStreamText(dict, data); text != "" {
+			if sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(text)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// pdfStreamText returns the text of a content stream, given its dictionary.
+// Images, fonts and streams with other filters are skipped.
+func pdfStreamText(dict, data []byte) string {
+	for _, skip := range []string{"/Image", "/FontFile", "/Length1", "/ObjStm", "/XRef", "/DCTDecode", "/JPXDecode", "/CCITTFaxDecode", "/JBIG2Decode", "/ASCII85Decode", "/LZWDecode"} {
+		if bytes.Contains(dict, []byte(skip)) {
+			return ""
+		}
+	}
+	if bytes.Contains(dict, []byte("/FlateDecode")) {
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return ""
+		}
+		// Keep what was decompressed from a truncated stream.
+		data, _ = io.ReadAll(zr)
+	}
+	return pdfContentText(data)
+}
+
+// pdfContentText interprets the text operators of a content stream.
+func pdfContentText(data []byte) string {
+	var sb strings.Builder
+	newline := func() {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+	}
+
+	var texts []string
+	var numbers []float64
+	inArray := false
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '(':
+			s, n := pdfLiteralString(data[i:])
+			texts = append(texts, pdfDecodeString(s))
+			i += n
+		case c == '<' && i+1 < len(data) && data[i+1] == '<', c == '>' && i+1 < len(data) && data[i+1] == '>':
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(data[i:], '>')
+			if end < 0 {
+				return strings.TrimSpace(sb.String())
+			}
+			texts = append(texts, pdfDecodeString(pdfHexString(data[i+1:i+end])))
+			i += end + 1
+		case c == '[':
+			inArray = true
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		case pdfWhitespace(c) || c == '{' || c == '}':
+			i++
+		default:
+			j := i + 1
+			for j < len(data) && !pdfWhitespace(data[j]) && !bytes.ContainsRune([]byte("()<>[]{}/%"), rune(data[j])) {
+				j++
+			}
+			token := string(data[i:j])
+			i = j
+
+			if n, err := strconv.ParseFloat(token, 64); err == nil {
+				numbers = append(numbers, n)
+				// Large negative kerning in a TJ array separates words.
+				if inArray && n < -200 {
+					texts = append(texts, " ")
+				}
+				continue
+			}
+			if strings.HasPrefix(token, "/") {
+				continue
+			}
+
+			switch token {
+			case "Tj", "TJ":
+				sb.WriteString(strings.Join(texts, ""))
+			case "'", "\"":
+				newline()
+				sb.WriteString(strings.Join(texts, ""))
+			case "T*", "ET", "Tm":
+				newline()
+			case "Td", "TD":
+				if len(numbers) >= 2 && numbers[len(numbers)-1] != 0 {
+					newline()
+				} else if len(numbers) >= 2 && numbers[len(numbers)-2] != 0 && !strings.HasSuffix(sb.String(), "\n") {
+					sb.WriteString(" ")
+				}
+			case "ID":
+				// Skip the binary data of an inline image.
+				end := bytes.Index(data[i:], []byte("EI"))
+				if end < 0 {
+					return strings.TrimSpace(sb.String())
+				}
+				i += end + 2
+			}
+			texts, numbers = texts[:0], numbers[:0]
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// pdfWhitespace reports whether c is a PDF whitespace character.
+func pdfWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+// pdfLiteralString decodes the literal string at the start of data, which
+// begins with "(", and returns it with the number of bytes consumed.
+func pdfLiteralString(data []byte) ([]byte, int) {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			if depth > 0 {
+				out = append(out, c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out, i + 1
+			}
+			out = append(out, c)
+		case '\\':
+			i++
+			if i >= len(data) {
+				return out, i
+			}
+			switch e := data[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				// A line continuation.
+				if i+1 < len(data) && data[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					n := 0
+					for n < 3 && i < len(data) && data[i] >= '0' && data[i] <= '7' {
+						v = v*8 + int(data[i]-'0')
+						i++
+						n++
+					}
+					i--
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return out, len(data)
+}
+
+// pdfHexString decodes the digits of a hexadecimal string; an odd final
+// digit is followed by 0.
+func pdfHexString(digits []byte) []byte {
+	var clean []byte
+	for _, c := range digits {
+		if !pdfWhitespace(c) {
+			clean = append(clean, c)
+		}
+	}
+	if len(clean)%2 == 1 {
+		clean = append(clean, '0')
+	}
+	out := make([]byte, 0, len(clean)/2)
+	for i := 0; i+1 < len(clean); i += 2 {
+		v, err := strconv.ParseUint(string(clean[i:i+2]), 16, 8)
+		if err != nil {
+			return nil
+		}
+		out = append(out, byte(v))
+	}
+	return out
+}
+
+// pdfDecodeString converts a PDF string to text: UTF-16 with a byte order
+// mark, otherwise Latin-1. Strings that are mostly control characters, such
+// as glyph indices of fonts with custom encodings, are dropped.
+func pdfDecodeString(s []byte) string {
+	var runes []rune
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		units := make([]uint16, 0, len(s)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		runes = utf16.Decode(units)
+	} else {
+		for _, b := range s {
+			runes = append(runes, rune(b))
+		}
+	}
+
+	var sb strings.Builder
+	dropped := 0
+	for _, r := range runes {
+		if r < 0x20 && r != '\t' && r != '\n' || r >= 0x7F && r < 0xA0 {
+			dropped++
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	if dropped*2 > len(runes) {
+		return ""
+	}
+	return sb.String()
+}
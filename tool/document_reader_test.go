@@ -0,0 +1,145 @@
+package tool
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeZip writes a zip archive with the given files to dir/name.
+func writeZip(t *testing.T, dir, name string, files map[string]string) string {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for fileName, content := range files {
+		w, err := zw.Create(fileName)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	filePath := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(filePath, buf.Bytes(), 0o644))
+	return filePath
+}
+
+func TestReadFile_Text(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte("héllo\nworld"), 0o644))
+
+	out, err := ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "héllo\nworld", out)
+}
+
+func TestReadFile_Binary(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "image.png")
+	require.NoError(t, os.WriteFile(filePath, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), 0o644))
+
+	out, err := ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("Binary file '%s', 16 bytes, cannot display.", filePath), out)
+}
+
+func TestReadFile_UTF16(t *testing.T) {
+	dir := t.TempDir()
+	little := filepath.Join(dir, "little.txt")
+	require.NoError(t, os.WriteFile(little, []byte{0xFF, 0xFE, 'h', 0, 0xE9, 0, '\n', 0, 0x16, 0x4E}, 0o644))
+	big := filepath.Join(dir, "big.csv")
+	require.NoError(t, os.WriteFile(big, []byte{0xFE, 0xFF, 0, 'a', 0, ',', 0, 'b'}, 0o644))
+
+	out, err := ReadFile(little)
+	require.NoError(t, err)
+	assert.Equal(t, "hé\n世", out)
+	out, err = ReadFile(big)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b", out)
+}
+
+func TestReadFile_ZipPartLimit(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "word/document.xml", Method: zip.Deflate})
+	require.NoError(t, err)
+	_, err = w.Write(make([]byte, maxZipPartSize+1))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	filePath := filepath.Join(t.TempDir(), "bomb.docx")
+	require.NoError(t, os.WriteFile(filePath, buf.Bytes(), 0o644))
+
+	_, err = ReadFile(filePath)
+	assert.ErrorContains(t, err, "word/document.xml is larger than")
+}
+
+func TestReadFile_DOCX(t *testing.T) {
+	filePath := writeZip(t, t.TempDir(), "report.docx", map[string]string{
+		"[Content_Types].xml": `<Types/>`,
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Quarterly </w:t></w:r><w:r><w:t>report</w:t></w:r></w:p>
+<w:p><w:r><w:t>Revenue</w:t><w:tab/><w:t>up &amp; right</w:t></w:r></w:p>
+</w:body></w:document>`,
+	})
+
+	out, err := ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "Quarterly report\nRevenue\tup & right", out)
+}
+
+func TestReadFile_XLSX(t *testing.T) {
+	// Saved without an extension, the workbook is recognized by its content.
+	filePath := writeZip(t, t.TempDir(), "sales", map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Q1" sheetId="1" r:id="rId1"/><sheet name="Notes" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/notes.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Region</t></si><si><t>Total</t></si><si><r><t>North, </t></r><r><t>East</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="C2"><v>42.5</v></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/notes.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>checked</t></is></c><c r="B1" t="b"><v>1</v></c></row>
+</sheetData></worksheet>`,
+	})
+
+	out, err := ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "Sheet: Q1\nRegion,Total\n\"North, East\",,42.5\n\nSheet: Notes\nchecked,true", out)
+}
+
+func TestReadFile_PDF(t *testing.T) {
+	var content bytes.Buffer
+	zw := zlib.NewWriter(&content)
+	zw.Write([]byte("BT /F1 12 Tf 72 720 Td (Hello, PDF) Tj 0 -14 Td [(Second) -300 (line \\(2\\))] TJ ET\n" +
+		"BT <FEFF00E9007400E9> Tj ET"))
+	zw.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", content.Len())
+	pdf.Write(content.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString("5 0 obj\n<< /Type /XObject /Subtype /Image /Length 4 >>\nstream\n\xff\xd8\xff\xe0\nendstream\nendobj\n%%EOF\n")
+
+	filePath := filepath.Join(t.TempDir(), "doc.pdf")
+	require.NoError(t, os.WriteFile(filePath, pdf.Bytes(), 0o644))
+
+	out, err := ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, PDF\nSecond line (2)\nété", out)
+
+	empty := filepath.Join(t.TempDir(), "scan.pdf")
+	require.NoError(t, os.WriteFile(empty, []byte("%PDF-1.4\n%%EOF\n"), 0o644))
+	out, err = ReadFile(empty)
+	require.NoError(t, err)
+	assert.Contains(t, out, "has no extractable text")
+}
@@ -0,0 +1,106 @@
+package tool
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DomainFilter restricts search results to, or away from, domains. A domain
+// also matches its subdomains, and a bare suffix such as "gov" or ".edu"
+// matches every domain ending in it. The zero value allows every result.
+type DomainFilter struct {
+	// Include, when not empty, keeps only results from these domains.
+	Include []string
+	// Exclude drops results from these domains.
+	Exclude []string
+}
+
+// IsZero reports whether the filter allows every result.
+func (f DomainFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Allows reports whether the filter keeps a result with the given URL.
+// Results with a URL that cannot be parsed are kept unless Include is set.
+func (f DomainFilter) Allows(rawURL string) bool {
+	if f.IsZero() {
+		return true
+	}
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Hostname() == "" {
+		return len(f.Include) == 0
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range f.Exclude {
+		if matchesDomain(host, domain) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, domain := range f.Include {
+		if matchesDomain(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the filter for cache keys and logs, e.g.
+// "include=.gov,.edu exclude=example.com".
+func (f DomainFilter) String() string {
+	var parts []string
+	if len(f.Include) > 0 {
+		parts = append(parts, "include="+strings.Join(f.Include, ","))
+	}
+	if len(f.Exclude) > 0 {
+		parts = append(parts, "exclude="+strings.Join(f.Exclude, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// normalizeDomain strips the wildcard and dot prefixes of a domain.
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "*")
+	return strings.TrimPrefix(domain, ".")
+}
+
+func matchesDomain(host, domain string) bool {
+	domain = normalizeDomain(domain)
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// normalizedDomains returns domains without wildcard and dot prefixes, for
+// APIs that filter natively.
+func normalizedDomains(domains []string) []string {
+	var normalized []string
+	for _, domain := range domains {
+		if domain = normalizeDomain(domain); domain != "" {
+			normalized = append(normalized, domain)
+		}
+	}
+	return normalized
+}
+
+// FilterResults drops the results of search results in the
+// "Title: ...\nURL: ..." format of TavilySearch and SerperSearch whose URL
+// the filter does not allow. Other blocks are kept.
+func FilterResults(results string, filter DomainFilter) string {
+	if filter.IsZero() {
+		return results
+	}
+	blocks := strings.Split(results, "\n\n")
+	kept := blocks[:0]
+	for _, block := range blocks {
+		if strings.HasPrefix(block, "Title: ") {
+			_, rest, ok := strings.Cut(block, "\nURL: ")
+			if link, _, _ := strings.Cut(rest, "\n"); ok && !filter.Allows(link) {
+				continue
+			}
+		}
+		kept = append(kept, block)
+	}
+	return strings.Join(kept, "\n\n")
+}
@@ -0,0 +1,62 @@
+package tool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainFilter(t *testing.T) {
+	var zero DomainFilter
+	assert.True(t, zero.Allows("https://anything.example"))
+
+	f := DomainFilter{Include: []string{".gov", "EXAMPLE.com"}, Exclude: []string{"spam.example.com"}}
+	assert.True(t, f.Allows("https://www.cdc.gov/flu"))
+	assert.True(t, f.Allows("https://example.com/"))
+	assert.True(t, f.Allows("https://docs.example.com/a"))
+	assert.False(t, f.Allows("https://spam.example.com/a"))
+	assert.False(t, f.Allows("https://notexample.com/"))
+	assert.False(t, f.Allows("https://gov.uk/"))
+	assert.False(t, f.Allows("not a url"))
+
+	exclude := DomainFilter{Exclude: []string{"spam.example.com"}}
+	assert.True(t, exclude.Allows("not a url"))
+	assert.Equal(t, "include=.gov,EXAMPLE.com exclude=spam.example.com", f.String())
+}
+
+func TestFilterResults(t *testing.T) {
+	results := "Title: A\nURL: https://a.gov/x\nContent: a\n\n" +
+		"Title: B\nURL: https://b.com/x\nContent: b\n\n" +
+		"\nRelevant Images:\n- Image URL: https://b.com/b.png\n"
+
+	out := FilterResults(results, DomainFilter{Include: []string{"gov"}})
+	assert.Equal(t, "Title: A\nURL: https://a.gov/x\nContent: a\n\n\nRelevant Images:\n- Image URL: https://b.com/b.png\n", out)
+	assert.Equal(t, results, FilterResults(results, DomainFilter{}))
+}
+
+func TestDuckDuckGoSearchFiltered(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"AbstractText":"Farm abstract","AbstractURL":"https://farm.example.com/go",
+			"RelatedTopics":[{"Text":"Go at Google","URL":"https://go.dev/about"},{"Text":"Farm topic","URL":"https://farm.example.com/t"}]}`))
+	}))
+	defer server.Close()
+	old := duckDuckGoEndpoint
+	duckDuckGoEndpoint = server.URL + "/"
+	t.Cleanup(func() { duckDuckGoEndpoint = old })
+
+	out, err := DuckDuckGoSearchFiltered(context.Background(), "go", DomainFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, "Farm abstract (Source: https://farm.example.com/go)", out)
+
+	out, err = DuckDuckGoSearchFiltered(context.Background(), "go", DomainFilter{Exclude: []string{"farm.example.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, "No direct abstract found. Related topics: Go at Google", out)
+
+	out, err = DuckDuckGoSearchFiltered(context.Background(), "go", DomainFilter{Include: []string{".edu"}})
+	require.NoError(t, err)
+	assert.Equal(t, "No relevant information found.", out)
+}
@@ -1,17 +1,170 @@
 package tool
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
-// ReadFile reads the content of a file and returns it as a string.
+// ReadFile reads the content of a file and returns it as a string. Text files
+// are returned as is; DOCX, XLSX and PDF files are converted to text (sheets
+// as CSV), and other binary files are described instead of displayed.
 func ReadFile(filePath string) (string, error) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file '%s': %w", filePath, err)
 	}
-	return string(content), nil
+	return contentText(filePath, content, detectFormat(filePath, content))
+}
+
+// contentText returns the text of a file in the given format.
+func contentText(filePath string, content []byte, format string) (string, error) {
+	switch format {
+	case formatText:
+		return string(content), nil
+	case formatBin:
+		return fmt.Sprintf("Binary file '%s', %d bytes, cannot display.", filePath, len(content)), nil
+	default:
+		text, err := documentText(format, content)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s file '%s': %w", strings.ToUpper(format), filePath, err)
+		}
+		if text == "" {
+			return fmt.Sprintf("%s file '%s', %d bytes, has no extractable text.", strings.ToUpper(format), filePath, len(content)), nil
+		}
+		return text, nil
+	}
+}
+
+// ReadFileOptions selects the part of a file returned by ReadFileWithOptions.
+type ReadFileOptions struct {
+	// Offset is the number of lines, or bytes with Bytes, to skip.
+	Offset int
+	// Limit is the maximum number of lines, or bytes with Bytes, to return.
+	// Zero reads to the end of the file.
+	Limit int
+	// Bytes counts Offset and Limit in bytes instead of lines.
+	Bytes bool
+}
+
+// ReadFileWithOptions is like ReadFile, returning only the window of the file
+// selected by opts after a header with the window's position and the total
+// line count (or size in bytes), so large files can be read page by page.
+// Text files are streamed; documents are windowed after text extraction.
+func ReadFileWithOptions(filePath string, opts ReadFileOptions) (string, error) {
+	if opts.Offset < 0 || opts.Limit < 0 {
+		return "", fmt.Errorf("offset and limit must not be negative")
+	}
+	if opts.Offset == 0 && opts.Limit == 0 {
+		return ReadFile(filePath)
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file '%s': %w", filePath, err)
+	}
+	defer f.Close()
+
+	head := make([]byte, binarySniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("failed to read file '%s': %w", filePath, err)
+	}
+	var r io.Reader
+	if format := detectFormat(filePath, head[:n]); format == formatText {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return "", fmt.Errorf("failed to read file '%s': %w", filePath, err)
+		}
+		r = f
+	} else {
+		// Documents are detected and converted as a whole.
+		content, err := io.ReadAll(io.MultiReader(bytes.NewReader(head[:n]), f))
+		if err != nil {
+			return "", fmt.Errorf("failed to read file '%s': %w", filePath, err)
+		}
+		format = detectFormat(filePath, content)
+		text, err := contentText(filePath, content, format)
+		if err != nil || format == formatBin {
+			return text, err
+		}
+		r = strings.NewReader(text)
+	}
+
+	if opts.Bytes {
+		return readByteWindow(filePath, r, opts)
+	}
+	return readLineWindow(filePath, r, opts)
+}
+
+// readLineWindow returns the lines of r selected by opts, counting all lines.
+func readLineWindow(name string, r io.Reader, opts ReadFileOptions) (string, error) {
+	var sb strings.Builder
+	lines, size := 0, 0
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			if lines >= opts.Offset && (opts.Limit == 0 || lines < opts.Offset+opts.Limit) {
+				sb.WriteString(line)
+			}
+			lines++
+			size += len(line)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read file '%s': %w", name, err)
+		}
+	}
+
+	if opts.Offset >= lines {
+		return fmt.Sprintf("[%s: line offset %d is past the end; the file has %d lines, %d bytes]", name, opts.Offset, lines, size), nil
+	}
+	last := lines
+	if opts.Limit > 0 {
+		last = min(lines, opts.Offset+opts.Limit)
+	}
+	return fmt.Sprintf("[%s: lines %d-%d of %d, %d bytes]\n%s", name, opts.Offset+1, last, lines, size, sb.String()), nil
+}
+
+// readByteWindow returns the bytes of r selected by opts.
+func readByteWindow(name string, r io.Reader, opts ReadFileOptions) (string, error) {
+	var size int64
+	if f, ok := r.(*os.File); ok {
+		info, err := f.Stat()
+		if err != nil {
+			return "", fmt.Errorf("failed to read file '%s': %w", name, err)
+		}
+		size = info.Size()
+		if _, err := f.Seek(min(int64(opts.Offset), size), io.SeekStart); err != nil {
+			return "", fmt.Errorf("failed to read file '%s': %w", name, err)
+		}
+	} else {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return "", err
+		}
+		size = int64(len(content))
+		r = bytes.NewReader(content[min(int64(opts.Offset), size):])
+	}
+
+	if int64(opts.Offset) >= size {
+		return fmt.Sprintf("[%s: byte offset %d is past the end; the file has %d bytes]", name, opts.Offset, size), nil
+	}
+	if opts.Limit > 0 {
+		r = io.LimitReader(r, int64(opts.Limit))
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file '%s': %w", name, err)
+	}
+	return fmt.Sprintf("[%s: bytes %d-%d of %d]\n%s", name, opts.Offset, opts.Offset+len(data), size, data), nil
 }
 
 // WriteFile writes the given content to a file.
@@ -23,3 +176,62 @@ func WriteFile(filePath string, content string) error {
 	}
 	return nil
 }
+
+// ErrOutsideSandbox is returned by DeleteFile for a path outside its allowed
+// directories.
+var ErrOutsideSandbox = errors.New("path is outside the allowed directories")
+
+// DeleteFile removes a single file, or a symbolic link without its target,
+// and reports what was removed. It refuses directories and paths outside the
+// roots, which default to the working directory; symbolic links in the
+// path's directory are resolved before the check.
+func DeleteFile(filePath string, roots ...string) (string, error) {
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to delete file '%s': %w", filePath, err)
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return "", fmt.Errorf("failed to delete file '%s': %w", filePath, err)
+	}
+	target := filepath.Join(dir, filepath.Base(abs))
+	if rel, ok := sandboxPath(target, roots); !ok || rel == "." {
+		return "", fmt.Errorf("refusing to delete '%s': %w", filePath, ErrOutsideSandbox)
+	}
+
+	info, err := os.Lstat(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to delete file '%s': %w", filePath, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("refusing to delete '%s': it is a directory", filePath)
+	}
+	if err := os.Remove(target); err != nil {
+		return "", fmt.Errorf("failed to delete file '%s': %w", filePath, err)
+	}
+	return fmt.Sprintf("Deleted file: %s (%d bytes)", target, info.Size()), nil
+}
+
+// sandboxPath returns path relative to the first of roots that contains it,
+// or "." for a root itself, and whether there is one.
+func sandboxPath(path string, roots []string) (string, bool) {
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
+		}
+		rel, err := filepath.Rel(abs, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return rel, true
+	}
+	return "", false
+}
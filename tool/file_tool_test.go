@@ -0,0 +1,103 @@
+package tool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileWithOptions_Lines(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "app.log")
+	var lines []string
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	content := strings.Join(lines, "\n")
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0o644))
+
+	out, err := ReadFileWithOptions(filePath, ReadFileOptions{Offset: 3, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("[%s: lines 4-5 of 10, %d bytes]\nline 4\nline 5\n", filePath, len(content)), out)
+
+	out, err = ReadFileWithOptions(filePath, ReadFileOptions{Offset: 8})
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("[%s: lines 9-10 of 10, %d bytes]\nline 9\nline 10", filePath, len(content)), out)
+
+	out, err = ReadFileWithOptions(filePath, ReadFileOptions{Limit: 100})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(out, content))
+
+	out, err = ReadFileWithOptions(filePath, ReadFileOptions{Offset: 10, Limit: 5})
+	require.NoError(t, err)
+	assert.Contains(t, out, "past the end; the file has 10 lines")
+
+	out, err = ReadFileWithOptions(filePath, ReadFileOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, content, out)
+
+	_, err = ReadFileWithOptions(filePath, ReadFileOptions{Offset: -1})
+	assert.Error(t, err)
+}
+
+func TestReadFileWithOptions_Bytes(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte("0123456789"), 0o644))
+
+	out, err := ReadFileWithOptions(filePath, ReadFileOptions{Offset: 2, Limit: 3, Bytes: true})
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("[%s: bytes 2-5 of 10]\n234", filePath), out)
+
+	out, err = ReadFileWithOptions(filePath, ReadFileOptions{Offset: 12, Bytes: true})
+	require.NoError(t, err)
+	assert.Contains(t, out, "past the end; the file has 10 bytes")
+}
+
+func TestReadFileWithOptions_Document(t *testing.T) {
+	filePath := writeZip(t, t.TempDir(), "report.docx", map[string]string{
+		"word/document.xml": `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>First</w:t></w:r></w:p><w:p><w:r><w:t>Second</w:t></w:r></w:p><w:p><w:r><w:t>Third</w:t></w:r></w:p>
+</w:body></w:document>`,
+	})
+
+	out, err := ReadFileWithOptions(filePath, ReadFileOptions{Offset: 1, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("[%s: lines 2-2 of 3, 18 bytes]\nSecond\n", filePath), out)
+}
+
+func TestDeleteFile(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	inside := filepath.Join(root, "tmp.csv")
+	require.NoError(t, os.WriteFile(inside, []byte("a,b"), 0o644))
+	secret := filepath.Join(outside, "secret.txt")
+	require.NoError(t, os.WriteFile(secret, []byte("keep"), 0o644))
+
+	out, err := DeleteFile(inside, root)
+	require.NoError(t, err)
+	assert.Contains(t, out, "tmp.csv (3 bytes)")
+	assert.NoFileExists(t, inside)
+
+	_, err = DeleteFile(filepath.Join(root, "..", filepath.Base(outside), "secret.txt"), root)
+	assert.ErrorIs(t, err, ErrOutsideSandbox)
+
+	// A linked directory inside the root does not open a way out of it.
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+	_, err = DeleteFile(filepath.Join(root, "link", "secret.txt"), root)
+	assert.ErrorIs(t, err, ErrOutsideSandbox)
+	assert.FileExists(t, secret)
+
+	require.NoError(t, os.Mkdir(filepath.Join(root, "dir"), 0o755))
+	_, err = DeleteFile(filepath.Join(root, "dir"), root)
+	assert.ErrorContains(t, err, "is a directory")
+
+	_, err = DeleteFile(root, root)
+	assert.ErrorIs(t, err, ErrOutsideSandbox)
+
+	_, err = DeleteFile(filepath.Join(root, "missing"), root)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,38 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RunFilterScript runs the script at scriptPath as a filter: input is
+// written to its standard input and its standard output is returned. Python
+// scripts (.py) run with python3 and others with bash. env adds KEY=VALUE
+// entries to the environment of the script. Standard error is only reported
+// in the error of a failed run, so it cannot leak into the output.
+func RunFilterScript(ctx context.Context, scriptPath string, env []string, input string) (string, error) {
+	interpreter := "bash"
+	if strings.HasSuffix(scriptPath, ".py") {
+		var err error
+		if interpreter, err = pythonExecutable(); err != nil {
+			return "", err
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, interpreter, scriptPath)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.WaitDelay = processWaitDelay
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("filter script '%s' failed: %w\nStderr: %s", scriptPath, err, decodeOutput(ctx, stderr.Bytes()))
+	}
+	return decodeOutput(ctx, stdout.Bytes()), nil
+}
@@ -0,0 +1,278 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// GitTool runs git in repositories inside its allowed directories.
+type GitTool struct {
+	// Roots are the directories git may work in, including their
+	// subdirectories. Empty allows only the working directory.
+	Roots []string
+	Env   []string // Extra KEY=VALUE environment entries for git
+}
+
+// Clone clones url, an https or ssh remote, into dir, which must not exist
+// yet and whose parent must be inside the allowed directories. Local
+// repositories cannot be cloned, so none outside the allowed directories is
+// copied into them.
+func (g GitTool) Clone(ctx context.Context, url, dir string) (string, error) {
+	if !isRemoteURL(url) {
+		return "", fmt.Errorf("invalid repository URL %q: only https and ssh remotes can be cloned", url)
+	}
+	if dir == "" {
+		return "", fmt.Errorf("a directory to clone into is required")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	parent, err := g.sandboxDir(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(parent, filepath.Base(abs))
+	if _, err := os.Lstat(target); err == nil {
+		return "", fmt.Errorf("cannot clone into '%s': it already exists", dir)
+	}
+	return g.run(ctx, parent, "clone", "--", url, target)
+}
+
+// Status returns the short status of the repository at dir.
+func (g GitTool) Status(ctx context.Context, dir string) (string, error) {
+	wd, err := g.repoDir(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	return g.run(ctx, wd, "status", "--short", "--branch")
+}
+
+// Diff returns the unstaged changes of the repository at dir, or the staged
+// ones, limited to paths when given.
+func (g GitTool) Diff(ctx context.Context, dir string, staged bool, paths []string) (string, error) {
+	wd, err := g.repoDir(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	args := []string{"diff"}
+	if staged {
+		args = append(args, "--staged")
+	}
+	args = append(args, "--")
+	return g.run(ctx, wd, append(args, paths...)...)
+}
+
+// Commit stages paths, or all changes when there are none, and commits them
+// with message in the repository at dir.
+func (g GitTool) Commit(ctx context.Context, dir, message string, paths []string) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("a commit message is required")
+	}
+	wd, err := g.repoDir(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	if _, err := g.run(ctx, wd, append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	return g.run(ctx, wd, "commit", "-m", message)
+}
+
+// sandboxDir resolves dir, defaulting to the first root, and checks that it
+// is inside the allowed directories.
+func (g GitTool) sandboxDir(dir string) (string, error) {
+	roots := g.roots()
+	if dir == "" {
+		dir = roots[0]
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("invalid git directory '%s': %w", dir, err)
+	}
+	if _, ok := sandboxPath(resolved, roots); !ok {
+		return "", fmt.Errorf("refusing to run git in '%s': %w", dir, ErrOutsideSandbox)
+	}
+	return resolved, nil
+}
+
+// repoDir resolves dir like sandboxDir and checks that the repository it is
+// in is inside the allowed directories too, as git otherwise uses one it
+// finds in a parent directory.
+func (g GitTool) repoDir(ctx context.Context, dir string) (string, error) {
+	wd, err := g.sandboxDir(dir)
+	if err != nil {
+		return "", err
+	}
+	out, err := g.run(ctx, wd, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	top, err := filepath.EvalSymlinks(strings.TrimSpace(out))
+	if err != nil {
+		return "", err
+	}
+	if _, ok := sandboxPath(top, g.roots()); !ok {
+		return "", fmt.Errorf("refusing to use the repository at '%s': %w", top, ErrOutsideSandbox)
+	}
+	return wd, nil
+}
+
+func (g GitTool) roots() []string {
+	if len(g.Roots) == 0 {
+		return []string{"."}
+	}
+	return g.Roots
+}
+
+// isRemoteURL reports whether url is an https or ssh remote: https://...,
+// ssh://... or the scp-like user@host:path.
+func isRemoteURL(url string) bool {
+	if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "ssh://") {
+		return true
+	}
+	at := strings.Index(url, "@")
+	colon := strings.Index(url, ":")
+	return at > 0 && colon > at+1 && !strings.ContainsAny(url[:colon], "/\\") && !strings.HasPrefix(url, "-")
+}
+
+// run runs git with args in dir and returns its combined stdout and stderr.
+// Git never prompts for credentials, and does not look for repositories
+// above the allowed directories.
+func (g GitTool) run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.WaitDelay = processWaitDelay
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if ceilings := g.ceilingDirs(); ceilings != "" {
+		env = append(env, "GIT_CEILING_DIRECTORIES="+ceilings)
+	}
+	cmd.Env = append(env, g.Env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w\nStdout: %s\nStderr: %s", args[0], err, stdout.String(), stderr.String())
+	}
+	return stdout.String() + stderr.String(), nil
+}
+
+// ceilingDirs returns the parents of the allowed directories, in the
+// format of GIT_CEILING_DIRECTORIES.
+func (g GitTool) ceilingDirs() string {
+	var dirs []string
+	for _, root := range g.roots() {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
+		}
+		if parent := filepath.Dir(abs); parent != abs {
+			dirs = append(dirs, parent)
+		}
+	}
+	return strings.Join(dirs, string(filepath.ListSeparator))
+}
+
+// GetGitTools returns the definitions of the git tools. They are not base
+// tools: skills opt in by listing them, e.g. "git_*", in their tools or
+// allowed-tools metadata.
+func GetGitTools() []openai.Tool {
+	dirParam := map[string]interface{}{
+		"type":        "string",
+		"description": "The repository directory. Defaults to the working directory.",
+	}
+	pathsParam := map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"description": "Optional paths to limit the operation to.",
+	}
+	return []openai.Tool{
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "git_clone",
+				Description: "Clones a remote git repository, given by an https or ssh URL, into a new directory.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"url": map[string]interface{}{
+							"type":        "string",
+							"description": "The https or ssh URL of the repository to clone.",
+						},
+						"dir": map[string]interface{}{
+							"type":        "string",
+							"description": "The directory to clone into. It must not exist yet.",
+						},
+					},
+					"required": []string{"url", "dir"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "git_status",
+				Description: "Shows the current branch and the changed files of a git repository.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"dir": dirParam,
+					},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "git_diff",
+				Description: "Shows the changes of a git repository that are not staged yet, or the staged ones.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"dir": dirParam,
+						"staged": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Show the staged changes instead.",
+						},
+						"paths": pathsParam,
+					},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "git_commit",
+				Description: "Stages the given paths, or all changes, and commits them.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"dir": dirParam,
+						"message": map[string]interface{}{
+							"type":        "string",
+							"description": "The commit message.",
+						},
+						"paths": pathsParam,
+					},
+					"required": []string{"message"},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitTool(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	env := []string{
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null",
+	}
+	git := GitTool{Roots: []string{root}, Env: env}
+
+	origin := filepath.Join(root, "origin")
+	require.NoError(t, os.Mkdir(origin, 0o755))
+	_, err := git.run(ctx, origin, "init", "-q")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(origin, "README.md"), []byte("hello\n"), 0o644))
+
+	out, err := git.Status(ctx, origin)
+	require.NoError(t, err)
+	assert.Contains(t, out, "?? README.md")
+
+	out, err = git.Commit(ctx, origin, "Add README", nil)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Add README")
+
+	// Only remotes can be cloned, so clone the local origin directly
+	clone := filepath.Join(root, "clone")
+	_, err = git.run(ctx, root, "clone", "-q", origin, clone)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(clone, "README.md"))
+
+	_, err = git.Clone(ctx, "https://example.com/repo.git", clone)
+	assert.ErrorContains(t, err, "already exists")
+	_, err = git.Clone(ctx, "https://example.com/repo.git", filepath.Join(t.TempDir(), "elsewhere"))
+	assert.ErrorIs(t, err, ErrOutsideSandbox)
+	for _, url := range []string{origin, "file://" + origin, "../origin", "ext::sh -c touch% /tmp/x", "--upload-pack=touch /tmp/x"} {
+		_, err = git.Clone(ctx, url, filepath.Join(root, "x"))
+		assert.ErrorContains(t, err, "invalid repository URL", url)
+	}
+	assert.True(t, isRemoteURL("git@github.com:smallnest/goskills.git"))
+	assert.True(t, isRemoteURL("ssh://git@github.com/smallnest/goskills.git"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(clone, "README.md"), []byte("hello, world\n"), 0o644))
+	out, err = git.Diff(ctx, clone, false, nil)
+	require.NoError(t, err)
+	assert.Contains(t, out, "+hello, world")
+
+	_, err = git.Commit(ctx, clone, " ", nil)
+	assert.ErrorContains(t, err, "commit message is required")
+
+	_, err = git.Status(ctx, t.TempDir())
+	assert.ErrorIs(t, err, ErrOutsideSandbox)
+
+	// A repository above the allowed directory is not used
+	inner := filepath.Join(origin, "inner")
+	require.NoError(t, os.Mkdir(inner, 0o755))
+	_, err = GitTool{Roots: []string{inner}, Env: env}.Status(ctx, inner)
+	assert.ErrorContains(t, err, "not a git repository")
+
+	// Failures return git's output and exit status.
+	_, err = git.Commit(ctx, origin, "Nothing to commit", nil)
+	assert.ErrorContains(t, err, "exit status 1")
+	assert.ErrorContains(t, err, "nothing to commit")
+}
@@ -0,0 +1,42 @@
+package tool
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	sharedHTTPClientMu sync.RWMutex
+	sharedHTTPClient   *http.Client
+)
+
+// SetHTTPClient installs the client the network tools send their requests
+// through, e.g. one with a proxy or custom TLS roots. Nil restores
+// http.DefaultClient, which is the default.
+func SetHTTPClient(c *http.Client) {
+	sharedHTTPClientMu.Lock()
+	defer sharedHTTPClientMu.Unlock()
+	sharedHTTPClient = c
+}
+
+// httpClient returns a copy of the client of the Options carried by ctx, or
+// else of the installed client, that times out after timeout unless the
+// client has a timeout of its own.
+func httpClient(ctx context.Context, timeout time.Duration) *http.Client {
+	c := optionsFrom(ctx).HTTPClient
+	if c == nil {
+		sharedHTTPClientMu.RLock()
+		c = sharedHTTPClient
+		sharedHTTPClientMu.RUnlock()
+	}
+	if c == nil {
+		c = http.DefaultClient
+	}
+	client := *c
+	if client.Timeout == 0 {
+		client.Timeout = timeout
+	}
+	return &client
+}
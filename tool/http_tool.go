@@ -0,0 +1,68 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxHTTPResponseSize limits how much of a response body HTTPRequest returns.
+const maxHTTPResponseSize = 1 << 20 // 1 MiB
+
+// HTTPRequest performs an HTTP request with the given method, headers and body.
+// It returns the status code together with the response body, truncated to maxHTTPResponseSize.
+// Non-2xx responses are not treated as errors so the caller can inspect them.
+func HTTPRequest(ctx context.Context, method, urlString string, headers map[string]string, body string) (string, error) {
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if method == "" {
+		method = http.MethodGet
+	}
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete:
+	default:
+		return "", fmt.Errorf("unsupported HTTP method: %s", method)
+	}
+
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, urlString, reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for %s: %w", urlString, err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := httpClient(ctx, 30*time.Second)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to perform %s request to %s: %w", method, urlString, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	truncated := len(respBody) > maxHTTPResponseSize
+	if truncated {
+		respBody = respBody[:maxHTTPResponseSize]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Status: %s\n\n", resp.Status))
+	sb.Write(respBody)
+	if truncated {
+		sb.WriteString(fmt.Sprintf("\n...(truncated to %d bytes)", maxHTTPResponseSize))
+	}
+
+	return sb.String(), nil
+}
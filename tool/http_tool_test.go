@@ -0,0 +1,72 @@
+package tool
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(r.Method + " " + r.Header.Get("X-Test") + " " + string(body)))
+	}))
+	defer server.Close()
+
+	out, err := HTTPRequest(context.Background(), "post", server.URL, map[string]string{"X-Test": "yes"}, `{"a":1}`)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Status: 201 Created")
+	assert.Contains(t, out, `POST yes {"a":1}`)
+}
+
+func TestHTTPRequest_Truncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", maxHTTPResponseSize+10)))
+	}))
+	defer server.Close()
+
+	out, err := HTTPRequest(context.Background(), "GET", server.URL, nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, out, "...(truncated to")
+}
+
+func TestHTTPRequest_UnsupportedMethod(t *testing.T) {
+	_, err := HTTPRequest(context.Background(), "PATCH", "http://example.com", nil, "")
+	assert.Error(t, err)
+}
+
+// headerTransport adds a header to every request, standing in for a proxy.
+type headerTransport struct{ requests int }
+
+func (h *headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	h.requests++
+	r = r.Clone(r.Context())
+	r.Header.Set("X-Test", "via-shared-client")
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestSetHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Test")))
+	}))
+	defer server.Close()
+
+	transport := &headerTransport{}
+	SetHTTPClient(&http.Client{Transport: transport})
+	t.Cleanup(func() { SetHTTPClient(nil) })
+
+	out, err := HTTPRequest(context.Background(), "GET", server.URL, nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, out, "via-shared-client")
+	assert.Equal(t, 1, transport.requests)
+	assert.Equal(t, 30*time.Second, httpClient(context.Background(), 30*time.Second).Timeout)
+}
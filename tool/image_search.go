@@ -0,0 +1,74 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SearchImage is an image found by an image search.
+type SearchImage struct {
+	URL string `json:"url"`
+	// Description is a caption or title of the image, when the provider has one.
+	Description string `json:"description,omitempty"`
+}
+
+// TavilyImageSearch searches for images related to query with Tavily, including
+// Tavily's generated image descriptions. Results are cached when a
+// ResultCache is installed.
+func TavilyImageSearch(ctx context.Context, query string) ([]SearchImage, error) {
+	return cachedImages(ctx, "tavily-images", query, func(ctx context.Context) ([]SearchImage, error) {
+		result, err := tavilyRequest(ctx, map[string]interface{}{
+			"query":                      query,
+			"search_depth":               "basic",
+			"max_results":                5,
+			"include_images":             true,
+			"include_image_descriptions": true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		images := make([]SearchImage, 0, len(result.Images))
+		for _, image := range result.Images {
+			images = append(images, SearchImage(image))
+		}
+		return images, nil
+	})
+}
+
+// SerperImageSearch searches Google Images for query through the Serper API.
+// Results are cached when a ResultCache is installed.
+func SerperImageSearch(ctx context.Context, query string) ([]SearchImage, error) {
+	return cachedImages(ctx, "serper-images", query, func(ctx context.Context) ([]SearchImage, error) {
+		result, err := serperRequest(ctx, query, SerperImages)
+		if err != nil {
+			return nil, err
+		}
+		images := make([]SearchImage, 0, len(result.Images))
+		for _, image := range result.Images {
+			images = append(images, SearchImage{URL: image.ImageURL, Description: image.Title})
+		}
+		return images, nil
+	})
+}
+
+// cachedImages caches image results as JSON.
+func cachedImages(ctx context.Context, provider, query string, fetch func(ctx context.Context) ([]SearchImage, error)) ([]SearchImage, error) {
+	data, err := cached(ctx, provider, query, func(ctx context.Context) (string, error) {
+		images, err := fetch(ctx)
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(images)
+		return string(data), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var images []SearchImage
+	if err := json.Unmarshal([]byte(data), &images); err != nil {
+		return nil, fmt.Errorf("invalid cached images: %w", err)
+	}
+	return images, nil
+}
@@ -7,69 +7,212 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
 )
 
-// WikipediaSearch performs a search on Wikipedia for the given query and returns a summary.
+// wikipediaEndpoint is the API URL pattern, formatted with the language code.
+var wikipediaEndpoint = "https://%s.wikipedia.org/w/api.php"
+
+// wikipediaLanguage matches Wikipedia language codes such as "en", "zh" or "zh-yue".
+var wikipediaLanguage = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]+)*$`)
+
+// wikipediaHeading matches the "== Heading ==" lines of a plain text extract.
+var wikipediaHeading = regexp.MustCompile(`^(={2,6})\s*(.*?)\s*={2,6}$`)
+
+// WikipediaOptions selects the language and the amount of an article returned
+// by WikipediaSearchWithOptions.
+type WikipediaOptions struct {
+	// Language is the Wikipedia language code, e.g. "de" for de.wikipedia.org.
+	// Empty uses English.
+	Language string
+	// FullArticle returns the whole article split into sections instead of
+	// only the introduction.
+	FullArticle bool
+	// Section returns only the section with this title (and its subsections).
+	// It implies FullArticle.
+	Section string
+}
+
+// WikipediaSection is a section of a Wikipedia article.
+type WikipediaSection struct {
+	Title string
+	// Level is the heading level, 2 for top-level sections.
+	Level   int
+	Content string
+}
+
+// WikipediaArticle is a Wikipedia article found by WikipediaLookup.
+type WikipediaArticle struct {
+	Title string
+	// URL is the canonical article URL, for citation.
+	URL string
+	// Summary is the introduction of the article.
+	Summary string
+	// Sections holds the rest of the article when the full article was requested.
+	Sections []WikipediaSection
+}
+
+// String formats the article as Markdown, ending with its source URL.
+func (a *WikipediaArticle) String() string {
+	var sb strings.Builder
+	if len(a.Sections) > 0 {
+		sb.WriteString("# " + a.Title + "\n\n")
+	}
+	if a.Summary != "" {
+		sb.WriteString(a.Summary + "\n\n")
+	}
+	for _, section := range a.Sections {
+		sb.WriteString(strings.Repeat("#", section.Level) + " " + section.Title + "\n\n")
+		if section.Content != "" {
+			sb.WriteString(section.Content + "\n\n")
+		}
+	}
+	sb.WriteString("Source: " + a.URL)
+	return sb.String()
+}
+
+// WikipediaSearch performs a search on English Wikipedia for the given query
+// and returns a summary with the article URL.
 // It uses the Wikipedia API.
-func WikipediaSearch(query string) (string, error) {
-	baseURL := "https://en.wikipedia.org/w/api.php"
+func WikipediaSearch(ctx context.Context, query string) (string, error) {
+	return WikipediaSearchWithOptions(ctx, query, WikipediaOptions{})
+}
+
+// WikipediaSearchWithOptions is like WikipediaSearch, in the language and
+// with the sections selected by opts.
+func WikipediaSearchWithOptions(ctx context.Context, query string, opts WikipediaOptions) (string, error) {
+	article, err := WikipediaLookup(ctx, query, opts)
+	if err != nil {
+		return "", err
+	}
+	if article == nil {
+		return "No relevant Wikipedia entry found.", nil
+	}
+	return article.String(), nil
+}
+
+// WikipediaLookup fetches the Wikipedia article titled query. It returns nil
+// when there is no such article.
+func WikipediaLookup(ctx context.Context, query string, opts WikipediaOptions) (*WikipediaArticle, error) {
+	language := strings.ToLower(opts.Language)
+	if language == "" {
+		language = "en"
+	}
+	if !wikipediaLanguage.MatchString(language) {
+		return nil, fmt.Errorf("invalid Wikipedia language %q", opts.Language)
+	}
+	fullArticle := opts.FullArticle || opts.Section != ""
+
 	params := url.Values{}
 	params.Add("action", "query")
 	params.Add("format", "json")
-	params.Add("prop", "extracts")
-	params.Add("exintro", "")     // Return only content before the first section
-	params.Add("explaintext", "") // Return plain text
-	params.Add("redirects", "1")  // Resolve redirects
+	params.Add("prop", "extracts|info")
+	params.Add("inprop", "url")           // Return the canonical URL
+	params.Add("explaintext", "")         // Return plain text
+	params.Add("exsectionformat", "wiki") // Mark sections with "== Heading =="
+	params.Add("redirects", "1")          // Resolve redirects
+	if !fullArticle {
+		params.Add("exintro", "") // Return only content before the first section
+	}
 	params.Add("titles", query)
 
-	searchURL := baseURL + "?" + params.Encode()
+	searchURL := fmt.Sprintf(wikipediaEndpoint, language) + "?" + params.Encode()
 
-	client := http.Client{
-		Timeout: 10 * time.Second,
-	}
+	client := httpClient(ctx, 10*time.Second)
 
-	req, err := http.NewRequestWithContext(context.Background(), "GET", searchURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to perform Wikipedia search: %w", err)
+		return nil, fmt.Errorf("failed to perform Wikipedia search: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Wikipedia API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("Wikipedia API returned status %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	var result struct {
 		Query struct {
 			Pages map[string]struct {
+				Title   string `json:"title"`
 				Extract string `json:"extract"`
+				FullURL string `json:"fullurl"`
 			} `json:"pages"`
 		} `json:"query"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to unmarshal Wikipedia response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal Wikipedia response: %w", err)
 	}
 
 	for _, page := range result.Query.Pages {
-		if page.Extract != "" {
-			// Clean up some common Wikipedia API artifacts
-			extract := strings.ReplaceAll(page.Extract, "(listen)", "")
-			extract = strings.TrimSpace(extract)
-			return extract, nil
+		if page.Extract == "" {
+			continue
+		}
+		// Clean up some common Wikipedia API artifacts
+		extract := strings.ReplaceAll(page.Extract, "(listen)", "")
+
+		article := &WikipediaArticle{Title: page.Title, URL: page.FullURL}
+		article.Summary, article.Sections = splitWikipediaSections(extract)
+		if opts.Section != "" {
+			article.Summary = ""
+			article.Sections = findWikipediaSection(article.Sections, opts.Section)
+			if article.Sections == nil {
+				return nil, fmt.Errorf("section %q not found in Wikipedia article %q", opts.Section, page.Title)
+			}
+		}
+		return article, nil
+	}
+
+	return nil, nil
+}
+
+// splitWikipediaSections splits a plain text extract into its introduction
+// and sections.
+func splitWikipediaSections(extract string) (string, []WikipediaSection) {
+	var intro strings.Builder
+	var sections []WikipediaSection
+	for _, line := range strings.Split(extract, "\n") {
+		if m := wikipediaHeading.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			sections = append(sections, WikipediaSection{Title: m[2], Level: len(m[1])})
+			continue
 		}
+		if len(sections) == 0 {
+			intro.WriteString(line + "\n")
+		} else {
+			sections[len(sections)-1].Content += line + "\n"
+		}
+	}
+
+	for i := range sections {
+		sections[i].Content = strings.TrimSpace(sections[i].Content)
 	}
+	return strings.TrimSpace(intro.String()), sections
+}
 
-	return "No relevant Wikipedia entry found.", nil
+// findWikipediaSection returns the section titled title, case-insensitively,
+// followed by its subsections, or nil if there is none.
+func findWikipediaSection(sections []WikipediaSection, title string) []WikipediaSection {
+	for i, section := range sections {
+		if !strings.EqualFold(section.Title, title) {
+			continue
+		}
+		end := i + 1
+		for end < len(sections) && sections[end].Level > section.Level {
+			end++
+		}
+		return sections[i:end]
+	}
+	return nil
 }
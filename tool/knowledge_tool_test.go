@@ -0,0 +1,69 @@
+package tool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const wikipediaTestResponse = `{"query":{"pages":{"1":{"title":"Go (programming language)","fullurl":"https://de.wikipedia.org/wiki/Go_(Programmiersprache)",
+"extract":"Go is a language.\n\n== History ==\nDesigned at Google.\n\n=== Versions ===\nGo 1 in 2012.\n\n== Design ==\nSimple."}}}}`
+
+// withWikipediaServer points the Wikipedia tools at a test server and records
+// the requested paths and queries.
+func withWikipediaServer(t *testing.T, requests *[]*http.Request) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(wikipediaTestResponse))
+	}))
+	t.Cleanup(server.Close)
+
+	old := wikipediaEndpoint
+	wikipediaEndpoint = server.URL + "/%s/api.php"
+	t.Cleanup(func() { wikipediaEndpoint = old })
+}
+
+func TestWikipediaSearch(t *testing.T) {
+	var requests []*http.Request
+	withWikipediaServer(t, &requests)
+
+	out, err := WikipediaSearch(context.Background(), "Go")
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/en/api.php", requests[0].URL.Path)
+	assert.True(t, requests[0].URL.Query().Has("exintro"))
+	assert.Contains(t, out, "Go is a language.")
+	assert.Contains(t, out, "Source: https://de.wikipedia.org/wiki/Go_(Programmiersprache)")
+}
+
+func TestWikipediaLookupSections(t *testing.T) {
+	var requests []*http.Request
+	withWikipediaServer(t, &requests)
+
+	article, err := WikipediaLookup(context.Background(), "Go", WikipediaOptions{Language: "de", FullArticle: true})
+	require.NoError(t, err)
+	assert.Equal(t, "/de/api.php", requests[0].URL.Path)
+	assert.False(t, requests[0].URL.Query().Has("exintro"))
+	assert.Equal(t, "Go is a language.", article.Summary)
+	assert.Equal(t, []WikipediaSection{
+		{Title: "History", Level: 2, Content: "Designed at Google."},
+		{Title: "Versions", Level: 3, Content: "Go 1 in 2012."},
+		{Title: "Design", Level: 2, Content: "Simple."},
+	}, article.Sections)
+
+	article, err = WikipediaLookup(context.Background(), "Go", WikipediaOptions{Section: "history"})
+	require.NoError(t, err)
+	assert.Empty(t, article.Summary)
+	assert.Len(t, article.Sections, 2)
+	assert.Contains(t, article.String(), "## History\n\nDesigned at Google.\n\n### Versions")
+
+	_, err = WikipediaLookup(context.Background(), "Go", WikipediaOptions{Section: "Reception"})
+	assert.Error(t, err)
+	_, err = WikipediaLookup(context.Background(), "Go", WikipediaOptions{Language: "evil.example.com/"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package tool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Metrics receives the outcome of every tool call, from the skill runner and
+// from the subagents, e.g. to update a call counter, a latency histogram and
+// an error counter per tool name in Prometheus.
+type Metrics interface {
+	// ObserveToolCall records a call of the tool name that took duration and
+	// failed with err, or succeeded if err is nil. It must be safe for
+	// concurrent use.
+	ObserveToolCall(name string, duration time.Duration, err error)
+}
+
+var (
+	metricsMu sync.RWMutex
+	metrics   Metrics
+)
+
+// SetMetrics installs the Metrics tool calls are reported to. Nil, the
+// default, turns reporting off.
+func SetMetrics(m Metrics) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	metrics = m
+}
+
+// RecordToolCall reports a call of the tool name that started at start to
+// the Metrics of the Options carried by ctx, or else to the installed
+// Metrics, if any.
+func RecordToolCall(ctx context.Context, name string, start time.Time, err error) {
+	m := optionsFrom(ctx).Metrics
+	if m == nil {
+		metricsMu.RLock()
+		m = metrics
+		metricsMu.RUnlock()
+	}
+	if m != nil {
+		m.ObserveToolCall(name, time.Since(start), err)
+	}
+}
@@ -0,0 +1,55 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Options are the settings of the tools called with a context carrying them,
+// so that agents in one process can use different settings. Unset fields fall
+// back to the process-wide settings of SetHTTPClient, SetResultCache,
+// SetMetrics and SetScriptOutputEncoding.
+type Options struct {
+	// HTTPClient is the client the network tools send their requests through.
+	HTTPClient *http.Client
+	// ResultCache caches the results of the network tools.
+	ResultCache *ResultCache
+	// Metrics receives the outcome of every tool call.
+	Metrics Metrics
+	// ScriptOutputEncoding is the encoding script output that is not valid
+	// UTF-8 is transcoded from; see LookupScriptOutputEncoding.
+	ScriptOutputEncoding encoding.Encoding
+}
+
+type optionsKey struct{}
+
+// WithOptions returns a copy of ctx whose tool calls use opts.
+func WithOptions(ctx context.Context, opts Options) context.Context {
+	return context.WithValue(ctx, optionsKey{}, opts)
+}
+
+// optionsFrom returns the options carried by ctx, if any.
+func optionsFrom(ctx context.Context) Options {
+	opts, _ := ctx.Value(optionsKey{}).(Options)
+	return opts
+}
+
+// LookupScriptOutputEncoding returns the encoding with the WHATWG name or
+// label name, such as "windows-1252", "gbk" or "shift_jis". The empty name
+// returns nil, which replaces the bytes of output that are not valid UTF-8
+// with U+FFFD.
+func LookupScriptOutputEncoding(name string) (encoding.Encoding, error) {
+	if name = strings.TrimSpace(name); name == "" {
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown script output encoding %q: %w", name, err)
+	}
+	return enc, nil
+}
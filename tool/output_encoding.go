@@ -0,0 +1,65 @@
+package tool
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+)
+
+var (
+	scriptOutputEncodingMu sync.RWMutex
+	scriptOutputEncoding   encoding.Encoding
+)
+
+// SetScriptOutputEncoding sets the encoding that script output which is not
+// valid UTF-8 is transcoded from, by its WHATWG name or label such as
+// "windows-1252", "gbk" or "shift_jis". The empty name restores the default,
+// which replaces the invalid bytes with U+FFFD.
+func SetScriptOutputEncoding(name string) error {
+	enc, err := LookupScriptOutputEncoding(name)
+	if err != nil {
+		return err
+	}
+	scriptOutputEncodingMu.Lock()
+	defer scriptOutputEncodingMu.Unlock()
+	scriptOutputEncoding = enc
+	return nil
+}
+
+// decodeOutput returns the output of a script as text. Valid UTF-8 is
+// returned as is. Binary output, holding NUL bytes, is base64-encoded after a
+// note, so that it reaches the model intact. Other text is transcoded from
+// the encoding of the Options carried by ctx or set with
+// SetScriptOutputEncoding, or else has its invalid bytes replaced with
+// U+FFFD, so that JSON output stays parseable.
+func decodeOutput(ctx context.Context, data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	if looksBinary(data) {
+		return fmt.Sprintf("[output is binary; %d bytes base64-encoded]\n%s", len(data), base64.StdEncoding.EncodeToString(data))
+	}
+	enc := optionsFrom(ctx).ScriptOutputEncoding
+	if enc == nil {
+		scriptOutputEncodingMu.RLock()
+		enc = scriptOutputEncoding
+		scriptOutputEncodingMu.RUnlock()
+	}
+	if enc != nil {
+		if text, err := enc.NewDecoder().Bytes(data); err == nil {
+			return string(text)
+		}
+	}
+	return strings.ToValidUTF8(string(data), "\uFFFD")
+}
+
+// looksBinary reports whether data holds NUL bytes, which no text encoding
+// the transcoder supports produces.
+func looksBinary(data []byte) bool {
+	return strings.IndexByte(string(data), 0) >= 0
+}
@@ -0,0 +1,70 @@
+package tool
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeOutput(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetScriptOutputEncoding("")) })
+
+	// GBK for "中文" and Latin-1 for "café"
+	gbk := []byte{0xd6, 0xd0, 0xce, 0xc4}
+	latin1 := []byte{'c', 'a', 'f', 0xe9}
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+
+	assert.Equal(t, "héllo 世界", decodeOutput(context.Background(), []byte("héllo 世界")))
+
+	assert.Equal(t, "caf\uFFFD", decodeOutput(context.Background(), latin1))
+	assert.Equal(t, "{\"name\": \"caf\uFFFD\"}", decodeOutput(context.Background(), []byte("{\"name\": \"caf\xe9\"}")))
+	out := decodeOutput(context.Background(), binary)
+	assert.Contains(t, out, "output is binary; 6 bytes base64-encoded")
+	assert.Contains(t, out, base64.StdEncoding.EncodeToString(binary))
+
+	require.NoError(t, SetScriptOutputEncoding("gbk"))
+	assert.Equal(t, "中文", decodeOutput(context.Background(), gbk))
+	assert.Contains(t, decodeOutput(context.Background(), binary), base64.StdEncoding.EncodeToString(binary))
+
+	require.NoError(t, SetScriptOutputEncoding("latin1"))
+	assert.Equal(t, "café", decodeOutput(context.Background(), latin1))
+
+	assert.Error(t, SetScriptOutputEncoding("no-such-encoding"))
+}
+
+func TestDecodeOutput_Options(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetScriptOutputEncoding("")) })
+	require.NoError(t, SetScriptOutputEncoding("gbk"))
+	latin1 := []byte{'c', 'a', 'f', 0xe9}
+
+	enc, err := LookupScriptOutputEncoding("latin1")
+	require.NoError(t, err)
+	ctx := WithOptions(context.Background(), Options{ScriptOutputEncoding: enc})
+	assert.Equal(t, "café", decodeOutput(ctx, latin1))
+
+	enc, err = LookupScriptOutputEncoding("")
+	require.NoError(t, err)
+	assert.Nil(t, enc)
+	_, err = LookupScriptOutputEncoding("no-such-encoding")
+	assert.Error(t, err)
+}
+
+func TestShellTool_NonUTF8Output(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetScriptOutputEncoding("")) })
+	script := filepath.Join(t.TempDir(), "latin1.sh")
+	require.NoError(t, os.WriteFile(script, []byte("printf 'caf\\351'\n"), 0o644))
+
+	out, err := RunShellScript(context.Background(), script, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "caf\uFFFD", out)
+
+	require.NoError(t, SetScriptOutputEncoding("windows-1252"))
+	out, err = RunShellScript(context.Background(), script, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "café", out)
+}
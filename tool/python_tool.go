@@ -2,65 +2,185 @@ package tool
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-
 	"os"
 	"os/exec"
+	"strings"
 	"text/template"
 )
 
 type PythonTool struct {
+	Env   []string // Extra KEY=VALUE environment entries for the script
+	Stdin string   // Written to the standard input of the script
+	Dir   string   // Working directory of the script; empty uses the current one
+	// CheckSyntax compiles the code before running it, so code with a
+	// syntax error is reported without running any of it.
+	CheckSyntax bool
+}
+
+func (t *PythonTool) Run(ctx context.Context, args map[string]any, code string) (string, error) {
+	stdout, stderr, err := t.run(ctx, args, code)
+	if err != nil {
+		return "", err
+	}
+	return stdout + stderr, nil
+}
+
+// maxInvalidJSONOutput limits how much of a non-JSON output RunJSON reports.
+const maxInvalidJSONOutput = 2000
+
+// RunJSON is like Run for scripts that print a JSON value on stdout. It
+// returns the value indented, or an error when stdout is not valid JSON.
+// Stderr is ignored unless the script fails.
+func (t *PythonTool) RunJSON(ctx context.Context, args map[string]any, code string) (string, error) {
+	stdout, stderr, err := t.run(ctx, args, code)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, bytes.TrimSpace([]byte(stdout)), "", "  "); err != nil {
+		if len(stdout) > maxInvalidJSONOutput {
+			stdout = stdout[:maxInvalidJSONOutput] + "...(truncated)"
+		}
+		return "", fmt.Errorf("script output is not valid JSON: %w\nStdout: %s\nStderr: %s", err, stdout, stderr)
+	}
+	return out.String(), nil
 }
 
-func (t *PythonTool) Run(args map[string]any, code string) (string, error) {
+// run renders the code template with args and runs it.
+func (t *PythonTool) run(ctx context.Context, args map[string]any, code string) (string, string, error) {
 	tmpl, err := template.New("python").Parse(code)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse python template: %w", err)
+		return "", "", fmt.Errorf("failed to parse python template: %w", err)
 	}
 
 	var script bytes.Buffer
 	err = tmpl.Execute(&script, args)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute python template: %w", err)
+		return "", "", fmt.Errorf("failed to execute python template: %w", err)
 	}
 
 	tmpfile, err := os.CreateTemp("", "python-*.py")
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %w", err)
+		return "", "", fmt.Errorf("failed to create temp file: %w", err)
 	}
 	defer os.Remove(tmpfile.Name())
 
 	if _, err := tmpfile.Write(script.Bytes()); err != nil {
-		return "", fmt.Errorf("failed to write to temp file: %w", err)
+		return "", "", fmt.Errorf("failed to write to temp file: %w", err)
 	}
 	if err := tmpfile.Close(); err != nil {
-		return "", fmt.Errorf("failed to close temp file: %w", err)
+		return "", "", fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if t.CheckSyntax {
+		if err := CheckPythonSyntax(ctx, tmpfile.Name()); err != nil {
+			return "", "", err
+		}
 	}
 
-	return RunPythonScript(tmpfile.Name(), nil)
+	return runPythonScript(ctx, tmpfile.Name(), nil, t.Env, t.Stdin, t.Dir)
 }
 
-// RunPythonScript executes a Python script and returns its combined stdout and stderr.
-// It tries to use 'python3' first, then falls back to 'python'.
-func RunPythonScript(scriptPath string, args []string) (string, error) {
-	pythonExe, err := exec.LookPath("python3")
+// ErrPythonSyntax is returned by CheckPythonSyntax for scripts that do not compile.
+var ErrPythonSyntax = errors.New("python syntax error")
+
+// pythonSyntaxCheck compiles the file named by its argument without running
+// it or writing bytecode, and prints the syntax error, if any.
+const pythonSyntaxCheck = `import sys
+try:
+    with open(sys.argv[1], encoding="utf-8") as f:
+        compile(f.read(), sys.argv[1], "exec")
+except SyntaxError as e:
+    print("%s (line %s, column %s)" % (e.msg, e.lineno, e.offset))
+    if e.text:
+        print(e.text.rstrip())
+    sys.exit(1)
+`
+
+// CheckPythonSyntax compiles the Python script at scriptPath without running
+// it. A script that does not compile yields an error wrapping
+// ErrPythonSyntax with the message, line and offending code.
+func CheckPythonSyntax(ctx context.Context, scriptPath string) error {
+	pythonExe, err := pythonExecutable()
 	if err != nil {
-		pythonExe, err = exec.LookPath("python")
-		if err != nil {
-			return "", fmt.Errorf("failed to find python3 or python in PATH: %w", err)
+		return err
+	}
+	cmd := exec.CommandContext(ctx, pythonExe, "-c", pythonSyntaxCheck, scriptPath)
+	cmd.WaitDelay = processWaitDelay
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stdout.Len() > 0 {
+			return fmt.Errorf("%w, nothing was run: %s", ErrPythonSyntax, strings.TrimSpace(stdout.String()))
 		}
+		return fmt.Errorf("failed to check python script '%s': %w\nStderr: %s", scriptPath, err, stderr.String())
+	}
+	return nil
+}
+
+// RunPythonScript executes a Python script and returns its combined stdout and stderr.
+// It tries to use 'python3' first, then falls back to 'python'. The script
+// is killed when ctx is done.
+func RunPythonScript(ctx context.Context, scriptPath string, args []string) (string, error) {
+	return RunPythonScriptWithEnv(ctx, scriptPath, args, nil)
+}
+
+// RunPythonScriptWithEnv is like RunPythonScript, adding env (KEY=VALUE entries)
+// to the environment of the script.
+func RunPythonScriptWithEnv(ctx context.Context, scriptPath string, args []string, env []string) (string, error) {
+	return RunPythonScriptInDir(ctx, "", scriptPath, args, env)
+}
+
+// RunPythonScriptInDir is like RunPythonScriptWithEnv, running the script in
+// the working directory dir. A relative scriptPath is relative to dir.
+func RunPythonScriptInDir(ctx context.Context, dir, scriptPath string, args []string, env []string) (string, error) {
+	stdout, stderr, err := runPythonScript(ctx, scriptPath, args, env, "", dir)
+	if err != nil {
+		return "", err
+	}
+	return stdout + stderr, nil
+}
+
+// runPythonScript runs a Python script in dir and returns its stdout and
+// stderr.
+func runPythonScript(ctx context.Context, scriptPath string, args []string, env []string, stdin, dir string) (string, string, error) {
+	pythonExe, err := pythonExecutable()
+	if err != nil {
+		return "", "", err
 	}
 
-	cmd := exec.Command(pythonExe, append([]string{scriptPath}, args...)...)
-	cmd.Env = os.Environ()
+	cmd := exec.CommandContext(ctx, pythonExe, append([]string{scriptPath}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Dir = dir
+	cmd.WaitDelay = processWaitDelay
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	err = cmd.Run()
 	if err != nil {
-		return "", fmt.Errorf("failed to run python script '%s' with '%s': %w\nStdout: %s\nStderr: %s", scriptPath, pythonExe, err, stdout.String(), stderr.String())
+		return "", "", fmt.Errorf("failed to run python script '%s' with '%s': %w\nStdout: %s\nStderr: %s", scriptPath, pythonExe, err, decodeOutput(ctx, stdout.Bytes()), decodeOutput(ctx, stderr.Bytes()))
 	}
 
-	return stdout.String() + stderr.String(), nil
+	return decodeOutput(ctx, stdout.Bytes()), decodeOutput(ctx, stderr.Bytes()), nil
+}
+
+// pythonExecutable returns the path of python3, or else python.
+func pythonExecutable() (string, error) {
+	pythonExe, err := exec.LookPath("python3")
+	if err != nil {
+		pythonExe, err = exec.LookPath("python")
+		if err != nil {
+			return "", fmt.Errorf("failed to find python3 or python in PATH: %w", err)
+		}
+	}
+	return pythonExe, nil
 }
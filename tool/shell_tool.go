@@ -2,16 +2,25 @@ package tool
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"text/template"
+	"time"
 )
 
 type ShellTool struct {
+	Env   []string // Extra KEY=VALUE environment entries for the script
+	Stdin string   // Written to the standard input of the script
+	Dir   string   // Working directory of the script; empty uses the current one
+	// AllowedCommands, when not empty, restricts the script to these
+	// commands. See CheckShellCommands.
+	AllowedCommands []string
 }
 
-func (t *ShellTool) Run(args map[string]any, code string) (string, error) {
+func (t *ShellTool) Run(ctx context.Context, args map[string]any, code string) (string, error) {
 	tmpl, err := template.New("shell").Parse(code)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse shell template: %w", err)
@@ -22,6 +31,11 @@ func (t *ShellTool) Run(args map[string]any, code string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to execute shell template: %w", err)
 	}
+	if len(t.AllowedCommands) > 0 {
+		if err := CheckShellCommands(script.String(), t.AllowedCommands); err != nil {
+			return "", err
+		}
+	}
 
 	tmpfile, err := os.CreateTemp("", "shell-*.sh")
 	if err != nil {
@@ -36,12 +50,39 @@ func (t *ShellTool) Run(args map[string]any, code string) (string, error) {
 		return "", fmt.Errorf("failed to close temp file: %w", err)
 	}
 
-	return RunShellScript(tmpfile.Name(), nil)
+	return runShellScript(ctx, tmpfile.Name(), nil, t.Env, t.Stdin, t.Dir)
 }
 
-// RunShellScript executes a shell script and returns its combined stdout and stderr.
-func RunShellScript(scriptPath string, args []string) (string, error) {
-	cmd := exec.Command("bash", append([]string{scriptPath}, args...)...)
+// processWaitDelay is how long a canceled script's output is still read
+// before returning, in case child processes keep it open.
+const processWaitDelay = time.Second
+
+// RunShellScript executes a shell script and returns its combined stdout and
+// stderr. The script is killed when ctx is done.
+func RunShellScript(ctx context.Context, scriptPath string, args []string) (string, error) {
+	return RunShellScriptWithEnv(ctx, scriptPath, args, nil)
+}
+
+// RunShellScriptWithEnv is like RunShellScript, adding env (KEY=VALUE entries)
+// to the environment of the script.
+func RunShellScriptWithEnv(ctx context.Context, scriptPath string, args []string, env []string) (string, error) {
+	return runShellScript(ctx, scriptPath, args, env, "", "")
+}
+
+// RunShellScriptInDir is like RunShellScriptWithEnv, running the script in
+// the working directory dir. A relative scriptPath is relative to dir.
+func RunShellScriptInDir(ctx context.Context, dir, scriptPath string, args []string, env []string) (string, error) {
+	return runShellScript(ctx, scriptPath, args, env, "", dir)
+}
+
+func runShellScript(ctx context.Context, scriptPath string, args []string, env []string, stdin, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "bash", append([]string{scriptPath}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Dir = dir
+	cmd.WaitDelay = processWaitDelay
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -49,8 +90,8 @@ func RunShellScript(scriptPath string, args []string) (string, error) {
 
 	err := cmd.Run()
 	if err != nil {
-		return "", fmt.Errorf("failed to run shell script '%s': %w\nStdout: %s\nStderr: %s", scriptPath, err, stdout.String(), stderr.String())
+		return "", fmt.Errorf("failed to run shell script '%s': %w\nStdout: %s\nStderr: %s", scriptPath, err, decodeOutput(ctx, stdout.Bytes()), decodeOutput(ctx, stderr.Bytes()))
 	}
 
-	return stdout.String() + stderr.String(), nil
+	return decodeOutput(ctx, stdout.Bytes()) + decodeOutput(ctx, stderr.Bytes()), nil
 }
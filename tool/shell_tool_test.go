@@ -0,0 +1,38 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunShellScript_Canceled(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "slow.sh")
+	require.NoError(t, os.WriteFile(script, []byte("sleep 10\n"), 0o755))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := RunShellScript(ctx, script, nil)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestWebFetch_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := WebFetch(ctx, "http://127.0.0.1:1/")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestShellTool_Stdin(t *testing.T) {
+	shell := ShellTool{Stdin: "b,2\na,1\n"}
+	out, err := shell.Run(context.Background(), nil, "sort | cut -d, -f1")
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\n", out)
+}
@@ -0,0 +1,146 @@
+package tool
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSQLDriver answers every query with the same rows and records the
+// statements it executes.
+type fakeSQLDriver struct {
+	columns []string
+	rows    [][]driver.Value
+	execs   []string
+}
+
+func (d *fakeSQLDriver) Open(name string) (driver.Conn, error) { return fakeSQLConn{d}, nil }
+
+type fakeSQLConn struct{ d *fakeSQLDriver }
+
+func (c fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeSQLStmt{c.d, query}, nil
+}
+func (c fakeSQLConn) Close() error              { return nil }
+func (c fakeSQLConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+func (c fakeSQLConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if !opts.ReadOnly {
+		return nil, errors.New("only read-only transactions are supported")
+	}
+	c.d.execs = append(c.d.execs, "BEGIN READ ONLY")
+	return fakeSQLTx{c.d}, nil
+}
+
+type fakeSQLTx struct{ d *fakeSQLDriver }
+
+func (tx fakeSQLTx) Commit() error { return errors.New("read-only transactions are not committed") }
+func (tx fakeSQLTx) Rollback() error {
+	tx.d.execs = append(tx.d.execs, "ROLLBACK")
+	return nil
+}
+
+type fakeSQLStmt struct {
+	d     *fakeSQLDriver
+	query string
+}
+
+func (s fakeSQLStmt) Close() error  { return nil }
+func (s fakeSQLStmt) NumInput() int { return 0 }
+func (s fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.execs = append(s.d.execs, s.query)
+	return driver.RowsAffected(3), nil
+}
+func (s fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeSQLRows{d: s.d}, nil
+}
+
+type fakeSQLRows struct {
+	d *fakeSQLDriver
+	i int
+}
+
+func (r *fakeSQLRows) Columns() []string { return r.d.columns }
+func (r *fakeSQLRows) Close() error      { return nil }
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if r.i == len(r.d.rows) {
+		return io.EOF
+	}
+	copy(dest, r.d.rows[r.i])
+	r.i++
+	return nil
+}
+
+var fakeSQL = &fakeSQLDriver{
+	columns: []string{"name", "total", "updated"},
+	rows: [][]driver.Value{
+		{[]byte("north|east"), int64(42), time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"south", nil, nil},
+		{"west", 7.5, nil},
+	},
+}
+
+func init() {
+	sql.Register("goskills-fake", fakeSQL)
+}
+
+func TestSQLQuery(t *testing.T) {
+	fakeSQL.execs = nil
+	out, err := SQLQuery(context.Background(), "goskills-fake:memory", "SELECT * FROM sales")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BEGIN READ ONLY", "ROLLBACK"}, fakeSQL.execs)
+	assert.Equal(t, "| name | total | updated |\n| --- | --- | --- |\n"+
+		"| north\\|east | 42 | 2025-01-02T03:04:05Z |\n| south | NULL | NULL |\n| west | 7.5 | NULL |\n(3 rows)", out)
+
+	out, err = SQLTool{DSN: "goskills-fake:memory", JSON: true, MaxRows: 2}.Query(context.Background(), "select name from sales")
+	require.NoError(t, err)
+	assert.Equal(t, `[{"name":"north|east","total":42,"updated":"2025-01-02T03:04:05Z"},{"name":"south","total":null,"updated":null}]`+
+		"\n(truncated to the first 2 rows)", out)
+
+	_, err = SQLQuery(context.Background(), "nosuchdriver:x", "SELECT 1")
+	assert.ErrorContains(t, err, `SQL driver "nosuchdriver" is not registered`)
+	_, err = SQLQuery(context.Background(), "memory", "SELECT 1")
+	assert.ErrorContains(t, err, "invalid SQL DSN")
+}
+
+func TestSQLQuery_Writes(t *testing.T) {
+	fakeSQL.execs = nil
+	_, err := SQLQuery(context.Background(), "goskills-fake:memory", "DELETE FROM sales")
+	assert.ErrorIs(t, err, ErrSQLWriteNotAllowed)
+	assert.Empty(t, fakeSQL.execs)
+
+	out, err := SQLTool{DSN: "goskills-fake:memory", AllowWrites: true}.Query(context.Background(), "DELETE FROM sales")
+	require.NoError(t, err)
+	assert.Equal(t, "Statement executed, 3 rows affected.", out)
+	assert.Equal(t, []string{"DELETE FROM sales"}, fakeSQL.execs)
+}
+
+func TestIsReadOnlySQL(t *testing.T) {
+	for query, want := range map[string]bool{
+		"SELECT * FROM t": true,
+		"  select a, replace(b, 'x', 'y') from t;  ":            true,
+		"WITH x AS (SELECT 1) SELECT * FROM x":                  true,
+		"SELECT 'DROP TABLE t' AS s -- delete later":            true,
+		"SELECT \"update\" FROM t /* insert */":                 true,
+		"DELETE FROM t":                                         false,
+		"SELECT 1; DROP TABLE t":                                false,
+		"WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d": false,
+		"SELECT * INTO backup FROM t":                           false,
+		"SELECT * FROM t FOR UPDATE":                            false,
+		"PRAGMA table_info(t)":                                  false,
+		"":                                                      false,
+	} {
+		assert.Equal(t, want, IsReadOnlySQL(query), query)
+	}
+
+	// Read-only queries are still approved, as they may call functions with
+	// side effects
+	assert.Equal(t, RiskDestructive, ToolRisk("sql_query", `{"query": "SELECT 1"}`))
+	assert.Equal(t, RiskDestructive, ToolRisk("sql_query", `{"query": "DROP TABLE t"}`))
+}
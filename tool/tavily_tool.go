@@ -4,26 +4,29 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 )
 
 // TavilySearch performs a web search using the Tavily API.
-func TavilySearch(query string) (string, error) {
-	return TavilySearchWithLimit(query, 20)
+func TavilySearch(ctx context.Context, query string) (string, error) {
+	return TavilySearchWithLimit(ctx, query, 20)
 }
 
 // TavilySearchWithLimit performs a web search using the Tavily API with a custom result limit.
-// TavilySearchWithLimit performs a web search using the Tavily API with a custom result limit.
-func TavilySearchWithLimit(query string, maxResults int) (string, error) {
-	apiKey := os.Getenv("TAVILY_API_KEY")
-	if apiKey == "" {
-		return "", fmt.Errorf("TAVILY_API_KEY environment variable is not set")
-	}
+// Results are cached when a ResultCache is installed.
+func TavilySearchWithLimit(ctx context.Context, query string, maxResults int) (string, error) {
+	return TavilySearchFiltered(ctx, query, maxResults, DomainFilter{})
+}
 
+// TavilySearchFiltered is like TavilySearchWithLimit, restricted to the
+// domains the filter allows. Tavily filters the domains itself.
+func TavilySearchFiltered(ctx context.Context, query string, maxResults int, filter DomainFilter) (string, error) {
 	if maxResults <= 0 {
 		maxResults = 5
 	}
@@ -31,68 +34,160 @@ func TavilySearchWithLimit(query string, maxResults int) (string, error) {
 		maxResults = 100
 	}
 
-	requestBody, err := json.Marshal(map[string]interface{}{
+	key := fmt.Sprintf("%d:%s", maxResults, query)
+	if !filter.IsZero() {
+		key += " " + filter.String()
+	}
+	return cached(ctx, "tavily", key, func(ctx context.Context) (string, error) {
+		return tavilySearch(ctx, query, maxResults, filter)
+	})
+}
+
+func tavilySearch(ctx context.Context, query string, maxResults int, filter DomainFilter) (string, error) {
+	body := map[string]interface{}{
 		"query":          query,
 		"search_depth":   "basic",
 		"max_results":    maxResults,
 		"include_images": true,
-	})
+	}
+	if include := normalizedDomains(filter.Include); len(include) > 0 {
+		body["include_domains"] = include
+	}
+	if exclude := normalizedDomains(filter.Exclude); len(exclude) > 0 {
+		body["exclude_domains"] = exclude
+	}
+	result, err := tavilyRequest(ctx, body)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request body: %w", err)
+		return "", err
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), "POST", "https://api.tavily.com/search", bytes.NewBuffer(requestBody))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	var sb bytes.Buffer
+	for _, item := range result.Results {
+		// Tavily matches whole domains only, so suffixes are checked here
+		if !filter.Allows(item.URL) {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("Title: %s\nURL: %s\nContent: %s\n\n", item.Title, item.URL, item.Content))
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if len(result.Images) > 0 {
+		sb.WriteString("\nRelevant Images:\n")
+		for _, image := range result.Images {
+			sb.WriteString(fmt.Sprintf("- Image URL: %s\n", image.URL))
+		}
+		sb.WriteString("\n")
+	}
 
-	client := http.Client{
-		Timeout: 30 * time.Second,
+	if sb.Len() == 0 {
+		return "No results found.", nil
 	}
 
-	resp, err := client.Do(req)
+	return sb.String(), nil
+}
+
+// ErrNoTavilyAnswer is returned by TavilyAnswer when Tavily has no
+// synthesized answer for the query.
+var ErrNoTavilyAnswer = errors.New("tavily returned no answer")
+
+// TavilyAnswer asks Tavily for a synthesized answer to query and returns it
+// with the supporting sources. Callers should fall back to TavilySearch on
+// ErrNoTavilyAnswer.
+func TavilyAnswer(ctx context.Context, query string) (string, error) {
+	result, err := tavilyRequest(ctx, map[string]interface{}{
+		"query":          query,
+		"search_depth":   "basic",
+		"max_results":    5,
+		"include_answer": true,
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to perform Tavily search: %w", err)
+		return "", err
+	}
+	if strings.TrimSpace(result.Answer) == "" {
+		return "", ErrNoTavilyAnswer
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Tavily API returned status %d: %s", resp.StatusCode, string(body))
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Answer: %s\n", strings.TrimSpace(result.Answer)))
+	if len(result.Results) > 0 {
+		sb.WriteString("\nSources:\n")
+		for _, item := range result.Results {
+			sb.WriteString(fmt.Sprintf("- [%s](%s)\n", item.Title, item.URL))
+		}
 	}
+	return sb.String(), nil
+}
+
+// tavilyEndpoint is the Tavily search API, replaced in tests.
+var tavilyEndpoint = "https://api.tavily.com/search"
+
+// tavilyResponse is the part of the Tavily search response that is used.
+type tavilyResponse struct {
+	Answer  string `json:"answer"`
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+	Images []tavilyImage `json:"images"`
+}
+
+// tavilyImage is an image of a Tavily response: a URL, or an object with a
+// URL and description when image descriptions were requested.
+type tavilyImage struct {
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
 
-	var result struct {
-		Results []struct {
-			Title   string `json:"title"`
-			URL     string `json:"url"`
-			Content string `json:"content"`
-		} `json:"results"`
-		Images []string `json:"images"`
+func (i *tavilyImage) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*i = tavilyImage{URL: url}
+		return nil
 	}
+	type plain tavilyImage
+	return json.Unmarshal(data, (*plain)(i))
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode Tavily response: %w", err)
+// tavilyRequest sends a search request with the given body to Tavily.
+func tavilyRequest(ctx context.Context, body map[string]interface{}) (tavilyResponse, error) {
+	apiKey := os.Getenv("TAVILY_API_KEY")
+	if apiKey == "" {
+		return tavilyResponse{}, fmt.Errorf("TAVILY_API_KEY environment variable is not set")
 	}
 
-	var sb bytes.Buffer
-	for _, item := range result.Results {
-		sb.WriteString(fmt.Sprintf("Title: %s\nURL: %s\nContent: %s\n\n", item.Title, item.URL, item.Content))
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return tavilyResponse{}, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	if len(result.Images) > 0 {
-		sb.WriteString("\nRelevant Images:\n")
-		for _, imgURL := range result.Images {
-			sb.WriteString(fmt.Sprintf("- Image URL: %s\n", imgURL))
-		}
-		sb.WriteString("\n")
+	req, err := http.NewRequestWithContext(ctx, "POST", tavilyEndpoint, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return tavilyResponse{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	if sb.Len() == 0 {
-		return "No results found.", nil
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	client := httpClient(ctx, 30*time.Second)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return tavilyResponse{}, fmt.Errorf("failed to perform Tavily search: %w", err)
 	}
+	defer resp.Body.Close()
 
-	return sb.String(), nil
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		// Tavily reports exhausted plan and pay-as-you-go limits with 432 and 433
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == 432 || resp.StatusCode == 433 {
+			return tavilyResponse{}, fmt.Errorf("%w: Tavily API returned status %d: %s", ErrSearchQuotaExceeded, resp.StatusCode, string(body))
+		}
+		return tavilyResponse{}, fmt.Errorf("Tavily API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result tavilyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return tavilyResponse{}, fmt.Errorf("failed to decode Tavily response: %w", err)
+	}
+	return result, nil
 }
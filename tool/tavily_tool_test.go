@@ -0,0 +1,69 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withTavilyServer points the Tavily tools at a test server returning response.
+func withTavilyServer(t *testing.T, response string, gotBody *map[string]interface{}) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	old := tavilyEndpoint
+	tavilyEndpoint = server.URL
+	t.Cleanup(func() { tavilyEndpoint = old })
+	t.Setenv("TAVILY_API_KEY", "test")
+}
+
+func TestTavilyAnswer(t *testing.T) {
+	var body map[string]interface{}
+	withTavilyServer(t, `{"answer":"Paris is the capital of France.","results":[{"title":"France","url":"https://example.com/france"}]}`, &body)
+
+	answer, err := TavilyAnswer(context.Background(), "capital of France")
+	require.NoError(t, err)
+	assert.Equal(t, true, body["include_answer"])
+	assert.Equal(t, "Answer: Paris is the capital of France.\n\nSources:\n- [France](https://example.com/france)\n", answer)
+}
+
+func TestTavilyAnswerMissing(t *testing.T) {
+	var body map[string]interface{}
+	withTavilyServer(t, `{"answer":"","results":[]}`, &body)
+
+	_, err := TavilyAnswer(context.Background(), "capital of France")
+	assert.ErrorIs(t, err, ErrNoTavilyAnswer)
+}
+
+func TestTavilyImageSearch(t *testing.T) {
+	var body map[string]interface{}
+	withTavilyServer(t, `{"images":[{"url":"https://example.com/a.png","description":"A gopher"},"https://example.com/b.png"]}`, &body)
+
+	images, err := TavilyImageSearch(context.Background(), "gopher")
+	require.NoError(t, err)
+	assert.Equal(t, true, body["include_image_descriptions"])
+	assert.Equal(t, []SearchImage{
+		{URL: "https://example.com/a.png", Description: "A gopher"},
+		{URL: "https://example.com/b.png"},
+	}, images)
+}
+
+func TestTavilySearchFiltered(t *testing.T) {
+	var body map[string]interface{}
+	withTavilyServer(t, `{"results":[{"title":"CDC","url":"https://www.cdc.gov/flu","content":"Flu"},{"title":"Farm","url":"https://farm.example.com/flu","content":"Spam"}]}`, &body)
+
+	out, err := TavilySearchFiltered(context.Background(), "flu", 5, DomainFilter{Include: []string{".gov", "*.example.com"}, Exclude: []string{"farm.example.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"gov", "example.com"}, body["include_domains"])
+	assert.Equal(t, []interface{}{"farm.example.com"}, body["exclude_domains"])
+	assert.Equal(t, "Title: CDC\nURL: https://www.cdc.gov/flu\nContent: Flu\n\n", out)
+}
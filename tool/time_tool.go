@@ -0,0 +1,193 @@
+package tool
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// now returns the current time; tests replace it.
+var now = time.Now
+
+// dateLayouts are the layouts accepted for dates, tried in order.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// loadLocation returns the IANA timezone name, or the local timezone when
+// name is empty.
+func loadLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+	if strings.EqualFold(name, "utc") {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q, want an IANA name such as Europe/Paris", name)
+	}
+	return loc, nil
+}
+
+// formatTime describes t with its weekday and timezone.
+func formatTime(t time.Time) string {
+	return fmt.Sprintf("%s (%s, %s, UTC%s)", t.Format(time.RFC3339), t.Weekday(), t.Location(), t.Format("-07:00"))
+}
+
+// CurrentTime returns the current date and time in the IANA timezone, e.g.
+// "America/New_York", or in the local timezone when it is empty.
+func CurrentTime(timezone string) (string, error) {
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return "", err
+	}
+	return formatTime(now().In(loc)), nil
+}
+
+// parseDate parses date in loc. Dates with an offset keep it; "now" is the
+// current time.
+func parseDate(date string, loc *time.Location) (time.Time, error) {
+	date = strings.TrimSpace(date)
+	if date == "" || strings.EqualFold(date, "now") {
+		return now().In(loc), nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, date, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, want e.g. 2025-03-01, 2025-03-01 14:30 or an RFC 3339 timestamp", date)
+}
+
+// DateAdd adds duration to date, both interpreted in timezone (see
+// CurrentTime). The duration is a sequence of signed amounts with the units
+// y, mo, w, d, h, m and s, e.g. "1y2mo", "-3d" or "2w 4h30m". Years, months
+// and days follow the calendar, so adding "1d" across a daylight saving
+// change keeps the time of day, and adding months or years to a day the
+// target month lacks gives its last day, e.g. January 31 plus "1mo" is the
+// end of February. An empty date or "now" is the current time.
+func DateAdd(date, duration, timezone string) (string, error) {
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return "", err
+	}
+	t, err := parseDate(date, loc)
+	if err != nil {
+		return "", err
+	}
+	years, months, days, clock, err := parseCalendarDuration(duration)
+	if err != nil {
+		return "", err
+	}
+	return formatTime(addMonths(t, 12*years+months).AddDate(0, 0, days).Add(clock)), nil
+}
+
+// addMonths adds months to t, clamping the day to the last day of the target
+// month instead of overflowing into the next one.
+func addMonths(t time.Time, months int) time.Time {
+	if months == 0 {
+		return t
+	}
+	year, month, day := t.Date()
+	hour, minute, sec := t.Clock()
+	first := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(day, last), hour, minute, sec, t.Nanosecond(), t.Location())
+}
+
+// calendarUnits are the units of a DateAdd duration, longest first so "mo"
+// is not read as minutes.
+var calendarUnits = []string{"mo", "y", "w", "d", "h", "m", "s"}
+
+// parseCalendarDuration splits a DateAdd duration into calendar and clock
+// parts.
+func parseCalendarDuration(duration string) (years, months, days int, clock time.Duration, err error) {
+	s := strings.ReplaceAll(strings.ToLower(duration), " ", "")
+	if s == "" {
+		return 0, 0, 0, 0, fmt.Errorf("a duration is required")
+	}
+	sign := 1
+	for s != "" {
+		switch s[0] {
+		case '-':
+			sign, s = -1, s[1:]
+		case '+':
+			sign, s = 1, s[1:]
+		}
+		end := 0
+		for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.') {
+			end++
+		}
+		if end == 0 {
+			return 0, 0, 0, 0, fmt.Errorf("invalid duration %q: expected a number", duration)
+		}
+		amount, parseErr := strconv.ParseFloat(s[:end], 64)
+		if parseErr != nil {
+			return 0, 0, 0, 0, fmt.Errorf("invalid duration %q: %w", duration, parseErr)
+		}
+		amount *= float64(sign)
+		s = s[end:]
+
+		unit := ""
+		for _, u := range calendarUnits {
+			if strings.HasPrefix(s, u) {
+				unit = u
+				break
+			}
+		}
+		s = s[len(unit):]
+		if unit != "h" && unit != "m" && unit != "s" && amount != math.Trunc(amount) {
+			return 0, 0, 0, 0, fmt.Errorf("invalid duration %q: years, months, weeks and days must be whole numbers", duration)
+		}
+		switch unit {
+		case "y":
+			years += int(amount)
+		case "mo":
+			months += int(amount)
+		case "w":
+			days += 7 * int(amount)
+		case "d":
+			days += int(amount)
+		case "h":
+			clock += time.Duration(amount * float64(time.Hour))
+		case "m":
+			clock += time.Duration(amount * float64(time.Minute))
+		case "s":
+			clock += time.Duration(amount * float64(time.Second))
+		default:
+			return 0, 0, 0, 0, fmt.Errorf("invalid duration %q: want units y, mo, w, d, h, m or s", duration)
+		}
+	}
+	return years, months, days, clock, nil
+}
+
+// DateDiff returns the time from one date to another, both interpreted in
+// timezone (see CurrentTime), in days and as a clock duration. It is
+// negative when to is before from.
+func DateDiff(from, to, timezone string) (string, error) {
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return "", err
+	}
+	start, err := parseDate(from, loc)
+	if err != nil {
+		return "", err
+	}
+	end, err := parseDate(to, loc)
+	if err != nil {
+		return "", err
+	}
+	d := end.Sub(start)
+	days := int(d / (24 * time.Hour))
+	rest := d - time.Duration(days)*24*time.Hour
+	return fmt.Sprintf("%d days %s (%s, %.2f days in total)", days, rest, d, d.Hours()/24), nil
+}
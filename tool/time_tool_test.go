@@ -0,0 +1,69 @@
+package tool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixedNow(t *testing.T, at time.Time) {
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestCurrentTime(t *testing.T) {
+	fixedNow(t, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	out, err := CurrentTime("Asia/Tokyo")
+	require.NoError(t, err)
+	assert.Equal(t, "2025-03-01T21:00:00+09:00 (Saturday, Asia/Tokyo, UTC+09:00)", out)
+
+	out, err = CurrentTime("utc")
+	require.NoError(t, err)
+	assert.Equal(t, "2025-03-01T12:00:00Z (Saturday, UTC, UTC+00:00)", out)
+
+	_, err = CurrentTime("Mars/Olympus")
+	assert.ErrorContains(t, err, `unknown timezone "Mars/Olympus"`)
+}
+
+func TestDateAdd(t *testing.T) {
+	fixedNow(t, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		date, duration, timezone string
+		want                     string
+	}{
+		// Months and years end on the last day of a shorter month
+		{"2024-01-31", "1mo", "UTC", "2024-02-29T00:00:00Z (Thursday, UTC, UTC+00:00)"},
+		{"2024-02-29", "1y", "UTC", "2025-02-28T00:00:00Z (Friday, UTC, UTC+00:00)"},
+		{"2024-03-31 08:15", "-1mo 1d", "UTC", "2024-02-28T08:15:00Z (Wednesday, UTC, UTC+00:00)"},
+		{"2024-01-31", "1y1mo", "UTC", "2025-02-28T00:00:00Z (Friday, UTC, UTC+00:00)"},
+		{"now", "-1w 2h30m", "UTC", "2025-02-22T09:30:00Z (Saturday, UTC, UTC+00:00)"},
+		// A day later across the start of daylight saving time keeps the time of day.
+		{"2025-03-29 10:00", "1d", "Europe/Paris", "2025-03-30T10:00:00+02:00 (Sunday, Europe/Paris, UTC+02:00)"},
+	}
+	for _, tt := range tests {
+		out, err := DateAdd(tt.date, tt.duration, tt.timezone)
+		require.NoError(t, err, tt.date+" + "+tt.duration)
+		assert.Equal(t, tt.want, out, tt.date+" + "+tt.duration)
+	}
+
+	_, err := DateAdd("2025-03-01", "1.5d", "UTC")
+	assert.ErrorContains(t, err, "whole numbers")
+	_, err = DateAdd("2025-03-01", "3 fortnights", "UTC")
+	assert.ErrorContains(t, err, "want units")
+	_, err = DateAdd("March 1st", "1d", "UTC")
+	assert.ErrorContains(t, err, "invalid date")
+}
+
+func TestDateDiff(t *testing.T) {
+	out, err := DateDiff("2025-01-01", "2025-03-01 06:00", "UTC")
+	require.NoError(t, err)
+	assert.Equal(t, "59 days 6h0m0s (1422h0m0s, 59.25 days in total)", out)
+
+	out, err = DateDiff("2025-01-02T00:00:00Z", "2025-01-01T00:00:00+01:00", "")
+	require.NoError(t, err)
+	assert.Equal(t, "-1 days -1h0m0s (-25h0m0s, -1.04 days in total)", out)
+}
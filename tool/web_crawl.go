@@ -0,0 +1,372 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// DefaultCrawlDepth is how many links deep WebCrawl follows by default.
+	DefaultCrawlDepth = 1
+	// DefaultCrawlBreadth is how many links of a page WebCrawl follows by default.
+	DefaultCrawlBreadth = 5
+	// MaxCrawlPages caps the pages fetched by one crawl.
+	MaxCrawlPages = 20
+	// MaxCrawlBytes caps the size of the digest WebCrawl returns.
+	MaxCrawlBytes = 256 << 10 // 256 KiB
+)
+
+const (
+	// crawlUserAgent is the name WebCrawl looks for in robots.txt, besides "*".
+	crawlUserAgent = "goskills"
+	// crawlUserAgentHeader is the User-Agent WebCrawl sends, which names
+	// crawlUserAgent so sites can address it in robots.txt.
+	crawlUserAgentHeader = "goskills/1.0 (+https://github.com/smallnest/goskills)"
+	// browserUserAgent is the User-Agent of WebFetch, which fetches single
+	// pages on behalf of a user.
+	browserUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+	// maxPageBytes caps the size of a page read by fetchDocument.
+	maxPageBytes = 5 << 20 // 5 MiB
+)
+
+// CrawlOptions limits a WebCrawl. Zero values use the defaults.
+type CrawlOptions struct {
+	// Depth is how many links away from the start page to go. Defaults to
+	// DefaultCrawlDepth, at most 3.
+	Depth int
+	// Breadth is how many new links of each page to follow. Defaults to
+	// DefaultCrawlBreadth.
+	Breadth int
+	// MaxPages caps the pages fetched, including the start page. Defaults
+	// to and is capped at MaxCrawlPages.
+	MaxPages int
+	// MaxBytes caps the size of the digest. Defaults to and is capped at
+	// MaxCrawlBytes.
+	MaxBytes int
+}
+
+// WebCrawl fetches the page at startURL and, breadth first, the links on the
+// same host it leads to, within the limits of opts. Paths disallowed by the
+// site's robots.txt are skipped. It returns the readable text of the pages,
+// each under a separator line with its URL, truncated to opts.MaxBytes.
+// Results are cached when a ResultCache is installed.
+func WebCrawl(ctx context.Context, startURL string, opts CrawlOptions) (string, error) {
+	opts = opts.withDefaults()
+	key := fmt.Sprintf("%s depth=%d breadth=%d pages=%d bytes=%d", startURL, opts.Depth, opts.Breadth, opts.MaxPages, opts.MaxBytes)
+	return cached(ctx, "web_crawl", key, func(ctx context.Context) (string, error) {
+		return webCrawl(ctx, startURL, opts)
+	})
+}
+
+func (o CrawlOptions) withDefaults() CrawlOptions {
+	if o.Depth <= 0 {
+		o.Depth = DefaultCrawlDepth
+	}
+	o.Depth = min(o.Depth, 3)
+	if o.Breadth <= 0 {
+		o.Breadth = DefaultCrawlBreadth
+	}
+	if o.MaxPages <= 0 || o.MaxPages > MaxCrawlPages {
+		o.MaxPages = MaxCrawlPages
+	}
+	if o.MaxBytes <= 0 || o.MaxBytes > MaxCrawlBytes {
+		o.MaxBytes = MaxCrawlBytes
+	}
+	return o
+}
+
+// crawlPage is a page queued for a crawl.
+type crawlPage struct {
+	url   *url.URL
+	depth int
+}
+
+func webCrawl(ctx context.Context, startURL string, opts CrawlOptions) (string, error) {
+	start, err := url.Parse(startURL)
+	if err != nil || (start.Scheme != "http" && start.Scheme != "https") || start.Host == "" {
+		return "", fmt.Errorf("invalid start URL: %s", startURL)
+	}
+	start.Fragment = ""
+
+	robots := fetchRobots(ctx, start)
+	if !robots.allowed(start) {
+		return "", fmt.Errorf("robots.txt of %s disallows %s", start.Host, start.Path)
+	}
+
+	queue := []crawlPage{{url: start}}
+	seen := map[string]bool{start.String(): true}
+	var sb strings.Builder
+	fetched, failed := 0, 0
+	truncated := false
+
+	for len(queue) > 0 && fetched < opts.MaxPages && !truncated {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		page := queue[0]
+		queue = queue[1:]
+
+		doc, final, err := fetchDocument(ctx, page.url.String(), crawlUserAgentHeader)
+		if err != nil {
+			if page.depth == 0 {
+				return "", err
+			}
+			failed++
+			continue
+		}
+		fetched++
+
+		entry := fmt.Sprintf("===== Page %d: %s (depth %d) =====\n", fetched, page.url, page.depth)
+		if title := strings.TrimSpace(doc.Find("title").First().Text()); title != "" {
+			entry += "Title: " + title + "\n"
+		}
+		entry += "\n" + readableText(doc) + "\n\n"
+		if sb.Len()+len(entry) > opts.MaxBytes {
+			entry = truncateUTF8(entry, opts.MaxBytes-sb.Len())
+			truncated = true
+		}
+		sb.WriteString(entry)
+
+		if page.depth >= opts.Depth {
+			continue
+		}
+		followed := 0
+		for _, link := range pageLinks(doc, final) {
+			if followed >= opts.Breadth {
+				break
+			}
+			if link.Host != start.Host || seen[link.String()] || !robots.allowed(link) {
+				continue
+			}
+			seen[link.String()] = true
+			queue = append(queue, crawlPage{url: link, depth: page.depth + 1})
+			followed++
+		}
+	}
+
+	if truncated {
+		sb.WriteString(fmt.Sprintf("\n...(truncated to %d bytes)\n", opts.MaxBytes))
+	}
+	if skipped := len(queue); skipped > 0 || failed > 0 {
+		sb.WriteString(fmt.Sprintf("Crawled %d pages; %d failed, %d not fetched because of the limits.\n", fetched, failed, skipped))
+	}
+	return sb.String(), nil
+}
+
+// fetchDocument fetches and parses the HTML page at urlString, sending
+// userAgent and reading at most maxPageBytes of it. It returns the URL the
+// page was served from, after redirects.
+func fetchDocument(ctx context.Context, urlString, userAgent string) (*goquery.Document, *url.URL, error) {
+	client := httpClient(ctx, 20*time.Second)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", urlString, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request for %s: %w", urlString, err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch URL %s: %w", urlString, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, fmt.Errorf("request to %s failed with status code %d", urlString, resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse HTML from %s: %w", urlString, err)
+	}
+	return doc, resp.Request.URL, nil
+}
+
+// readableText returns the text of the body of doc without scripts and
+// styles, one trimmed line per line of text.
+func readableText(doc *goquery.Document) string {
+	doc.Find("script, style, noscript").Each(func(i int, s *goquery.Selection) {
+		s.Remove()
+	})
+	var lines []string
+	for _, line := range strings.Split(doc.Find("body").Text(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pageLinks returns the http and https links of doc, resolved against base,
+// without fragments, in document order.
+func pageLinks(doc *goquery.Document, base *url.URL) []*url.URL {
+	var links []*url.URL
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		link, err := base.Parse(strings.TrimSpace(href))
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			return
+		}
+		link.Fragment = ""
+		link.RawFragment = ""
+		links = append(links, link)
+	})
+	return links
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
+
+// robotsRules are the Allow and Disallow path patterns of a robots.txt group.
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+// allowed reports whether the rules allow fetching u. The longest matching
+// pattern wins, and Allow wins a tie, as in RFC 9309.
+func (r robotsRules) allowed(u *url.URL) bool {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	longest, allow := -1, true
+	for _, pattern := range r.allow {
+		if len(pattern) > longest && robotsMatch(pattern, path) {
+			longest, allow = len(pattern), true
+		}
+	}
+	for _, pattern := range r.disallow {
+		if len(pattern) > longest && robotsMatch(pattern, path) {
+			longest, allow = len(pattern), false
+		}
+	}
+	return allow
+}
+
+// robotsMatch reports whether a robots.txt path pattern matches path. A "*"
+// matches any sequence of characters and a trailing "$" anchors the pattern
+// at the end of the path; otherwise patterns match prefixes.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
+
+// fetchRobots fetches the robots.txt of the host of u. As in RFC 9309, a
+// missing robots.txt (a 4xx status) allows everything, while an unreachable
+// one (a network error or 5xx status) disallows everything.
+func fetchRobots(ctx context.Context, u *url.URL) robotsRules {
+	disallowAll := robotsRules{disallow: []string{"/"}}
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL.String(), nil)
+	if err != nil {
+		return disallowAll
+	}
+	req.Header.Set("User-Agent", crawlUserAgentHeader)
+	resp, err := httpClient(ctx, 10*time.Second).Do(req)
+	if err != nil {
+		return disallowAll
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return disallowAll
+	}
+	if resp.StatusCode != http.StatusOK {
+		return robotsRules{}
+	}
+	return parseRobots(io.LimitReader(resp.Body, 512<<10), crawlUserAgent)
+}
+
+// parseRobots returns the rules of the group of a robots.txt for agent, or
+// of the "*" group when there is none for agent.
+func parseRobots(r io.Reader, agent string) robotsRules {
+	var own, wildcard robotsRules
+	hasOwn := false
+	var agents []string
+	inRules := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+		switch field {
+		case "user-agent":
+			if inRules {
+				// A new group starts
+				agents, inRules = nil, false
+			}
+			value = strings.ToLower(value)
+			agents = append(agents, value)
+			hasOwn = hasOwn || value == agent
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				// An empty Disallow allows everything
+				continue
+			}
+			for _, a := range agents {
+				var rules *robotsRules
+				switch {
+				case a == agent:
+					rules = &own
+				case a == "*":
+					rules = &wildcard
+				default:
+					continue
+				}
+				if field == "allow" {
+					rules.allow = append(rules.allow, value)
+				} else {
+					rules.disallow = append(rules.disallow, value)
+				}
+			}
+		}
+	}
+	if hasOwn {
+		return own
+	}
+	return wildcard
+}
@@ -0,0 +1,146 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// crawlSite serves a small site: the index links to pages a, b and private,
+// an external site and itself; page a links to page c.
+func crawlSite(t *testing.T, robots string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			if robots == "" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, robots)
+		case "/":
+			fmt.Fprintf(w, `<html><head><title>Home</title></head><body><p>Welcome</p>
+<a href="/a">A</a> <a href="b#top">B</a> <a href="/private/x">P</a>
+<a href="https://example.org/">Elsewhere</a> <a href="%s/">Home</a><script>var x;</script></body></html>`, server.URL)
+		case "/a":
+			fmt.Fprint(w, `<html><body><p>Page A</p><a href="/c">C</a></body></html>`)
+		case "/b", "/c", "/private/x":
+			fmt.Fprintf(w, `<html><body><p>Page %s</p></body></html>`, strings.ToUpper(r.URL.Path[1:]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebCrawl(t *testing.T) {
+	server := crawlSite(t, "User-agent: *\nDisallow: /private\n")
+
+	out, err := WebCrawl(context.Background(), server.URL+"/", CrawlOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, out, "===== Page 1: "+server.URL+"/ (depth 0) =====\nTitle: Home\n\nWelcome")
+	assert.Contains(t, out, "===== Page 2: "+server.URL+"/a (depth 1) =====")
+	assert.Contains(t, out, "===== Page 3: "+server.URL+"/b (depth 1) =====")
+	assert.NotContains(t, out, "var x")
+	assert.NotContains(t, out, "Page PRIVATE/X", "robots.txt disallows /private")
+	assert.NotContains(t, out, "Page C", "page c is two links deep")
+	assert.NotContains(t, out, "Page 4:")
+
+	out, err = WebCrawl(context.Background(), server.URL+"/", CrawlOptions{Depth: 2})
+	require.NoError(t, err)
+	assert.Contains(t, out, "===== Page 4: "+server.URL+"/c (depth 2) =====")
+}
+
+func TestWebCrawl_Limits(t *testing.T) {
+	server := crawlSite(t, "")
+
+	out, err := WebCrawl(context.Background(), server.URL+"/", CrawlOptions{MaxPages: 2})
+	require.NoError(t, err)
+	assert.Contains(t, out, "Page A")
+	assert.NotContains(t, out, "Page B")
+	assert.Contains(t, out, "Crawled 2 pages; 0 failed, 2 not fetched because of the limits.")
+
+	out, err = WebCrawl(context.Background(), server.URL+"/", CrawlOptions{Breadth: 1})
+	require.NoError(t, err)
+	assert.Contains(t, out, "Page A")
+	assert.NotContains(t, out, "Page B")
+
+	out, err = WebCrawl(context.Background(), server.URL+"/", CrawlOptions{MaxBytes: 80})
+	require.NoError(t, err)
+	assert.Contains(t, out, "...(truncated to 80 bytes)")
+	assert.NotContains(t, out, "Page 2:")
+}
+
+func TestWebCrawl_StartDisallowed(t *testing.T) {
+	server := crawlSite(t, "User-agent: goskills\nDisallow: /\n\nUser-agent: *\nAllow: /\n")
+
+	_, err := WebCrawl(context.Background(), server.URL+"/", CrawlOptions{})
+	assert.ErrorContains(t, err, "robots.txt")
+
+	_, err = WebCrawl(context.Background(), "ftp://example.com/", CrawlOptions{})
+	assert.ErrorContains(t, err, "invalid start URL")
+}
+
+func TestParseRobots(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`# comment
+User-agent: other
+Disallow: /
+
+User-agent: *
+Disallow: /docs
+Allow: /docs/public
+Disallow:
+`), crawlUserAgent)
+
+	allowed := func(path string) bool {
+		u, err := http.NewRequest("GET", "https://example.com"+path, nil)
+		require.NoError(t, err)
+		return rules.allowed(u.URL)
+	}
+	assert.True(t, allowed("/"))
+	assert.False(t, allowed("/docs/guide"))
+	assert.True(t, allowed("/docs/public/intro"))
+
+	rules = parseRobots(strings.NewReader(`User-agent: goskills
+Disallow: /*.pdf$
+Disallow: /search*q=
+Allow: /search?p
+`), crawlUserAgent)
+	assert.False(t, allowed("/files/report.pdf"))
+	assert.True(t, allowed("/files/report.pdf.html"))
+	assert.False(t, allowed("/search?page=2&q=go"), "the longer Disallow pattern wins")
+	assert.True(t, allowed("/search?page=2"))
+}
+
+func TestWebCrawl_Robots(t *testing.T) {
+	var agents []string
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, `<html><body><p>Hello</p></body></html>`)
+	}))
+	defer server.Close()
+
+	// An unreachable robots.txt disallows everything, a missing one nothing
+	_, err := WebCrawl(context.Background(), server.URL+"/", CrawlOptions{})
+	assert.ErrorContains(t, err, "robots.txt")
+	status = http.StatusNotFound
+	out, err := WebCrawl(context.Background(), server.URL+"/", CrawlOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, out, "Hello")
+
+	for _, agent := range agents {
+		assert.Contains(t, agent, crawlUserAgent)
+	}
+}
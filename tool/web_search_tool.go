@@ -12,16 +12,34 @@ import (
 )
 
 // DuckDuckGoSearch performs a DuckDuckGo search for the given query.
-// It uses the DuckDuckGo Instant Answer API.
-func DuckDuckGoSearch(query string) (string, error) {
-	baseURL := "https://api.duckduckgo.com/?format=json&q="
-	searchURL := baseURL + url.QueryEscape(query)
+// It uses the DuckDuckGo Instant Answer API. Results are cached when a
+// ResultCache is installed.
+func DuckDuckGoSearch(ctx context.Context, query string) (string, error) {
+	return DuckDuckGoSearchFiltered(ctx, query, DomainFilter{})
+}
 
-	client := http.Client{
-		Timeout: 10 * time.Second,
+// DuckDuckGoSearchFiltered is like DuckDuckGoSearch, restricted to the
+// domains the filter allows. The API cannot filter, so the abstract and
+// related topics are filtered by their URLs.
+func DuckDuckGoSearchFiltered(ctx context.Context, query string, filter DomainFilter) (string, error) {
+	key := query
+	if !filter.IsZero() {
+		key += " " + filter.String()
 	}
+	return cached(ctx, "duckduckgo", key, func(ctx context.Context) (string, error) {
+		return duckDuckGoSearch(ctx, query, filter)
+	})
+}
+
+// duckDuckGoEndpoint is the DuckDuckGo Instant Answer API, replaced in tests.
+var duckDuckGoEndpoint = "https://api.duckduckgo.com/"
+
+func duckDuckGoSearch(ctx context.Context, query string, filter DomainFilter) (string, error) {
+	searchURL := duckDuckGoEndpoint + "?format=json&q=" + url.QueryEscape(query)
 
-	req, err := http.NewRequestWithContext(context.Background(), "GET", searchURL, nil)
+	client := httpClient(ctx, 10*time.Second)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -54,14 +72,17 @@ func DuckDuckGoSearch(query string) (string, error) {
 		return "", fmt.Errorf("failed to unmarshal DuckDuckGo response: %w", err)
 	}
 
-	if result.AbstractText != "" {
+	if result.AbstractText != "" && filter.Allows(result.AbstractURL) {
 		return fmt.Sprintf("%s (Source: %s)", result.AbstractText, result.AbstractURL), nil
-	} else if len(result.RelatedTopics) > 0 {
-		// Fallback to related topics if no abstract
-		var topics []string
-		for _, topic := range result.RelatedTopics {
+	}
+	// Fallback to related topics if no abstract
+	var topics []string
+	for _, topic := range result.RelatedTopics {
+		if topic.Text != "" && filter.Allows(topic.URL) {
 			topics = append(topics, topic.Text)
 		}
+	}
+	if len(topics) > 0 {
 		return fmt.Sprintf("No direct abstract found. Related topics: %s", strings.Join(topics, "; ")), nil
 	}
 
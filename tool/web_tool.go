@@ -1,41 +1,25 @@
 package tool
 
 import (
+	"context"
 	"fmt"
-	"net/http"
-	"time"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
 // WebFetch retrieves the main text content from a given URL.
 // It uses goquery to parse the HTML and extract text, removing script and style tags.
-func WebFetch(urlString string) (string, error) {
-	client := http.Client{
-		Timeout: 20 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", urlString, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request for %s: %w", urlString, err)
-	}
-	// Set a realistic User-Agent
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch URL %s: %w", urlString, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("request to %s failed with status code %d", urlString, resp.StatusCode)
-	}
+// Results are cached when a ResultCache is installed.
+func WebFetch(ctx context.Context, urlString string) (string, error) {
+	return cached(ctx, "web_fetch", urlString, func(ctx context.Context) (string, error) {
+		return webFetch(ctx, urlString)
+	})
+}
 
-	// Parse the HTML document
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+func webFetch(ctx context.Context, urlString string) (string, error) {
+	doc, _, err := fetchDocument(ctx, urlString, browserUserAgent)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse HTML from %s: %w", urlString, err)
+		return "", err
 	}
 
 	// Remove script and style elements
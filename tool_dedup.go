@@ -0,0 +1,27 @@
+package goskills
+
+import (
+	"encoding/json"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/tool"
+)
+
+// dedupKey returns the key identifying tc among the calls of one turn, and
+// false when tc may have side effects and must always run. Only calls that
+// tool.ToolRisk considers safe are deduplicated.
+func dedupKey(tc openai.ToolCall) (string, bool) {
+	if tool.ToolRisk(tc.Function.Name, tc.Function.Arguments) != tool.RiskSafe {
+		return "", false
+	}
+
+	// Re-encode the arguments so key order and whitespace do not matter
+	args := tc.Function.Arguments
+	var v any
+	if err := json.Unmarshal([]byte(args), &v); err == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			args = string(canonical)
+		}
+	}
+	return tc.Function.Name + "\x00" + args, true
+}
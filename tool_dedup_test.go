@@ -0,0 +1,34 @@
+package goskills
+
+import (
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupKey(t *testing.T) {
+	call := func(name, args string) openai.ToolCall {
+		return openai.ToolCall{Function: openai.FunctionCall{Name: name, Arguments: args}}
+	}
+
+	k1, ok := dedupKey(call("web_fetch", `{"url": "https://example.com"}`))
+	assert.True(t, ok)
+	k2, _ := dedupKey(call("web_fetch", `{"url":"https://example.com"}`))
+	assert.Equal(t, k1, k2, "whitespace must not matter")
+
+	k3, _ := dedupKey(call("web_fetch", `{"url":"https://example.org"}`))
+	assert.NotEqual(t, k1, k3)
+
+	k4, _ := dedupKey(call("http_request", `{"url":"https://example.com","method":"GET"}`))
+	k5, ok := dedupKey(call("http_request", `{"method":"GET","url":"https://example.com"}`))
+	assert.True(t, ok)
+	assert.Equal(t, k4, k5, "key order must not matter")
+
+	_, ok = dedupKey(call("http_request", `{"method":"POST","url":"https://example.com"}`))
+	assert.False(t, ok)
+	_, ok = dedupKey(call("write_file", `{"filePath":"a.txt","content":"x"}`))
+	assert.False(t, ok)
+	_, ok = dedupKey(call("run_shell_code", `{"code":"date"}`))
+	assert.False(t, ok)
+}
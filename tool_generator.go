@@ -1,16 +1,27 @@
 package goskills
 
 import (
+	"encoding/json"
 	"fmt"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
 	"github.com/smallnest/goskills/tool"
 )
 
-// GenerateToolDefinitions generates the list of OpenAI tools for a given skill.
-// It returns the tool definitions and a map of tool names to script paths for execution.
+// GenerateToolDefinitions returns the tools a skill offers to the model and
+// a map from the name of each script tool to the absolute path of its script.
+//
+// The tools are the built-in tools, filtered by the skill's allowed-tools
+// metadata, and the git tools (tool.GetGitTools) the metadata names, followed by one "run_<script path>" tool per script in
+// Resources.Scripts, where non-alphanumeric characters of the path become
+// underscores. Script tools are always offered, as is a "retrieve_docs" tool
+// that searches the skill's .md and .txt documents when it has any; MCP tools
+// are added at run time. Use DescribeTools for a readable summary.
 func GenerateToolDefinitions(skill SkillPackage) ([]openai.Tool, map[string]string) {
 	var tools []openai.Tool
 	scriptMap := make(map[string]string)
@@ -19,31 +30,51 @@ func GenerateToolDefinitions(skill SkillPackage) ([]openai.Tool, map[string]stri
 	baseTools := tool.GetBaseTools()
 
 	if len(skill.Meta.AllowedTools) > 0 {
-		allowedMap := make(map[string]bool)
-		for _, t := range skill.Meta.AllowedTools {
-			allowedMap[t] = true
-		}
-
-		for _, t := range baseTools {
-			if allowedMap[t.Function.Name] {
-				tools = append(tools, t)
-			}
-		}
+		tools = append(tools, FilterAllowedTools(baseTools, skill.Meta.AllowedTools)...)
 	} else {
 		tools = append(tools, baseTools...)
 	}
 
+	// Git tools are only offered to skills that list them.
+	if len(skill.Meta.AllowedTools) > 0 {
+		tools = append(tools, FilterAllowedTools(tool.GetGitTools(), skill.Meta.AllowedTools)...)
+	}
+
 	// 2. Script Tools
 	for _, scriptRelPath := range skill.Resources.Scripts {
-		toolDef, toolName := generateScriptTool(skill.Path, scriptRelPath)
+		toolDef, toolName := generateScriptTool(scriptRelPath, skill.Meta.Scripts[scriptRelPath])
 		tools = append(tools, toolDef)
 		scriptMap[toolName] = filepath.Join(skill.Path, scriptRelPath)
 	}
 
+	// 3. Document retrieval
+	if len(skillDocuments(skill)) > 0 {
+		tools = append(tools, generateRetrieveDocsTool())
+	}
+
 	return tools, scriptMap
 }
 
-func generateScriptTool(skillPath, scriptRelPath string) (openai.Tool, string) {
+// FilterAllowedTools returns the tools whose names match one of the allowed
+// patterns. A pattern is an exact tool name or a path.Match glob.
+func FilterAllowedTools(tools []openai.Tool, allowed []string) []openai.Tool {
+	var filtered []openai.Tool
+	for _, t := range tools {
+		if t.Function == nil {
+			continue
+		}
+		for _, pattern := range allowed {
+			if ok, _ := path.Match(pattern, t.Function.Name); ok {
+				filtered = append(filtered, t)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// scriptToolName returns the name of the tool that runs a script.
+func scriptToolName(scriptRelPath string) string {
 	// Normalize name: replace non-alphanumeric with underscore
 	safeName := strings.Map(func(r rune) rune {
 		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
@@ -51,7 +82,11 @@ func generateScriptTool(skillPath, scriptRelPath string) (openai.Tool, string) {
 		}
 		return '_'
 	}, scriptRelPath)
-	toolName := "run_" + safeName
+	return "run_" + safeName
+}
+
+func generateScriptTool(scriptRelPath string, spec ScriptSpec) (openai.Tool, string) {
+	toolName := scriptToolName(scriptRelPath)
 
 	// Determine type based on extension
 	ext := filepath.Ext(scriptRelPath)
@@ -61,6 +96,20 @@ func generateScriptTool(skillPath, scriptRelPath string) (openai.Tool, string) {
 	} else {
 		description = fmt.Sprintf("Executes the shell script '%s'.", scriptRelPath)
 	}
+	if spec.Description != "" {
+		description += " " + spec.Description
+	}
+
+	if len(spec.Parameters) > 0 {
+		return openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        toolName,
+				Description: description,
+				Parameters:  scriptParametersSchema(spec),
+			},
+		}, toolName
+	}
 
 	return openai.Tool{
 		Type: openai.ToolTypeFunction,
@@ -82,3 +131,65 @@ func generateScriptTool(skillPath, scriptRelPath string) (openai.Tool, string) {
 		},
 	}, toolName
 }
+
+// DescribeTools returns a human-readable summary of the tools skill offers to
+// the model: their names, risk levels, descriptions and parameters, and the
+// script each script tool runs.
+func DescribeTools(skill SkillPackage) string {
+	tools, scriptMap := GenerateToolDefinitions(skill)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d tools for skill %s\n", len(tools), skill.QualifiedName())
+	for _, t := range tools {
+		if t.Function == nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n%s [%s]\n", t.Function.Name, tool.ToolRisk(t.Function.Name, ""))
+		if script, ok := scriptMap[t.Function.Name]; ok {
+			fmt.Fprintf(&sb, "  Script: %s\n", script)
+		}
+		if t.Function.Description != "" {
+			fmt.Fprintf(&sb, "  %s\n", t.Function.Description)
+		}
+		describeParameters(&sb, t.Function.Parameters)
+	}
+	return sb.String()
+}
+
+// describeParameters writes one line per property of a JSON schema.
+func describeParameters(sb *strings.Builder, parameters any) {
+	data, err := json.Marshal(parameters)
+	if err != nil {
+		return
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Type        string `json:"type"`
+			Description string `json:"description"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if json.Unmarshal(data, &schema) != nil || len(schema.Properties) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb.WriteString("  Parameters:\n")
+	for _, name := range names {
+		prop := schema.Properties[name]
+		kind := prop.Type
+		if slices.Contains(schema.Required, name) {
+			kind += ", required"
+		}
+		fmt.Fprintf(sb, "    %s (%s)", name, kind)
+		if prop.Description != "" {
+			fmt.Fprintf(sb, ": %s", prop.Description)
+		}
+		sb.WriteString("\n")
+	}
+}
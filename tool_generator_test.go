@@ -0,0 +1,106 @@
+package goskills
+
+import (
+	"errors"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func toolNames(skill SkillPackage) []string {
+	tools, _ := GenerateToolDefinitions(skill)
+	var names []string
+	for _, t := range tools {
+		names = append(names, t.Function.Name)
+	}
+	return names
+}
+
+func TestGenerateToolDefinitions_AllowedTools(t *testing.T) {
+	all := toolNames(SkillPackage{})
+	assert.Contains(t, all, "http_request")
+	assert.Contains(t, all, "run_python_code")
+
+	restricted := toolNames(SkillPackage{
+		Meta:      SkillMeta{AllowedTools: []string{"read_file", "write_file", "run_python_*"}},
+		Resources: SkillResources{Scripts: []string{"scripts/tidy.py"}},
+	})
+	assert.ElementsMatch(t, []string{"read_file", "write_file", "run_python_code", "run_python_json", "run_python_script", "run_scripts_tidy_py"}, restricted)
+}
+
+func TestDescribeTools(t *testing.T) {
+	desc := DescribeTools(SkillPackage{
+		Path:      "/skills/tidy",
+		Meta:      SkillMeta{Name: "tidy", AllowedTools: []string{"read_file"}},
+		Resources: SkillResources{Scripts: []string{"scripts/tidy.py"}},
+	})
+	assert.Contains(t, desc, "2 tools for skill tidy")
+	assert.Contains(t, desc, "read_file [safe]")
+	assert.Contains(t, desc, "    filePath (string, required): ")
+	assert.Contains(t, desc, "run_scripts_tidy_py [destructive]\n  Script: /skills/tidy/scripts/tidy.py\n")
+	assert.Contains(t, desc, "    args (array): Arguments to pass to the script.")
+}
+
+func TestGenerateToolDefinitions_ScriptParameters(t *testing.T) {
+	spec := ScriptSpec{
+		Description: "Resizes an image.",
+		Parameters: []SkillInput{
+			{Name: "path", Description: "Image to resize.", Required: true},
+			{Name: "width", Type: "integer"},
+			{Name: "keepRatio", Type: "boolean"},
+		},
+	}
+	tools, _ := GenerateToolDefinitions(SkillPackage{
+		Meta:      SkillMeta{AllowedTools: []string{"run_scripts_*"}, Scripts: map[string]ScriptSpec{"scripts/resize.py": spec}},
+		Resources: SkillResources{Scripts: []string{"scripts/resize.py"}},
+	})
+	require.Len(t, tools, 1)
+	assert.Equal(t, "Executes the python script 'scripts/resize.py'. Resizes an image.", tools[0].Function.Description)
+
+	schema := tools[0].Function.Parameters.(map[string]interface{})
+	assert.Equal(t, []string{"path"}, schema["required"])
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string", "description": "Image to resize."}, properties["path"])
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, properties["width"])
+	assert.NotContains(t, properties, "args")
+}
+
+func TestScriptArguments(t *testing.T) {
+	spec := ScriptSpec{Parameters: []SkillInput{
+		{Name: "path", Required: true},
+		{Name: "width", Type: "integer"},
+		{Name: "keepRatio", Type: "boolean"},
+		{Name: "tags", Type: "array"},
+	}}
+	call := func(args string) openai.ToolCall {
+		return openai.ToolCall{Function: openai.FunctionCall{Name: "run_scripts_resize_py", Arguments: args}}
+	}
+
+	args, err := scriptArguments(call(`{"keepRatio": true, "path": "a.png", "width": 640, "tags": ["x", "y"]}`), spec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.png", "640", "true", `["x","y"]`}, args)
+
+	args, err = scriptArguments(call(`{"path": "a.png", "keepRatio": false}`), spec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.png", "", "false"}, args)
+
+	_, err = scriptArguments(call(`{"width": 640}`), spec)
+	var argErr *ToolArgumentsError
+	require.True(t, errors.As(err, &argErr))
+	assert.Contains(t, err.Error(), "path")
+
+	_, err = scriptArguments(call(`{"path": "a.png", "width": 6.5}`), spec)
+	require.Error(t, err)
+}
+
+func TestGenerateToolDefinitions_GitTools(t *testing.T) {
+	assert.NotContains(t, toolNames(SkillPackage{}), "git_status")
+
+	names := toolNames(SkillPackage{Meta: SkillMeta{AllowedTools: []string{"read_file", "git_*"}}})
+	assert.ElementsMatch(t, []string{"read_file", "git_clone", "git_status", "git_diff", "git_commit"}, names)
+
+	names = toolNames(SkillPackage{Meta: SkillMeta{AllowedTools: []string{"git_status"}}})
+	assert.Equal(t, []string{"git_status"}, names)
+}
@@ -0,0 +1,45 @@
+package goskills
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// DefaultMaxToolOutputBytes is the tool output limit used when
+// RunnerConfig.MaxToolOutputBytes is zero.
+const DefaultMaxToolOutputBytes = 64 << 10
+
+// truncateToolOutput shortens output to about limit bytes, replacing the cut
+// part with a marker. With keepTail, the last third of the budget is spent on
+// the end of the output, where errors and summaries usually are. A limit <= 0
+// disables truncation. It reports whether output was truncated.
+func truncateToolOutput(output string, limit int, keepTail bool) (string, bool) {
+	if limit <= 0 || len(output) <= limit {
+		return output, false
+	}
+
+	headLen, tailLen := limit, 0
+	if keepTail {
+		tailLen = limit / 3
+		headLen = limit - tailLen
+	}
+	// Cut on rune boundaries so no invalid UTF-8 is sent to the model
+	for headLen > 0 && !utf8.RuneStart(output[headLen]) {
+		headLen--
+	}
+	tailStart := len(output) - tailLen
+	for tailStart < len(output) && !utf8.RuneStart(output[tailStart]) {
+		tailStart++
+	}
+
+	marker := fmt.Sprintf("\n\n[... truncated %d of %d bytes ...]\n\n", tailStart-headLen, len(output))
+	return output[:headLen] + marker + output[tailStart:], true
+}
+
+// toolOutputLimit returns the configured tool output limit.
+func (a *Agent) toolOutputLimit() int {
+	if a.cfg.MaxToolOutputBytes == 0 {
+		return DefaultMaxToolOutputBytes
+	}
+	return a.cfg.MaxToolOutputBytes
+}
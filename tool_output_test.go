@@ -0,0 +1,44 @@
+package goskills
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateToolOutput(t *testing.T) {
+	out, truncated := truncateToolOutput("short", 100, false)
+	assert.False(t, truncated)
+	assert.Equal(t, "short", out)
+
+	long := strings.Repeat("a", 60) + strings.Repeat("z", 40)
+	out, truncated = truncateToolOutput(long, 30, false)
+	assert.True(t, truncated)
+	assert.Equal(t, strings.Repeat("a", 30)+"\n\n[... truncated 70 of 100 bytes ...]\n\n", out)
+
+	out, truncated = truncateToolOutput(long, 30, true)
+	assert.True(t, truncated)
+	assert.True(t, strings.HasPrefix(out, strings.Repeat("a", 20)+"\n\n[... truncated 70 of 100 bytes ...]"))
+	assert.True(t, strings.HasSuffix(out, strings.Repeat("z", 10)))
+
+	// Negative limits disable truncation
+	out, truncated = truncateToolOutput(long, -1, false)
+	assert.False(t, truncated)
+	assert.Equal(t, long, out)
+}
+
+func TestTruncateToolOutputUTF8(t *testing.T) {
+	long := strings.Repeat("日本語", 20)
+	out, truncated := truncateToolOutput(long, 31, true)
+	assert.True(t, truncated)
+	assert.True(t, utf8.ValidString(out))
+}
+
+func TestToolOutputLimit(t *testing.T) {
+	a := &Agent{}
+	assert.Equal(t, DefaultMaxToolOutputBytes, a.toolOutputLimit())
+	a.cfg.MaxToolOutputBytes = 1024
+	assert.Equal(t, 1024, a.toolOutputLimit())
+}
@@ -0,0 +1,161 @@
+package goskills
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ExecutionTrace is the complete transcript of a skill run: every message
+// sent to or received from the model, including tool calls and their
+// results, in the order they were added. Unlike the audit log, it keeps
+// messages that history compaction later summarized. Secrets are masked as
+// in the audit log. Enable it with RunnerConfig.RecordTrace or TraceFile.
+type ExecutionTrace struct {
+	Skill    string        `json:"skill"`
+	Model    string        `json:"model"`
+	Prompt   string        `json:"prompt"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration_ns"`
+	Entries  []TraceEntry  `json:"entries"`
+	Output   string        `json:"output"`
+	Error    string        `json:"error,omitempty"`
+	Usage    TokenUsage    `json:"usage"`
+	// SystemFingerprints are those of Agent.SystemFingerprints.
+	SystemFingerprints []string `json:"system_fingerprints,omitempty"`
+}
+
+// TraceEntry is a message of an ExecutionTrace and the time it was added.
+type TraceEntry struct {
+	Time    time.Time                    `json:"time"`
+	Message openai.ChatCompletionMessage `json:"message"`
+}
+
+// Messages returns the messages of the trace, e.g. to seed
+// RunnerConfig.History or a test fixture.
+func (t *ExecutionTrace) Messages() []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, len(t.Entries))
+	for i, entry := range t.Entries {
+		messages[i] = entry.Message
+	}
+	return messages
+}
+
+// WriteTraceFile writes trace to path as indented JSON.
+func WriteTraceFile(path string, trace *ExecutionTrace) error {
+	data, err := json.MarshalIndent(trace, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// LoadTraceFile reads a trace written by WriteTraceFile.
+func LoadTraceFile(path string) (*ExecutionTrace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var trace ExecutionTrace
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return nil, fmt.Errorf("invalid trace %s: %w", path, err)
+	}
+	return &trace, nil
+}
+
+// ReplayTrace renders the conversation of trace as text, without calling the
+// model again.
+func ReplayTrace(trace *ExecutionTrace) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Skill: %s\nModel: %s\nStarted: %s (%s)\n", trace.Skill, trace.Model,
+		trace.Started.Format(time.RFC3339), trace.Duration.Round(time.Millisecond))
+	for _, entry := range trace.Entries {
+		msg := entry.Message
+		sb.WriteString("\n")
+		if msg.Role == openai.ChatMessageRoleTool {
+			fmt.Fprintf(&sb, "[tool %s]\n", msg.ToolCallID)
+		} else {
+			fmt.Fprintf(&sb, "[%s]\n", msg.Role)
+		}
+		if msg.Content != "" {
+			sb.WriteString(msg.Content)
+			sb.WriteString("\n")
+		}
+		for _, tc := range msg.ToolCalls {
+			fmt.Fprintf(&sb, "-> %s %s (%s)\n", tc.Function.Name, tc.Function.Arguments, tc.ID)
+		}
+	}
+	sb.WriteString("\n")
+	if trace.Error != "" {
+		fmt.Fprintf(&sb, "Error: %s\n", trace.Error)
+	}
+	fmt.Fprintf(&sb, "Output:\n%s\n", trace.Output)
+	fmt.Fprintf(&sb, "Usage: %d tokens (%d prompt, %d completion)\n",
+		trace.Usage.TotalTokens, trace.Usage.PromptTokens, trace.Usage.CompletionTokens)
+	return sb.String()
+}
+
+// Trace returns the trace of the current or last run, or nil when tracing is
+// disabled.
+func (a *Agent) Trace() *ExecutionTrace {
+	return a.trace
+}
+
+// tracing reports whether runs are traced.
+func (a *Agent) tracing() bool {
+	return a.cfg.RecordTrace || a.cfg.TraceFile != ""
+}
+
+// startTrace starts a new trace of running skill for prompt.
+func (a *Agent) startTrace(prompt string, skill SkillPackage) {
+	if !a.tracing() {
+		a.trace = nil
+		return
+	}
+	a.trace = &ExecutionTrace{
+		Skill:   skill.QualifiedName(),
+		Model:   a.skillModel(skill),
+		Prompt:  a.redact(prompt),
+		Started: time.Now(),
+	}
+}
+
+// traceMessages adds messages, with their secrets masked, to the trace.
+func (a *Agent) traceMessages(messages ...openai.ChatCompletionMessage) {
+	if a.trace == nil {
+		return
+	}
+	now := time.Now()
+	for _, msg := range messages {
+		a.trace.Entries = append(a.trace.Entries, TraceEntry{Time: now, Message: a.redactMessage(msg)})
+	}
+}
+
+// finishTrace records the result of the run and writes the trace to
+// RunnerConfig.TraceFile, if set.
+func (a *Agent) finishTrace(output string, err error) {
+	if a.trace == nil {
+		return
+	}
+	a.trace.Duration = time.Since(a.trace.Started)
+	a.trace.Output = a.redact(output)
+	a.trace.Error = ""
+	if err != nil {
+		a.trace.Error = a.redact(err.Error())
+	}
+	a.trace.Usage = a.usage
+	a.trace.SystemFingerprints = a.fingerprints
+	if a.cfg.TraceFile != "" {
+		if err := WriteTraceFile(a.cfg.TraceFile, a.trace); err != nil {
+			a.logAlways(context.Background(), slog.LevelWarn, "failed to write trace",
+				fmt.Sprintf("⚠️ Failed to write trace: %v\n", err),
+				slog.String("path", a.cfg.TraceFile), slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package goskills
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutionTrace(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("token=abcdef123456"), 0o644))
+
+	read := agenttest.CallTools(agenttest.ToolCall("call_1", "read_file", map[string]string{"filePath": filepath.Join(dir, "notes.txt")}))
+	read.Usage = openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+	answer := agenttest.Reply("The notes hold a token.")
+	answer.Usage = openai.Usage{PromptTokens: 20, CompletionTokens: 5, TotalTokens: 25}
+	client := agenttest.NewFakeClient(read, answer)
+
+	traceFile := filepath.Join(t.TempDir(), "trace.json")
+	a, err := NewAgent(RunnerConfig{Client: client, Model: "gpt-4o", AutoApproveTools: true, TraceFile: traceFile}, nil)
+	require.NoError(t, err)
+
+	skill := SkillPackage{Path: dir, Meta: SkillMeta{Name: "notes"}, Body: "Read notes."}
+	output, err := a.executeSkillWithTools(context.Background(), "what is in the notes?", skill)
+	require.NoError(t, err)
+
+	trace := a.Trace()
+	require.NotNil(t, trace)
+	assert.Equal(t, "notes", trace.Skill)
+	assert.Equal(t, "gpt-4o", trace.Model)
+	assert.Equal(t, output, trace.Output)
+	assert.Equal(t, 40, trace.Usage.TotalTokens)
+
+	var roles []string
+	for _, msg := range trace.Messages() {
+		roles = append(roles, msg.Role)
+	}
+	assert.Equal(t, []string{"system", "user", "assistant", "tool", "assistant"}, roles)
+	assert.Equal(t, "token=[REDACTED]", trace.Entries[3].Message.Content)
+
+	loaded, err := LoadTraceFile(traceFile)
+	require.NoError(t, err)
+	assert.Equal(t, trace.Messages(), loaded.Messages())
+
+	replay := ReplayTrace(loaded)
+	assert.Contains(t, replay, "Skill: notes\nModel: gpt-4o\n")
+	assert.Contains(t, replay, "[user]\nwhat is in the notes?\n")
+	assert.Contains(t, replay, "-> read_file ")
+	assert.Contains(t, replay, "[tool call_1]\ntoken=[REDACTED]\n")
+	assert.Contains(t, replay, "Output:\nThe notes hold a token.\n")
+	assert.Len(t, client.Requests(), 2)
+}
+
+func TestExecutionTraceDisabled(t *testing.T) {
+	a := &Agent{}
+	a.startTrace("task", SkillPackage{})
+	a.addMessages(openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "task"})
+	a.finishTrace("done", nil)
+	assert.Nil(t, a.Trace())
+	assert.Len(t, a.messages, 1)
+}
@@ -0,0 +1,54 @@
+package goskills
+
+import (
+	"context"
+
+	openai "github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// noopTracer is used when RunnerConfig.Tracer is nil.
+var noopTracer = noop.NewTracerProvider().Tracer("github.com/smallnest/goskills")
+
+// startSpan starts a span on the configured tracer.
+func (a *Agent) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := a.cfg.Tracer
+	if tracer == nil {
+		tracer = noopTracer
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// createChatCompletion sends req to the LLM inside a span that records the
+// model and token usage.
+func (a *Agent) createChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	ctx, span := a.startSpan(ctx, "goskills.llm_request",
+		attribute.String("llm.model", req.Model),
+		attribute.Int("llm.messages", len(req.Messages)),
+		attribute.Int("llm.tools", len(req.Tools)),
+	)
+	resp, err := a.client.CreateChatCompletion(ctx, req)
+	if err == nil {
+		a.recordUsage(req.Model, resp.Usage)
+		a.recordFingerprint(resp.SystemFingerprint)
+		span.SetAttributes(
+			attribute.Int("llm.prompt_tokens", resp.Usage.PromptTokens),
+			attribute.Int("llm.completion_tokens", resp.Usage.CompletionTokens),
+			attribute.Int("llm.total_tokens", resp.Usage.TotalTokens),
+		)
+	}
+	endSpan(span, err)
+	return resp, err
+}
@@ -0,0 +1,46 @@
+package goskills
+
+import (
+	"context"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+// recordingTracer records the names of the spans it starts.
+type recordingTracer struct {
+	embedded.Tracer
+	spans []string
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	t.spans = append(t.spans, name)
+	return noopTracer.Start(ctx, name, opts...)
+}
+
+func TestSelectSkillTracing(t *testing.T) {
+	resp := agenttest.Reply("'pdf'")
+	resp.Usage = openai.Usage{PromptTokens: 10, CompletionTokens: 1, TotalTokens: 11}
+
+	tracer := &recordingTracer{}
+	a, err := NewAgent(RunnerConfig{Client: agenttest.NewFakeClient(resp), Tracer: tracer}, nil)
+	require.NoError(t, err)
+
+	name, err := a.selectSkill(context.Background(), "summarize a.pdf", map[string]SkillPackage{"pdf": {}})
+	require.NoError(t, err)
+	assert.Equal(t, "pdf", name)
+	assert.Equal(t, []string{"goskills.select_skill", "goskills.llm_request"}, tracer.spans)
+}
+
+func TestStartSpanWithoutTracer(t *testing.T) {
+	a := &Agent{}
+	ctx, span := a.startSpan(context.Background(), "goskills.test")
+	defer span.End()
+	assert.NotNil(t, ctx)
+	assert.False(t, span.IsRecording())
+}
@@ -0,0 +1,94 @@
+package goskills
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrBudgetExceeded is returned, together with the partial result, when a run
+// reaches RunnerConfig.MaxCostUSD or RunnerConfig.MaxTokens.
+var ErrBudgetExceeded = errors.New("cost limit reached")
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// DefaultModelPrices are the list prices of the providers' default models,
+// used for models missing from RunnerConfig.ModelPrices.
+var DefaultModelPrices = map[string]ModelPrice{
+	"gpt-4o":            {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+	"gpt-4o-mini":       {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	"claude-sonnet-4-5": {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	"gemini-2.5-flash":  {InputPerMillion: 0.30, OutputPerMillion: 2.50},
+}
+
+// TokenUsage is the accumulated LLM usage of a run.
+type TokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	// CostUSD only includes models with a known price.
+	CostUSD float64
+}
+
+// Usage returns the LLM usage of the current run.
+func (a *Agent) Usage() TokenUsage {
+	return a.usage
+}
+
+// SystemFingerprints returns the distinct system fingerprints of the LLM
+// responses of the last run, in order. A fingerprint that differs from an
+// earlier run with the same RunnerConfig.Seed, or more than one, means the
+// backend changed and its outputs may not be reproducible. Backends that do
+// not report fingerprints leave it empty.
+func (a *Agent) SystemFingerprints() []string {
+	return a.fingerprints
+}
+
+// recordFingerprint adds the system fingerprint of a response, if new.
+func (a *Agent) recordFingerprint(fingerprint string) {
+	if fingerprint != "" && !slices.Contains(a.fingerprints, fingerprint) {
+		a.fingerprints = append(a.fingerprints, fingerprint)
+	}
+}
+
+// recordUsage adds the usage of one completion by model.
+func (a *Agent) recordUsage(model string, usage openai.Usage) {
+	a.usage.PromptTokens += usage.PromptTokens
+	a.usage.CompletionTokens += usage.CompletionTokens
+	a.usage.TotalTokens += usage.TotalTokens
+
+	price, ok := a.cfg.ModelPrices[model]
+	if !ok {
+		price, ok = DefaultModelPrices[model]
+	}
+	if ok {
+		a.usage.CostUSD += float64(usage.PromptTokens)*price.InputPerMillion/1e6 +
+			float64(usage.CompletionTokens)*price.OutputPerMillion/1e6
+	}
+}
+
+// budgetExceeded describes the ceiling the run has reached, or returns "".
+func (a *Agent) budgetExceeded() string {
+	if a.cfg.MaxCostUSD > 0 && a.usage.CostUSD >= a.cfg.MaxCostUSD {
+		return fmt.Sprintf("spent $%.4f of the $%.4f limit", a.usage.CostUSD, a.cfg.MaxCostUSD)
+	}
+	if a.cfg.MaxTokens > 0 && a.usage.TotalTokens >= a.cfg.MaxTokens {
+		return fmt.Sprintf("used %d of the %d token limit", a.usage.TotalTokens, a.cfg.MaxTokens)
+	}
+	return ""
+}
+
+// budgetNotice appends the cost limit notice to a partial result.
+func budgetNotice(partial, reason string) string {
+	notice := fmt.Sprintf("[%v: %s; stopping early]", ErrBudgetExceeded, reason)
+	if partial == "" {
+		return notice
+	}
+	return partial + "\n\n" + notice
+}
@@ -0,0 +1,64 @@
+package goskills
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/smallnest/goskills/agent/agenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordUsage(t *testing.T) {
+	a := &Agent{cfg: RunnerConfig{ModelPrices: map[string]ModelPrice{
+		"custom": {InputPerMillion: 1, OutputPerMillion: 2},
+	}}}
+	a.recordUsage("custom", openai.Usage{PromptTokens: 1000000, CompletionTokens: 500000, TotalTokens: 1500000})
+	a.recordUsage("unknown", openai.Usage{PromptTokens: 10, CompletionTokens: 10, TotalTokens: 20})
+
+	usage := a.Usage()
+	assert.Equal(t, 1500020, usage.TotalTokens)
+	assert.InDelta(t, 2.0, usage.CostUSD, 1e-9)
+}
+
+func TestBudgetExceededStopsLoop(t *testing.T) {
+	working := agenttest.CallTools(agenttest.ToolCall("1", "read_file", map[string]string{"filePath": "a.txt"}))
+	working.Choices[0].Message.Content = "Working on it."
+	working.Usage = openai.Usage{PromptTokens: 900, CompletionTokens: 100, TotalTokens: 1000}
+	client := agenttest.NewFakeClient(working)
+
+	a, err := NewAgent(RunnerConfig{
+		Client:    client,
+		MaxTokens: 1000,
+	}, nil)
+	require.NoError(t, err)
+
+	output, err := a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Len(t, client.Requests(), 1)
+	assert.Contains(t, output, "Working on it.")
+	assert.Contains(t, output, "cost limit reached")
+	// The unanswered tool call is not left in the history
+	last := a.messages[len(a.messages)-1]
+	assert.Equal(t, "Working on it.", last.Content)
+	assert.Empty(t, last.ToolCalls)
+}
+
+func TestBudgetExceededKeepsContinuedAnswer(t *testing.T) {
+	part := func(content string, tokens int) openai.ChatCompletionResponse {
+		resp := agenttest.Reply(content)
+		resp.Choices[0].FinishReason = openai.FinishReasonLength
+		resp.Usage = openai.Usage{PromptTokens: tokens, TotalTokens: tokens}
+		return resp
+	}
+	client := agenttest.NewFakeClient(part("part 1 ", 600), part("part 2 ", 600))
+	a, err := NewAgent(RunnerConfig{Client: client, MaxTokens: 1000}, nil)
+	require.NoError(t, err)
+
+	output, err := a.executeSkillWithTools(context.Background(), "task", SkillPackage{Path: t.TempDir()})
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.True(t, strings.HasPrefix(output, "part 1 part 2 "), output)
+	assert.Contains(t, output, "cost limit reached")
+}
@@ -0,0 +1,78 @@
+package goskills
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the goskills library.
+const Version = "0.3.5"
+
+// parseVersion parses a version like "v1.2.3" or "1.2" into its numeric
+// components. Pre-release and build suffixes ("-rc1", "+meta") are ignored.
+func parseVersion(v string) ([]int, error) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i != -1 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil, fmt.Errorf("empty version")
+	}
+
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", v)
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
+
+// compareVersions returns -1, 0 or 1 if a is older than, equal to or newer than b.
+func compareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// CheckSkillCompatibility returns an error if the skill requires a newer
+// goskills than the running Version.
+func CheckSkillCompatibility(pkg *SkillPackage) error {
+	if pkg.Meta.MinGoskillsVersion == "" {
+		return nil
+	}
+	cmp, err := compareVersions(pkg.Meta.MinGoskillsVersion, Version)
+	if err != nil {
+		return fmt.Errorf("skill %q has an invalid min-goskills-version: %w", pkg.Meta.Name, err)
+	}
+	if cmp > 0 {
+		return fmt.Errorf("skill %q requires goskills %s or newer, running %s", pkg.Meta.Name, pkg.Meta.MinGoskillsVersion, Version)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package goskills
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"v0.3.5", "0.3.5", 0},
+		{"0.3", "0.3.0", 0},
+		{"0.4.0", "0.3.5", 1},
+		{"0.3.10", "0.3.9", 1},
+		{"0.3.5-rc1", "0.3.6", -1},
+	}
+	for _, tt := range tests {
+		got, err := compareVersions(tt.a, tt.b)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s vs %s", tt.a, tt.b)
+	}
+
+	_, err := compareVersions("latest", "1.0")
+	assert.Error(t, err)
+}
+
+func TestCheckSkillCompatibility(t *testing.T) {
+	assert.NoError(t, CheckSkillCompatibility(&SkillPackage{}))
+	assert.NoError(t, CheckSkillCompatibility(&SkillPackage{Meta: SkillMeta{MinGoskillsVersion: Version}}))
+	assert.Error(t, CheckSkillCompatibility(&SkillPackage{Meta: SkillMeta{Name: "future", MinGoskillsVersion: "99.0"}}))
+	assert.Error(t, CheckSkillCompatibility(&SkillPackage{Meta: SkillMeta{MinGoskillsVersion: "next"}}))
+}